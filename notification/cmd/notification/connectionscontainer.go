@@ -47,7 +47,6 @@ func newConnectionsContainer(
 		if err != nil {
 			return fmt.Errorf("failed to init DB for migrations: %w", err)
 		}
-		defer db.Close()
 
 		if err = applyMigrations(db.DB, pathToMigrations); err != nil {
 			return fmt.Errorf("migration failed: %w", err)
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	domainservice "notification/pkg/domain/service"
+)
+
+const rateLimitCleanupInterval = time.Hour
+
+// deliveryWorker разбирает очередь уведомлений; реплик может быть несколько
+func deliveryWorker(
+	config *config,
+	logger *log.Logger,
+	closer *multiCloser,
+) *cli.Command {
+	return &cli.Command{
+		Name:  "delivery-worker",
+		Usage: "Sends queued notifications and digests and retries failed deliveries",
+		Action: func(c *cli.Context) error {
+			connContainer, err := newConnectionsContainer(config, logger, closer)
+			if err != nil {
+				return errors.Wrap(err, "failed to init connections")
+			}
+
+			container, err := newDependencyContainer(config, logger, connContainer, closer)
+			if err != nil {
+				return errors.Wrap(err, "failed to init dependencies")
+			}
+			return runDeliveryWorker(c.Context, config, logger, container.deliveryService, container.digestService, container.rateLimitService)
+		},
+	}
+}
+
+func runDeliveryWorker(
+	ctx context.Context,
+	config *config,
+	logger *log.Logger,
+	deliveryService domainservice.DeliveryService,
+	digestService domainservice.DigestService,
+	rateLimitService domainservice.RateLimitService,
+) error {
+	logger.Infof("delivery worker started, polling every %v", config.DeliveryPollInterval)
+	ticker := time.NewTicker(config.DeliveryPollInterval)
+	defer ticker.Stop()
+
+	var cleanedAt time.Time
+	for {
+		// Счётчики лимитов чистятся редко: прошедшие окна только занимают место
+		if now := time.Now().UTC(); now.Sub(cleanedAt) >= rateLimitCleanupInterval {
+			if err := rateLimitService.DeleteExpired(now); err != nil {
+				logger.Errorf("rate limit cleanup failed: %v", err)
+			} else {
+				cleanedAt = now
+			}
+		}
+
+		// Дайджесты собираются до отправки, чтобы уйти в той же итерации
+		flushed, err := digestService.FlushDue(config.DeliveryBatchSize)
+		if err != nil {
+			logger.Errorf("digest flush failed: %v", err)
+		} else if flushed > 0 {
+			logger.Infof("flushed %d digests", flushed)
+		}
+
+		// Полная пачка означает, что очередь не разобрана, поэтому следующая берётся без ожидания
+		delivered, err := deliveryService.DeliverDue(config.DeliveryBatchSize)
+		if err != nil {
+			logger.Errorf("delivery failed: %v", err)
+		} else if delivered > 0 {
+			logger.Infof("delivered %d notifications", delivered)
+		}
+		if err == nil && delivered == config.DeliveryBatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Infof("Shutdown signal received, stopping delivery worker...")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
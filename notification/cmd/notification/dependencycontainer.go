@@ -1,18 +1,141 @@
 package main
 
-import "github.com/jmoiron/sqlx"
+import (
+	"github.com/jmoiron/sqlx"
+	log "github.com/sirupsen/logrus"
 
-// TODO: добавить зависимости
+	"notification/pkg/domain/model"
+	domainservice "notification/pkg/domain/service"
+	"notification/pkg/infrastructure/event"
+	"notification/pkg/infrastructure/mysql"
+)
 
 func newDependencyContainer(
-	_ *config,
+	config *config,
+	logger *log.Logger,
 	connContainer *connectionsContainer,
+	closer *multiCloser,
 ) (*dependencyContainer, error) {
+	notificationRepository := mysql.NewNotificationRepository(connContainer.db)
+	eventDispatcher := event.NewLogEventDispatcher(logger)
+
+	deviceTokenRepository := mysql.NewDeviceTokenRepository(connContainer.db)
+	senders, err := newSenders(config, logger, deviceTokenRepository, closer)
+	if err != nil {
+		return nil, err
+	}
+
+	contactService := domainservice.NewContactService(
+		mysql.NewEmailAddressRepository(connContainer.db),
+		mysql.NewPhoneNumberRepository(connContainer.db),
+		deviceTokenRepository,
+	)
+	templateService := domainservice.NewTemplateService(
+		mysql.NewTemplateRepository(connContainer.db),
+		config.TemplateDefaultLocale,
+	)
+	preferenceService := domainservice.NewPreferenceService(mysql.NewPreferenceRepository(connContainer.db))
+	notificationService := domainservice.NewNotificationService(
+		notificationRepository,
+		templateService,
+		contactService,
+		preferenceService,
+		senders,
+		eventDispatcher,
+	)
+	// Обработчик событий брокера пишет через репозитории транзакции inbox, поэтому сервисы
+	// собираются заново для каждого сообщения; отправители и диспетчер общие
+	newDomainEventHandler := func(repos model.InboxRepositories) domainservice.DomainEventHandler {
+		contactService := domainservice.NewContactService(repos.EmailAddresses(), repos.PhoneNumbers(), repos.DeviceTokens())
+		notificationService := domainservice.NewNotificationService(
+			repos.Notifications(),
+			domainservice.NewTemplateService(repos.Templates(), config.TemplateDefaultLocale),
+			contactService,
+			domainservice.NewPreferenceService(repos.Preferences()),
+			senders,
+			eventDispatcher,
+		)
+		return domainservice.NewDomainEventHandler(notificationService, contactService)
+	}
+	rateLimitService := domainservice.NewRateLimitService(
+		mysql.NewRateLimitRepository(connContainer.db),
+		newRateLimitConfig(config),
+	)
+	undeliverableAddressRepository := mysql.NewUndeliverableAddressRepository(connContainer.db)
+	deliveryService := domainservice.NewDeliveryService(
+		notificationRepository,
+		mysql.NewDeliveryAttemptRepository(connContainer.db),
+		undeliverableAddressRepository,
+		senders,
+		rateLimitService,
+		eventDispatcher,
+		domainservice.DeliveryConfig{
+			MaxAttempts: config.DeliveryMaxAttempts,
+			BaseDelay:   config.DeliveryBaseDelay,
+			MaxDelay:    config.DeliveryMaxDelay,
+			ClaimLease:  config.DeliveryClaimLease,
+		},
+	)
+	digestService := domainservice.NewDigestService(
+		notificationRepository,
+		templateService,
+		preferenceService,
+		domainservice.DigestConfig{MaxItems: config.DigestMaxItems},
+	)
+
+	receiptService := domainservice.NewReceiptService(
+		notificationRepository,
+		undeliverableAddressRepository,
+		eventDispatcher,
+	)
+
 	return &dependencyContainer{
-		db: connContainer.db,
+		db:                  connContainer.db,
+		notificationService: notificationService,
+		deliveryService:     deliveryService,
+		digestService:       digestService,
+		rateLimitService:    rateLimitService,
+		receiptService:      receiptService,
+		templateService:     templateService,
+		contactService:      contactService,
+		preferenceService:   preferenceService,
+
+		newDomainEventHandler: newDomainEventHandler,
 	}, nil
 }
 
 type dependencyContainer struct {
 	db *sqlx.DB
+
+	notificationService domainservice.NotificationService
+	deliveryService     domainservice.DeliveryService
+	digestService       domainservice.DigestService
+	rateLimitService    domainservice.RateLimitService
+	receiptService      domainservice.ReceiptService
+	templateService     domainservice.TemplateService
+	contactService      domainservice.ContactService
+	preferenceService   domainservice.PreferenceService
+
+	newDomainEventHandler func(repos model.InboxRepositories) domainservice.DomainEventHandler
+}
+
+func newRateLimitConfig(config *config) domainservice.RateLimitConfig {
+	recipient := func(limit int) domainservice.RateLimit {
+		return domainservice.RateLimit{Limit: limit, Window: config.RateLimitRecipientWindow}
+	}
+	channel := func(limit int) domainservice.RateLimit {
+		return domainservice.RateLimit{Limit: limit, Window: config.RateLimitChannelWindow}
+	}
+	return domainservice.RateLimitConfig{
+		Recipient: map[model.NotificationCategory]domainservice.RateLimit{
+			model.Transactional: recipient(config.RateLimitTransactionalPerWindow),
+			model.Marketing:     recipient(config.RateLimitMarketingPerWindow),
+			model.Security:      recipient(config.RateLimitSecurityPerWindow),
+		},
+		Channel: map[model.NotificationChannel]domainservice.RateLimit{
+			model.Email: channel(config.RateLimitEmailPerWindow),
+			model.SMS:   channel(config.RateLimitSMSPerWindow),
+			model.Push:  channel(config.RateLimitPushPerWindow),
+		},
+	}
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
 )
 
 // TODO:  appID используется как префикс для env-переменных
@@ -56,6 +57,8 @@ func runApp(
 		Name: appID,
 		Commands: []*cli.Command{
 			service(config, logger, closer),
+			deliveryWorker(config, logger, closer),
+			messageHandler(config, logger, closer),
 			migrate(config, logger),
 		},
 	}
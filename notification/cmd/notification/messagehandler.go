@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"notification/pkg/infrastructure/integrationevent"
+	"notification/pkg/infrastructure/mysql"
+)
+
+// messageHandler создаёт уведомления по событиям из domain_event_exchange; реплик может быть несколько
+func messageHandler(
+	config *config,
+	logger *log.Logger,
+	closer *multiCloser,
+) *cli.Command {
+	return &cli.Command{
+		Name:  "message-handler",
+		Usage: "Turns domain events of other services into notifications",
+		Action: func(c *cli.Context) error {
+			connContainer, err := newConnectionsContainer(config, logger, closer)
+			if err != nil {
+				return errors.Wrap(err, "failed to init connections")
+			}
+
+			container, err := newDependencyContainer(config, logger, connContainer, closer)
+			if err != nil {
+				return errors.Wrap(err, "failed to init dependencies")
+			}
+
+			handler := integrationevent.NewMessageHandler(
+				container.newDomainEventHandler,
+				mysql.NewInbox(connContainer.db),
+				mysql.NewParkedMessageRepository(connContainer.db),
+				logger,
+			)
+			consumer := integrationevent.NewConsumer(integrationevent.ConsumerConfig{
+				URL:        config.AMQPURL,
+				Queue:      config.AMQPQueue,
+				Prefetch:   config.AMQPPrefetch,
+				RetryDelay: config.AMQPRetryDelay,
+			}, handler, logger)
+			return consumer.Run(c.Context)
+		},
+	}
+}
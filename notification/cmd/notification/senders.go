@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/infrastructure/sender"
+)
+
+// newSenders подключает настоящих провайдеров для сконфигурированных каналов, остальные каналы пишутся в лог
+func newSenders(
+	config *config,
+	logger *log.Logger,
+	deviceTokenRepository model.DeviceTokenRepository,
+	closer *multiCloser,
+) (map[model.NotificationChannel]model.NotificationSender, error) {
+	senders := map[model.NotificationChannel]model.NotificationSender{
+		model.Email: sender.NewLogSender(logger, model.Email),
+		model.SMS:   sender.NewLogSender(logger, model.SMS),
+		model.Push:  sender.NewLogSender(logger, model.Push),
+	}
+
+	if config.SMTPHost != "" {
+		smtpSender, err := sender.NewSMTPSender(sender.SMTPConfig{
+			Host:                     config.SMTPHost,
+			Port:                     config.SMTPPort,
+			Username:                 config.SMTPUsername,
+			Password:                 config.SMTPPassword,
+			TLSMode:                  sender.SMTPTLSMode(config.SMTPTLSMode),
+			From:                     config.SMTPFrom,
+			FromName:                 config.SMTPFromName,
+			MessageIDDomain:          config.SMTPMessageIDDomain,
+			UnsubscribeURL:           config.SMTPUnsubscribeURL,
+			UnsubscribeMailto:        config.SMTPUnsubscribeMailto,
+			Timeout:                  config.SMTPTimeout,
+			IdleTimeout:              config.SMTPIdleTimeout,
+			MaxMessagesPerConnection: config.SMTPMaxMessagesPerConnection,
+		})
+		if err != nil {
+			return nil, err
+		}
+		closer.Add(smtpSender)
+		senders[model.Email] = smtpSender
+	}
+
+	if config.SMSProviderURL != "" {
+		smsSender, err := sender.NewSMSSender(
+			sender.NewHTTPSMSProvider(sender.HTTPSMSProviderConfig{
+				URL:     config.SMSProviderURL,
+				APIKey:  config.SMSProviderAPIKey,
+				Timeout: config.SMSProviderTimeout,
+			}),
+			sender.SMSConfig{SenderID: config.SMSSenderID, MaxSegments: config.SMSMaxSegments},
+		)
+		if err != nil {
+			return nil, err
+		}
+		senders[model.SMS] = smsSender
+	}
+
+	pushProviders, err := newPushProviders(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(pushProviders) > 0 {
+		senders[model.Push] = sender.NewPushSender(deviceTokenRepository, pushProviders, logger)
+	}
+	return senders, nil
+}
+
+func newPushProviders(config *config) (map[model.DevicePlatform]sender.PushProvider, error) {
+	providers := make(map[model.DevicePlatform]sender.PushProvider)
+	if config.APNsKeyFile != "" {
+		key, err := os.ReadFile(config.APNsKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read APNs key")
+		}
+		apnsProvider, err := sender.NewAPNsProvider(sender.APNsConfig{
+			URL:        config.APNsURL,
+			KeyID:      config.APNsKeyID,
+			TeamID:     config.APNsTeamID,
+			Topic:      config.APNsTopic,
+			PrivateKey: key,
+			Timeout:    config.PushTimeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+		providers[model.IOS] = apnsProvider
+	}
+
+	if config.FCMCredentialsFile != "" {
+		data, err := os.ReadFile(config.FCMCredentialsFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read FCM credentials")
+		}
+		var credentials struct {
+			ProjectID   string `json:"project_id"`
+			ClientEmail string `json:"client_email"`
+			PrivateKey  string `json:"private_key"`
+			TokenURI    string `json:"token_uri"`
+		}
+		if err = json.Unmarshal(data, &credentials); err != nil {
+			return nil, errors.Wrap(err, "malformed FCM credentials")
+		}
+		projectID := config.FCMProjectID
+		if projectID == "" {
+			projectID = credentials.ProjectID
+		}
+		fcmProvider, err := sender.NewFCMProvider(sender.FCMConfig{
+			URL:         config.FCMURL,
+			ProjectID:   projectID,
+			ClientEmail: credentials.ClientEmail,
+			PrivateKey:  []byte(credentials.PrivateKey),
+			TokenURL:    credentials.TokenURI,
+			Timeout:     config.PushTimeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+		providers[model.Android] = fcmProvider
+		providers[model.Web] = fcmProvider
+	}
+	return providers, nil
+}
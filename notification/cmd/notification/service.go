@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"net"
+	"net/http"
 	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
 	"google.golang.org/grpc"
 
 	api "notification/api/server/notificationinternal"
@@ -22,32 +24,39 @@ func service(
 ) *cli.Command {
 	return &cli.Command{
 		Name:  "service",
-		Usage: "Runs the gRPC service",
+		Usage: "Runs the gRPC service and the delivery receipt webhook",
 		Action: func(c *cli.Context) error {
 			connContainer, err := newConnectionsContainer(config, logger, closer)
 			if err != nil {
 				return errors.Wrap(err, "failed to init connections")
 			}
 
-			container, err := newDependencyContainer(config, connContainer)
+			container, err := newDependencyContainer(config, logger, connContainer, closer)
 			if err != nil {
 				return errors.Wrap(err, "failed to init dependencies")
 			}
-			return startGRPCServer(c.Context, config, logger, container)
+			return startServers(c.Context, config, logger, container)
 		},
 	}
 }
 
-func startGRPCServer(
+const receiptWebhookPath = "/webhooks/receipts"
+
+func startServers(
 	ctx context.Context,
 	config *config,
 	logger *log.Logger,
-	_ *dependencyContainer,
+	container *dependencyContainer,
 ) error {
 	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(makeGrpcUnaryInterceptor(logger)))
 
-	// TODO: зарегистрировать свой сервер вместо шаблонного
-	api.RegisterNotificationInternalServiceServer(grpcServer, transport.NewInternalAPI())
+	api.RegisterNotificationInternalServiceServer(grpcServer, transport.NewInternalAPI(
+		container.notificationService,
+		container.deliveryService,
+		container.templateService,
+		container.contactService,
+		container.preferenceService,
+	))
 
 	listener, err := net.Listen("tcp", config.ServeGRPCAddress)
 	if err != nil {
@@ -55,21 +64,54 @@ func startGRPCServer(
 	}
 	logger.Infof("gRPC server listening on %s", config.ServeGRPCAddress)
 
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 2)
 	go func() {
 		errCh <- grpcServer.Serve(listener)
 	}()
 
+	// Без секрета подпись квитанций не проверить, поэтому вебхук не поднимается
+	var httpServer *http.Server
+	if config.ReceiptWebhookSecret != "" {
+		mux := http.NewServeMux()
+		mux.Handle(receiptWebhookPath, transport.NewReceiptWebhook(container.receiptService, config.ReceiptWebhookSecret, logger))
+		httpServer = &http.Server{
+			Addr:              config.ServeHTTPAddress,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errCh <- errors.Wrapf(err, "failed to serve http on %s", config.ServeHTTPAddress)
+			}
+		}()
+		logger.Infof("receipt webhook listening on %s%s", config.ServeHTTPAddress, receiptWebhookPath)
+	} else {
+		logger.Warnf("receipt webhook secret is not set, delivery receipts are not accepted")
+	}
+
 	select {
 	case err := <-errCh:
 		return err
 	case <-ctx.Done():
-		logger.Infof("Shutdown signal received, stopping gRPC server...")
+		logger.Infof("Shutdown signal received, stopping servers...")
+		if httpServer != nil {
+			shutdownHTTPServer(httpServer, logger)
+		}
 		shutdownGRPCServer(grpcServer, logger)
 		return nil
 	}
 }
 
+func shutdownHTTPServer(server *http.Server, logger *log.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Warnf("HTTP server shutdown failed: %v", err)
+		return
+	}
+	logger.Infof("HTTP server stopped gracefully")
+}
+
 func shutdownGRPCServer(server *grpc.Server, logger *log.Logger) {
 	done := make(chan struct{})
 	go func() {
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS notification
+(
+    `id`                VARCHAR(64)  NOT NULL,
+    `user_id`           VARCHAR(64)  NOT NULL,
+    `channel`           INT          NOT NULL,
+    `recipient_address` VARCHAR(255) NOT NULL,
+    `subject`           VARCHAR(255) NOT NULL,
+    `body`              TEXT         NOT NULL,
+    `status`            INT          NOT NULL,
+    `failure_reason`    TEXT         NOT NULL,
+    `created_at`        DATETIME     NOT NULL,
+    `sent_at`           DATETIME     NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_notification_user_created` (`user_id`, `created_at`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS notification_delivery_attempt;
+
+ALTER TABLE notification
+    DROP KEY `idx_notification_status_next_attempt`,
+    DROP COLUMN `locked_until`,
+    DROP COLUMN `next_attempt_at`,
+    DROP COLUMN `attempts`;
@@ -0,0 +1,25 @@
+ALTER TABLE notification
+    ADD COLUMN `attempts`        INT      NOT NULL DEFAULT 0,
+    ADD COLUMN `next_attempt_at` DATETIME NULL,
+    ADD COLUMN `locked_until`    DATETIME NULL;
+
+UPDATE notification SET `next_attempt_at` = `created_at`;
+
+ALTER TABLE notification
+    MODIFY COLUMN `next_attempt_at` DATETIME NOT NULL,
+    ADD KEY `idx_notification_status_next_attempt` (`status`, `next_attempt_at`);
+
+CREATE TABLE IF NOT EXISTS notification_delivery_attempt
+(
+    `id`              VARCHAR(64) NOT NULL,
+    `notification_id` VARCHAR(64) NOT NULL,
+    `attempted_at`    DATETIME    NOT NULL,
+    `error`           TEXT        NOT NULL,
+    `permanent`       TINYINT(1)  NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_delivery_attempt_notification` (`notification_id`, `attempted_at`),
+    CONSTRAINT `fk_delivery_attempt_notification` FOREIGN KEY (`notification_id`) REFERENCES notification (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
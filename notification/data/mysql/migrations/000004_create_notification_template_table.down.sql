@@ -0,0 +1,3 @@
+ALTER TABLE notification DROP COLUMN `html_body`;
+
+DROP TABLE IF EXISTS notification_template;
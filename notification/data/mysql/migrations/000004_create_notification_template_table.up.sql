@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS notification_template
+(
+    `name`       VARCHAR(128) NOT NULL,
+    `channel`    INT          NOT NULL,
+    `locale`     VARCHAR(32)  NOT NULL,
+    `subject`    VARCHAR(255) NOT NULL,
+    `text_body`  TEXT         NOT NULL,
+    `html_body`  TEXT         NOT NULL,
+    `variables`  JSON         NOT NULL,
+    `updated_at` DATETIME     NOT NULL,
+    PRIMARY KEY (`name`, `channel`, `locale`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+ALTER TABLE notification ADD COLUMN `html_body` MEDIUMTEXT NOT NULL AFTER `body`;
+
+-- Тексты, которые раньше были зашиты в код, канал 0 - email
+INSERT INTO notification_template (`name`, `channel`, `locale`, `subject`, `text_body`, `html_body`, `variables`, `updated_at`)
+VALUES ('welcome', 0, 'en',
+        'Welcome to our store!',
+        'Hi {{.firstName}}, thanks for joining us!',
+        '<p>Hi {{.firstName}}, thanks for joining us!</p>',
+        '[{"name": "firstName", "type": "string"}]',
+        NOW()),
+       ('order_confirmation', 0, 'en',
+        'Your order {{.orderID}} has been confirmed!',
+        'We have received your order and will process it shortly.',
+        '<p>We have received your order <b>{{.orderID}}</b> and will process it shortly.</p>',
+        '[{"name": "orderID", "type": "uuid"}]',
+        NOW()),
+       ('payment_failed', 0, 'en',
+        'Payment failed for order {{.orderID}}',
+        'Unfortunately, the payment for your order failed. Reason: {{.reason}}',
+        '<p>Unfortunately, the payment for your order <b>{{.orderID}}</b> failed.</p><p>Reason: {{.reason}}</p>',
+        '[{"name": "orderID", "type": "uuid"}, {"name": "reason", "type": "string"}]',
+        NOW());
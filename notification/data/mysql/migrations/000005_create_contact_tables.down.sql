@@ -0,0 +1,4 @@
+DELETE FROM notification_template WHERE `channel` IN (1, 2);
+
+DROP TABLE IF EXISTS user_device_token;
+DROP TABLE IF EXISTS user_phone_number;
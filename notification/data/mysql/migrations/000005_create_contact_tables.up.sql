@@ -0,0 +1,67 @@
+CREATE TABLE IF NOT EXISTS user_phone_number
+(
+    `user_id`    VARCHAR(64) NOT NULL,
+    `number`     VARCHAR(16) NOT NULL,
+    `updated_at` DATETIME    NOT NULL,
+    PRIMARY KEY (`user_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+-- Токены FCM бывают длиннее допустимого для индекса, поэтому уникальность держится на SHA-256 токена
+CREATE TABLE IF NOT EXISTS user_device_token
+(
+    `id`           VARCHAR(64) NOT NULL,
+    `user_id`      VARCHAR(64) NOT NULL,
+    `platform`     INT         NOT NULL,
+    `token`        TEXT        NOT NULL,
+    `token_hash`   CHAR(64)    NOT NULL,
+    `created_at`   DATETIME    NOT NULL,
+    `last_seen_at` DATETIME    NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_device_token_hash` (`token_hash`),
+    KEY `idx_device_token_user` (`user_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+-- Тексты для SMS (канал 1) и push (канал 2); у них нет HTML-варианта, у SMS тема не отправляется
+INSERT INTO notification_template (`name`, `channel`, `locale`, `subject`, `text_body`, `html_body`, `variables`, `updated_at`)
+VALUES ('welcome', 1, 'en',
+        'Welcome',
+        'Hi {{.firstName}}, thanks for joining us!',
+        '',
+        '[{"name": "firstName", "type": "string"}]',
+        NOW()),
+       ('order_confirmation', 1, 'en',
+        'Order confirmed',
+        'Your order {{.orderID}} has been confirmed.',
+        '',
+        '[{"name": "orderID", "type": "uuid"}]',
+        NOW()),
+       ('payment_failed', 1, 'en',
+        'Payment failed',
+        'Payment for order {{.orderID}} failed: {{.reason}}',
+        '',
+        '[{"name": "orderID", "type": "uuid"}, {"name": "reason", "type": "string"}]',
+        NOW()),
+       ('welcome', 2, 'en',
+        'Welcome to our store!',
+        'Hi {{.firstName}}, thanks for joining us!',
+        '',
+        '[{"name": "firstName", "type": "string"}]',
+        NOW()),
+       ('order_confirmation', 2, 'en',
+        'Order confirmed',
+        'We have received your order and will process it shortly.',
+        '',
+        '[{"name": "orderID", "type": "uuid"}]',
+        NOW()),
+       ('payment_failed', 2, 'en',
+        'Payment failed',
+        'Unfortunately, the payment for your order failed. Reason: {{.reason}}',
+        '',
+        '[{"name": "orderID", "type": "uuid"}, {"name": "reason", "type": "string"}]',
+        NOW());
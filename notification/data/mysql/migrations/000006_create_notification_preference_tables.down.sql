@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS notification_quiet_hours;
+DROP TABLE IF EXISTS notification_channel_preference;
+
+ALTER TABLE notification DROP COLUMN `category`;
+ALTER TABLE notification_template DROP COLUMN `category`;
@@ -0,0 +1,30 @@
+-- Категория: 0 - транзакционные, 1 - маркетинговые, 2 - безопасность
+ALTER TABLE notification_template ADD COLUMN `category` INT NOT NULL DEFAULT 0 AFTER `locale`;
+ALTER TABLE notification ADD COLUMN `category` INT NOT NULL DEFAULT 0 AFTER `channel`;
+
+-- Хранятся только явно заданные пары категория × канал, остальные включены
+CREATE TABLE IF NOT EXISTS notification_channel_preference
+(
+    `user_id`    VARCHAR(64) NOT NULL,
+    `category`   INT         NOT NULL,
+    `channel`    INT         NOT NULL,
+    `enabled`    TINYINT(1)  NOT NULL,
+    `updated_at` DATETIME    NOT NULL,
+    PRIMARY KEY (`user_id`, `category`, `channel`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+CREATE TABLE IF NOT EXISTS notification_quiet_hours
+(
+    `user_id`      VARCHAR(64) NOT NULL,
+    `start_minute` INT         NOT NULL,
+    `end_minute`   INT         NOT NULL,
+    `time_zone`    VARCHAR(64) NOT NULL,
+    `updated_at`   DATETIME    NOT NULL,
+    PRIMARY KEY (`user_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
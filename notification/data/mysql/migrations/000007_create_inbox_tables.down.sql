@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS parked_message;
+DROP TABLE IF EXISTS inbox_message;
+DROP TABLE IF EXISTS user_email_address;
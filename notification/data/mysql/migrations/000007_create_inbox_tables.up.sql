@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS user_email_address
+(
+    `user_id`    VARCHAR(64)  NOT NULL,
+    `address`    VARCHAR(320) NOT NULL,
+    `updated_at` DATETIME     NOT NULL,
+    PRIMARY KEY (`user_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+-- Идентификаторы уже обработанных сообщений брокера
+CREATE TABLE IF NOT EXISTS inbox_message
+(
+    `message_id`   VARCHAR(255) NOT NULL,
+    `processed_at` DATETIME     NOT NULL,
+    PRIMARY KEY (`message_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+CREATE TABLE IF NOT EXISTS parked_message
+(
+    `message_id`  VARCHAR(255) NOT NULL,
+    `routing_key` VARCHAR(255) NOT NULL,
+    `body`        MEDIUMBLOB   NOT NULL,
+    `reason`      TEXT         NOT NULL,
+    `parked_at`   DATETIME     NOT NULL,
+    PRIMARY KEY (`message_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
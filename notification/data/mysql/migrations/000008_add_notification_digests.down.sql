@@ -0,0 +1,5 @@
+DELETE FROM notification_template WHERE `name` = 'digest';
+DROP TABLE IF EXISTS notification_digest_preference;
+
+ALTER TABLE notification DROP KEY `idx_notification_status_user`;
+ALTER TABLE notification DROP COLUMN `digest_id`;
@@ -0,0 +1,25 @@
+-- digest_id - уведомление-дайджест, которым отправлено накопленное уведомление
+ALTER TABLE notification ADD COLUMN `digest_id` VARCHAR(64) NULL AFTER `locked_until`;
+ALTER TABLE notification ADD KEY `idx_notification_status_user` (`status`, `user_id`, `channel`);
+
+-- Частота: 1 - раз в час, 2 - раз в сутки; отсутствие строки - каждое письмо отдельно
+CREATE TABLE IF NOT EXISTS notification_digest_preference
+(
+    `user_id`    VARCHAR(64) NOT NULL,
+    `category`   INT         NOT NULL,
+    `frequency`  INT         NOT NULL,
+    `updated_at` DATETIME    NOT NULL,
+    PRIMARY KEY (`user_id`, `category`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+-- Шаблон дайджеста получает count и items вместо объявленных переменных
+INSERT INTO notification_template (`name`, `channel`, `locale`, `category`, `subject`, `text_body`, `html_body`, `variables`, `updated_at`)
+VALUES ('digest', 0, 'en', 0,
+        'You have {{.count}} new notifications',
+        'Here is what happened since our last email:\n{{range .items}}\n* {{.Subject}}\n  {{.Body}}\n{{end}}',
+        '<p>Here is what happened since our last email:</p><ul>{{range .items}}<li><b>{{.Subject}}</b><br>{{.Body}}</li>{{end}}</ul>',
+        '[]',
+        NOW());
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS rate_limit_counter;
+
+ALTER TABLE notification DROP COLUMN `rate_limit_reason`;
+ALTER TABLE notification DROP COLUMN `rate_limit_decision`;
@@ -0,0 +1,17 @@
+-- rate_limit_decision: 0 - не проверялось, 1 - разрешено, 2 - отложено, 3 - отброшено
+ALTER TABLE notification ADD COLUMN `rate_limit_decision` INT NOT NULL DEFAULT 0 AFTER `digest_id`;
+ALTER TABLE notification ADD COLUMN `rate_limit_reason` VARCHAR(255) NOT NULL DEFAULT '' AFTER `rate_limit_decision`;
+
+-- Счётчик отправок в фиксированном окне; ключ - получатель с категорией или канал
+CREATE TABLE IF NOT EXISTS rate_limit_counter
+(
+    `bucket_key`   VARCHAR(128) NOT NULL,
+    `window_start` DATETIME     NOT NULL,
+    `window_end`   DATETIME     NOT NULL,
+    `count`        INT          NOT NULL,
+    PRIMARY KEY (`bucket_key`, `window_start`),
+    KEY `idx_rate_limit_counter_window_end` (`window_end`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
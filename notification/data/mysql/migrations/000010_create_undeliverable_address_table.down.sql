@@ -0,0 +1 @@
+DROP TABLE IF EXISTS undeliverable_address;
@@ -0,0 +1,14 @@
+-- Адреса с жёстким отказом провайдера; уникальность держится на SHA-256, как у токенов устройств
+CREATE TABLE IF NOT EXISTS undeliverable_address
+(
+    `channel`         INT          NOT NULL,
+    `address_hash`    CHAR(64)     NOT NULL,
+    `address`         TEXT         NOT NULL,
+    `reason`          TEXT         NOT NULL,
+    `notification_id` VARCHAR(64)  NOT NULL,
+    `created_at`      DATETIME     NOT NULL,
+    PRIMARY KEY (`channel`, `address_hash`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
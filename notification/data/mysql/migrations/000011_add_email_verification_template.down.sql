@@ -0,0 +1 @@
+DELETE FROM notification_template WHERE `name` = 'email_verification';
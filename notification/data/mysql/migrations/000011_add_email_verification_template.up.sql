@@ -0,0 +1,8 @@
+-- Токен подтверждения приходит в событии сервиса user; категория 2 - безопасность, письмо не откладывается
+INSERT INTO notification_template (`name`, `channel`, `locale`, `category`, `subject`, `text_body`, `html_body`, `variables`, `updated_at`)
+VALUES ('email_verification', 0, 'en', 2,
+        'Confirm your email address',
+        'Hi {{.firstName}}, use this code to confirm your email address: {{.token}}\nThe code is valid until {{.expiresAt.Format "2006-01-02 15:04"}} UTC.',
+        '<p>Hi {{.firstName}}, use this code to confirm your email address:</p><p><b>{{.token}}</b></p><p>The code is valid until {{.expiresAt.Format "2006-01-02 15:04"}} UTC.</p>',
+        '[{"name": "firstName", "type": "string"}, {"name": "token", "type": "string"}, {"name": "expiresAt", "type": "date"}]',
+        NOW());
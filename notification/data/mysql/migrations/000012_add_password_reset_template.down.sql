@@ -0,0 +1 @@
+DELETE FROM notification_template WHERE `name` = 'password_reset';
@@ -0,0 +1,8 @@
+-- Токен сброса пароля приходит в событии сервиса user; категория 2 - безопасность, письмо не откладывается
+INSERT INTO notification_template (`name`, `channel`, `locale`, `category`, `subject`, `text_body`, `html_body`, `variables`, `updated_at`)
+VALUES ('password_reset', 0, 'en', 2,
+        'Reset your password',
+        'Hi {{.firstName}}, use this code to reset your password: {{.token}}\nThe code is valid until {{.expiresAt.Format "2006-01-02 15:04"}} UTC. If you did not request a reset, ignore this email.',
+        '<p>Hi {{.firstName}}, use this code to reset your password:</p><p><b>{{.token}}</b></p><p>The code is valid until {{.expiresAt.Format "2006-01-02 15:04"}} UTC. If you did not request a reset, ignore this email.</p>',
+        '[{"name": "firstName", "type": "string"}, {"name": "token", "type": "string"}, {"name": "expiresAt", "type": "date"}]',
+        NOW());
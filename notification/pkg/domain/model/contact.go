@@ -0,0 +1,118 @@
+package model
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidEmailAddress   = errors.New("email address is invalid")
+	ErrEmailAddressNotFound  = errors.New("user has no email address")
+	ErrInvalidPhoneNumber    = errors.New("phone number is not in E.164 format")
+	ErrPhoneNumberNotFound   = errors.New("user has no phone number")
+	ErrInvalidDeviceToken    = errors.New("device token is invalid")
+	ErrUnknownDevicePlatform = errors.New("unknown device platform")
+	ErrDeviceTokenNotFound   = errors.New("device token not found")
+	ErrNoDevicesRegistered   = errors.New("user has no registered devices")
+)
+
+type DevicePlatform int
+
+const (
+	// IOS - токены APNs
+	IOS DevicePlatform = iota
+	// Android и Web - токены FCM
+	Android
+	Web
+)
+
+// EmailAddress - адрес, на который уходят письма без явно указанного получателя
+type EmailAddress struct {
+	UserID    uuid.UUID
+	Address   string
+	UpdatedAt time.Time
+}
+
+type EmailAddressRepository interface {
+	Find(userID uuid.UUID) (*EmailAddress, error)
+	// Save создаёт или заменяет адрес пользователя
+	Save(emailAddress *EmailAddress) error
+	Delete(userID uuid.UUID) error
+}
+
+// ParseEmailAddress оставляет только сам адрес, отбрасывая отображаемое имя
+func ParseEmailAddress(address string) (string, error) {
+	parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+	if err != nil {
+		return "", ErrInvalidEmailAddress
+	}
+	return parsed.Address, nil
+}
+
+// PhoneNumber хранится в формате E.164, по одному номеру на пользователя
+type PhoneNumber struct {
+	UserID    uuid.UUID
+	Number    string
+	UpdatedAt time.Time
+}
+
+type PhoneNumberRepository interface {
+	Find(userID uuid.UUID) (*PhoneNumber, error)
+	// Save создаёт или заменяет номер пользователя
+	Save(phoneNumber *PhoneNumber) error
+	Delete(userID uuid.UUID) error
+}
+
+// DeviceToken - адрес push-уведомлений одного устройства; у пользователя их может быть несколько
+type DeviceToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Platform   DevicePlatform
+	Token      string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+}
+
+type DeviceTokenRepository interface {
+	NextID() (uuid.UUID, error)
+	FindByToken(token string) (*DeviceToken, error)
+	// Save создаёт токен или обновляет существующий с тем же значением Token,
+	// в том числе переносит его к другому пользователю
+	Save(deviceToken *DeviceToken) error
+	ListForUser(userID uuid.UUID) ([]DeviceToken, error)
+	Delete(token string) error
+}
+
+const (
+	minPhoneDigits = 7
+	maxPhoneDigits = 15
+)
+
+// ParsePhoneNumber приводит номер к E.164: убирает пробелы, скобки, дефисы и точки,
+// заменяет международный префикс 00 на +
+func ParsePhoneNumber(value string) (string, error) {
+	number := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+	digits, ok := strings.CutPrefix(number, "+")
+	if !ok || len(digits) < minPhoneDigits || len(digits) > maxPhoneDigits || digits[0] == '0' {
+		return "", ErrInvalidPhoneNumber
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidPhoneNumber
+		}
+	}
+	return number, nil
+}
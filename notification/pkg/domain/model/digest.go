@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCategoryNotBatchable   = errors.New("security notifications cannot be batched into digests")
+	ErrUnknownDigestFrequency = errors.New("unknown digest frequency")
+)
+
+type DigestFrequency int
+
+const (
+	// Immediate - каждое уведомление отправляется отдельным письмом
+	Immediate DigestFrequency = iota
+	Hourly
+	Daily
+)
+
+func (f DigestFrequency) Valid() bool {
+	return f >= Immediate && f <= Daily
+}
+
+// Period - сколько дайджест копится с момента первого попавшего в него уведомления
+func (f DigestFrequency) Period() time.Duration {
+	switch f {
+	case Hourly:
+		return time.Hour
+	case Daily:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+type DigestPreference struct {
+	Category  NotificationCategory
+	Frequency DigestFrequency
+}
+
+// DigestKey - получатель, накопленные уведомления которого уходят одним дайджестом
+type DigestKey struct {
+	UserID           uuid.UUID
+	Channel          NotificationChannel
+	RecipientAddress string
+}
@@ -1,6 +1,10 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type NotificationSent struct {
 	NotificationID uuid.UUID
@@ -18,3 +22,44 @@ type NotificationFailed struct {
 }
 
 func (e NotificationFailed) Type() string { return "NotificationFailed" }
+
+type NotificationSuppressed struct {
+	NotificationID uuid.UUID
+	UserID         uuid.UUID
+	Channel        NotificationChannel
+	Category       NotificationCategory
+}
+
+func (e NotificationSuppressed) Type() string { return "NotificationSuppressed" }
+
+type NotificationRateLimited struct {
+	NotificationID uuid.UUID
+	UserID         uuid.UUID
+	Channel        NotificationChannel
+	Category       NotificationCategory
+	Reason         string
+}
+
+func (e NotificationRateLimited) Type() string { return "NotificationRateLimited" }
+
+type NotificationDelivered struct {
+	NotificationID uuid.UUID
+	UserID         uuid.UUID
+	Channel        NotificationChannel
+	Category       NotificationCategory
+	DeliveredAt    time.Time
+}
+
+func (e NotificationDelivered) Type() string { return "NotificationDelivered" }
+
+type NotificationBounced struct {
+	NotificationID uuid.UUID
+	UserID         uuid.UUID
+	Channel        NotificationChannel
+	Category       NotificationCategory
+	Hard           bool
+	Reason         string
+	BouncedAt      time.Time
+}
+
+func (e NotificationBounced) Type() string { return "NotificationBounced" }
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrMessageAlreadyProcessed = errors.New("message already processed")
+
+// InboxRepositories - репозитории, привязанные к транзакции, в которой запоминается сообщение
+type InboxRepositories interface {
+	Notifications() NotificationRepository
+	Templates() TemplateRepository
+	Preferences() PreferenceRepository
+	EmailAddresses() EmailAddressRepository
+	PhoneNumbers() PhoneNumberRepository
+	DeviceTokens() DeviceTokenRepository
+}
+
+// Inbox делает обработку сообщений брокера идемпотентной: брокер доставляет их хотя бы один раз
+type Inbox interface {
+	// Process выполняет handle и запоминает messageID в одной транзакции с его изменениями.
+	// Для уже обработанного сообщения handle не вызывается и возвращается ErrMessageAlreadyProcessed
+	Process(messageID string, handle func(repos InboxRepositories) error) error
+}
+
+// ParkedMessage - сообщение, которое невозможно обработать; оно подтверждается брокеру, чтобы не блокировать очередь,
+// и сохраняется для разбора
+type ParkedMessage struct {
+	MessageID  string
+	RoutingKey string
+	Body       []byte
+	Reason     string
+	ParkedAt   time.Time
+}
+
+type ParkedMessageRepository interface {
+	// Park идемпотентен по MessageID
+	Park(message ParkedMessage) error
+}
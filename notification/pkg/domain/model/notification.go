@@ -8,7 +8,13 @@ import (
 )
 
 var (
-	ErrNotificationNotFound = errors.New("notification not found")
+	ErrNotificationNotFound    = errors.New("notification not found")
+	ErrNoSenderConfigured      = errors.New("no sender configured for channel")
+	ErrMissingTemplateVariable = errors.New("notification template variable is missing")
+	ErrEmptyRecipient          = errors.New("notification recipient is empty")
+	ErrNotificationNotRequeued = errors.New("only failed, dead-lettered and rate-limited notifications can be requeued")
+	// ErrPermanentFailure оборачивают ошибки отправителей, которые бессмысленно повторять
+	ErrPermanentFailure = errors.New("permanent delivery failure")
 )
 
 type NotificationChannel int
@@ -22,30 +28,102 @@ const (
 type NotificationStatus int
 
 const (
+	// Pending - уведомление ждёт отправки воркером, в том числе повторной
 	Pending NotificationStatus = iota
 	Sent
+	// Failed - отправитель вернул постоянную ошибку, повторять бесполезно
 	Failed
+	// DeadLettered - исчерпаны попытки отправки, уведомление ждёт разбора оператором
+	DeadLettered
+	// Suppressed - пользователь отключил категорию в этом канале или адрес недоставляем, уведомление не отправлялось
+	Suppressed
+	// Batched - уведомление копится для дайджеста, NextAttemptAt - когда дайджест пора отправить
+	Batched
+	// Digested - уведомление отправляется в составе дайджеста DigestID
+	Digested
+	// RateLimited - уведомление отброшено лимитом получателя
+	RateLimited
+	// Delivered, Bounced и Complained приходят от провайдера после Sent
+	Delivered
+	Bounced
+	// Complained - получатель пожаловался на спам
+	Complained
 )
 
 type Notification struct {
 	ID               uuid.UUID
 	UserID           uuid.UUID
 	Channel          NotificationChannel
+	Category         NotificationCategory
 	RecipientAddress string
 	Subject          string
 	Body             string
+	HTMLBody         string // пуст, если у шаблона нет HTML-варианта
 	Status           NotificationStatus
 	FailureReason    string
 	CreatedAt        time.Time
 	SentAt           *time.Time
+	// Attempts - число попыток с момента создания или последнего Requeue
+	Attempts      int
+	NextAttemptAt time.Time
+	// LockedUntil - срок, на который воркер захватил уведомление
+	LockedUntil *time.Time
+	// DigestID - уведомление-дайджест, в составе которого отправлено это
+	DigestID *uuid.UUID
+	// RateLimitDecision и RateLimitReason - результат последней проверки лимитов; причина пуста для Allowed
+	RateLimitDecision RateLimitDecision
+	RateLimitReason   string
 }
 
 type NotificationRepository interface {
 	NextID() (uuid.UUID, error)
 	Create(notification *Notification) error
 	Update(notification *Notification) error
+	Find(id uuid.UUID) (*Notification, error)
+	// ListForUser возвращает не больше limit последних уведомлений, новые первыми
+	ListForUser(userID uuid.UUID, limit int) ([]Notification, error)
+	// ClaimDue захватывает до limit уведомлений в Pending, чей NextAttemptAt наступил,
+	// так что параллельные воркеры не получают одни и те же уведомления
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]Notification, error)
+	ListByStatus(status NotificationStatus, limit int) ([]Notification, error)
+	// ListDueDigests возвращает до limit получателей, у которых накопленные в Batched уведомления пора
+	// отправить: наступил NextAttemptAt одного из них или их набралось не меньше maxItems
+	ListDueDigests(now time.Time, maxItems, limit int) ([]DigestKey, error)
+	// FlushDigest блокирует до maxItems старейших уведомлений получателя в Batched, пропуская захваченные
+	// другим воркером, и в одной транзакции сохраняет собранный build дайджест и переводит их в Digested.
+	// Если build вернул nil, ничего не меняется
+	FlushDigest(key DigestKey, maxItems int, build func(items []Notification) (*Notification, error)) (*Notification, error)
+}
+
+// DeliveryAttempt - запись об одной попытке отправки
+type DeliveryAttempt struct {
+	ID             uuid.UUID
+	NotificationID uuid.UUID
+	AttemptedAt    time.Time
+	// Error пуст для успешной попытки
+	Error     string
+	Permanent bool
+}
+
+type DeliveryAttemptRepository interface {
+	NextID() (uuid.UUID, error)
+	Create(attempt *DeliveryAttempt) error
+	// ListForNotification возвращает попытки в порядке AttemptedAt
+	ListForNotification(notificationID uuid.UUID) ([]DeliveryAttempt, error)
+}
+
+// OutgoingMessage - то, что уходит провайдеру; HTMLBody учитывают только каналы, которые его поддерживают.
+// Recipient push-уведомления - токен устройства или пустая строка, если отправлять на все устройства UserID
+type OutgoingMessage struct {
+	NotificationID uuid.UUID
+	UserID         uuid.UUID
+	Recipient      string
+	Subject        string
+	TextBody       string
+	HTMLBody       string
 }
 
 type NotificationSender interface {
-	Send(recipient, subject, body string) error
+	// Send возвращает ошибку, обёрнутую в ErrPermanentFailure, если повторная отправка не поможет
+	Send(message OutgoingMessage) error
 }
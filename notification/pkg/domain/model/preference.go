@@ -0,0 +1,141 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCategoryNotSuppressible = errors.New("transactional and security notifications cannot be disabled")
+	ErrUnknownCategory         = errors.New("unknown notification category")
+	ErrUnknownChannel          = errors.New("unknown notification channel")
+	ErrInvalidQuietHours       = errors.New("quiet hours are invalid")
+	ErrInvalidTimeZone         = errors.New("unknown time zone")
+)
+
+type NotificationCategory int
+
+const (
+	// Transactional - ответ на действие пользователя: заказ, оплата, регистрация
+	Transactional NotificationCategory = iota
+	Marketing
+	// Security - вход с нового устройства, смена пароля и т.п.
+	Security
+)
+
+var NotificationCategories = []NotificationCategory{Transactional, Marketing, Security}
+
+var NotificationChannels = []NotificationChannel{Email, SMS, Push}
+
+// Suppressible - можно ли отказаться от категории; транзакционные уведомления и уведомления безопасности приходят всегда
+func (c NotificationCategory) Suppressible() bool {
+	return c == Marketing
+}
+
+// Urgent - срочные уведомления отправляются и в тихие часы
+func (c NotificationCategory) Urgent() bool {
+	return c == Security
+}
+
+// Batchable - уведомления категории можно собирать в дайджест
+func (c NotificationCategory) Batchable() bool {
+	return !c.Urgent()
+}
+
+func (c NotificationCategory) Valid() bool {
+	return c >= Transactional && c <= Security
+}
+
+func (c NotificationChannel) Valid() bool {
+	return c >= Email && c <= Push
+}
+
+type ChannelPreference struct {
+	Category NotificationCategory
+	Channel  NotificationChannel
+	Enabled  bool
+}
+
+const minutesPerDay = 24 * 60
+
+// QuietHours - ежедневное окно [Start; End) в минутах от полуночи по часовому поясу пользователя.
+// Окно может переходить через полночь, например 22:00-07:00
+type QuietHours struct {
+	Start    int
+	End      int
+	TimeZone string
+}
+
+func (q QuietHours) Validate() error {
+	if q.Start < 0 || q.Start >= minutesPerDay || q.End < 0 || q.End >= minutesPerDay || q.Start == q.End {
+		return ErrInvalidQuietHours
+	}
+	if _, err := time.LoadLocation(q.TimeZone); err != nil || q.TimeZone == "" {
+		return ErrInvalidTimeZone
+	}
+	return nil
+}
+
+// DeferUntil возвращает конец окна, если now попадает в тихие часы
+func (q QuietHours) DeferUntil(now time.Time) (time.Time, bool) {
+	location, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	inside := minute >= q.Start && minute < q.End
+	if q.Start > q.End {
+		inside = minute >= q.Start || minute < q.End
+	}
+	if !inside {
+		return time.Time{}, false
+	}
+	end := time.Date(local.Year(), local.Month(), local.Day(), q.End/60, q.End%60, 0, 0, location)
+	if !end.After(local) {
+		end = time.Date(local.Year(), local.Month(), local.Day()+1, q.End/60, q.End%60, 0, 0, location)
+	}
+	return end.UTC(), true
+}
+
+// NotificationPreferences хранит только явно заданные настройки; всё, что не указано, включено
+type NotificationPreferences struct {
+	UserID     uuid.UUID
+	Channels   []ChannelPreference
+	Digests    []DigestPreference
+	QuietHours *QuietHours
+	UpdatedAt  time.Time
+}
+
+func (p *NotificationPreferences) Enabled(category NotificationCategory, channel NotificationChannel) bool {
+	if !category.Suppressible() {
+		return true
+	}
+	for _, preference := range p.Channels {
+		if preference.Category == category && preference.Channel == channel {
+			return preference.Enabled
+		}
+	}
+	return true
+}
+
+func (p *NotificationPreferences) DigestFrequency(category NotificationCategory) DigestFrequency {
+	if !category.Batchable() {
+		return Immediate
+	}
+	for _, preference := range p.Digests {
+		if preference.Category == category {
+			return preference.Frequency
+		}
+	}
+	return Immediate
+}
+
+type PreferenceRepository interface {
+	// Find возвращает пустые настройки, если пользователь ничего не менял
+	Find(userID uuid.UUID) (*NotificationPreferences, error)
+	// Save заменяет все настройки пользователя
+	Save(preferences *NotificationPreferences) error
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+type RateLimitAction int
+
+const (
+	// DelayExcess - уведомление сверх лимита ждёт следующего окна
+	DelayExcess RateLimitAction = iota
+	// DropExcess - уведомление сверх лимита не отправляется
+	DropExcess
+)
+
+// ExcessAction - что делать с уведомлением категории сверх лимита получателя: рекламу не жалко потерять,
+// остальное должно дойти. Лимиты провайдера всегда только откладывают отправку
+func (c NotificationCategory) ExcessAction() RateLimitAction {
+	if c == Marketing {
+		return DropExcess
+	}
+	return DelayExcess
+}
+
+// RateLimitDecision - результат последней проверки лимитов перед отправкой
+type RateLimitDecision int
+
+const (
+	// NotChecked - уведомление ещё не доходило до отправки
+	NotChecked RateLimitDecision = iota
+	Allowed
+	Delayed
+	Dropped
+)
+
+// RateLimitBucket - счётчик отправок в окне фиксированной длины, общий для всех воркеров
+type RateLimitBucket struct {
+	Key         string
+	Limit       int
+	WindowStart time.Time
+	Window      time.Duration
+}
+
+func (b RateLimitBucket) WindowEnd() time.Time {
+	return b.WindowStart.Add(b.Window)
+}
+
+type RateLimitRepository interface {
+	// Acquire занимает по одному месту в каждом счётчике атомарно для всех воркеров. Если хотя бы один
+	// счётчик заполнен, ничего не занимается и возвращается его индекс, иначе -1
+	Acquire(buckets []RateLimitBucket) (int, error)
+	// Release возвращает места, занятые Acquire; счётчик не опускается ниже нуля
+	Release(buckets []RateLimitBucket) error
+	// DeleteExpired удаляет счётчики окон, закончившихся до before
+	DeleteExpired(before time.Time) error
+}
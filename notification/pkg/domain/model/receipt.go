@@ -0,0 +1,56 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrUndeliverableAddressNotFound = errors.New("address is not marked undeliverable")
+
+type ReceiptType int
+
+const (
+	// ReceiptDelivered - провайдер получателя принял уведомление
+	ReceiptDelivered ReceiptType = iota
+	ReceiptBounced
+	// ReceiptComplained - получатель пометил письмо как спам
+	ReceiptComplained
+)
+
+// DeliveryReceipt - колбэк провайдера о судьбе уже отправленного уведомления
+type DeliveryReceipt struct {
+	NotificationID uuid.UUID
+	Type           ReceiptType
+	// HardBounce - адрес не существует или навсегда отклоняет уведомления
+	HardBounce bool
+	Reason     string
+	OccurredAt time.Time
+}
+
+// UndeliverableAddress - адрес, вернувший жёсткий отказ; отправка на него подавляется
+type UndeliverableAddress struct {
+	Channel        NotificationChannel
+	Address        string
+	Reason         string
+	NotificationID uuid.UUID
+	CreatedAt      time.Time
+}
+
+type UndeliverableAddressRepository interface {
+	Find(channel NotificationChannel, address string) (*UndeliverableAddress, error)
+	// Save не перезаписывает уже помеченный адрес, чтобы сохранить причину первого отказа
+	Save(address *UndeliverableAddress) error
+}
+
+// NormalizeAddress приводит адрес к виду, в котором он хранится в списке недоставляемых.
+// Регистр почтовых адресов на практике не различается
+func NormalizeAddress(channel NotificationChannel, address string) string {
+	address = strings.TrimSpace(address)
+	if channel == Email {
+		return strings.ToLower(address)
+	}
+	return address
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTemplateNotFound        = errors.New("notification template not found")
+	ErrInvalidTemplate         = errors.New("notification template is invalid")
+	ErrInvalidTemplateVariable = errors.New("notification template variable has invalid value")
+)
+
+type TemplateVariableType int
+
+const (
+	VariableString TemplateVariableType = iota
+	// VariableNumber - десятичное число, в шаблон передаётся строкой в исходном виде
+	VariableNumber
+	// VariableDate - дата в формате 2006-01-02 или RFC 3339, в шаблон передаётся как time.Time
+	VariableDate
+	VariableUUID
+)
+
+type TemplateVariable struct {
+	Name string
+	Type TemplateVariableType
+}
+
+// Template - текст уведомления для пары канал × локаль. Subject, TextBody и HTMLBody
+// записаны в синтаксисе text/template, HTMLBody экранируется как html/template.
+// По Category к уведомлению применяются настройки пользователя
+type Template struct {
+	Name      string
+	Channel   NotificationChannel
+	Locale    string
+	Category  NotificationCategory
+	Subject   string
+	TextBody  string
+	HTMLBody  string
+	Variables []TemplateVariable
+	UpdatedAt time.Time
+}
+
+type TemplateRepository interface {
+	Find(name string, channel NotificationChannel, locale string) (*Template, error)
+	// Save создаёт шаблон или заменяет существующий с тем же именем, каналом и локалью
+	Save(template *Template) error
+	List() ([]Template, error)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+// ContactService хранит адреса email, SMS и push-уведомлений пользователей
+type ContactService interface {
+	// SetEmailAddress возвращает адрес без отображаемого имени
+	SetEmailAddress(userID uuid.UUID, address string) (string, error)
+	RemoveEmailAddress(userID uuid.UUID) error
+	GetEmailAddress(userID uuid.UUID) (*model.EmailAddress, error)
+	// SetPhoneNumber возвращает номер, приведённый к E.164
+	SetPhoneNumber(userID uuid.UUID, number string) (string, error)
+	RemovePhoneNumber(userID uuid.UUID) error
+	GetPhoneNumber(userID uuid.UUID) (*model.PhoneNumber, error)
+	// RegisterDevice идемпотентен: повторная регистрация того же токена только обновляет LastSeenAt,
+	// а токен, перешедший к другому пользователю, перестаёт получать уведомления прежнего
+	RegisterDevice(userID uuid.UUID, platform model.DevicePlatform, token string) (*model.DeviceToken, error)
+	UnregisterDevice(userID uuid.UUID, token string) error
+	ListDevices(userID uuid.UUID) ([]model.DeviceToken, error)
+	// FindDevice возвращает токен, только если он зарегистрирован на этого пользователя
+	FindDevice(userID uuid.UUID, token string) (*model.DeviceToken, error)
+}
+
+const (
+	apnsTokenLength   = 64
+	maxFCMTokenLength = 4096
+)
+
+func NewContactService(
+	emailRepo model.EmailAddressRepository,
+	phoneRepo model.PhoneNumberRepository,
+	deviceRepo model.DeviceTokenRepository,
+) ContactService {
+	return &contactService{
+		emailRepo:  emailRepo,
+		phoneRepo:  phoneRepo,
+		deviceRepo: deviceRepo,
+	}
+}
+
+type contactService struct {
+	emailRepo  model.EmailAddressRepository
+	phoneRepo  model.PhoneNumberRepository
+	deviceRepo model.DeviceTokenRepository
+}
+
+func (s *contactService) SetEmailAddress(userID uuid.UUID, address string) (string, error) {
+	normalized, err := model.ParseEmailAddress(address)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	err = s.emailRepo.Save(&model.EmailAddress{
+		UserID:    userID,
+		Address:   normalized,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return normalized, nil
+}
+
+func (s *contactService) RemoveEmailAddress(userID uuid.UUID) error {
+	if _, err := s.emailRepo.Find(userID); err != nil {
+		return err
+	}
+	return s.emailRepo.Delete(userID)
+}
+
+func (s *contactService) GetEmailAddress(userID uuid.UUID) (*model.EmailAddress, error) {
+	return s.emailRepo.Find(userID)
+}
+
+func (s *contactService) SetPhoneNumber(userID uuid.UUID, number string) (string, error) {
+	normalized, err := model.ParsePhoneNumber(number)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	err = s.phoneRepo.Save(&model.PhoneNumber{
+		UserID:    userID,
+		Number:    normalized,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return normalized, nil
+}
+
+func (s *contactService) RemovePhoneNumber(userID uuid.UUID) error {
+	if _, err := s.phoneRepo.Find(userID); err != nil {
+		return err
+	}
+	return s.phoneRepo.Delete(userID)
+}
+
+func (s *contactService) GetPhoneNumber(userID uuid.UUID) (*model.PhoneNumber, error) {
+	return s.phoneRepo.Find(userID)
+}
+
+func (s *contactService) RegisterDevice(
+	userID uuid.UUID,
+	platform model.DevicePlatform,
+	token string,
+) (*model.DeviceToken, error) {
+	token, err := normalizeDeviceToken(platform, token)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	device, err := s.deviceRepo.FindByToken(token)
+	switch {
+	case err == nil:
+		if device.UserID != userID || device.Platform != platform {
+			device.UserID = userID
+			device.Platform = platform
+			device.CreatedAt = now
+		}
+	case errors.Is(err, model.ErrDeviceTokenNotFound):
+		id, err := s.deviceRepo.NextID()
+		if err != nil {
+			return nil, err
+		}
+		device = &model.DeviceToken{
+			ID:        id,
+			UserID:    userID,
+			Platform:  platform,
+			Token:     token,
+			CreatedAt: now,
+		}
+	default:
+		return nil, err
+	}
+	device.LastSeenAt = now
+	if err = s.deviceRepo.Save(device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+func (s *contactService) UnregisterDevice(userID uuid.UUID, token string) error {
+	if _, err := s.FindDevice(userID, token); err != nil {
+		return err
+	}
+	return s.deviceRepo.Delete(token)
+}
+
+func (s *contactService) ListDevices(userID uuid.UUID) ([]model.DeviceToken, error) {
+	return s.deviceRepo.ListForUser(userID)
+}
+
+func (s *contactService) FindDevice(userID uuid.UUID, token string) (*model.DeviceToken, error) {
+	device, err := s.deviceRepo.FindByToken(strings.TrimSpace(token))
+	if err != nil {
+		return nil, err
+	}
+	// Чужой токен неотличим от несуществующего
+	if device.UserID != userID {
+		return nil, errors.WithStack(model.ErrDeviceTokenNotFound)
+	}
+	return device, nil
+}
+
+// normalizeDeviceToken проверяет формат токена: APNs выдаёт 32 байта в hex, FCM - непрозрачную строку
+func normalizeDeviceToken(platform model.DevicePlatform, token string) (string, error) {
+	token = strings.TrimSpace(token)
+	switch platform {
+	case model.IOS:
+		// Старые клиенты присылают токен в виде описания NSData: "<a1b2 c3d4 ...>"
+		token = strings.ToLower(strings.NewReplacer("<", "", ">", "", " ", "").Replace(token))
+		if len(token) != apnsTokenLength || strings.Trim(token, "0123456789abcdef") != "" {
+			return "", errors.WithStack(model.ErrInvalidDeviceToken)
+		}
+	case model.Android, model.Web:
+		if token == "" || len(token) > maxFCMTokenLength || strings.Trim(token, fcmTokenAlphabet) != "" {
+			return "", errors.WithStack(model.ErrInvalidDeviceToken)
+		}
+	default:
+		return "", errors.WithStack(model.ErrUnknownDevicePlatform)
+	}
+	return token, nil
+}
+
+const fcmTokenAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_:"
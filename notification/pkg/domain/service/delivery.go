@@ -0,0 +1,267 @@
+package service
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+type DeliveryConfig struct {
+	// MaxAttempts - после стольких временных ошибок подряд уведомление уходит в DeadLettered
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// ClaimLease - на это время захваченное уведомление недоступно другим воркерам
+	ClaimLease time.Duration
+}
+
+type DeliveryService interface {
+	// DeliverDue отправляет до limit наступивших уведомлений и возвращает число обработанных
+	DeliverDue(limit int) (int, error)
+	ListDeadLettered(limit int) ([]model.Notification, error)
+	// Requeue возвращает уведомление в очередь с обнулённым счётчиком попыток, в том числе отброшенное лимитом
+	Requeue(notificationID uuid.UUID) error
+	ListAttempts(notificationID uuid.UUID) ([]model.DeliveryAttempt, error)
+}
+
+func NewDeliveryService(
+	repo model.NotificationRepository,
+	attemptRepo model.DeliveryAttemptRepository,
+	addressRepo model.UndeliverableAddressRepository,
+	senders map[model.NotificationChannel]model.NotificationSender,
+	rateLimitService RateLimitService,
+	dispatcher EventDispatcher,
+	config DeliveryConfig,
+) DeliveryService {
+	return &deliveryService{
+		repo:             repo,
+		attemptRepo:      attemptRepo,
+		addressRepo:      addressRepo,
+		senders:          senders,
+		rateLimitService: rateLimitService,
+		dispatcher:       dispatcher,
+		config:           config,
+	}
+}
+
+type deliveryService struct {
+	repo             model.NotificationRepository
+	attemptRepo      model.DeliveryAttemptRepository
+	addressRepo      model.UndeliverableAddressRepository
+	senders          map[model.NotificationChannel]model.NotificationSender
+	rateLimitService RateLimitService
+	dispatcher       EventDispatcher
+	config           DeliveryConfig
+}
+
+func (s *deliveryService) DeliverDue(limit int) (int, error) {
+	notifications, err := s.repo.ClaimDue(time.Now().UTC(), s.config.ClaimLease, limit)
+	if err != nil {
+		return 0, err
+	}
+	for i := range notifications {
+		if err := s.deliver(&notifications[i]); err != nil {
+			// Захват истечёт через ClaimLease, и уведомление подберёт следующий проход
+			return i, err
+		}
+	}
+	return len(notifications), nil
+}
+
+func (s *deliveryService) ListDeadLettered(limit int) ([]model.Notification, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	return s.repo.ListByStatus(model.DeadLettered, limit)
+}
+
+func (s *deliveryService) Requeue(notificationID uuid.UUID) error {
+	notification, err := s.repo.Find(notificationID)
+	if err != nil {
+		return err
+	}
+	if notification.Status != model.DeadLettered && notification.Status != model.Failed && notification.Status != model.RateLimited {
+		return errors.WithStack(model.ErrNotificationNotRequeued)
+	}
+	notification.Status = model.Pending
+	notification.Attempts = 0
+	notification.NextAttemptAt = time.Now().UTC()
+	notification.LockedUntil = nil
+	return s.repo.Update(notification)
+}
+
+func (s *deliveryService) ListAttempts(notificationID uuid.UUID) ([]model.DeliveryAttempt, error) {
+	if _, err := s.repo.Find(notificationID); err != nil {
+		return nil, err
+	}
+	return s.attemptRepo.ListForNotification(notificationID)
+}
+
+func (s *deliveryService) deliver(notification *model.Notification) error {
+	// Адрес мог вернуть жёсткий отказ уже после постановки уведомления в очередь
+	if notification.RecipientAddress != "" {
+		address, err := s.addressRepo.Find(
+			notification.Channel,
+			model.NormalizeAddress(notification.Channel, notification.RecipientAddress),
+		)
+		if err == nil {
+			return s.suppressUndeliverable(notification, address)
+		}
+		if !errors.Is(err, model.ErrUndeliverableAddressNotFound) {
+			return err
+		}
+	}
+
+	sender, ok := s.senders[notification.Channel]
+	var rateLimit RateLimitResult
+	if ok {
+		var err error
+		rateLimit, err = s.rateLimitService.Check(notification, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		notification.RateLimitDecision = rateLimit.Decision
+		notification.RateLimitReason = rateLimit.Reason
+		if rateLimit.Decision != model.Allowed {
+			return s.throttle(notification, rateLimit)
+		}
+	}
+
+	var sendErr error
+	if ok {
+		sendErr = sender.Send(model.OutgoingMessage{
+			NotificationID: notification.ID,
+			UserID:         notification.UserID,
+			Recipient:      notification.RecipientAddress,
+			Subject:        notification.Subject,
+			TextBody:       notification.Body,
+			HTMLBody:       notification.HTMLBody,
+		})
+	} else {
+		// Отправителя убрали из конфигурации после постановки в очередь
+		sendErr = errors.Wrapf(model.ErrPermanentFailure, "no sender configured for channel %d", notification.Channel)
+	}
+	permanent := errors.Is(sendErr, model.ErrPermanentFailure)
+	if sendErr != nil && !permanent {
+		// Временная ошибка не должна съедать лимит: иначе сбой провайдера откладывает и чужие уведомления
+		if err := s.rateLimitService.Release(rateLimit); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	attemptID, err := s.attemptRepo.NextID()
+	if err != nil {
+		return err
+	}
+	attempt := &model.DeliveryAttempt{
+		ID:             attemptID,
+		NotificationID: notification.ID,
+		AttemptedAt:    now,
+		Permanent:      permanent,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err = s.attemptRepo.Create(attempt); err != nil {
+		return err
+	}
+
+	notification.Attempts++
+	notification.LockedUntil = nil
+	switch {
+	case sendErr == nil:
+		notification.Status = model.Sent
+		notification.SentAt = &now
+		notification.FailureReason = ""
+	case permanent:
+		notification.Status = model.Failed
+		notification.FailureReason = sendErr.Error()
+	case notification.Attempts >= s.config.MaxAttempts:
+		notification.Status = model.DeadLettered
+		notification.FailureReason = sendErr.Error()
+	default:
+		notification.FailureReason = sendErr.Error()
+		notification.NextAttemptAt = now.Add(s.backoff(notification.Attempts))
+	}
+	if err = s.repo.Update(notification); err != nil {
+		return err
+	}
+
+	switch notification.Status {
+	case model.Sent:
+		_ = s.dispatcher.Dispatch(model.NotificationSent{
+			NotificationID: notification.ID, UserID: notification.UserID, Channel: notification.Channel,
+		})
+	case model.Failed, model.DeadLettered:
+		_ = s.dispatcher.Dispatch(model.NotificationFailed{
+			NotificationID: notification.ID,
+			UserID:         notification.UserID,
+			Channel:        notification.Channel,
+			Reason:         notification.FailureReason,
+		})
+	}
+	return nil
+}
+
+func (s *deliveryService) suppressUndeliverable(notification *model.Notification, address *model.UndeliverableAddress) error {
+	notification.Status = model.Suppressed
+	notification.FailureReason = "address is undeliverable: " + address.Reason
+	notification.LockedUntil = nil
+	if err := s.repo.Update(notification); err != nil {
+		return err
+	}
+	_ = s.dispatcher.Dispatch(model.NotificationSuppressed{
+		NotificationID: notification.ID,
+		UserID:         notification.UserID,
+		Channel:        notification.Channel,
+		Category:       notification.Category,
+	})
+	return nil
+}
+
+// throttle не считает задержку лимитом попыткой отправки: провайдер уведомление не видел
+func (s *deliveryService) throttle(notification *model.Notification, result RateLimitResult) error {
+	notification.LockedUntil = nil
+	if result.Decision == model.Dropped {
+		notification.Status = model.RateLimited
+	} else {
+		notification.NextAttemptAt = result.RetryAt
+	}
+	if err := s.repo.Update(notification); err != nil {
+		return err
+	}
+	if notification.Status == model.RateLimited {
+		_ = s.dispatcher.Dispatch(model.NotificationRateLimited{
+			NotificationID: notification.ID,
+			UserID:         notification.UserID,
+			Channel:        notification.Channel,
+			Category:       notification.Category,
+			Reason:         notification.RateLimitReason,
+		})
+	}
+	return nil
+}
+
+// backoff растёт экспоненциально от BaseDelay до MaxDelay; половина задержки случайна,
+// чтобы повторы после массового сбоя провайдера не приходили одновременно
+func (s *deliveryService) backoff(attempts int) time.Duration {
+	delay := s.config.MaxDelay
+	if shift := attempts - 1; shift < 32 {
+		if d := s.config.BaseDelay << shift; d > 0 && d < delay {
+			delay = d
+		}
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half+1) // nolint:gosec
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"time"
+
+	"notification/pkg/domain/model"
+)
+
+type DigestConfig struct {
+	// MaxItems - дайджест отправляется досрочно, когда в нём набралось столько уведомлений
+	MaxItems int
+}
+
+// DigestService собирает накопленные в Batched уведомления в дайджесты; безопасен для нескольких воркеров
+type DigestService interface {
+	// FlushDue ставит в очередь отправки дайджесты не больше чем для limit получателей
+	// и возвращает число созданных дайджестов
+	FlushDue(limit int) (int, error)
+}
+
+func NewDigestService(
+	repo model.NotificationRepository,
+	templateService TemplateService,
+	preferenceService PreferenceService,
+	config DigestConfig,
+) DigestService {
+	return &digestService{
+		repo:              repo,
+		templateService:   templateService,
+		preferenceService: preferenceService,
+		config:            config,
+	}
+}
+
+type digestService struct {
+	repo              model.NotificationRepository
+	templateService   TemplateService
+	preferenceService PreferenceService
+	config            DigestConfig
+}
+
+func (s *digestService) FlushDue(limit int) (int, error) {
+	now := time.Now().UTC()
+	keys, err := s.repo.ListDueDigests(now, s.config.MaxItems, limit)
+	if err != nil {
+		return 0, err
+	}
+	flushed := 0
+	for _, key := range keys {
+		digest, err := s.repo.FlushDigest(key, s.config.MaxItems, func(items []model.Notification) (*model.Notification, error) {
+			return s.buildDigest(key, items, now)
+		})
+		if err != nil {
+			return flushed, err
+		}
+		if digest != nil {
+			flushed++
+		}
+	}
+	return flushed, nil
+}
+
+func (s *digestService) buildDigest(key model.DigestKey, items []model.Notification, now time.Time) (*model.Notification, error) {
+	// Пока этот воркер ждал, другой мог забрать старые уведомления; оставшиеся уйдут в свой срок
+	if !s.due(items, now) {
+		return nil, nil
+	}
+	message, err := s.templateService.RenderDigest(key.Channel, "", items)
+	if err != nil {
+		return nil, err
+	}
+	decision, err := s.preferenceService.Decide(key.UserID, message.Category, key.Channel, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.repo.NextID()
+	if err != nil {
+		return nil, err
+	}
+	digest := &model.Notification{
+		ID:               id,
+		UserID:           key.UserID,
+		Channel:          key.Channel,
+		Category:         message.Category,
+		RecipientAddress: key.RecipientAddress,
+		Subject:          message.Subject,
+		Body:             message.TextBody,
+		HTMLBody:         message.HTMLBody,
+		Status:           model.Pending,
+		CreatedAt:        now,
+		NextAttemptAt:    now,
+	}
+	if !decision.DeferUntil.IsZero() {
+		digest.NextAttemptAt = decision.DeferUntil
+	}
+	return digest, nil
+}
+
+func (s *digestService) due(items []model.Notification, now time.Time) bool {
+	if len(items) == 0 {
+		return false
+	}
+	if len(items) >= s.config.MaxItems {
+		return true
+	}
+	for _, item := range items {
+		if !item.NextAttemptAt.After(now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+// DomainEventHandler превращает события сервисов пользователей и платежей в уведомления.
+// Email пользователя приходит только в событиях сервиса пользователей, поэтому он сохраняется
+// в контактах и подставляется в уведомления о платежах
+type DomainEventHandler interface {
+	// HandleUserCreated сохраняет адрес и отправляет приветственное письмо; без адреса ничего не делает
+	HandleUserCreated(userID uuid.UUID, login string, email *string) error
+	HandleUserEmailChanged(userID uuid.UUID, email string) error
+	HandleUserEmailRemoved(userID uuid.UUID) error
+	// HandleUserDeleted забывает все контакты пользователя
+	HandleUserDeleted(userID uuid.UUID) error
+	HandlePaymentFailed(userID, orderID uuid.UUID, reason string) error
+	// HandleVerificationRequested отправляет токен подтверждения на адрес, указанный при регистрации;
+	// в контактах адрес не сохраняется, пока его не подтвердят
+	HandleVerificationRequested(userID uuid.UUID, email, firstName, token string, expiresAt time.Time) error
+	// HandlePasswordResetRequested отправляет токен сброса на текущий адрес пользователя из события
+	HandlePasswordResetRequested(userID uuid.UUID, email, firstName, token string, expiresAt time.Time) error
+}
+
+func NewDomainEventHandler(notificationService NotificationService, contactService ContactService) DomainEventHandler {
+	return &domainEventHandler{
+		notificationService: notificationService,
+		contactService:      contactService,
+	}
+}
+
+type domainEventHandler struct {
+	notificationService NotificationService
+	contactService      ContactService
+}
+
+func (h *domainEventHandler) HandleUserCreated(userID uuid.UUID, login string, email *string) error {
+	if email == nil {
+		return nil
+	}
+	address, err := h.contactService.SetEmailAddress(userID, *email)
+	if err != nil {
+		return err
+	}
+	_, err = h.notificationService.SendWelcomeEmail(userID, address, login, "")
+	return err
+}
+
+func (h *domainEventHandler) HandleUserEmailChanged(userID uuid.UUID, email string) error {
+	_, err := h.contactService.SetEmailAddress(userID, email)
+	return err
+}
+
+func (h *domainEventHandler) HandleUserEmailRemoved(userID uuid.UUID) error {
+	return ignoreErr(h.contactService.RemoveEmailAddress(userID), model.ErrEmailAddressNotFound)
+}
+
+func (h *domainEventHandler) HandleUserDeleted(userID uuid.UUID) error {
+	if err := h.HandleUserEmailRemoved(userID); err != nil {
+		return err
+	}
+	if err := ignoreErr(h.contactService.RemovePhoneNumber(userID), model.ErrPhoneNumberNotFound); err != nil {
+		return err
+	}
+	devices, err := h.contactService.ListDevices(userID)
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		err = h.contactService.UnregisterDevice(userID, device.Token)
+		if err = ignoreErr(err, model.ErrDeviceTokenNotFound); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *domainEventHandler) HandlePaymentFailed(userID, orderID uuid.UUID, reason string) error {
+	_, err := h.notificationService.NotifyPaymentFailed(userID, "", orderID, reason, "")
+	return ignoreErr(err, model.ErrEmptyRecipient)
+}
+
+func (h *domainEventHandler) HandleVerificationRequested(
+	userID uuid.UUID,
+	email, firstName, token string,
+	expiresAt time.Time,
+) error {
+	address, err := model.ParseEmailAddress(email)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = h.notificationService.SendVerificationEmail(userID, address, firstName, token, expiresAt, "")
+	return err
+}
+
+func (h *domainEventHandler) HandlePasswordResetRequested(
+	userID uuid.UUID,
+	email, firstName, token string,
+	expiresAt time.Time,
+) error {
+	address, err := model.ParseEmailAddress(email)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = h.notificationService.SendPasswordResetEmail(userID, address, firstName, token, expiresAt, "")
+	return err
+}
+
+// ignoreErr нужен, чтобы повторная доставка события или пользователь без адреса не считались ошибкой
+func ignoreErr(err, target error) error {
+	if errors.Is(err, target) {
+		return nil
+	}
+	return err
+}
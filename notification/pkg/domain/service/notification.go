@@ -1,93 +1,264 @@
 package service
 
 import (
-	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
 	"notification/pkg/domain/model"
 )
 
 type Event interface{ Type() string }
 type EventDispatcher interface{ Dispatch(event Event) error }
 
+// Локаль во всех методах необязательна, см. TemplateService.Render
 type NotificationService interface {
-	SendWelcomeEmail(userID uuid.UUID, email, firstName string) error
-	NotifyOrderConfirmation(userID uuid.UUID, email string, orderID uuid.UUID) error
-	NotifyPaymentFailed(userID uuid.UUID, email string, orderID uuid.UUID, reason string) error
+	SendWelcomeEmail(userID uuid.UUID, email, firstName, locale string) (uuid.UUID, error)
+	NotifyOrderConfirmation(userID uuid.UUID, email string, orderID uuid.UUID, locale string) (uuid.UUID, error)
+	NotifyPaymentFailed(userID uuid.UUID, email string, orderID uuid.UUID, reason, locale string) (uuid.UUID, error)
+	// SendVerificationEmail отправляет токен подтверждения на адрес из запроса, а не на сохранённый
+	SendVerificationEmail(userID uuid.UUID, email, firstName, token string, expiresAt time.Time, locale string) (uuid.UUID, error)
+	SendPasswordResetEmail(userID uuid.UUID, email, firstName, token string, expiresAt time.Time, locale string) (uuid.UUID, error)
+	// SendNotification отправляет уведомление по шаблону templateName в произвольный канал.
+	// Пустой recipient заменяется сохранённым адресом или номером пользователя, для push - всеми его устройствами
+	SendNotification(
+		userID uuid.UUID,
+		templateName string,
+		channel model.NotificationChannel,
+		recipient string,
+		locale string,
+		variables map[string]string,
+	) (uuid.UUID, error)
+	GetNotification(id uuid.UUID) (*model.Notification, error)
+	ListNotificationsForUser(userID uuid.UUID, limit int) ([]model.Notification, error)
 }
 
-func NewNotificationService(repo model.NotificationRepository, senders map[model.NotificationChannel]model.NotificationSender, dispatcher EventDispatcher) NotificationService {
-	return &notificationService{repo: repo, senders: senders, dispatcher: dispatcher}
+const (
+	defaultListLimit = 50
+	maxListLimit     = 100
+)
+
+func NewNotificationService(
+	repo model.NotificationRepository,
+	templateService TemplateService,
+	contactService ContactService,
+	preferenceService PreferenceService,
+	senders map[model.NotificationChannel]model.NotificationSender,
+	dispatcher EventDispatcher,
+) NotificationService {
+	return &notificationService{
+		repo:              repo,
+		templateService:   templateService,
+		contactService:    contactService,
+		preferenceService: preferenceService,
+		senders:           senders,
+		dispatcher:        dispatcher,
+	}
 }
 
 type notificationService struct {
-	repo       model.NotificationRepository
-	senders    map[model.NotificationChannel]model.NotificationSender
-	dispatcher EventDispatcher
+	repo              model.NotificationRepository
+	templateService   TemplateService
+	contactService    ContactService
+	preferenceService PreferenceService
+	senders           map[model.NotificationChannel]model.NotificationSender
+	dispatcher        EventDispatcher
 }
 
-func (s *notificationService) SendWelcomeEmail(userID uuid.UUID, email, firstName string) error {
-	subject := "Welcome to our store!"
-	body := fmt.Sprintf("Hi %s, thanks for joining us!", firstName)
+func (s *notificationService) SendWelcomeEmail(userID uuid.UUID, email, firstName, locale string) (uuid.UUID, error) {
+	return s.SendNotification(userID, TemplateWelcome, model.Email, email, locale, map[string]string{
+		"firstName": firstName,
+	})
+}
+
+func (s *notificationService) NotifyOrderConfirmation(
+	userID uuid.UUID,
+	email string,
+	orderID uuid.UUID,
+	locale string,
+) (uuid.UUID, error) {
+	return s.SendNotification(userID, TemplateOrderConfirmation, model.Email, email, locale, map[string]string{
+		"orderID": orderID.String(),
+	})
+}
 
-	return s.orchestrateSend(userID, email, subject, body, model.Email)
+func (s *notificationService) NotifyPaymentFailed(
+	userID uuid.UUID,
+	email string,
+	orderID uuid.UUID,
+	reason, locale string,
+) (uuid.UUID, error) {
+	return s.SendNotification(userID, TemplatePaymentFailed, model.Email, email, locale, map[string]string{
+		"orderID": orderID.String(),
+		"reason":  reason,
+	})
 }
 
-func (s *notificationService) NotifyOrderConfirmation(userID uuid.UUID, email string, orderID uuid.UUID) error {
-	subject := fmt.Sprintf("Your order %s has been confirmed!", orderID.String())
-	body := "We have received your order and will process it shortly."
+func (s *notificationService) SendVerificationEmail(
+	userID uuid.UUID,
+	email, firstName, token string,
+	expiresAt time.Time,
+	locale string,
+) (uuid.UUID, error) {
+	return s.SendNotification(userID, TemplateEmailVerification, model.Email, email, locale, map[string]string{
+		"firstName": firstName,
+		"token":     token,
+		"expiresAt": expiresAt.UTC().Format(time.RFC3339),
+	})
+}
 
-	return s.orchestrateSend(userID, email, subject, body, model.Email)
+func (s *notificationService) SendPasswordResetEmail(
+	userID uuid.UUID,
+	email, firstName, token string,
+	expiresAt time.Time,
+	locale string,
+) (uuid.UUID, error) {
+	return s.SendNotification(userID, TemplatePasswordReset, model.Email, email, locale, map[string]string{
+		"firstName": firstName,
+		"token":     token,
+		"expiresAt": expiresAt.UTC().Format(time.RFC3339),
+	})
 }
 
-func (s *notificationService) NotifyPaymentFailed(userID uuid.UUID, email string, orderID uuid.UUID, reason string) error {
-	subject := fmt.Sprintf("Payment failed for order %s", orderID.String())
-	body := fmt.Sprintf("Unfortunately, the payment for your order failed. Reason: %s", reason)
+func (s *notificationService) SendNotification(
+	userID uuid.UUID,
+	templateName string,
+	channel model.NotificationChannel,
+	recipient string,
+	locale string,
+	variables map[string]string,
+) (uuid.UUID, error) {
+	// Без отправителя уведомление никогда не ушло бы, поэтому канал проверяется до сохранения
+	if _, ok := s.senders[channel]; !ok {
+		return uuid.Nil, errors.Wrapf(model.ErrNoSenderConfigured, "channel %d", channel)
+	}
+	recipient, err := s.resolveRecipient(userID, channel, recipient)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	message, err := s.templateService.Render(templateName, channel, locale, variables)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return s.orchestrateSend(userID, recipient, message, channel)
+}
 
-	return s.orchestrateSend(userID, email, subject, body, model.Email)
+func (s *notificationService) GetNotification(id uuid.UUID) (*model.Notification, error) {
+	return s.repo.Find(id)
 }
 
-func (s *notificationService) orchestrateSend(userID uuid.UUID, recipient, subject, body string, channel model.NotificationChannel) error {
+func (s *notificationService) ListNotificationsForUser(userID uuid.UUID, limit int) ([]model.Notification, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	return s.repo.ListForUser(userID, limit)
+}
+
+// resolveRecipient проверяет адрес получателя и подставляет сохранённый, если он не передан.
+// Пустой адрес push-уведомления означает все устройства пользователя на момент отправки
+func (s *notificationService) resolveRecipient(
+	userID uuid.UUID,
+	channel model.NotificationChannel,
+	recipient string,
+) (string, error) {
+	recipient = strings.TrimSpace(recipient)
+	switch channel {
+	case model.SMS:
+		if recipient == "" {
+			phoneNumber, err := s.contactService.GetPhoneNumber(userID)
+			if err != nil {
+				return "", err
+			}
+			return phoneNumber.Number, nil
+		}
+		number, err := model.ParsePhoneNumber(recipient)
+		return number, errors.WithStack(err)
+	case model.Push:
+		if recipient != "" {
+			device, err := s.contactService.FindDevice(userID, recipient)
+			if err != nil {
+				return "", err
+			}
+			return device.Token, nil
+		}
+		devices, err := s.contactService.ListDevices(userID)
+		if err != nil {
+			return "", err
+		}
+		if len(devices) == 0 {
+			return "", errors.WithStack(model.ErrNoDevicesRegistered)
+		}
+		return "", nil
+	default:
+		if recipient == "" {
+			emailAddress, err := s.contactService.GetEmailAddress(userID)
+			if errors.Is(err, model.ErrEmailAddressNotFound) {
+				return "", errors.WithStack(model.ErrEmptyRecipient)
+			}
+			if err != nil {
+				return "", err
+			}
+			return emailAddress.Address, nil
+		}
+		return recipient, nil
+	}
+}
+
+// orchestrateSend только сохраняет уведомление, отправляет его DeliveryService. Уведомление, отключённое
+// пользователем, сохраняется в Suppressed, отложенное в дайджест - в Batched до DigestService,
+// а попавшее на тихие часы ждёт их окончания в Pending
+func (s *notificationService) orchestrateSend(
+	userID uuid.UUID,
+	recipient string,
+	message *RenderedMessage,
+	channel model.NotificationChannel,
+) (uuid.UUID, error) {
+	now := time.Now().UTC()
+	decision, err := s.preferenceService.Decide(userID, message.Category, channel, now)
+	if err != nil {
+		return uuid.Nil, err
+	}
 	notifID, err := s.repo.NextID()
 	if err != nil {
-		return err
+		return uuid.Nil, err
 	}
 	notification := &model.Notification{
 		ID:               notifID,
 		UserID:           userID,
 		Channel:          channel,
+		Category:         message.Category,
 		RecipientAddress: recipient,
-		Subject:          subject,
-		Body:             body,
+		Subject:          message.Subject,
+		Body:             message.TextBody,
+		HTMLBody:         message.HTMLBody,
 		Status:           model.Pending,
-		CreatedAt:        time.Now().UTC(),
+		CreatedAt:        now,
+		NextAttemptAt:    now,
 	}
-	if err := s.repo.Create(notification); err != nil {
-		return err
+	switch {
+	case decision.Suppressed:
+		notification.Status = model.Suppressed
+	case decision.Digest != model.Immediate:
+		notification.Status = model.Batched
+		notification.NextAttemptAt = now.Add(decision.Digest.Period())
+	case !decision.DeferUntil.IsZero():
+		notification.NextAttemptAt = decision.DeferUntil
 	}
-
-	sender, ok := s.senders[channel]
-	if !ok {
-		return fmt.Errorf("no sender configured for channel %v", channel)
+	if err := s.repo.Create(notification); err != nil {
+		return uuid.Nil, err
 	}
-
-	err = sender.Send(recipient, subject, body)
-
-	if err != nil {
-		notification.Status = model.Failed
-		notification.FailureReason = err.Error()
-		_ = s.dispatcher.Dispatch(model.NotificationFailed{
-			NotificationID: notifID, UserID: userID, Channel: channel, Reason: err.Error(),
-		})
-	} else {
-		now := time.Now().UTC()
-		notification.Status = model.Sent
-		notification.SentAt = &now
-		_ = s.dispatcher.Dispatch(model.NotificationSent{
-			NotificationID: notifID, UserID: userID, Channel: channel,
+	if decision.Suppressed {
+		_ = s.dispatcher.Dispatch(model.NotificationSuppressed{
+			NotificationID: notifID,
+			UserID:         userID,
+			Channel:        channel,
+			Category:       message.Category,
 		})
 	}
-
-	return s.repo.Update(notification)
+	return notifID, nil
 }
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"time"
+	// Часовые пояса пользователей не должны зависеть от tzdata в образе
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+// DeliveryDecision - что делать с новым уведомлением по настройкам пользователя
+type DeliveryDecision struct {
+	Suppressed bool
+	// Digest не Immediate, если письмо нужно отложить в дайджест; тогда DeferUntil относится к самому дайджесту
+	Digest model.DigestFrequency
+	// DeferUntil не нулевое, если уведомление пришлось на тихие часы
+	DeferUntil time.Time
+}
+
+type PreferenceService interface {
+	// GetPreferences возвращает настройки для всех пар категория × канал и частоту дайджеста
+	// для всех категорий, включая значения по умолчанию
+	GetPreferences(userID uuid.UUID) (*model.NotificationPreferences, error)
+	// SetChannelPreferences меняет только переданные пары, остальные остаются как были
+	SetChannelPreferences(userID uuid.UUID, preferences []model.ChannelPreference) error
+	// SetDigestFrequency задаёт, как часто отправлять письма категории одним дайджестом
+	SetDigestFrequency(userID uuid.UUID, category model.NotificationCategory, frequency model.DigestFrequency) error
+	// SetQuietHours с nil отключает тихие часы
+	SetQuietHours(userID uuid.UUID, quietHours *model.QuietHours) error
+	Decide(userID uuid.UUID, category model.NotificationCategory, channel model.NotificationChannel, now time.Time) (DeliveryDecision, error)
+}
+
+func NewPreferenceService(repo model.PreferenceRepository) PreferenceService {
+	return &preferenceService{repo: repo}
+}
+
+type preferenceService struct {
+	repo model.PreferenceRepository
+}
+
+func (s *preferenceService) GetPreferences(userID uuid.UUID) (*model.NotificationPreferences, error) {
+	stored, err := s.repo.Find(userID)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.NotificationPreferences{
+		UserID:     userID,
+		QuietHours: stored.QuietHours,
+		UpdatedAt:  stored.UpdatedAt,
+	}
+	for _, category := range model.NotificationCategories {
+		for _, channel := range model.NotificationChannels {
+			result.Channels = append(result.Channels, model.ChannelPreference{
+				Category: category,
+				Channel:  channel,
+				Enabled:  stored.Enabled(category, channel),
+			})
+		}
+		result.Digests = append(result.Digests, model.DigestPreference{
+			Category:  category,
+			Frequency: stored.DigestFrequency(category),
+		})
+	}
+	return result, nil
+}
+
+func (s *preferenceService) SetChannelPreferences(userID uuid.UUID, preferences []model.ChannelPreference) error {
+	for _, preference := range preferences {
+		if !preference.Category.Valid() {
+			return errors.Wrapf(model.ErrUnknownCategory, "category %d", preference.Category)
+		}
+		if !preference.Channel.Valid() {
+			return errors.Wrapf(model.ErrUnknownChannel, "channel %d", preference.Channel)
+		}
+		if !preference.Enabled && !preference.Category.Suppressible() {
+			return errors.WithStack(model.ErrCategoryNotSuppressible)
+		}
+	}
+	stored, err := s.repo.Find(userID)
+	if err != nil {
+		return err
+	}
+	for _, preference := range preferences {
+		if !preference.Category.Suppressible() {
+			continue
+		}
+		stored.Channels = mergeChannelPreference(stored.Channels, preference)
+	}
+	stored.UserID = userID
+	stored.UpdatedAt = time.Now().UTC()
+	return s.repo.Save(stored)
+}
+
+func (s *preferenceService) SetDigestFrequency(
+	userID uuid.UUID,
+	category model.NotificationCategory,
+	frequency model.DigestFrequency,
+) error {
+	if !category.Valid() {
+		return errors.Wrapf(model.ErrUnknownCategory, "category %d", category)
+	}
+	if !frequency.Valid() {
+		return errors.Wrapf(model.ErrUnknownDigestFrequency, "frequency %d", frequency)
+	}
+	if !category.Batchable() && frequency != model.Immediate {
+		return errors.WithStack(model.ErrCategoryNotBatchable)
+	}
+	stored, err := s.repo.Find(userID)
+	if err != nil {
+		return err
+	}
+	digests := stored.Digests[:0]
+	for _, preference := range stored.Digests {
+		if preference.Category != category {
+			digests = append(digests, preference)
+		}
+	}
+	if frequency != model.Immediate {
+		digests = append(digests, model.DigestPreference{Category: category, Frequency: frequency})
+	}
+	stored.UserID = userID
+	stored.Digests = digests
+	stored.UpdatedAt = time.Now().UTC()
+	return s.repo.Save(stored)
+}
+
+func (s *preferenceService) SetQuietHours(userID uuid.UUID, quietHours *model.QuietHours) error {
+	if quietHours != nil {
+		if err := quietHours.Validate(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	stored, err := s.repo.Find(userID)
+	if err != nil {
+		return err
+	}
+	stored.UserID = userID
+	stored.QuietHours = quietHours
+	stored.UpdatedAt = time.Now().UTC()
+	return s.repo.Save(stored)
+}
+
+func (s *preferenceService) Decide(
+	userID uuid.UUID,
+	category model.NotificationCategory,
+	channel model.NotificationChannel,
+	now time.Time,
+) (DeliveryDecision, error) {
+	preferences, err := s.repo.Find(userID)
+	if err != nil {
+		return DeliveryDecision{}, err
+	}
+	if !preferences.Enabled(category, channel) {
+		return DeliveryDecision{Suppressed: true}, nil
+	}
+	var decision DeliveryDecision
+	// Дайджест бывает только у писем
+	if channel == model.Email {
+		decision.Digest = preferences.DigestFrequency(category)
+	}
+	if preferences.QuietHours != nil && !category.Urgent() {
+		if until, ok := preferences.QuietHours.DeferUntil(now); ok {
+			decision.DeferUntil = until
+		}
+	}
+	return decision, nil
+}
+
+func mergeChannelPreference(preferences []model.ChannelPreference, preference model.ChannelPreference) []model.ChannelPreference {
+	for i := range preferences {
+		if preferences[i].Category == preference.Category && preferences[i].Channel == preference.Channel {
+			preferences[i].Enabled = preference.Enabled
+			return preferences
+		}
+	}
+	return append(preferences, preference)
+}
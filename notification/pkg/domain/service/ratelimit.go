@@ -0,0 +1,124 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"notification/pkg/domain/model"
+)
+
+// RateLimit с нулевым Limit не ограничивает отправку
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+type RateLimitConfig struct {
+	// Recipient защищает адрес получателя от лавины уведомлений одной категории
+	Recipient map[model.NotificationCategory]RateLimit
+	// Channel - пропускная способность провайдера канала
+	Channel map[model.NotificationChannel]RateLimit
+}
+
+type RateLimitResult struct {
+	Decision model.RateLimitDecision
+	Reason   string
+	// RetryAt - начало окна, в котором отложенное уведомление можно отправить
+	RetryAt time.Time
+	// Acquired - счётчики, в которых Check занял место под разрешённую отправку
+	Acquired []model.RateLimitBucket
+}
+
+type RateLimitService interface {
+	// Check занимает место в лимитах получателя и канала под отправку уведомления
+	Check(notification *model.Notification, now time.Time) (RateLimitResult, error)
+	// Release возвращает места, занятые Check, если отправка не дошла до провайдера
+	Release(result RateLimitResult) error
+	// DeleteExpired удаляет счётчики, которые уже не могут понадобиться
+	DeleteExpired(now time.Time) error
+}
+
+func NewRateLimitService(repo model.RateLimitRepository, config RateLimitConfig) RateLimitService {
+	return &rateLimitService{repo: repo, config: config}
+}
+
+type rateLimitService struct {
+	repo   model.RateLimitRepository
+	config RateLimitConfig
+}
+
+func (s *rateLimitService) Check(notification *model.Notification, now time.Time) (RateLimitResult, error) {
+	var buckets []model.RateLimitBucket
+	// Лимит получателя идёт первым, чтобы при обоих превышениях сработала политика категории
+	recipientLimit := s.config.Recipient[notification.Category]
+	if recipientLimit.Limit > 0 {
+		buckets = append(buckets, newBucket(recipientKey(notification), recipientLimit, now))
+	}
+	if channelLimit := s.config.Channel[notification.Channel]; channelLimit.Limit > 0 {
+		buckets = append(buckets, newBucket(fmt.Sprintf("channel:%d", notification.Channel), channelLimit, now))
+	}
+	if len(buckets) == 0 {
+		return RateLimitResult{Decision: model.Allowed}, nil
+	}
+
+	exceeded, err := s.repo.Acquire(buckets)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if exceeded < 0 {
+		return RateLimitResult{Decision: model.Allowed, Acquired: buckets}, nil
+	}
+	bucket := buckets[exceeded]
+	if exceeded == 0 && recipientLimit.Limit > 0 {
+		reason := fmt.Sprintf("recipient limit of %d per %v exceeded", bucket.Limit, bucket.Window)
+		if notification.Category.ExcessAction() == model.DropExcess {
+			return RateLimitResult{Decision: model.Dropped, Reason: reason}, nil
+		}
+		return RateLimitResult{Decision: model.Delayed, Reason: reason, RetryAt: bucket.WindowEnd()}, nil
+	}
+	return RateLimitResult{
+		Decision: model.Delayed,
+		Reason:   fmt.Sprintf("channel limit of %d per %v exceeded", bucket.Limit, bucket.Window),
+		RetryAt:  bucket.WindowEnd(),
+	}, nil
+}
+
+func (s *rateLimitService) Release(result RateLimitResult) error {
+	if len(result.Acquired) == 0 {
+		return nil
+	}
+	return s.repo.Release(result.Acquired)
+}
+
+func (s *rateLimitService) DeleteExpired(now time.Time) error {
+	var longest time.Duration
+	for _, limit := range s.config.Recipient {
+		longest = max(longest, limit.Window)
+	}
+	for _, limit := range s.config.Channel {
+		longest = max(longest, limit.Window)
+	}
+	return s.repo.DeleteExpired(now.Add(-longest))
+}
+
+func newBucket(key string, limit RateLimit, now time.Time) model.RateLimitBucket {
+	return model.RateLimitBucket{
+		Key:         key,
+		Limit:       limit.Limit,
+		WindowStart: now.Truncate(limit.Window),
+		Window:      limit.Window,
+	}
+}
+
+// recipientKey хеширует адрес: токен устройства может быть длиннее допустимого для ключа.
+// Push на все устройства пользователя ограничивается по самому пользователю
+func recipientKey(notification *model.Notification) string {
+	address := notification.RecipientAddress
+	if address == "" {
+		address = notification.UserID.String()
+	}
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%d:%d:%s", notification.Channel, notification.Category, address)))
+	return "recipient:" + hex.EncodeToString(hash[:])
+}
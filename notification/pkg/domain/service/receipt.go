@@ -0,0 +1,106 @@
+package service
+
+import (
+	"time"
+
+	"notification/pkg/domain/model"
+)
+
+type ReceiptService interface {
+	// Apply переводит отправленное уведомление в состояние из квитанции провайдера. Повторные
+	// и пришедшие не по порядку квитанции игнорируются, чтобы провайдер не повторял их бесконечно
+	Apply(receipt model.DeliveryReceipt) error
+}
+
+func NewReceiptService(
+	repo model.NotificationRepository,
+	addressRepo model.UndeliverableAddressRepository,
+	dispatcher EventDispatcher,
+) ReceiptService {
+	return &receiptService{
+		repo:        repo,
+		addressRepo: addressRepo,
+		dispatcher:  dispatcher,
+	}
+}
+
+type receiptService struct {
+	repo        model.NotificationRepository
+	addressRepo model.UndeliverableAddressRepository
+	dispatcher  EventDispatcher
+}
+
+func (s *receiptService) Apply(receipt model.DeliveryReceipt) error {
+	notification, err := s.repo.Find(receipt.NotificationID)
+	if err != nil {
+		return err
+	}
+	if !receiptApplies(notification.Status, receipt.Type) {
+		return nil
+	}
+	if receipt.OccurredAt.IsZero() {
+		receipt.OccurredAt = time.Now().UTC()
+	}
+
+	switch receipt.Type {
+	case model.ReceiptDelivered:
+		notification.Status = model.Delivered
+	case model.ReceiptBounced:
+		// Адрес помечается до смены статуса: если обновление не пройдёт, повтор квитанции пометит его снова
+		if receipt.HardBounce && notification.RecipientAddress != "" {
+			err = s.addressRepo.Save(&model.UndeliverableAddress{
+				Channel:        notification.Channel,
+				Address:        model.NormalizeAddress(notification.Channel, notification.RecipientAddress),
+				Reason:         receipt.Reason,
+				NotificationID: notification.ID,
+				CreatedAt:      receipt.OccurredAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		notification.Status = model.Bounced
+		notification.FailureReason = receipt.Reason
+	case model.ReceiptComplained:
+		notification.Status = model.Complained
+		notification.FailureReason = receipt.Reason
+	}
+	if err = s.repo.Update(notification); err != nil {
+		return err
+	}
+
+	switch notification.Status {
+	case model.Delivered:
+		_ = s.dispatcher.Dispatch(model.NotificationDelivered{
+			NotificationID: notification.ID,
+			UserID:         notification.UserID,
+			Channel:        notification.Channel,
+			Category:       notification.Category,
+			DeliveredAt:    receipt.OccurredAt,
+		})
+	case model.Bounced:
+		_ = s.dispatcher.Dispatch(model.NotificationBounced{
+			NotificationID: notification.ID,
+			UserID:         notification.UserID,
+			Channel:        notification.Channel,
+			Category:       notification.Category,
+			Hard:           receipt.HardBounce,
+			Reason:         receipt.Reason,
+			BouncedAt:      receipt.OccurredAt,
+		})
+	}
+	return nil
+}
+
+// receiptApplies допускает отказ и жалобу после доставки: провайдер получателя может вернуть письмо
+// или получатель пожаловаться уже после того, как письмо принято
+func receiptApplies(status model.NotificationStatus, receiptType model.ReceiptType) bool {
+	switch status {
+	case model.Sent:
+		return true
+	case model.Delivered:
+		return receiptType == model.ReceiptBounced || receiptType == model.ReceiptComplained
+	default:
+		return false
+	}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+const (
+	TemplateWelcome           = "welcome"
+	TemplateOrderConfirmation = "order_confirmation"
+	TemplatePaymentFailed     = "payment_failed"
+	TemplateEmailVerification = "email_verification"
+	TemplatePasswordReset     = "password_reset"
+	// TemplateDigest получает не объявленные переменные, а count и items с полями Subject, Body и CreatedAt
+	TemplateDigest = "digest"
+)
+
+var (
+	numberPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+	localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+)
+
+type RenderedMessage struct {
+	Category model.NotificationCategory
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+type TemplateService interface {
+	// SaveTemplate проверяет синтаксис шаблона и сохраняет его; отправки сразу используют новый текст
+	SaveTemplate(template model.Template) error
+	ListTemplates() ([]model.Template, error)
+	// Render ищет шаблон для locale, затем для её языка без региона и в конце для локали по умолчанию
+	Render(name string, channel model.NotificationChannel, locale string, variables map[string]string) (*RenderedMessage, error)
+	// RenderDigest собирает одно сообщение со списком уведомлений по шаблону TemplateDigest
+	RenderDigest(channel model.NotificationChannel, locale string, items []model.Notification) (*RenderedMessage, error)
+}
+
+func NewTemplateService(repo model.TemplateRepository, defaultLocale string) TemplateService {
+	return &templateService{repo: repo, defaultLocale: normalizeLocale(defaultLocale)}
+}
+
+type templateService struct {
+	repo          model.TemplateRepository
+	defaultLocale string
+}
+
+func (s *templateService) SaveTemplate(t model.Template) error {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Locale = normalizeLocale(t.Locale)
+	if t.Name == "" || !localePattern.MatchString(t.Locale) || strings.TrimSpace(t.TextBody) == "" || !t.Category.Valid() {
+		return errors.WithStack(model.ErrInvalidTemplate)
+	}
+	seen := make(map[string]struct{}, len(t.Variables))
+	for _, variable := range t.Variables {
+		if _, ok := seen[variable.Name]; ok || variable.Name == "" {
+			return errors.Wrapf(model.ErrInvalidTemplate, "variable %q", variable.Name)
+		}
+		seen[variable.Name] = struct{}{}
+	}
+	parsed, err := parseTemplate(&t)
+	if err != nil {
+		return err
+	}
+	// Пробный рендер ловит обращения к необъявленным переменным до того, как шаблон попадёт в отправку
+	sample := sampleData(t.Variables)
+	if t.Name == TemplateDigest {
+		sample = digestData([]model.Notification{{}})
+	}
+	if _, err = parsed.execute(sample); err != nil {
+		return errors.Wrapf(model.ErrInvalidTemplate, "%v", err)
+	}
+	t.UpdatedAt = time.Now().UTC()
+	return s.repo.Save(&t)
+}
+
+func (s *templateService) ListTemplates() ([]model.Template, error) {
+	return s.repo.List()
+}
+
+func (s *templateService) Render(
+	name string,
+	channel model.NotificationChannel,
+	locale string,
+	variables map[string]string,
+) (*RenderedMessage, error) {
+	t, err := s.find(name, channel, locale)
+	if err != nil {
+		return nil, err
+	}
+	data, err := templateData(t.Variables, variables)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseTemplate(t)
+	if err != nil {
+		return nil, err
+	}
+	message, err := parsed.execute(data)
+	if err != nil {
+		return nil, err
+	}
+	message.Category = t.Category
+	return message, nil
+}
+
+func (s *templateService) RenderDigest(
+	channel model.NotificationChannel,
+	locale string,
+	items []model.Notification,
+) (*RenderedMessage, error) {
+	t, err := s.find(TemplateDigest, channel, locale)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseTemplate(t)
+	if err != nil {
+		return nil, err
+	}
+	message, err := parsed.execute(digestData(items))
+	if err != nil {
+		return nil, err
+	}
+	message.Category = t.Category
+	return message, nil
+}
+
+func (s *templateService) find(name string, channel model.NotificationChannel, locale string) (*model.Template, error) {
+	for _, candidate := range s.fallbackLocales(locale) {
+		t, err := s.repo.Find(name, channel, candidate)
+		if err == nil {
+			return t, nil
+		}
+		if !errors.Is(err, model.ErrTemplateNotFound) {
+			return nil, err
+		}
+	}
+	return nil, errors.Wrapf(model.ErrTemplateNotFound, "%s for channel %d", name, channel)
+}
+
+func (s *templateService) fallbackLocales(locale string) []string {
+	locale = normalizeLocale(locale)
+	var locales []string
+	add := func(l string) {
+		for _, existing := range locales {
+			if existing == l {
+				return
+			}
+		}
+		if l != "" {
+			locales = append(locales, l)
+		}
+	}
+	add(locale)
+	if i := strings.IndexByte(locale, '-'); i > 0 {
+		add(locale[:i])
+	}
+	add(s.defaultLocale)
+	return locales
+}
+
+// templateData проверяет переданные значения по объявленным типам; лишние значения отбрасываются
+func templateData(declared []model.TemplateVariable, values map[string]string) (map[string]interface{}, error) {
+	data := make(map[string]interface{}, len(declared))
+	for _, variable := range declared {
+		value, ok := values[variable.Name]
+		if !ok {
+			return nil, errors.Wrap(model.ErrMissingTemplateVariable, variable.Name)
+		}
+		switch variable.Type {
+		case model.VariableNumber:
+			if !numberPattern.MatchString(value) {
+				return nil, errors.Wrapf(model.ErrInvalidTemplateVariable, "%s must be a number", variable.Name)
+			}
+			data[variable.Name] = value
+		case model.VariableDate:
+			date, err := parseDate(value)
+			if err != nil {
+				return nil, errors.Wrapf(model.ErrInvalidTemplateVariable, "%s must be a date", variable.Name)
+			}
+			data[variable.Name] = date
+		case model.VariableUUID:
+			if _, err := uuid.Parse(value); err != nil {
+				return nil, errors.Wrapf(model.ErrInvalidTemplateVariable, "%s must be a UUID", variable.Name)
+			}
+			data[variable.Name] = value
+		default:
+			data[variable.Name] = value
+		}
+	}
+	return data, nil
+}
+
+type digestItem struct {
+	Subject   string
+	Body      string
+	CreatedAt time.Time
+}
+
+func digestData(items []model.Notification) map[string]interface{} {
+	digestItems := make([]digestItem, 0, len(items))
+	for _, item := range items {
+		digestItems = append(digestItems, digestItem{
+			Subject:   item.Subject,
+			Body:      item.Body,
+			CreatedAt: item.CreatedAt,
+		})
+	}
+	return map[string]interface{}{
+		"count": len(items),
+		"items": digestItems,
+	}
+}
+
+func sampleData(declared []model.TemplateVariable) map[string]interface{} {
+	data := make(map[string]interface{}, len(declared))
+	for _, variable := range declared {
+		if variable.Type == model.VariableDate {
+			data[variable.Name] = time.Time{}
+		} else {
+			data[variable.Name] = ""
+		}
+	}
+	return data
+}
+
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+type parsedTemplate struct {
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+	hasHTML bool
+}
+
+// parseTemplate с missingkey=error: обращение к необъявленной переменной - ошибка, а не пустая строка
+func parseTemplate(t *model.Template) (*parsedTemplate, error) {
+	subject, err := template.New("subject").Option("missingkey=error").Parse(t.Subject)
+	if err != nil {
+		return nil, errors.Wrapf(model.ErrInvalidTemplate, "subject: %v", err)
+	}
+	text, err := template.New("text").Option("missingkey=error").Parse(t.TextBody)
+	if err != nil {
+		return nil, errors.Wrapf(model.ErrInvalidTemplate, "text body: %v", err)
+	}
+	html, err := htmltemplate.New("html").Option("missingkey=error").Parse(t.HTMLBody)
+	if err != nil {
+		return nil, errors.Wrapf(model.ErrInvalidTemplate, "html body: %v", err)
+	}
+	return &parsedTemplate{
+		subject: subject,
+		text:    text,
+		html:    html,
+		hasHTML: strings.TrimSpace(t.HTMLBody) != "",
+	}, nil
+}
+
+func (p *parsedTemplate) execute(data map[string]interface{}) (*RenderedMessage, error) {
+	var subject, text, html bytes.Buffer
+	if err := p.subject.Execute(&subject, data); err != nil {
+		return nil, executionError("subject", err)
+	}
+	if err := p.text.Execute(&text, data); err != nil {
+		return nil, executionError("text body", err)
+	}
+	if p.hasHTML {
+		if err := p.html.Execute(&html, data); err != nil {
+			return nil, executionError("html body", err)
+		}
+	}
+	return &RenderedMessage{
+		Subject:  strings.TrimSpace(subject.String()),
+		TextBody: text.String(),
+		HTMLBody: html.String(),
+	}, nil
+}
+
+func executionError(part string, err error) error {
+	// Так text/template сообщает об отсутствующем ключе при missingkey=error
+	if strings.Contains(err.Error(), "map has no entry for key") {
+		return errors.Wrapf(model.ErrMissingTemplateVariable, "%s: %v", part, err)
+	}
+	return errors.Wrapf(model.ErrInvalidTemplate, "%s: %v", part, err)
+}
@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/domain/service"
+)
+
+const testAPNsToken = "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+
+func newTestContactService() service.ContactService {
+	return service.NewContactService(
+		&mockEmailAddressRepository{store: make(map[uuid.UUID]*model.EmailAddress)},
+		&mockPhoneNumberRepository{store: make(map[uuid.UUID]*model.PhoneNumber)},
+		&mockDeviceTokenRepository{store: make(map[string]*model.DeviceToken)},
+	)
+}
+
+func TestSetPhoneNumber(t *testing.T) {
+	contactService := newTestContactService()
+	userID := uuid.New()
+
+	number, err := contactService.SetPhoneNumber(userID, " +1 (555) 010-0000 ")
+	require.NoError(t, err)
+	assert.Equal(t, "+15550100000", number)
+
+	number, err = contactService.SetPhoneNumber(userID, "0049 30 1234567")
+	require.NoError(t, err)
+	assert.Equal(t, "+49301234567", number)
+	saved, err := contactService.GetPhoneNumber(userID)
+	require.NoError(t, err)
+	assert.Equal(t, "+49301234567", saved.Number)
+
+	for _, invalid := range []string{"", "5550100000", "+0123456789", "+1234", "+1234567890123456", "+1555CALLNOW"} {
+		_, err = contactService.SetPhoneNumber(userID, invalid)
+		assert.ErrorIs(t, err, model.ErrInvalidPhoneNumber, invalid)
+	}
+
+	require.NoError(t, contactService.RemovePhoneNumber(userID))
+	_, err = contactService.GetPhoneNumber(userID)
+	assert.ErrorIs(t, err, model.ErrPhoneNumberNotFound)
+	assert.ErrorIs(t, contactService.RemovePhoneNumber(userID), model.ErrPhoneNumberNotFound)
+}
+
+func TestRegisterDevice(t *testing.T) {
+	contactService := newTestContactService()
+	userID := uuid.New()
+
+	t.Run("Validates token format", func(t *testing.T) {
+		_, err := contactService.RegisterDevice(userID, model.IOS, "not-hex")
+		assert.ErrorIs(t, err, model.ErrInvalidDeviceToken)
+		_, err = contactService.RegisterDevice(userID, model.Android, "token with spaces")
+		assert.ErrorIs(t, err, model.ErrInvalidDeviceToken)
+		_, err = contactService.RegisterDevice(userID, model.Android, strings.Repeat("a", 4097))
+		assert.ErrorIs(t, err, model.ErrInvalidDeviceToken)
+		_, err = contactService.RegisterDevice(userID, model.DevicePlatform(42), "token")
+		assert.ErrorIs(t, err, model.ErrUnknownDevicePlatform)
+	})
+
+	t.Run("Registration is idempotent", func(t *testing.T) {
+		// Токен в виде описания NSData приводится к hex
+		first, err := contactService.RegisterDevice(userID, model.IOS, "<"+strings.ToUpper(testAPNsToken[:32])+" "+testAPNsToken[32:]+">")
+		require.NoError(t, err)
+		assert.Equal(t, testAPNsToken, first.Token)
+		again, err := contactService.RegisterDevice(userID, model.IOS, testAPNsToken)
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, again.ID)
+		_, err = contactService.RegisterDevice(userID, model.Android, "fcm:token_1-abc")
+		require.NoError(t, err)
+
+		devices, err := contactService.ListDevices(userID)
+		require.NoError(t, err)
+		assert.Len(t, devices, 2)
+	})
+
+	t.Run("Token moves to the new user", func(t *testing.T) {
+		otherUserID := uuid.New()
+		_, err := contactService.RegisterDevice(otherUserID, model.Android, "fcm:token_1-abc")
+		require.NoError(t, err)
+
+		devices, err := contactService.ListDevices(userID)
+		require.NoError(t, err)
+		require.Len(t, devices, 1)
+		assert.Equal(t, testAPNsToken, devices[0].Token)
+
+		// Чужой токен нельзя отвязать
+		assert.ErrorIs(t, contactService.UnregisterDevice(userID, "fcm:token_1-abc"), model.ErrDeviceTokenNotFound)
+		require.NoError(t, contactService.UnregisterDevice(otherUserID, "fcm:token_1-abc"))
+		devices, err = contactService.ListDevices(otherUserID)
+		require.NoError(t, err)
+		assert.Empty(t, devices)
+	})
+}
+
+func TestSendNotification_ResolvesContacts(t *testing.T) {
+	repo := &mockNotificationRepository{store: make(map[uuid.UUID]*model.Notification)}
+	templateService := newTestTemplateService(t)
+	for _, channel := range []model.NotificationChannel{model.SMS, model.Push} {
+		require.NoError(t, templateService.SaveTemplate(model.Template{
+			Name:      service.TemplateWelcome,
+			Channel:   channel,
+			Locale:    "en",
+			Subject:   "Welcome",
+			TextBody:  "Hi {{.firstName}}!",
+			Variables: []model.TemplateVariable{{Name: "firstName", Type: model.VariableString}},
+		}))
+	}
+	contactService := newTestContactService()
+	notificationService := service.NewNotificationService(repo, templateService, contactService, newTestPreferenceService(), map[model.NotificationChannel]model.NotificationSender{
+		model.Email: &mockNotificationSender{},
+		model.SMS:   &mockNotificationSender{},
+		model.Push:  &mockNotificationSender{},
+	}, &mockEventDispatcher{})
+	userID := uuid.New()
+	variables := map[string]string{"firstName": "John"}
+
+	t.Run("SMS", func(t *testing.T) {
+		_, err := notificationService.SendNotification(userID, service.TemplateWelcome, model.SMS, "", "", variables)
+		assert.ErrorIs(t, err, model.ErrPhoneNumberNotFound)
+		_, err = notificationService.SendNotification(userID, service.TemplateWelcome, model.SMS, "555-0100", "", variables)
+		assert.ErrorIs(t, err, model.ErrInvalidPhoneNumber)
+
+		_, err = contactService.SetPhoneNumber(userID, "+44 20 7946 0958")
+		require.NoError(t, err)
+		id, err := notificationService.SendNotification(userID, service.TemplateWelcome, model.SMS, "", "", variables)
+		require.NoError(t, err)
+		assert.Equal(t, "+442079460958", repo.store[id].RecipientAddress)
+
+		id, err = notificationService.SendNotification(userID, service.TemplateWelcome, model.SMS, "+1 555 010 0000", "", variables)
+		require.NoError(t, err)
+		assert.Equal(t, "+15550100000", repo.store[id].RecipientAddress)
+	})
+
+	t.Run("Push", func(t *testing.T) {
+		_, err := notificationService.SendNotification(userID, service.TemplateWelcome, model.Push, "", "", variables)
+		assert.ErrorIs(t, err, model.ErrNoDevicesRegistered)
+
+		_, err = contactService.RegisterDevice(userID, model.IOS, testAPNsToken)
+		require.NoError(t, err)
+		id, err := notificationService.SendNotification(userID, service.TemplateWelcome, model.Push, "", "", variables)
+		require.NoError(t, err)
+		assert.Empty(t, repo.store[id].RecipientAddress)
+
+		id, err = notificationService.SendNotification(userID, service.TemplateWelcome, model.Push, testAPNsToken, "", variables)
+		require.NoError(t, err)
+		assert.Equal(t, testAPNsToken, repo.store[id].RecipientAddress)
+
+		_, err = notificationService.SendNotification(uuid.New(), service.TemplateWelcome, model.Push, testAPNsToken, "", variables)
+		assert.ErrorIs(t, err, model.ErrDeviceTokenNotFound)
+	})
+
+	t.Run("Email still requires recipient", func(t *testing.T) {
+		_, err := notificationService.SendWelcomeEmail(userID, " ", "John", "")
+		assert.ErrorIs(t, err, model.ErrEmptyRecipient)
+	})
+}
+
+type mockEmailAddressRepository struct {
+	store map[uuid.UUID]*model.EmailAddress
+}
+
+func (m *mockEmailAddressRepository) Find(userID uuid.UUID) (*model.EmailAddress, error) {
+	if emailAddress, ok := m.store[userID]; ok {
+		return emailAddress, nil
+	}
+	return nil, model.ErrEmailAddressNotFound
+}
+func (m *mockEmailAddressRepository) Save(emailAddress *model.EmailAddress) error {
+	m.store[emailAddress.UserID] = emailAddress
+	return nil
+}
+func (m *mockEmailAddressRepository) Delete(userID uuid.UUID) error {
+	delete(m.store, userID)
+	return nil
+}
+
+type mockPhoneNumberRepository struct {
+	store map[uuid.UUID]*model.PhoneNumber
+}
+
+func (m *mockPhoneNumberRepository) Find(userID uuid.UUID) (*model.PhoneNumber, error) {
+	if phoneNumber, ok := m.store[userID]; ok {
+		return phoneNumber, nil
+	}
+	return nil, model.ErrPhoneNumberNotFound
+}
+func (m *mockPhoneNumberRepository) Save(phoneNumber *model.PhoneNumber) error {
+	m.store[phoneNumber.UserID] = phoneNumber
+	return nil
+}
+func (m *mockPhoneNumberRepository) Delete(userID uuid.UUID) error {
+	delete(m.store, userID)
+	return nil
+}
+
+type mockDeviceTokenRepository struct {
+	store map[string]*model.DeviceToken
+}
+
+func (m *mockDeviceTokenRepository) NextID() (uuid.UUID, error) { return uuid.New(), nil }
+func (m *mockDeviceTokenRepository) FindByToken(token string) (*model.DeviceToken, error) {
+	if deviceToken, ok := m.store[token]; ok {
+		found := *deviceToken
+		return &found, nil
+	}
+	return nil, model.ErrDeviceTokenNotFound
+}
+func (m *mockDeviceTokenRepository) Save(deviceToken *model.DeviceToken) error {
+	saved := *deviceToken
+	m.store[deviceToken.Token] = &saved
+	return nil
+}
+func (m *mockDeviceTokenRepository) ListForUser(userID uuid.UUID) ([]model.DeviceToken, error) {
+	var deviceTokens []model.DeviceToken
+	for _, deviceToken := range m.store {
+		if deviceToken.UserID == userID {
+			deviceTokens = append(deviceTokens, *deviceToken)
+		}
+	}
+	return deviceTokens, nil
+}
+func (m *mockDeviceTokenRepository) Delete(token string) error {
+	delete(m.store, token)
+	return nil
+}
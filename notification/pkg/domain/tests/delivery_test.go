@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/domain/service"
+)
+
+const (
+	testMaxAttempts    = 3
+	testDigestMaxItems = 3
+)
+
+type deliveryFixture struct {
+	notificationService service.NotificationService
+	deliveryService     service.DeliveryService
+	digestService       service.DigestService
+	receiptService      service.ReceiptService
+	repo                *mockNotificationRepository
+	attemptRepo         *mockDeliveryAttemptRepository
+	rateLimitRepo       *mockRateLimitRepository
+	addressRepo         *mockUndeliverableAddressRepository
+	sender              *mockNotificationSender
+	dispatcher          *mockEventDispatcher
+	contactService      service.ContactService
+	preferenceService   service.PreferenceService
+}
+
+func setupDelivery(t *testing.T) *deliveryFixture {
+	t.Helper()
+	return setupRateLimitedDelivery(t, service.RateLimitConfig{})
+}
+
+func setupRateLimitedDelivery(t *testing.T, rateLimitConfig service.RateLimitConfig) *deliveryFixture {
+	t.Helper()
+	repo := &mockNotificationRepository{
+		store:  make(map[uuid.UUID]*model.Notification),
+		locked: make(map[uuid.UUID]bool),
+	}
+	attemptRepo := &mockDeliveryAttemptRepository{}
+	rateLimitRepo := &mockRateLimitRepository{counters: make(map[string]int)}
+	addressRepo := &mockUndeliverableAddressRepository{store: make(map[string]*model.UndeliverableAddress)}
+	sender := &mockNotificationSender{}
+	dispatcher := &mockEventDispatcher{}
+	contactService := newTestContactService()
+	preferenceService := newTestPreferenceService()
+	templateService := newTestTemplateService(t)
+
+	senders := map[model.NotificationChannel]model.NotificationSender{
+		model.Email: sender,
+	}
+	return &deliveryFixture{
+		notificationService: service.NewNotificationService(
+			repo,
+			templateService,
+			contactService,
+			preferenceService,
+			senders,
+			dispatcher,
+		),
+		deliveryService: service.NewDeliveryService(
+			repo,
+			attemptRepo,
+			addressRepo,
+			senders,
+			service.NewRateLimitService(rateLimitRepo, rateLimitConfig),
+			dispatcher,
+			service.DeliveryConfig{
+				MaxAttempts: testMaxAttempts,
+				BaseDelay:   time.Minute,
+				MaxDelay:    time.Hour,
+				ClaimLease:  time.Minute,
+			},
+		),
+		digestService: service.NewDigestService(
+			repo,
+			templateService,
+			preferenceService,
+			service.DigestConfig{MaxItems: testDigestMaxItems},
+		),
+		receiptService:    service.NewReceiptService(repo, addressRepo, dispatcher),
+		repo:              repo,
+		attemptRepo:       attemptRepo,
+		rateLimitRepo:     rateLimitRepo,
+		addressRepo:       addressRepo,
+		sender:            sender,
+		dispatcher:        dispatcher,
+		contactService:    contactService,
+		preferenceService: preferenceService,
+	}
+}
+
+// makeDue переносит следующую попытку в прошлое, не дожидаясь backoff
+func (f *deliveryFixture) makeDue(id uuid.UUID) {
+	f.repo.store[id].NextAttemptAt = time.Now().Add(-time.Second)
+}
+
+func TestDeliverDue_RetriesWithBackoff(t *testing.T) {
+	f := setupDelivery(t)
+	f.sender.ShouldError = true
+	id, err := f.notificationService.SendWelcomeEmail(uuid.New(), "retry@example.com", "John", "")
+	require.NoError(t, err)
+
+	var delays []time.Duration
+	for i := 0; i < testMaxAttempts-1; i++ {
+		_, err = f.deliveryService.DeliverDue(10)
+		require.NoError(t, err)
+		notification := f.repo.store[id]
+		require.Equal(t, model.Pending, notification.Status)
+		assert.Nil(t, notification.LockedUntil)
+		delays = append(delays, time.Until(notification.NextAttemptAt))
+
+		// До наступления NextAttemptAt уведомление не захватывается
+		delivered, err := f.deliveryService.DeliverDue(10)
+		require.NoError(t, err)
+		assert.Zero(t, delivered)
+		f.makeDue(id)
+	}
+	// Задержка с джиттером лежит в [base/2; base] и удваивается с каждой попыткой
+	assert.InDelta(t, 45*time.Second, delays[0], float64(16*time.Second))
+	assert.InDelta(t, 90*time.Second, delays[1], float64(31*time.Second))
+
+	f.dispatcher.Reset()
+	_, err = f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+
+	notification := f.repo.store[id]
+	assert.Equal(t, model.DeadLettered, notification.Status)
+	assert.Equal(t, "failed to send", notification.FailureReason)
+	require.Len(t, f.dispatcher.events, 1)
+	_, ok := f.dispatcher.events[0].(model.NotificationFailed)
+	assert.True(t, ok)
+
+	attempts, err := f.deliveryService.ListAttempts(id)
+	require.NoError(t, err)
+	require.Len(t, attempts, testMaxAttempts)
+	for _, attempt := range attempts {
+		assert.Equal(t, "failed to send", attempt.Error)
+		assert.False(t, attempt.Permanent)
+	}
+
+	deadLettered, err := f.deliveryService.ListDeadLettered(0)
+	require.NoError(t, err)
+	require.Len(t, deadLettered, 1)
+	assert.Equal(t, id, deadLettered[0].ID)
+
+	t.Run("Requeue", func(t *testing.T) {
+		f.sender.ShouldError = false
+		require.NoError(t, f.deliveryService.Requeue(id))
+		assert.Equal(t, model.Pending, f.repo.store[id].Status)
+		assert.Zero(t, f.repo.store[id].Attempts)
+
+		_, err := f.deliveryService.DeliverDue(10)
+		require.NoError(t, err)
+		assert.Equal(t, model.Sent, f.repo.store[id].Status)
+
+		attempts, _ := f.deliveryService.ListAttempts(id)
+		assert.Len(t, attempts, testMaxAttempts+1)
+		assert.ErrorIs(t, f.deliveryService.Requeue(id), model.ErrNotificationNotRequeued)
+	})
+}
+
+func TestDeliverDue_PermanentFailure(t *testing.T) {
+	f := setupDelivery(t)
+	f.sender.Permanent = true
+	id, err := f.notificationService.SendWelcomeEmail(uuid.New(), "gone@example.com", "John", "")
+	require.NoError(t, err)
+
+	_, err = f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+
+	assert.Equal(t, model.Failed, f.repo.store[id].Status)
+	assert.Equal(t, 1, f.sender.SendCount)
+	attempts, _ := f.deliveryService.ListAttempts(id)
+	require.Len(t, attempts, 1)
+	assert.True(t, attempts[0].Permanent)
+}
+
+func TestDeliverDue_SkipsClaimed(t *testing.T) {
+	f := setupDelivery(t)
+	id, err := f.notificationService.SendWelcomeEmail(uuid.New(), "claimed@example.com", "John", "")
+	require.NoError(t, err)
+	lockedUntil := time.Now().Add(time.Minute)
+	f.repo.store[id].LockedUntil = &lockedUntil
+
+	delivered, err := f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	assert.Zero(t, f.sender.SendCount)
+}
+
+type mockDeliveryAttemptRepository struct {
+	attempts []model.DeliveryAttempt
+}
+
+func (m *mockDeliveryAttemptRepository) NextID() (uuid.UUID, error) { return uuid.New(), nil }
+func (m *mockDeliveryAttemptRepository) Create(attempt *model.DeliveryAttempt) error {
+	m.attempts = append(m.attempts, *attempt)
+	return nil
+}
+func (m *mockDeliveryAttemptRepository) ListForNotification(notificationID uuid.UUID) ([]model.DeliveryAttempt, error) {
+	var attempts []model.DeliveryAttempt
+	for _, attempt := range m.attempts {
+		if attempt.NotificationID == notificationID {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
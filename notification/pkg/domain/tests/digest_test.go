@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+)
+
+func TestSetDigestFrequency_Validates(t *testing.T) {
+	preferenceService := newTestPreferenceService()
+	userID := uuid.New()
+
+	assert.ErrorIs(t, preferenceService.SetDigestFrequency(userID, model.Security, model.Hourly), model.ErrCategoryNotBatchable)
+	assert.ErrorIs(t, preferenceService.SetDigestFrequency(userID, model.Marketing, model.DigestFrequency(42)), model.ErrUnknownDigestFrequency)
+	assert.ErrorIs(t, preferenceService.SetDigestFrequency(userID, model.NotificationCategory(42), model.Hourly), model.ErrUnknownCategory)
+	require.NoError(t, preferenceService.SetDigestFrequency(userID, model.Security, model.Immediate))
+
+	require.NoError(t, preferenceService.SetDigestFrequency(userID, model.Marketing, model.Daily))
+	require.NoError(t, preferenceService.SetDigestFrequency(userID, model.Transactional, model.Hourly))
+	require.NoError(t, preferenceService.SetDigestFrequency(userID, model.Transactional, model.Immediate))
+
+	preferences, err := preferenceService.GetPreferences(userID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.DigestPreference{
+		{Category: model.Transactional, Frequency: model.Immediate},
+		{Category: model.Marketing, Frequency: model.Daily},
+		{Category: model.Security, Frequency: model.Immediate},
+	}, preferences.Digests)
+}
+
+func TestDigest_FlushedOnSchedule(t *testing.T) {
+	f := setupDelivery(t)
+	userID := uuid.New()
+	require.NoError(t, f.preferenceService.SetDigestFrequency(userID, model.Transactional, model.Hourly))
+
+	var ids []uuid.UUID
+	for i := 0; i < 2; i++ {
+		id, err := f.notificationService.NotifyOrderConfirmation(userID, "busy@example.com", uuid.New(), "")
+		require.NoError(t, err)
+		ids = append(ids, id)
+		notification := f.repo.store[id]
+		assert.Equal(t, model.Batched, notification.Status)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), notification.NextAttemptAt, time.Minute)
+	}
+
+	// До срока дайджест не собирается и ничего не отправляется
+	flushed, err := f.digestService.FlushDue(10)
+	require.NoError(t, err)
+	assert.Zero(t, flushed)
+	delivered, err := f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+
+	f.makeDue(ids[0])
+	flushed, err = f.digestService.FlushDue(10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, flushed)
+
+	digestID := f.repo.store[ids[0]].DigestID
+	require.NotNil(t, digestID)
+	for _, id := range ids {
+		assert.Equal(t, model.Digested, f.repo.store[id].Status)
+		assert.Equal(t, *digestID, *f.repo.store[id].DigestID)
+	}
+	digest := f.repo.store[*digestID]
+	assert.Equal(t, model.Pending, digest.Status)
+	assert.Equal(t, "busy@example.com", digest.RecipientAddress)
+	assert.Equal(t, "You have 2 new notifications", digest.Subject)
+	assert.Contains(t, digest.Body, f.repo.store[ids[0]].Subject)
+	assert.Contains(t, digest.Body, f.repo.store[ids[1]].Subject)
+
+	delivered, err = f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, 1, f.sender.SendCount)
+	assert.Equal(t, *digestID, f.sender.LastMessage.NotificationID)
+
+	t.Run("Security and other users are not batched", func(t *testing.T) {
+		id, err := f.notificationService.SendWelcomeEmail(uuid.New(), "other@example.com", "John", "")
+		require.NoError(t, err)
+		assert.Equal(t, model.Pending, f.repo.store[id].Status)
+	})
+}
+
+func TestDigest_FlushedAtSizeLimit(t *testing.T) {
+	f := setupDelivery(t)
+	userID := uuid.New()
+	require.NoError(t, f.preferenceService.SetDigestFrequency(userID, model.Transactional, model.Daily))
+
+	var ids []uuid.UUID
+	for i := 0; i < testDigestMaxItems+1; i++ {
+		id, err := f.notificationService.NotifyOrderConfirmation(userID, "busy@example.com", uuid.New(), "")
+		require.NoError(t, err)
+		f.repo.store[id].CreatedAt = f.repo.store[id].CreatedAt.Add(time.Duration(i) * time.Millisecond)
+		ids = append(ids, id)
+	}
+
+	flushed, err := f.digestService.FlushDue(10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, flushed)
+	for _, id := range ids[:testDigestMaxItems] {
+		assert.Equal(t, model.Digested, f.repo.store[id].Status)
+	}
+	// Не поместившееся уведомление ждёт следующего дайджеста
+	assert.Equal(t, model.Batched, f.repo.store[ids[testDigestMaxItems]].Status)
+
+	flushed, err = f.digestService.FlushDue(10)
+	require.NoError(t, err)
+	assert.Zero(t, flushed)
+}
+
+func TestDigest_SkipsItemsClaimedByAnotherWorker(t *testing.T) {
+	f := setupDelivery(t)
+	userID := uuid.New()
+	require.NoError(t, f.preferenceService.SetDigestFrequency(userID, model.Transactional, model.Hourly))
+
+	oldID, err := f.notificationService.NotifyOrderConfirmation(userID, "busy@example.com", uuid.New(), "")
+	require.NoError(t, err)
+	f.makeDue(oldID)
+	newID, err := f.notificationService.NotifyOrderConfirmation(userID, "busy@example.com", uuid.New(), "")
+	require.NoError(t, err)
+
+	// Другой воркер держит просроченное уведомление: свежее одно не отправляется раньше срока
+	f.repo.locked[oldID] = true
+	flushed, err := f.digestService.FlushDue(10)
+	require.NoError(t, err)
+	assert.Zero(t, flushed)
+	assert.Equal(t, model.Batched, f.repo.store[newID].Status)
+
+	delete(f.repo.locked, oldID)
+	flushed, err = f.digestService.FlushDue(10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, flushed)
+	assert.Equal(t, model.Digested, f.repo.store[newID].Status)
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/domain/service"
+)
+
+func TestDomainEventHandler_UserLifecycle(t *testing.T) {
+	f := setupDelivery(t)
+	eventHandler := service.NewDomainEventHandler(f.notificationService, f.contactService)
+	userID := uuid.New()
+	email := "John <john@example.com>"
+
+	require.NoError(t, eventHandler.HandleUserCreated(userID, "john", &email))
+	stored, err := f.contactService.GetEmailAddress(userID)
+	require.NoError(t, err)
+	assert.Equal(t, "john@example.com", stored.Address)
+
+	require.Len(t, f.repo.store, 1)
+	for _, notification := range f.repo.store {
+		assert.Equal(t, "john@example.com", notification.RecipientAddress)
+		assert.Contains(t, notification.Body, "Hi john")
+	}
+
+	t.Run("Payments use the stored address", func(t *testing.T) {
+		require.NoError(t, eventHandler.HandleUserEmailChanged(userID, "new@example.com"))
+		orderID := uuid.New()
+		require.NoError(t, eventHandler.HandlePaymentFailed(userID, orderID, "insufficient funds"))
+
+		notifications, err := f.notificationService.ListNotificationsForUser(userID, 0)
+		require.NoError(t, err)
+		require.Len(t, notifications, 2)
+		assert.Equal(t, "new@example.com", notifications[0].RecipientAddress)
+		assert.Contains(t, notifications[0].Subject, orderID.String())
+	})
+
+	t.Run("Deleted user gets nothing", func(t *testing.T) {
+		_, err := f.contactService.SetPhoneNumber(userID, "+15550100000")
+		require.NoError(t, err)
+		_, err = f.contactService.RegisterDevice(userID, model.Android, "device-token")
+		require.NoError(t, err)
+
+		require.NoError(t, eventHandler.HandleUserDeleted(userID))
+		// Повторная доставка события не падает
+		require.NoError(t, eventHandler.HandleUserDeleted(userID))
+
+		_, err = f.contactService.GetPhoneNumber(userID)
+		assert.ErrorIs(t, err, model.ErrPhoneNumberNotFound)
+		devices, err := f.contactService.ListDevices(userID)
+		require.NoError(t, err)
+		assert.Empty(t, devices)
+
+		count := len(f.repo.store)
+		require.NoError(t, eventHandler.HandlePaymentFailed(userID, uuid.New(), "insufficient funds"))
+		assert.Len(t, f.repo.store, count)
+	})
+}
+
+func TestDomainEventHandler_VerificationRequested(t *testing.T) {
+	f := setupDelivery(t)
+	eventHandler := service.NewDomainEventHandler(f.notificationService, f.contactService)
+	userID := uuid.New()
+	expiresAt := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+
+	require.NoError(t, eventHandler.HandleVerificationRequested(userID, "john@example.com", "John", "raw-token", expiresAt))
+
+	require.Len(t, f.repo.store, 1)
+	for _, notification := range f.repo.store {
+		assert.Equal(t, "john@example.com", notification.RecipientAddress)
+		assert.Equal(t, model.Security, notification.Category)
+		assert.Contains(t, notification.Body, "raw-token")
+		assert.Contains(t, notification.Body, "2026-10-19 12:30")
+	}
+	// Адрес не подтверждён, поэтому в контакты не попадает
+	_, err := f.contactService.GetEmailAddress(userID)
+	assert.ErrorIs(t, err, model.ErrEmailAddressNotFound)
+
+	invalid := "not an email"
+	assert.ErrorIs(t, eventHandler.HandleVerificationRequested(userID, invalid, "John", "raw-token", expiresAt), model.ErrInvalidEmailAddress)
+}
+
+func TestDomainEventHandler_PasswordResetRequested(t *testing.T) {
+	f := setupDelivery(t)
+	eventHandler := service.NewDomainEventHandler(f.notificationService, f.contactService)
+	userID := uuid.New()
+	expiresAt := time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC)
+
+	require.NoError(t, eventHandler.HandlePasswordResetRequested(userID, "john@example.com", "John", "reset-token", expiresAt))
+
+	require.Len(t, f.repo.store, 1)
+	for _, notification := range f.repo.store {
+		assert.Equal(t, "john@example.com", notification.RecipientAddress)
+		assert.Equal(t, model.Security, notification.Category)
+		assert.Contains(t, notification.Body, "reset-token")
+	}
+	assert.ErrorIs(t, eventHandler.HandlePasswordResetRequested(userID, "invalid", "John", "reset-token", expiresAt), model.ErrInvalidEmailAddress)
+}
+
+func TestDomainEventHandler_Validates(t *testing.T) {
+	f := setupDelivery(t)
+	eventHandler := service.NewDomainEventHandler(f.notificationService, f.contactService)
+
+	invalid := "not an email"
+	assert.ErrorIs(t, eventHandler.HandleUserCreated(uuid.New(), "john", &invalid), model.ErrInvalidEmailAddress)
+	assert.ErrorIs(t, eventHandler.HandleUserEmailChanged(uuid.New(), invalid), model.ErrInvalidEmailAddress)
+
+	// Пользователь без адреса регистрируется без приветственного письма
+	require.NoError(t, eventHandler.HandleUserCreated(uuid.New(), "john", nil))
+	require.NoError(t, eventHandler.HandleUserEmailRemoved(uuid.New()))
+	assert.Empty(t, f.repo.store)
+}
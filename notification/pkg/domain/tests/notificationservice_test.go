@@ -2,29 +2,25 @@ package tests
 
 import (
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"notification/pkg/domain/model"
 	"notification/pkg/domain/service"
+	"sort"
 	"testing"
+	"time"
 )
 
 func setup(t *testing.T) (service.NotificationService, *mockNotificationRepository, *mockNotificationSender, *mockEventDispatcher) {
-	repo := &mockNotificationRepository{store: make(map[uuid.UUID]*model.Notification)}
-	sender := &mockNotificationSender{}
-	dispatcher := &mockEventDispatcher{}
-
-	senders := map[model.NotificationChannel]model.NotificationSender{
-		model.Email: sender,
-	}
-
-	notificationService := service.NewNotificationService(repo, senders, dispatcher)
-	return notificationService, repo, sender, dispatcher
+	f := setupDelivery(t)
+	return f.notificationService, f.repo, f.sender, f.dispatcher
 }
 
 func TestSendWelcomeEmail(t *testing.T) {
-	notificationService, repo, sender, dispatcher := setup(t)
+	f := setupDelivery(t)
+	notificationService, repo, sender, dispatcher := f.notificationService, f.repo, f.sender, f.dispatcher
 
 	t.Run("Success path", func(t *testing.T) {
 		sender.ShouldError = false
@@ -32,18 +28,25 @@ func TestSendWelcomeEmail(t *testing.T) {
 
 		userID := uuid.New()
 		email := "test@example.com"
-		err := notificationService.SendWelcomeEmail(userID, email, "John")
+		notificationID, err := notificationService.SendWelcomeEmail(userID, email, "John", "")
+
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, notificationID)
+		// Запрос только ставит уведомление в очередь
+		assert.Equal(t, 0, sender.SendCount)
+		assert.Equal(t, model.Pending, repo.store[notificationID].Status)
 
+		delivered, err := f.deliveryService.DeliverDue(10)
 		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
 
 		assert.Equal(t, 1, sender.SendCount)
 		assert.Equal(t, email, sender.LastRecipient)
+		assert.Equal(t, notificationID, sender.LastMessage.NotificationID)
+		assert.Contains(t, sender.LastMessage.HTMLBody, "<p>Hi John")
 
 		require.Len(t, repo.store, 1)
-		var savedNotif *model.Notification
-		for _, n := range repo.store {
-			savedNotif = n
-		}
+		savedNotif := repo.store[notificationID]
 		assert.Equal(t, model.Sent, savedNotif.Status)
 		assert.NotNil(t, savedNotif.SentAt)
 
@@ -56,27 +59,105 @@ func TestSendWelcomeEmail(t *testing.T) {
 		sender.ShouldError = true
 		dispatcher.Reset()
 
-		err := notificationService.SendWelcomeEmail(uuid.New(), "fail@example.com", "Jane")
+		notificationID, err := notificationService.SendWelcomeEmail(uuid.New(), "fail@example.com", "Jane", "")
+		require.NoError(t, err)
+		_, err = f.deliveryService.DeliverDue(10)
 		require.NoError(t, err)
 
-		var savedNotif *model.Notification
-		for _, n := range repo.store {
-			if n.RecipientAddress == "fail@example.com" {
-				savedNotif = n
-			}
-		}
+		savedNotif := repo.store[notificationID]
 		require.NotNil(t, savedNotif)
-		assert.Equal(t, model.Failed, savedNotif.Status)
+		// Временная ошибка не делает уведомление окончательно неотправленным
+		assert.Equal(t, model.Pending, savedNotif.Status)
 		assert.Equal(t, "failed to send", savedNotif.FailureReason)
+		assert.True(t, savedNotif.NextAttemptAt.After(time.Now()))
+		assert.Empty(t, dispatcher.events)
+	})
+}
 
-		require.Len(t, dispatcher.events, 1)
-		_, ok := dispatcher.events[0].(model.NotificationFailed)
-		assert.True(t, ok)
+func TestSendNotification(t *testing.T) {
+	notificationService, repo, sender, _ := setup(t)
+	userID := uuid.New()
+
+	t.Run("Renders template", func(t *testing.T) {
+		sender.ShouldError = false
+		orderID := uuid.New()
+		notificationID, err := notificationService.SendNotification(
+			userID, service.TemplatePaymentFailed, model.Email, "pay@example.com", "",
+			map[string]string{"orderID": orderID.String(), "reason": "card declined"},
+		)
+		require.NoError(t, err)
+
+		saved := repo.store[notificationID]
+		require.NotNil(t, saved)
+		assert.Equal(t, "Payment failed for order "+orderID.String(), saved.Subject)
+		assert.Contains(t, saved.Body, "card declined")
+	})
+
+	t.Run("Missing variable", func(t *testing.T) {
+		_, err := notificationService.SendNotification(
+			userID, service.TemplatePaymentFailed, model.Email, "pay@example.com", "",
+			map[string]string{"orderID": uuid.NewString()},
+		)
+		assert.ErrorIs(t, err, model.ErrMissingTemplateVariable)
+	})
+
+	t.Run("Unknown template", func(t *testing.T) {
+		_, err := notificationService.SendNotification(userID, "unknown", model.Email, "pay@example.com", "", nil)
+		assert.ErrorIs(t, err, model.ErrTemplateNotFound)
+	})
+
+	t.Run("Channel without sender is not persisted", func(t *testing.T) {
+		before := len(repo.store)
+		_, err := notificationService.SendNotification(
+			userID, service.TemplateWelcome, model.SMS, "+10000000000", "", map[string]string{"firstName": "John"},
+		)
+		assert.ErrorIs(t, err, model.ErrNoSenderConfigured)
+		assert.Len(t, repo.store, before)
 	})
 }
 
+func TestGetNotification(t *testing.T) {
+	f := setupDelivery(t)
+	notificationService := f.notificationService
+	userID := uuid.New()
+
+	f.sender.Permanent = true
+	failedID, err := notificationService.NotifyOrderConfirmation(userID, "order@example.com", uuid.New(), "")
+	require.NoError(t, err)
+	_, err = f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+	f.sender.Permanent = false
+	sentID, err := notificationService.SendWelcomeEmail(userID, "order@example.com", "John", "")
+	require.NoError(t, err)
+	_, err = notificationService.SendWelcomeEmail(uuid.New(), "other@example.com", "Jane", "")
+	require.NoError(t, err)
+	_, err = f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+
+	failed, err := notificationService.GetNotification(failedID)
+	require.NoError(t, err)
+	assert.Equal(t, model.Failed, failed.Status)
+	assert.Contains(t, failed.FailureReason, "mailbox does not exist")
+	assert.Nil(t, failed.SentAt)
+
+	notifications, err := notificationService.ListNotificationsForUser(userID, 0)
+	require.NoError(t, err)
+	require.Len(t, notifications, 2)
+	for _, notification := range notifications {
+		if notification.ID == sentID {
+			assert.Equal(t, model.Sent, notification.Status)
+			assert.NotNil(t, notification.SentAt)
+		}
+	}
+
+	_, err = notificationService.GetNotification(uuid.New())
+	assert.ErrorIs(t, err, model.ErrNotificationNotFound)
+}
+
 type mockNotificationRepository struct {
 	store map[uuid.UUID]*model.Notification
+	// locked имитирует строки, захваченные другим воркером через SKIP LOCKED
+	locked map[uuid.UUID]bool
 }
 
 func (m *mockNotificationRepository) NextID() (uuid.UUID, error) { return uuid.New(), nil }
@@ -89,15 +170,117 @@ func (m *mockNotificationRepository) Update(n *model.Notification) error {
 	return nil
 }
 
+func (m *mockNotificationRepository) Find(id uuid.UUID) (*model.Notification, error) {
+	if n, ok := m.store[id]; ok {
+		return n, nil
+	}
+	return nil, model.ErrNotificationNotFound
+}
+func (m *mockNotificationRepository) ListForUser(userID uuid.UUID, limit int) ([]model.Notification, error) {
+	var notifications []model.Notification
+	for _, n := range m.store {
+		if n.UserID == userID {
+			notifications = append(notifications, *n)
+		}
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+	}
+	return notifications, nil
+}
+func (m *mockNotificationRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]model.Notification, error) {
+	var claimed []model.Notification
+	for _, n := range m.store {
+		if len(claimed) == limit {
+			break
+		}
+		if n.Status != model.Pending || n.NextAttemptAt.After(now) || (n.LockedUntil != nil && n.LockedUntil.After(now)) {
+			continue
+		}
+		lockedUntil := now.Add(lease)
+		n.LockedUntil = &lockedUntil
+		claimed = append(claimed, *n)
+	}
+	return claimed, nil
+}
+func (m *mockNotificationRepository) ListByStatus(status model.NotificationStatus, limit int) ([]model.Notification, error) {
+	var notifications []model.Notification
+	for _, n := range m.store {
+		if n.Status == status && len(notifications) < limit {
+			notifications = append(notifications, *n)
+		}
+	}
+	return notifications, nil
+}
+
+func (m *mockNotificationRepository) ListDueDigests(now time.Time, maxItems, limit int) ([]model.DigestKey, error) {
+	counts := make(map[model.DigestKey]int)
+	due := make(map[model.DigestKey]bool)
+	for _, n := range m.store {
+		if n.Status != model.Batched {
+			continue
+		}
+		key := model.DigestKey{UserID: n.UserID, Channel: n.Channel, RecipientAddress: n.RecipientAddress}
+		counts[key]++
+		due[key] = due[key] || !n.NextAttemptAt.After(now) || counts[key] >= maxItems
+	}
+	var keys []model.DigestKey
+	for key, ok := range due {
+		if ok && len(keys) < limit {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+func (m *mockNotificationRepository) FlushDigest(
+	key model.DigestKey,
+	maxItems int,
+	build func(items []model.Notification) (*model.Notification, error),
+) (*model.Notification, error) {
+	var items []model.Notification
+	for _, n := range m.store {
+		if n.Status == model.Batched && !m.locked[n.ID] &&
+			n.UserID == key.UserID && n.Channel == key.Channel && n.RecipientAddress == key.RecipientAddress {
+			items = append(items, *n)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	if len(items) > maxItems {
+		items = items[:maxItems]
+	}
+	digest, err := build(items)
+	if err != nil || digest == nil {
+		return nil, err
+	}
+	m.store[digest.ID] = digest
+	for _, item := range items {
+		n := m.store[item.ID]
+		n.Status = model.Digested
+		n.DigestID = &digest.ID
+	}
+	return digest, nil
+}
+
 type mockNotificationSender struct {
 	SendCount     int
 	LastRecipient string
+	LastMessage   model.OutgoingMessage
 	ShouldError   bool
+	Permanent     bool
 }
 
-func (m *mockNotificationSender) Send(recipient, subject, body string) error {
+func (m *mockNotificationSender) Send(message model.OutgoingMessage) error {
 	m.SendCount++
-	m.LastRecipient = recipient
+	m.LastRecipient = message.Recipient
+	m.LastMessage = message
+	if m.Permanent {
+		return fmt.Errorf("%w: mailbox does not exist", model.ErrPermanentFailure)
+	}
 	if m.ShouldError {
 		return errors.New("failed to send")
 	}
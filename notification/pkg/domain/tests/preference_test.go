@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/domain/service"
+)
+
+const templatePromo = "promo"
+
+func newTestPreferenceService() service.PreferenceService {
+	return service.NewPreferenceService(&mockPreferenceRepository{store: make(map[uuid.UUID]*model.NotificationPreferences)})
+}
+
+// setupPreferenceDelivery добавляет к фикстуре доставки маркетинговый шаблон
+func setupPreferenceDelivery(t *testing.T) (*deliveryFixture, service.NotificationService) {
+	t.Helper()
+	f := setupDelivery(t)
+	templateService := newTestTemplateService(t)
+	require.NoError(t, templateService.SaveTemplate(model.Template{
+		Name:     templatePromo,
+		Locale:   "en",
+		Category: model.Marketing,
+		Subject:  "Sale",
+		TextBody: "Everything is 50% off",
+	}))
+	require.NoError(t, templateService.SaveTemplate(model.Template{
+		Name:     service.TemplateWelcome,
+		Channel:  model.Email,
+		Locale:   "en",
+		Category: model.Security,
+		Subject:  "New sign-in",
+		TextBody: "Someone signed in to your account",
+	}))
+	notificationService := service.NewNotificationService(
+		f.repo,
+		templateService,
+		f.contactService,
+		f.preferenceService,
+		map[model.NotificationChannel]model.NotificationSender{model.Email: f.sender},
+		f.dispatcher,
+	)
+	return f, notificationService
+}
+
+func TestGetPreferences_ReturnsFullMatrix(t *testing.T) {
+	preferenceService := newTestPreferenceService()
+	userID := uuid.New()
+	require.NoError(t, preferenceService.SetChannelPreferences(userID, []model.ChannelPreference{
+		{Category: model.Marketing, Channel: model.SMS, Enabled: false},
+	}))
+
+	preferences, err := preferenceService.GetPreferences(userID)
+	require.NoError(t, err)
+	require.Len(t, preferences.Channels, len(model.NotificationCategories)*len(model.NotificationChannels))
+	for _, preference := range preferences.Channels {
+		expected := !(preference.Category == model.Marketing && preference.Channel == model.SMS)
+		assert.Equal(t, expected, preference.Enabled, "%d/%d", preference.Category, preference.Channel)
+	}
+	assert.Nil(t, preferences.QuietHours)
+}
+
+func TestSetChannelPreferences_Validates(t *testing.T) {
+	preferenceService := newTestPreferenceService()
+	userID := uuid.New()
+
+	for _, category := range []model.NotificationCategory{model.Transactional, model.Security} {
+		err := preferenceService.SetChannelPreferences(userID, []model.ChannelPreference{
+			{Category: category, Channel: model.Email, Enabled: false},
+		})
+		assert.ErrorIs(t, err, model.ErrCategoryNotSuppressible)
+	}
+	err := preferenceService.SetChannelPreferences(userID, []model.ChannelPreference{
+		{Category: model.NotificationCategory(42), Channel: model.Email},
+	})
+	assert.ErrorIs(t, err, model.ErrUnknownCategory)
+	err = preferenceService.SetChannelPreferences(userID, []model.ChannelPreference{
+		{Category: model.Marketing, Channel: model.NotificationChannel(42)},
+	})
+	assert.ErrorIs(t, err, model.ErrUnknownChannel)
+
+	// Повторное включение не теряет остальные настройки
+	require.NoError(t, preferenceService.SetChannelPreferences(userID, []model.ChannelPreference{
+		{Category: model.Marketing, Channel: model.Email, Enabled: false},
+		{Category: model.Marketing, Channel: model.Push, Enabled: false},
+	}))
+	require.NoError(t, preferenceService.SetChannelPreferences(userID, []model.ChannelPreference{
+		{Category: model.Marketing, Channel: model.Push, Enabled: true},
+	}))
+	decision, err := preferenceService.Decide(userID, model.Marketing, model.Email, time.Now())
+	require.NoError(t, err)
+	assert.True(t, decision.Suppressed)
+	decision, err = preferenceService.Decide(userID, model.Marketing, model.Push, time.Now())
+	require.NoError(t, err)
+	assert.False(t, decision.Suppressed)
+}
+
+func TestSetQuietHours_Validates(t *testing.T) {
+	preferenceService := newTestPreferenceService()
+	userID := uuid.New()
+
+	for _, quietHours := range []model.QuietHours{
+		{Start: -1, End: 60, TimeZone: "UTC"},
+		{Start: 60, End: 24 * 60, TimeZone: "UTC"},
+		{Start: 60, End: 60, TimeZone: "UTC"},
+	} {
+		assert.ErrorIs(t, preferenceService.SetQuietHours(userID, &quietHours), model.ErrInvalidQuietHours)
+	}
+	for _, timeZone := range []string{"", "Mars/Olympus"} {
+		err := preferenceService.SetQuietHours(userID, &model.QuietHours{Start: 0, End: 60, TimeZone: timeZone})
+		assert.ErrorIs(t, err, model.ErrInvalidTimeZone)
+	}
+
+	require.NoError(t, preferenceService.SetQuietHours(userID, &model.QuietHours{Start: 0, End: 60, TimeZone: "UTC"}))
+	require.NoError(t, preferenceService.SetQuietHours(userID, nil))
+	preferences, err := preferenceService.GetPreferences(userID)
+	require.NoError(t, err)
+	assert.Nil(t, preferences.QuietHours)
+}
+
+func TestQuietHours_DeferUntil(t *testing.T) {
+	// 22:00-07:00 по Москве (UTC+3)
+	quietHours := model.QuietHours{Start: 22 * 60, End: 7 * 60, TimeZone: "Europe/Moscow"}
+
+	for _, tc := range []struct {
+		name  string
+		now   time.Time
+		until time.Time
+	}{
+		{"Before midnight", time.Date(2024, 3, 10, 20, 30, 0, 0, time.UTC), time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC)},
+		{"After midnight", time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC)},
+		{"Window start is inclusive", time.Date(2024, 3, 10, 19, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC)},
+		{"Window end is exclusive", time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC), time.Time{}},
+		{"Daytime", time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC), time.Time{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			until, ok := quietHours.DeferUntil(tc.now)
+			assert.Equal(t, !tc.until.IsZero(), ok)
+			assert.True(t, tc.until.Equal(until), "expected %s, got %s", tc.until, until)
+		})
+	}
+}
+
+func TestSendNotification_SuppressedByPreferences(t *testing.T) {
+	f, notificationService := setupPreferenceDelivery(t)
+	userID := uuid.New()
+	require.NoError(t, f.preferenceService.SetChannelPreferences(userID, []model.ChannelPreference{
+		{Category: model.Marketing, Channel: model.Email, Enabled: false},
+	}))
+
+	id, err := notificationService.SendNotification(userID, templatePromo, model.Email, "user@example.com", "", nil)
+	require.NoError(t, err)
+
+	notification := f.repo.store[id]
+	assert.Equal(t, model.Suppressed, notification.Status)
+	assert.Equal(t, model.Marketing, notification.Category)
+	require.Len(t, f.dispatcher.events, 1)
+	assert.Equal(t, model.NotificationSuppressed{
+		NotificationID: id,
+		UserID:         userID,
+		Channel:        model.Email,
+		Category:       model.Marketing,
+	}, f.dispatcher.events[0])
+
+	delivered, err := f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	assert.Zero(t, f.sender.SendCount)
+
+	// Другие пользователи и каналы не затронуты
+	id, err = notificationService.SendNotification(uuid.New(), templatePromo, model.Email, "other@example.com", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, model.Pending, f.repo.store[id].Status)
+}
+
+func TestSendNotification_DeferredByQuietHours(t *testing.T) {
+	f, notificationService := setupPreferenceDelivery(t)
+	userID := uuid.New()
+	// Окно на весь день, кроме двух минут впереди, чтобы тест не зависел от текущего времени
+	now := time.Now().UTC()
+	end := (now.Hour()*60 + now.Minute() + 2) % (24 * 60)
+	start := (end + 2) % (24 * 60)
+	require.NoError(t, f.preferenceService.SetQuietHours(userID, &model.QuietHours{Start: start, End: end, TimeZone: "UTC"}))
+
+	id, err := notificationService.SendNotification(userID, templatePromo, model.Email, "user@example.com", "", nil)
+	require.NoError(t, err)
+	notification := f.repo.store[id]
+	assert.Equal(t, model.Pending, notification.Status)
+	assert.True(t, notification.NextAttemptAt.After(now.Add(time.Minute)))
+	assert.Equal(t, end, notification.NextAttemptAt.Hour()*60+notification.NextAttemptAt.Minute())
+
+	delivered, err := f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+
+	t.Run("Security is not deferred", func(t *testing.T) {
+		id, err := notificationService.SendNotification(userID, service.TemplateWelcome, model.Email, "user@example.com", "", nil)
+		require.NoError(t, err)
+		assert.False(t, f.repo.store[id].NextAttemptAt.After(time.Now()))
+	})
+}
+
+type mockPreferenceRepository struct {
+	store map[uuid.UUID]*model.NotificationPreferences
+}
+
+func (m *mockPreferenceRepository) Find(userID uuid.UUID) (*model.NotificationPreferences, error) {
+	if preferences, ok := m.store[userID]; ok {
+		found := *preferences
+		found.Channels = append([]model.ChannelPreference(nil), preferences.Channels...)
+		return &found, nil
+	}
+	return &model.NotificationPreferences{UserID: userID}, nil
+}
+func (m *mockPreferenceRepository) Save(preferences *model.NotificationPreferences) error {
+	m.store[preferences.UserID] = preferences
+	return nil
+}
@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/domain/service"
+)
+
+// enqueue ставит письмо в очередь в обход шаблонов: лимитам важны только категория и адрес
+func (f *deliveryFixture) enqueue(t *testing.T, category model.NotificationCategory, recipient string) uuid.UUID {
+	t.Helper()
+	now := time.Now().UTC()
+	notification := &model.Notification{
+		ID:               uuid.New(),
+		UserID:           uuid.New(),
+		Channel:          model.Email,
+		Category:         category,
+		RecipientAddress: recipient,
+		Subject:          "Subject",
+		Body:             "Body",
+		Status:           model.Pending,
+		CreatedAt:        now,
+		NextAttemptAt:    now,
+	}
+	require.NoError(t, f.repo.Create(notification))
+	return notification.ID
+}
+
+// deliverInOrder отправляет уведомления по одному, чтобы порядок занятия лимитов был известен
+func (f *deliveryFixture) deliverInOrder(t *testing.T, ids ...uuid.UUID) {
+	t.Helper()
+	for _, id := range ids {
+		f.repo.store[id].NextAttemptAt = time.Now().Add(time.Hour)
+	}
+	for _, id := range ids {
+		f.makeDue(id)
+		_, err := f.deliveryService.DeliverDue(10)
+		require.NoError(t, err)
+	}
+}
+
+func TestDeliverDue_DropsMarketingOverRecipientLimit(t *testing.T) {
+	f := setupRateLimitedDelivery(t, service.RateLimitConfig{
+		Recipient: map[model.NotificationCategory]service.RateLimit{
+			model.Marketing: {Limit: 2, Window: time.Hour},
+		},
+	})
+	first := f.enqueue(t, model.Marketing, "promo@example.com")
+	second := f.enqueue(t, model.Marketing, "promo@example.com")
+	third := f.enqueue(t, model.Marketing, "promo@example.com")
+	other := f.enqueue(t, model.Marketing, "other@example.com")
+	f.deliverInOrder(t, first, second, third, other)
+
+	for _, id := range []uuid.UUID{first, second, other} {
+		assert.Equal(t, model.Sent, f.repo.store[id].Status)
+		assert.Equal(t, model.Allowed, f.repo.store[id].RateLimitDecision)
+		assert.Empty(t, f.repo.store[id].RateLimitReason)
+	}
+	assert.Equal(t, 3, f.sender.SendCount)
+
+	dropped := f.repo.store[third]
+	assert.Equal(t, model.RateLimited, dropped.Status)
+	assert.Equal(t, model.Dropped, dropped.RateLimitDecision)
+	assert.Equal(t, "recipient limit of 2 per 1h0m0s exceeded", dropped.RateLimitReason)
+	assert.Nil(t, dropped.LockedUntil)
+	assert.Zero(t, dropped.Attempts)
+	attempts, err := f.deliveryService.ListAttempts(third)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+
+	var events []model.NotificationRateLimited
+	for _, event := range f.dispatcher.events {
+		if rateLimited, ok := event.(model.NotificationRateLimited); ok {
+			events = append(events, rateLimited)
+		}
+	}
+	require.Len(t, events, 1)
+	assert.Equal(t, third, events[0].NotificationID)
+	assert.Equal(t, model.Marketing, events[0].Category)
+	assert.Equal(t, dropped.RateLimitReason, events[0].Reason)
+
+	t.Run("Requeue", func(t *testing.T) {
+		require.NoError(t, f.deliveryService.Requeue(third))
+		assert.Equal(t, model.Pending, f.repo.store[third].Status)
+	})
+}
+
+func TestDeliverDue_DelaysTransactionalOverRecipientLimit(t *testing.T) {
+	f := setupRateLimitedDelivery(t, service.RateLimitConfig{
+		Recipient: map[model.NotificationCategory]service.RateLimit{
+			model.Transactional: {Limit: 1, Window: time.Hour},
+		},
+	})
+	first := f.enqueue(t, model.Transactional, "orders@example.com")
+	second := f.enqueue(t, model.Transactional, "orders@example.com")
+	f.deliverInOrder(t, first, second)
+
+	assert.Equal(t, model.Sent, f.repo.store[first].Status)
+	delayed := f.repo.store[second]
+	assert.Equal(t, model.Pending, delayed.Status)
+	assert.Equal(t, model.Delayed, delayed.RateLimitDecision)
+	assert.Equal(t, "recipient limit of 1 per 1h0m0s exceeded", delayed.RateLimitReason)
+	assert.Equal(t, time.Now().UTC().Truncate(time.Hour).Add(time.Hour), delayed.NextAttemptAt)
+	assert.Nil(t, delayed.LockedUntil)
+
+	// Задержка лимитом не тратит попытки доставки
+	assert.Zero(t, delayed.Attempts)
+	attempts, err := f.deliveryService.ListAttempts(second)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+
+	delivered, err := f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	assert.Equal(t, 1, f.sender.SendCount)
+}
+
+func TestDeliverDue_ChannelLimitDelaysWithoutConsumingRecipientLimit(t *testing.T) {
+	f := setupRateLimitedDelivery(t, service.RateLimitConfig{
+		Recipient: map[model.NotificationCategory]service.RateLimit{
+			model.Marketing: {Limit: 5, Window: time.Hour},
+		},
+		Channel: map[model.NotificationChannel]service.RateLimit{
+			model.Email: {Limit: 1, Window: time.Hour},
+		},
+	})
+	first := f.enqueue(t, model.Marketing, "promo@example.com")
+	second := f.enqueue(t, model.Marketing, "promo@example.com")
+	f.deliverInOrder(t, first, second)
+
+	// Маркетинг отбрасывается только лимитом получателя, лимит канала всегда откладывает
+	delayed := f.repo.store[second]
+	assert.Equal(t, model.Pending, delayed.Status)
+	assert.Equal(t, model.Delayed, delayed.RateLimitDecision)
+	assert.Equal(t, "channel limit of 1 per 1h0m0s exceeded", delayed.RateLimitReason)
+
+	var recipientCount int
+	for key, count := range f.rateLimitRepo.counters {
+		if strings.HasPrefix(key, "recipient:") {
+			recipientCount += count
+		}
+	}
+	assert.Equal(t, 1, recipientCount)
+}
+
+func TestDeliverDue_TransientFailureReleasesLimit(t *testing.T) {
+	f := setupRateLimitedDelivery(t, service.RateLimitConfig{
+		Recipient: map[model.NotificationCategory]service.RateLimit{
+			model.Transactional: {Limit: 1, Window: time.Hour},
+		},
+		Channel: map[model.NotificationChannel]service.RateLimit{
+			model.Email: {Limit: 1, Window: time.Hour},
+		},
+	})
+	id := f.enqueue(t, model.Transactional, "orders@example.com")
+	f.sender.ShouldError = true
+	f.deliverInOrder(t, id)
+	require.Equal(t, model.Pending, f.repo.store[id].Status)
+	require.Equal(t, 1, f.repo.store[id].Attempts)
+
+	// Повтор после сбоя провайдера укладывается в те же лимиты
+	f.sender.ShouldError = false
+	f.deliverInOrder(t, id)
+	notification := f.repo.store[id]
+	assert.Equal(t, model.Sent, notification.Status)
+	assert.Equal(t, model.Allowed, notification.RateLimitDecision)
+	assert.Equal(t, 2, f.sender.SendCount)
+
+	t.Run("Permanent failure keeps the slot", func(t *testing.T) {
+		f := setupRateLimitedDelivery(t, service.RateLimitConfig{
+			Channel: map[model.NotificationChannel]service.RateLimit{
+				model.Email: {Limit: 1, Window: time.Hour},
+			},
+		})
+		rejected := f.enqueue(t, model.Transactional, "missing@example.com")
+		next := f.enqueue(t, model.Transactional, "orders@example.com")
+		f.sender.Permanent = true
+		f.deliverInOrder(t, rejected)
+		f.sender.Permanent = false
+		f.deliverInOrder(t, next)
+
+		assert.Equal(t, model.Failed, f.repo.store[rejected].Status)
+		assert.Equal(t, model.Delayed, f.repo.store[next].RateLimitDecision)
+	})
+}
+
+func TestRateLimitService_DeleteExpired(t *testing.T) {
+	repo := &mockRateLimitRepository{counters: make(map[string]int)}
+	rateLimitService := service.NewRateLimitService(repo, service.RateLimitConfig{
+		Recipient: map[model.NotificationCategory]service.RateLimit{
+			model.Transactional: {Limit: 1, Window: time.Hour},
+		},
+		Channel: map[model.NotificationChannel]service.RateLimit{
+			model.Email: {Limit: 1, Window: time.Minute},
+		},
+	})
+	now := time.Now().UTC()
+	require.NoError(t, rateLimitService.DeleteExpired(now))
+	// Счётчики самого длинного окна должны пережить очистку
+	assert.Equal(t, now.Add(-time.Hour), repo.deletedBefore)
+}
+
+type mockRateLimitRepository struct {
+	counters      map[string]int
+	deletedBefore time.Time
+}
+
+func (m *mockRateLimitRepository) Acquire(buckets []model.RateLimitBucket) (int, error) {
+	for i, bucket := range buckets {
+		if m.counters[mockBucketKey(bucket)] >= bucket.Limit {
+			return i, nil
+		}
+	}
+	for _, bucket := range buckets {
+		m.counters[mockBucketKey(bucket)]++
+	}
+	return -1, nil
+}
+
+func (m *mockRateLimitRepository) Release(buckets []model.RateLimitBucket) error {
+	for _, bucket := range buckets {
+		if key := mockBucketKey(bucket); m.counters[key] > 0 {
+			m.counters[key]--
+		}
+	}
+	return nil
+}
+
+func (m *mockRateLimitRepository) DeleteExpired(before time.Time) error {
+	m.deletedBefore = before
+	return nil
+}
+
+func mockBucketKey(bucket model.RateLimitBucket) string {
+	return bucket.Key + "@" + bucket.WindowStart.Format(time.RFC3339)
+}
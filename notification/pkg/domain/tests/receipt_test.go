@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+)
+
+// sendWelcome отправляет приветственное письмо до статуса Sent
+func (f *deliveryFixture) sendWelcome(t *testing.T, recipient string) uuid.UUID {
+	t.Helper()
+	id, err := f.notificationService.SendWelcomeEmail(uuid.New(), recipient, "John", "")
+	require.NoError(t, err)
+	_, err = f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+	require.Equal(t, model.Sent, f.repo.store[id].Status)
+	f.dispatcher.Reset()
+	return id
+}
+
+func TestApplyReceipt_Delivered(t *testing.T) {
+	f := setupDelivery(t)
+	id := f.sendWelcome(t, "john@example.com")
+	deliveredAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, f.receiptService.Apply(model.DeliveryReceipt{
+		NotificationID: id,
+		Type:           model.ReceiptDelivered,
+		OccurredAt:     deliveredAt,
+	}))
+
+	assert.Equal(t, model.Delivered, f.repo.store[id].Status)
+	require.Len(t, f.dispatcher.events, 1)
+	event, ok := f.dispatcher.events[0].(model.NotificationDelivered)
+	require.True(t, ok)
+	assert.Equal(t, id, event.NotificationID)
+	assert.Equal(t, deliveredAt, event.DeliveredAt)
+}
+
+func TestApplyReceipt_HardBounceSuppressesAddress(t *testing.T) {
+	f := setupDelivery(t)
+	id := f.sendWelcome(t, "Gone@Example.com")
+
+	require.NoError(t, f.receiptService.Apply(model.DeliveryReceipt{
+		NotificationID: id,
+		Type:           model.ReceiptBounced,
+		HardBounce:     true,
+		Reason:         "550 mailbox does not exist",
+	}))
+
+	bounced := f.repo.store[id]
+	assert.Equal(t, model.Bounced, bounced.Status)
+	assert.Equal(t, "550 mailbox does not exist", bounced.FailureReason)
+	require.Len(t, f.dispatcher.events, 1)
+	event, ok := f.dispatcher.events[0].(model.NotificationBounced)
+	require.True(t, ok)
+	assert.True(t, event.Hard)
+	assert.False(t, event.BouncedAt.IsZero())
+
+	// Адрес сравнивается без учёта регистра, отправитель больше не вызывается
+	f.dispatcher.Reset()
+	sendCount := f.sender.SendCount
+	next, err := f.notificationService.SendWelcomeEmail(uuid.New(), "gone@example.com", "John", "")
+	require.NoError(t, err)
+	_, err = f.deliveryService.DeliverDue(10)
+	require.NoError(t, err)
+
+	suppressed := f.repo.store[next]
+	assert.Equal(t, model.Suppressed, suppressed.Status)
+	assert.Equal(t, "address is undeliverable: 550 mailbox does not exist", suppressed.FailureReason)
+	assert.Nil(t, suppressed.LockedUntil)
+	assert.Equal(t, sendCount, f.sender.SendCount)
+	require.Len(t, f.dispatcher.events, 1)
+	_, ok = f.dispatcher.events[0].(model.NotificationSuppressed)
+	assert.True(t, ok)
+}
+
+func TestApplyReceipt_SoftBounceKeepsAddress(t *testing.T) {
+	f := setupDelivery(t)
+	id := f.sendWelcome(t, "full@example.com")
+
+	require.NoError(t, f.receiptService.Apply(model.DeliveryReceipt{
+		NotificationID: id,
+		Type:           model.ReceiptBounced,
+		Reason:         "452 mailbox full",
+	}))
+	assert.Equal(t, model.Bounced, f.repo.store[id].Status)
+	assert.Empty(t, f.addressRepo.store)
+
+	f.sendWelcome(t, "full@example.com")
+}
+
+func TestApplyReceipt_Transitions(t *testing.T) {
+	f := setupDelivery(t)
+	id := f.sendWelcome(t, "john@example.com")
+	apply := func(receiptType model.ReceiptType) {
+		t.Helper()
+		require.NoError(t, f.receiptService.Apply(model.DeliveryReceipt{NotificationID: id, Type: receiptType, Reason: "abuse"}))
+	}
+
+	apply(model.ReceiptDelivered)
+	apply(model.ReceiptDelivered)
+	assert.Len(t, f.dispatcher.events, 1, "duplicate receipt is ignored")
+
+	// Жалоба приходит уже после доставки
+	apply(model.ReceiptComplained)
+	assert.Equal(t, model.Complained, f.repo.store[id].Status)
+	assert.Equal(t, "abuse", f.repo.store[id].FailureReason)
+
+	// Запоздавшие квитанции не откатывают конечное состояние
+	apply(model.ReceiptDelivered)
+	apply(model.ReceiptBounced)
+	assert.Equal(t, model.Complained, f.repo.store[id].Status)
+	assert.Len(t, f.dispatcher.events, 1)
+
+	f.sender.ShouldError = true
+	pending, err := f.notificationService.SendWelcomeEmail(uuid.New(), "retry@example.com", "John", "")
+	require.NoError(t, err)
+	require.NoError(t, f.receiptService.Apply(model.DeliveryReceipt{NotificationID: pending, Type: model.ReceiptDelivered}))
+	assert.Equal(t, model.Pending, f.repo.store[pending].Status)
+
+	err = f.receiptService.Apply(model.DeliveryReceipt{NotificationID: uuid.New(), Type: model.ReceiptDelivered})
+	assert.ErrorIs(t, err, model.ErrNotificationNotFound)
+}
+
+type mockUndeliverableAddressRepository struct {
+	store map[string]*model.UndeliverableAddress
+}
+
+func (m *mockUndeliverableAddressRepository) Find(channel model.NotificationChannel, address string) (*model.UndeliverableAddress, error) {
+	if stored, ok := m.store[mockAddressKey(channel, address)]; ok {
+		return stored, nil
+	}
+	return nil, model.ErrUndeliverableAddressNotFound
+}
+
+func (m *mockUndeliverableAddressRepository) Save(address *model.UndeliverableAddress) error {
+	key := mockAddressKey(address.Channel, address.Address)
+	if _, ok := m.store[key]; !ok {
+		m.store[key] = address
+	}
+	return nil
+}
+
+func mockAddressKey(channel model.NotificationChannel, address string) string {
+	return fmt.Sprintf("%d:%s", channel, address)
+}
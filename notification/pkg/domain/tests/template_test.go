@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/domain/service"
+)
+
+// newTestTemplateService заполняет хранилище теми же шаблонами, что и миграция
+func newTestTemplateService(t *testing.T) service.TemplateService {
+	t.Helper()
+	templateService := service.NewTemplateService(newMockTemplateRepository(), "en")
+	for _, template := range []model.Template{
+		{
+			Name:      service.TemplateWelcome,
+			Locale:    "en",
+			Subject:   "Welcome to our store!",
+			TextBody:  "Hi {{.firstName}}, thanks for joining us!",
+			HTMLBody:  "<p>Hi {{.firstName}}, thanks for joining us!</p>",
+			Variables: []model.TemplateVariable{{Name: "firstName", Type: model.VariableString}},
+		},
+		{
+			Name:      service.TemplateOrderConfirmation,
+			Locale:    "en",
+			Subject:   "Your order {{.orderID}} has been confirmed!",
+			TextBody:  "We have received your order and will process it shortly.",
+			Variables: []model.TemplateVariable{{Name: "orderID", Type: model.VariableUUID}},
+		},
+		{
+			Name:     service.TemplateDigest,
+			Locale:   "en",
+			Subject:  "You have {{.count}} new notifications",
+			TextBody: "{{range .items}}* {{.Subject}}\n{{end}}",
+			HTMLBody: "<ul>{{range .items}}<li>{{.Subject}}</li>{{end}}</ul>",
+		},
+		{
+			Name:     service.TemplateEmailVerification,
+			Locale:   "en",
+			Category: model.Security,
+			Subject:  "Confirm your email address",
+			TextBody: "Hi {{.firstName}}, your code is {{.token}}, valid until {{.expiresAt.Format \"2006-01-02 15:04\"}} UTC",
+			Variables: []model.TemplateVariable{
+				{Name: "firstName", Type: model.VariableString},
+				{Name: "token", Type: model.VariableString},
+				{Name: "expiresAt", Type: model.VariableDate},
+			},
+		},
+		{
+			Name:     service.TemplatePasswordReset,
+			Locale:   "en",
+			Category: model.Security,
+			Subject:  "Reset your password",
+			TextBody: "Hi {{.firstName}}, your reset code is {{.token}}, valid until {{.expiresAt.Format \"2006-01-02 15:04\"}} UTC",
+			Variables: []model.TemplateVariable{
+				{Name: "firstName", Type: model.VariableString},
+				{Name: "token", Type: model.VariableString},
+				{Name: "expiresAt", Type: model.VariableDate},
+			},
+		},
+		{
+			Name:     service.TemplatePaymentFailed,
+			Locale:   "en",
+			Subject:  "Payment failed for order {{.orderID}}",
+			TextBody: "Unfortunately, the payment for your order failed. Reason: {{.reason}}",
+			Variables: []model.TemplateVariable{
+				{Name: "orderID", Type: model.VariableUUID},
+				{Name: "reason", Type: model.VariableString},
+			},
+		},
+	} {
+		require.NoError(t, templateService.SaveTemplate(template))
+	}
+	return templateService
+}
+
+func TestRenderTemplate(t *testing.T) {
+	templateService := newTestTemplateService(t)
+	require.NoError(t, templateService.SaveTemplate(model.Template{
+		Name:     service.TemplateWelcome,
+		Locale:   "ru",
+		Subject:  "Добро пожаловать!",
+		TextBody: "Привет, {{.firstName}}!",
+		HTMLBody: "<p>Привет, {{.firstName}}!</p>",
+		Variables: []model.TemplateVariable{
+			{Name: "firstName", Type: model.VariableString},
+		},
+	}))
+
+	t.Run("Locale falls back to language", func(t *testing.T) {
+		message, err := templateService.Render(service.TemplateWelcome, model.Email, "ru_RU", map[string]string{"firstName": "Иван"})
+		require.NoError(t, err)
+		assert.Equal(t, "Добро пожаловать!", message.Subject)
+		assert.Equal(t, "Привет, Иван!", message.TextBody)
+	})
+
+	t.Run("Unknown locale falls back to default", func(t *testing.T) {
+		message, err := templateService.Render(service.TemplateWelcome, model.Email, "de-DE", map[string]string{"firstName": "Hans"})
+		require.NoError(t, err)
+		assert.Equal(t, "Hi Hans, thanks for joining us!", message.TextBody)
+	})
+
+	t.Run("HTML variant is escaped", func(t *testing.T) {
+		message, err := templateService.Render(service.TemplateWelcome, model.Email, "en", map[string]string{"firstName": "<script>"})
+		require.NoError(t, err)
+		assert.Equal(t, "Hi <script>, thanks for joining us!", message.TextBody)
+		assert.Equal(t, "<p>Hi &lt;script&gt;, thanks for joining us!</p>", message.HTMLBody)
+	})
+
+	t.Run("Missing variable is a validation error", func(t *testing.T) {
+		_, err := templateService.Render(service.TemplateWelcome, model.Email, "en", map[string]string{})
+		assert.ErrorIs(t, err, model.ErrMissingTemplateVariable)
+	})
+
+	t.Run("Typed variables are validated", func(t *testing.T) {
+		_, err := templateService.Render(service.TemplateOrderConfirmation, model.Email, "en", map[string]string{"orderID": "42"})
+		assert.ErrorIs(t, err, model.ErrInvalidTemplateVariable)
+
+		_, err = templateService.Render(service.TemplateOrderConfirmation, model.Email, "en", map[string]string{"orderID": uuid.NewString()})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Template for another channel is not used", func(t *testing.T) {
+		_, err := templateService.Render(service.TemplateWelcome, model.SMS, "en", map[string]string{"firstName": "John"})
+		assert.ErrorIs(t, err, model.ErrTemplateNotFound)
+	})
+}
+
+func TestSaveTemplate(t *testing.T) {
+	templateService := newTestTemplateService(t)
+
+	t.Run("Typed date and number", func(t *testing.T) {
+		require.NoError(t, templateService.SaveTemplate(model.Template{
+			Name:     "refund",
+			Channel:  model.SMS,
+			Locale:   "en",
+			TextBody: `Refund of {{.amount}} issued on {{.date.Format "02.01.2006"}}`,
+			Variables: []model.TemplateVariable{
+				{Name: "amount", Type: model.VariableNumber},
+				{Name: "date", Type: model.VariableDate},
+			},
+		}))
+		message, err := templateService.Render("refund", model.SMS, "en", map[string]string{"amount": "12.50", "date": "2026-03-01"})
+		require.NoError(t, err)
+		assert.Equal(t, "Refund of 12.50 issued on 01.03.2026", message.TextBody)
+
+		_, err = templateService.Render("refund", model.SMS, "en", map[string]string{"amount": "twelve", "date": "2026-03-01"})
+		assert.ErrorIs(t, err, model.ErrInvalidTemplateVariable)
+	})
+
+	t.Run("Undeclared variable is rejected", func(t *testing.T) {
+		err := templateService.SaveTemplate(model.Template{
+			Name:      "broken",
+			Locale:    "en",
+			TextBody:  "Hi {{.firstName}} {{.lastName}}",
+			Variables: []model.TemplateVariable{{Name: "firstName"}},
+		})
+		assert.ErrorIs(t, err, model.ErrInvalidTemplate)
+	})
+
+	t.Run("Syntax error is rejected", func(t *testing.T) {
+		err := templateService.SaveTemplate(model.Template{Name: "broken", Locale: "en", TextBody: "Hi {{.firstName"})
+		assert.ErrorIs(t, err, model.ErrInvalidTemplate)
+	})
+
+	t.Run("Edited copy is used at once", func(t *testing.T) {
+		require.NoError(t, templateService.SaveTemplate(model.Template{
+			Name:      service.TemplateWelcome,
+			Locale:    "EN",
+			Subject:   "Glad you are here",
+			TextBody:  "Hello, {{.firstName}}",
+			Variables: []model.TemplateVariable{{Name: "firstName"}},
+		}))
+		message, err := templateService.Render(service.TemplateWelcome, model.Email, "", map[string]string{"firstName": "John"})
+		require.NoError(t, err)
+		assert.Equal(t, "Glad you are here", message.Subject)
+		assert.Empty(t, message.HTMLBody)
+	})
+}
+
+type templateKey struct {
+	name    string
+	channel model.NotificationChannel
+	locale  string
+}
+
+type mockTemplateRepository struct {
+	store map[templateKey]model.Template
+}
+
+func newMockTemplateRepository() *mockTemplateRepository {
+	return &mockTemplateRepository{store: make(map[templateKey]model.Template)}
+}
+
+func (m *mockTemplateRepository) Find(name string, channel model.NotificationChannel, locale string) (*model.Template, error) {
+	template, ok := m.store[templateKey{name: name, channel: channel, locale: locale}]
+	if !ok {
+		return nil, model.ErrTemplateNotFound
+	}
+	return &template, nil
+}
+func (m *mockTemplateRepository) Save(template *model.Template) error {
+	m.store[templateKey{name: template.Name, channel: template.Channel, locale: template.Locale}] = *template
+	return nil
+}
+func (m *mockTemplateRepository) List() ([]model.Template, error) {
+	templates := make([]model.Template, 0, len(m.store))
+	for _, template := range m.store {
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
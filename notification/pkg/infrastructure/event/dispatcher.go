@@ -0,0 +1,24 @@
+package event
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"notification/pkg/domain/service"
+)
+
+// NewLogEventDispatcher пишет доменные события в лог, пока у сервиса нет брокера сообщений
+func NewLogEventDispatcher(logger *log.Logger) service.EventDispatcher {
+	return &logEventDispatcher{logger: logger}
+}
+
+type logEventDispatcher struct {
+	logger *log.Logger
+}
+
+func (d *logEventDispatcher) Dispatch(event service.Event) error {
+	d.logger.WithFields(log.Fields{
+		"eventType": event.Type(),
+		"event":     event,
+	}).Infof("domain event dispatched")
+	return nil
+}
@@ -0,0 +1,92 @@
+package integrationevent
+
+const (
+	ExchangeName = "domain_event_exchange"
+	ExchangeKind = "topic"
+
+	UserCreatedRoutingKey   = "user.user_created"
+	UserUpdatedRoutingKey   = "user.user_updated"
+	UserDeletedRoutingKey   = "user.user_deleted"
+	PaymentFailedRoutingKey = "payment.payment_failed"
+
+	// Эти события публикует сервис user (integrationevent.NewEventDispatcher)
+	UserVerificationRequestedRoutingKey = "user.user_verification_requested"
+	PasswordResetRequestedRoutingKey    = "user.password_reset_requested"
+	UserErasedRoutingKey                = "user.user_erased"
+)
+
+// RoutingKeys - события, на которые подписана очередь сервиса
+var RoutingKeys = []string{
+	UserCreatedRoutingKey,
+	UserUpdatedRoutingKey,
+	UserDeletedRoutingKey,
+	UserVerificationRequestedRoutingKey,
+	PasswordResetRequestedRoutingKey,
+	UserErasedRoutingKey,
+	PaymentFailedRoutingKey,
+}
+
+// Форматы событий сервиса пользователей совпадают с его сериализатором
+
+type UserCreated struct {
+	UserID    string  `json:"user_id"`
+	Status    int     `json:"status"`
+	Login     string  `json:"login"`
+	Email     *string `json:"email,omitempty"`
+	Telegram  *string `json:"telegram,omitempty"`
+	CreatedAt int64   `json:"created_at"`
+}
+
+type UserUpdated struct {
+	UserID        string `json:"user_id"`
+	UpdatedFields *struct {
+		Status   *int    `json:"status,omitempty"`
+		Email    *string `json:"email,omitempty"`
+		Telegram *string `json:"telegram,omitempty"`
+	} `json:"updated_fields,omitempty"`
+	RemovedFields *struct {
+		Email    *bool `json:"email,omitempty"`
+		Telegram *bool `json:"telegram,omitempty"`
+	} `json:"removed_fields,omitempty"`
+	UpdatedAt int64 `json:"updated_at,omitempty"`
+}
+
+type UserDeleted struct {
+	UserID    string `json:"user_id"`
+	Status    int    `json:"status"`
+	DeletedAt int64  `json:"deleted_at"`
+	Hard      bool   `json:"hard"`
+}
+
+// UserVerificationRequested несёт исходный токен подтверждения, ExpiresAt - unix-время в секундах
+type UserVerificationRequested struct {
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// PasswordResetRequested несёт исходный токен сброса пароля, ExpiresAt - unix-время в секундах
+type PasswordResetRequested struct {
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// UserErased - данные пользователя удалены по запросу, контакты нужно забыть
+type UserErased struct {
+	UserID string `json:"user_id"`
+}
+
+// PaymentFailed публикует сервис платежей (integrationevent.NewEventDispatcher).
+// ReferenceID платежа за заказ равен идентификатору заказа
+type PaymentFailed struct {
+	WalletID    string `json:"wallet_id"`
+	UserID      string `json:"user_id"`
+	ReferenceID string `json:"reference_id"`
+	Reason      string `json:"reason"`
+	ReasonCode  string `json:"reason_code"`
+}
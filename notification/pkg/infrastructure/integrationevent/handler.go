@@ -0,0 +1,233 @@
+package integrationevent
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/domain/service"
+)
+
+// ErrPoisonMessage - сообщение не разбирается или не проходит проверку, повторная доставка не поможет
+var ErrPoisonMessage = errors.New("message cannot be processed")
+
+type Message struct {
+	ID         string
+	RoutingKey string
+	Body       []byte
+}
+
+type MessageHandler interface {
+	// Handle возвращает ошибку, только если сообщение нужно доставить повторно.
+	// Обработанные, повторные и отложенные в parked_message сообщения подтверждаются
+	Handle(message Message) error
+}
+
+// EventHandlerFactory собирает обработчик событий поверх репозиториев транзакции inbox
+type EventHandlerFactory func(repos model.InboxRepositories) service.DomainEventHandler
+
+func NewMessageHandler(
+	newEventHandler EventHandlerFactory,
+	inbox model.Inbox,
+	parkedMessageRepo model.ParkedMessageRepository,
+	logger *log.Logger,
+) MessageHandler {
+	return &messageHandler{
+		newEventHandler:   newEventHandler,
+		inbox:             inbox,
+		parkedMessageRepo: parkedMessageRepo,
+		logger:            logger,
+	}
+}
+
+type messageHandler struct {
+	newEventHandler   EventHandlerFactory
+	inbox             model.Inbox
+	parkedMessageRepo model.ParkedMessageRepository
+	logger            *log.Logger
+}
+
+func (h *messageHandler) Handle(message Message) error {
+	l := h.logger.WithFields(log.Fields{
+		"messageID":  message.ID,
+		"routingKey": message.RoutingKey,
+	})
+	// Без идентификатора повторную доставку не отличить от нового события
+	if message.ID == "" {
+		return h.park(l, message, errors.Wrap(ErrPoisonMessage, "message id is empty"))
+	}
+
+	err := h.inbox.Process(message.ID, func(repos model.InboxRepositories) error {
+		return dispatch(h.newEventHandler(repos), message)
+	})
+	switch {
+	case err == nil:
+		l.Info("message processed")
+		return nil
+	case errors.Is(err, model.ErrMessageAlreadyProcessed):
+		l.Info("duplicate message skipped")
+		return nil
+	case isPoison(err):
+		return h.park(l, message, err)
+	default:
+		l.WithError(err).Error("failed to process message")
+		return err
+	}
+}
+
+func (h *messageHandler) park(l *log.Entry, message Message, reason error) error {
+	err := h.parkedMessageRepo.Park(model.ParkedMessage{
+		MessageID:  message.ID,
+		RoutingKey: message.RoutingKey,
+		Body:       message.Body,
+		Reason:     reason.Error(),
+		ParkedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		l.WithError(err).Error("failed to park message")
+		return err
+	}
+	l.WithError(reason).Warn("message parked")
+	return nil
+}
+
+func dispatch(eventHandler service.DomainEventHandler, message Message) error {
+	switch message.RoutingKey {
+	case UserCreatedRoutingKey:
+		var event UserCreated
+		if err := decode(message.Body, &event); err != nil {
+			return err
+		}
+		userID, err := parseUUID(event.UserID)
+		if err != nil {
+			return err
+		}
+		return eventHandler.HandleUserCreated(userID, event.Login, event.Email)
+	case UserUpdatedRoutingKey:
+		var event UserUpdated
+		if err := decode(message.Body, &event); err != nil {
+			return err
+		}
+		userID, err := parseUUID(event.UserID)
+		if err != nil {
+			return err
+		}
+		if event.UpdatedFields != nil && event.UpdatedFields.Email != nil {
+			return eventHandler.HandleUserEmailChanged(userID, *event.UpdatedFields.Email)
+		}
+		if event.RemovedFields != nil && event.RemovedFields.Email != nil && *event.RemovedFields.Email {
+			return eventHandler.HandleUserEmailRemoved(userID)
+		}
+		return nil
+	case UserDeletedRoutingKey:
+		var event UserDeleted
+		if err := decode(message.Body, &event); err != nil {
+			return err
+		}
+		userID, err := parseUUID(event.UserID)
+		if err != nil {
+			return err
+		}
+		return eventHandler.HandleUserDeleted(userID)
+	case UserErasedRoutingKey:
+		var event UserErased
+		if err := decode(message.Body, &event); err != nil {
+			return err
+		}
+		userID, err := parseUUID(event.UserID)
+		if err != nil {
+			return err
+		}
+		return eventHandler.HandleUserDeleted(userID)
+	case UserVerificationRequestedRoutingKey:
+		var event UserVerificationRequested
+		if err := decode(message.Body, &event); err != nil {
+			return err
+		}
+		userID, err := parseUUID(event.UserID)
+		if err != nil {
+			return err
+		}
+		if event.Token == "" {
+			return errors.Wrap(ErrPoisonMessage, "token is empty")
+		}
+		return eventHandler.HandleVerificationRequested(
+			userID,
+			event.Email,
+			event.FirstName,
+			event.Token,
+			time.Unix(event.ExpiresAt, 0).UTC(),
+		)
+	case PasswordResetRequestedRoutingKey:
+		var event PasswordResetRequested
+		if err := decode(message.Body, &event); err != nil {
+			return err
+		}
+		userID, err := parseUUID(event.UserID)
+		if err != nil {
+			return err
+		}
+		if event.Token == "" {
+			return errors.Wrap(ErrPoisonMessage, "token is empty")
+		}
+		return eventHandler.HandlePasswordResetRequested(
+			userID,
+			event.Email,
+			event.FirstName,
+			event.Token,
+			time.Unix(event.ExpiresAt, 0).UTC(),
+		)
+	case PaymentFailedRoutingKey:
+		var event PaymentFailed
+		if err := decode(message.Body, &event); err != nil {
+			return err
+		}
+		userID, err := parseUUID(event.UserID)
+		if err != nil {
+			return err
+		}
+		orderID, err := parseUUID(event.ReferenceID)
+		if err != nil {
+			return err
+		}
+		return eventHandler.HandlePaymentFailed(userID, orderID, event.Reason)
+	default:
+		return errors.Wrapf(ErrPoisonMessage, "unknown routing key %q", message.RoutingKey)
+	}
+}
+
+func decode(body []byte, event interface{}) error {
+	if err := json.Unmarshal(body, event); err != nil {
+		return errors.Wrapf(ErrPoisonMessage, "invalid payload: %v", err)
+	}
+	return nil
+}
+
+func parseUUID(value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, errors.Wrapf(ErrPoisonMessage, "invalid id %q", value)
+	}
+	return id, nil
+}
+
+// isPoison - ошибки данных и настройки шаблонов; ошибки базы и прочие сбои считаются временными
+func isPoison(err error) bool {
+	for _, poison := range []error{
+		ErrPoisonMessage,
+		model.ErrInvalidEmailAddress,
+		model.ErrTemplateNotFound,
+		model.ErrInvalidTemplateVariable,
+		model.ErrMissingTemplateVariable,
+		model.ErrNoSenderConfigured,
+	} {
+		if errors.Is(err, poison) {
+			return true
+		}
+	}
+	return false
+}
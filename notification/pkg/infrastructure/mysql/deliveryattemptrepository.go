@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+func NewDeliveryAttemptRepository(db *sqlx.DB) model.DeliveryAttemptRepository {
+	return &deliveryAttemptRepository{db: db}
+}
+
+type deliveryAttemptRepository struct {
+	db *sqlx.DB
+}
+
+type sqlxDeliveryAttempt struct {
+	ID             uuid.UUID `db:"id"`
+	NotificationID uuid.UUID `db:"notification_id"`
+	AttemptedAt    time.Time `db:"attempted_at"`
+	Error          string    `db:"error"`
+	Permanent      bool      `db:"permanent"`
+}
+
+const deliveryAttemptColumns = `id, notification_id, attempted_at, error, permanent`
+
+func (r *deliveryAttemptRepository) NextID() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+func (r *deliveryAttemptRepository) Create(attempt *model.DeliveryAttempt) error {
+	_, err := r.db.Exec(
+		`INSERT INTO notification_delivery_attempt (`+deliveryAttemptColumns+`) VALUES (?, ?, ?, ?, ?)`,
+		attempt.ID,
+		attempt.NotificationID,
+		attempt.AttemptedAt,
+		attempt.Error,
+		attempt.Permanent,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *deliveryAttemptRepository) ListForNotification(notificationID uuid.UUID) ([]model.DeliveryAttempt, error) {
+	var rows []sqlxDeliveryAttempt
+	err := r.db.Select(
+		&rows,
+		`SELECT `+deliveryAttemptColumns+` FROM notification_delivery_attempt
+		WHERE notification_id = ? ORDER BY attempted_at, id`,
+		notificationID,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	attempts := make([]model.DeliveryAttempt, 0, len(rows))
+	for _, row := range rows {
+		attempts = append(attempts, model.DeliveryAttempt{
+			ID:             row.ID,
+			NotificationID: row.NotificationID,
+			AttemptedAt:    row.AttemptedAt,
+			Error:          row.Error,
+			Permanent:      row.Permanent,
+		})
+	}
+	return attempts, nil
+}
@@ -0,0 +1,107 @@
+package mysql
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+func NewDeviceTokenRepository(db *sqlx.DB) model.DeviceTokenRepository {
+	return &deviceTokenRepository{db: db}
+}
+
+type deviceTokenRepository struct {
+	db dbExecutor
+}
+
+type sqlxDeviceToken struct {
+	ID         uuid.UUID `db:"id"`
+	UserID     uuid.UUID `db:"user_id"`
+	Platform   int       `db:"platform"`
+	Token      string    `db:"token"`
+	CreatedAt  time.Time `db:"created_at"`
+	LastSeenAt time.Time `db:"last_seen_at"`
+}
+
+const deviceTokenColumns = `id, user_id, platform, token, created_at, last_seen_at`
+
+func (r *deviceTokenRepository) NextID() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+func (r *deviceTokenRepository) FindByToken(token string) (*model.DeviceToken, error) {
+	var row sqlxDeviceToken
+	err := r.db.Get(&row, `SELECT `+deviceTokenColumns+` FROM user_device_token WHERE token_hash = ?`, tokenHash(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrDeviceTokenNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	deviceToken := fromSQLXDeviceToken(row)
+	return &deviceToken, nil
+}
+
+func (r *deviceTokenRepository) Save(deviceToken *model.DeviceToken) error {
+	_, err := r.db.Exec(
+		`INSERT INTO user_device_token (`+deviceTokenColumns+`, token_hash) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			user_id = VALUES(user_id),
+			platform = VALUES(platform),
+			created_at = VALUES(created_at),
+			last_seen_at = VALUES(last_seen_at)`,
+		deviceToken.ID,
+		deviceToken.UserID,
+		deviceToken.Platform,
+		deviceToken.Token,
+		deviceToken.CreatedAt,
+		deviceToken.LastSeenAt,
+		tokenHash(deviceToken.Token),
+	)
+	return errors.WithStack(err)
+}
+
+func (r *deviceTokenRepository) ListForUser(userID uuid.UUID) ([]model.DeviceToken, error) {
+	var rows []sqlxDeviceToken
+	err := r.db.Select(
+		&rows,
+		`SELECT `+deviceTokenColumns+` FROM user_device_token WHERE user_id = ? ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	deviceTokens := make([]model.DeviceToken, 0, len(rows))
+	for _, row := range rows {
+		deviceTokens = append(deviceTokens, fromSQLXDeviceToken(row))
+	}
+	return deviceTokens, nil
+}
+
+func (r *deviceTokenRepository) Delete(token string) error {
+	_, err := r.db.Exec(`DELETE FROM user_device_token WHERE token_hash = ?`, tokenHash(token))
+	return errors.WithStack(err)
+}
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func fromSQLXDeviceToken(row sqlxDeviceToken) model.DeviceToken {
+	return model.DeviceToken{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		Platform:   model.DevicePlatform(row.Platform),
+		Token:      row.Token,
+		CreatedAt:  row.CreatedAt,
+		LastSeenAt: row.LastSeenAt,
+	}
+}
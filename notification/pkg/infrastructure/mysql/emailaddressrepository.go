@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+func NewEmailAddressRepository(db *sqlx.DB) model.EmailAddressRepository {
+	return &emailAddressRepository{db: db}
+}
+
+type emailAddressRepository struct {
+	db dbExecutor
+}
+
+type sqlxEmailAddress struct {
+	UserID    uuid.UUID `db:"user_id"`
+	Address   string    `db:"address"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (r *emailAddressRepository) Find(userID uuid.UUID) (*model.EmailAddress, error) {
+	var row sqlxEmailAddress
+	err := r.db.Get(&row, `SELECT user_id, address, updated_at FROM user_email_address WHERE user_id = ?`, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrEmailAddressNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	return &model.EmailAddress{
+		UserID:    row.UserID,
+		Address:   row.Address,
+		UpdatedAt: row.UpdatedAt,
+	}, nil
+}
+
+func (r *emailAddressRepository) Save(emailAddress *model.EmailAddress) error {
+	_, err := r.db.Exec(
+		`INSERT INTO user_email_address (user_id, address, updated_at) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE address = VALUES(address), updated_at = VALUES(updated_at)`,
+		emailAddress.UserID,
+		emailAddress.Address,
+		emailAddress.UpdatedAt,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *emailAddressRepository) Delete(userID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM user_email_address WHERE user_id = ?`, userID)
+	return errors.WithStack(err)
+}
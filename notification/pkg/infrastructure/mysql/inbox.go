@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+func NewInbox(db *sqlx.DB) model.Inbox {
+	return &inbox{db: db}
+}
+
+type inbox struct {
+	db *sqlx.DB
+}
+
+// Process держит строку inbox_message заблокированной до конца обработки, поэтому повторная доставка
+// того же сообщения другому консьюмеру ждёт коммита и видит дубликат. handle пишет через репозитории
+// той же транзакции: уведомления и отметка о сообщении фиксируются или откатываются вместе
+func (i *inbox) Process(messageID string, handle func(repos model.InboxRepositories) error) (err error) {
+	tx, err := i.db.Beginx()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	result, err := tx.Exec(
+		`INSERT IGNORE INTO inbox_message (message_id, processed_at) VALUES (?, ?)`,
+		messageID,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if inserted == 0 {
+		return errors.WithStack(model.ErrMessageAlreadyProcessed)
+	}
+	if err = handle(&inboxRepositories{tx: tx}); err != nil {
+		return err
+	}
+	return errors.WithStack(tx.Commit())
+}
+
+type inboxRepositories struct {
+	tx *sqlx.Tx
+}
+
+func (r *inboxRepositories) Notifications() model.NotificationRepository {
+	return &notificationRepository{db: r.tx}
+}
+
+func (r *inboxRepositories) Templates() model.TemplateRepository {
+	return &templateRepository{db: r.tx}
+}
+
+func (r *inboxRepositories) Preferences() model.PreferenceRepository {
+	return &preferenceRepository{db: r.tx}
+}
+
+func (r *inboxRepositories) EmailAddresses() model.EmailAddressRepository {
+	return &emailAddressRepository{db: r.tx}
+}
+
+func (r *inboxRepositories) PhoneNumbers() model.PhoneNumberRepository {
+	return &phoneNumberRepository{db: r.tx}
+}
+
+func (r *inboxRepositories) DeviceTokens() model.DeviceTokenRepository {
+	return &deviceTokenRepository{db: r.tx}
+}
+
+func NewParkedMessageRepository(db *sqlx.DB) model.ParkedMessageRepository {
+	return &parkedMessageRepository{db: db}
+}
+
+type parkedMessageRepository struct {
+	db *sqlx.DB
+}
+
+func (r *parkedMessageRepository) Park(message model.ParkedMessage) error {
+	_, err := r.db.Exec(
+		`INSERT IGNORE INTO parked_message (message_id, routing_key, body, reason, parked_at) VALUES (?, ?, ?, ?, ?)`,
+		message.MessageID,
+		message.RoutingKey,
+		message.Body,
+		message.Reason,
+		message.ParkedAt,
+	)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,305 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+func NewNotificationRepository(db *sqlx.DB) model.NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+type notificationRepository struct {
+	db dbExecutor
+}
+
+type sqlxNotification struct {
+	ID                uuid.UUID           `db:"id"`
+	UserID            uuid.UUID           `db:"user_id"`
+	Channel           int                 `db:"channel"`
+	Category          int                 `db:"category"`
+	RecipientAddress  string              `db:"recipient_address"`
+	Subject           string              `db:"subject"`
+	Body              string              `db:"body"`
+	HTMLBody          string              `db:"html_body"`
+	Status            int                 `db:"status"`
+	FailureReason     string              `db:"failure_reason"`
+	CreatedAt         time.Time           `db:"created_at"`
+	SentAt            sql.Null[time.Time] `db:"sent_at"`
+	Attempts          int                 `db:"attempts"`
+	NextAttemptAt     time.Time           `db:"next_attempt_at"`
+	LockedUntil       sql.Null[time.Time] `db:"locked_until"`
+	DigestID          sql.Null[uuid.UUID] `db:"digest_id"`
+	RateLimitDecision int                 `db:"rate_limit_decision"`
+	RateLimitReason   string              `db:"rate_limit_reason"`
+}
+
+const notificationColumns = `id, user_id, channel, category, recipient_address, subject, body, html_body, status,
+	failure_reason, created_at, sent_at, attempts, next_attempt_at, locked_until, digest_id,
+	rate_limit_decision, rate_limit_reason`
+
+func (r *notificationRepository) NextID() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+func (r *notificationRepository) Create(notification *model.Notification) error {
+	return insertNotification(r.db, notification)
+}
+
+func (r *notificationRepository) Update(notification *model.Notification) error {
+	_, err := r.db.Exec(
+		`UPDATE notification SET
+			status = ?,
+			failure_reason = ?,
+			sent_at = ?,
+			attempts = ?,
+			next_attempt_at = ?,
+			locked_until = ?,
+			rate_limit_decision = ?,
+			rate_limit_reason = ?
+		WHERE id = ?`,
+		notification.Status,
+		notification.FailureReason,
+		toSQLNull(notification.SentAt),
+		notification.Attempts,
+		notification.NextAttemptAt,
+		toSQLNull(notification.LockedUntil),
+		notification.RateLimitDecision,
+		notification.RateLimitReason,
+		notification.ID,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *notificationRepository) Find(id uuid.UUID) (*model.Notification, error) {
+	var notification sqlxNotification
+	err := r.db.Get(&notification, `SELECT `+notificationColumns+` FROM notification WHERE id = ?`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrNotificationNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	return fromSQLXNotification(notification), nil
+}
+
+func (r *notificationRepository) ListForUser(userID uuid.UUID, limit int) ([]model.Notification, error) {
+	var rows []sqlxNotification
+	err := r.db.Select(
+		&rows,
+		`SELECT `+notificationColumns+` FROM notification WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		userID,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return fromSQLXNotifications(rows), nil
+}
+
+func (r *notificationRepository) ListByStatus(status model.NotificationStatus, limit int) ([]model.Notification, error) {
+	var rows []sqlxNotification
+	err := r.db.Select(
+		&rows,
+		`SELECT `+notificationColumns+` FROM notification WHERE status = ? ORDER BY next_attempt_at DESC LIMIT ?`,
+		status,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return fromSQLXNotifications(rows), nil
+}
+
+// ClaimDue блокирует строки через SKIP LOCKED и проставляет locked_until в той же транзакции,
+// поэтому воркеры на разных репликах разбирают непересекающиеся пачки
+func (r *notificationRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]model.Notification, error) {
+	var notifications []model.Notification
+	err := withinTransaction(r.db, func(tx dbExecutor) error {
+		var rows []sqlxNotification
+		err := tx.Select(
+			&rows,
+			`SELECT `+notificationColumns+` FROM notification
+			WHERE status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until <= ?)
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED`,
+			model.Pending,
+			now,
+			now,
+			limit,
+		)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		lockedUntil := now.Add(lease)
+		ids := make([]uuid.UUID, 0, len(rows))
+		for i := range rows {
+			ids = append(ids, rows[i].ID)
+			rows[i].LockedUntil = sql.Null[time.Time]{V: lockedUntil, Valid: true}
+		}
+		query, args, err := sqlx.In(`UPDATE notification SET locked_until = ? WHERE id IN (?)`, lockedUntil, ids)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err = tx.Exec(query, args...); err != nil {
+			return errors.WithStack(err)
+		}
+		notifications = fromSQLXNotifications(rows)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *notificationRepository) ListDueDigests(now time.Time, maxItems, limit int) ([]model.DigestKey, error) {
+	var rows []struct {
+		UserID           uuid.UUID `db:"user_id"`
+		Channel          int       `db:"channel"`
+		RecipientAddress string    `db:"recipient_address"`
+	}
+	err := r.db.Select(
+		&rows,
+		`SELECT user_id, channel, recipient_address FROM notification
+		WHERE status = ?
+		GROUP BY user_id, channel, recipient_address
+		HAVING MIN(next_attempt_at) <= ? OR COUNT(*) >= ?
+		ORDER BY MIN(next_attempt_at)
+		LIMIT ?`,
+		model.Batched,
+		now,
+		maxItems,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	keys := make([]model.DigestKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, model.DigestKey{
+			UserID:           row.UserID,
+			Channel:          model.NotificationChannel(row.Channel),
+			RecipientAddress: row.RecipientAddress,
+		})
+	}
+	return keys, nil
+}
+
+// FlushDigest, как и ClaimDue, полагается на SKIP LOCKED: воркер, пришедший за тем же получателем,
+// не ждёт первого и не получает уже забранные им уведомления
+func (r *notificationRepository) FlushDigest(
+	key model.DigestKey,
+	maxItems int,
+	build func(items []model.Notification) (*model.Notification, error),
+) (*model.Notification, error) {
+	var digest *model.Notification
+	err := withinTransaction(r.db, func(tx dbExecutor) error {
+		var rows []sqlxNotification
+		err := tx.Select(
+			&rows,
+			`SELECT `+notificationColumns+` FROM notification
+			WHERE status = ? AND user_id = ? AND channel = ? AND recipient_address = ?
+			ORDER BY created_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED`,
+			model.Batched,
+			key.UserID,
+			key.Channel,
+			key.RecipientAddress,
+			maxItems,
+		)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		digest, err = build(fromSQLXNotifications(rows))
+		if err != nil || digest == nil {
+			return err
+		}
+
+		if err = insertNotification(tx, digest); err != nil {
+			return err
+		}
+		ids := make([]uuid.UUID, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+		query, args, err := sqlx.In(`UPDATE notification SET status = ?, digest_id = ? WHERE id IN (?)`, model.Digested, digest.ID, ids)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = tx.Exec(query, args...)
+		return errors.WithStack(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+func insertNotification(execer sqlx.Execer, notification *model.Notification) error {
+	_, err := execer.Exec(
+		`INSERT INTO notification (`+notificationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		notification.ID,
+		notification.UserID,
+		notification.Channel,
+		notification.Category,
+		notification.RecipientAddress,
+		notification.Subject,
+		notification.Body,
+		notification.HTMLBody,
+		notification.Status,
+		notification.FailureReason,
+		notification.CreatedAt,
+		toSQLNull(notification.SentAt),
+		notification.Attempts,
+		notification.NextAttemptAt,
+		toSQLNull(notification.LockedUntil),
+		toSQLNull(notification.DigestID),
+		notification.RateLimitDecision,
+		notification.RateLimitReason,
+	)
+	return errors.WithStack(err)
+}
+
+func fromSQLXNotifications(rows []sqlxNotification) []model.Notification {
+	notifications := make([]model.Notification, 0, len(rows))
+	for _, row := range rows {
+		notifications = append(notifications, *fromSQLXNotification(row))
+	}
+	return notifications
+}
+
+func fromSQLXNotification(notification sqlxNotification) *model.Notification {
+	return &model.Notification{
+		ID:                notification.ID,
+		UserID:            notification.UserID,
+		Channel:           model.NotificationChannel(notification.Channel),
+		Category:          model.NotificationCategory(notification.Category),
+		RecipientAddress:  notification.RecipientAddress,
+		Subject:           notification.Subject,
+		Body:              notification.Body,
+		HTMLBody:          notification.HTMLBody,
+		Status:            model.NotificationStatus(notification.Status),
+		FailureReason:     notification.FailureReason,
+		CreatedAt:         notification.CreatedAt,
+		SentAt:            fromSQLNull(notification.SentAt),
+		Attempts:          notification.Attempts,
+		NextAttemptAt:     notification.NextAttemptAt,
+		LockedUntil:       fromSQLNull(notification.LockedUntil),
+		DigestID:          fromSQLNull(notification.DigestID),
+		RateLimitDecision: model.RateLimitDecision(notification.RateLimitDecision),
+		RateLimitReason:   notification.RateLimitReason,
+	}
+}
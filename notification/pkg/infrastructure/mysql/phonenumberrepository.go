@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+func NewPhoneNumberRepository(db *sqlx.DB) model.PhoneNumberRepository {
+	return &phoneNumberRepository{db: db}
+}
+
+type phoneNumberRepository struct {
+	db dbExecutor
+}
+
+type sqlxPhoneNumber struct {
+	UserID    uuid.UUID `db:"user_id"`
+	Number    string    `db:"number"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (r *phoneNumberRepository) Find(userID uuid.UUID) (*model.PhoneNumber, error) {
+	var row sqlxPhoneNumber
+	err := r.db.Get(&row, `SELECT user_id, number, updated_at FROM user_phone_number WHERE user_id = ?`, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrPhoneNumberNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	return &model.PhoneNumber{
+		UserID:    row.UserID,
+		Number:    row.Number,
+		UpdatedAt: row.UpdatedAt,
+	}, nil
+}
+
+func (r *phoneNumberRepository) Save(phoneNumber *model.PhoneNumber) error {
+	_, err := r.db.Exec(
+		`INSERT INTO user_phone_number (user_id, number, updated_at) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE number = VALUES(number), updated_at = VALUES(updated_at)`,
+		phoneNumber.UserID,
+		phoneNumber.Number,
+		phoneNumber.UpdatedAt,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *phoneNumberRepository) Delete(userID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM user_phone_number WHERE user_id = ?`, userID)
+	return errors.WithStack(err)
+}
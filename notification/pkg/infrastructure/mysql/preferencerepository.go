@@ -0,0 +1,166 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+func NewPreferenceRepository(db *sqlx.DB) model.PreferenceRepository {
+	return &preferenceRepository{db: db}
+}
+
+type preferenceRepository struct {
+	db dbExecutor
+}
+
+type sqlxChannelPreference struct {
+	Category  int       `db:"category"`
+	Channel   int       `db:"channel"`
+	Enabled   bool      `db:"enabled"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type sqlxDigestPreference struct {
+	Category  int       `db:"category"`
+	Frequency int       `db:"frequency"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type sqlxQuietHours struct {
+	StartMinute int       `db:"start_minute"`
+	EndMinute   int       `db:"end_minute"`
+	TimeZone    string    `db:"time_zone"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func (r *preferenceRepository) Find(userID uuid.UUID) (*model.NotificationPreferences, error) {
+	preferences := &model.NotificationPreferences{UserID: userID}
+
+	var channelRows []sqlxChannelPreference
+	err := r.db.Select(
+		&channelRows,
+		`SELECT category, channel, enabled, updated_at FROM notification_channel_preference WHERE user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, row := range channelRows {
+		preferences.Channels = append(preferences.Channels, model.ChannelPreference{
+			Category: model.NotificationCategory(row.Category),
+			Channel:  model.NotificationChannel(row.Channel),
+			Enabled:  row.Enabled,
+		})
+		if row.UpdatedAt.After(preferences.UpdatedAt) {
+			preferences.UpdatedAt = row.UpdatedAt
+		}
+	}
+
+	var digestRows []sqlxDigestPreference
+	err = r.db.Select(
+		&digestRows,
+		`SELECT category, frequency, updated_at FROM notification_digest_preference WHERE user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, row := range digestRows {
+		preferences.Digests = append(preferences.Digests, model.DigestPreference{
+			Category:  model.NotificationCategory(row.Category),
+			Frequency: model.DigestFrequency(row.Frequency),
+		})
+		if row.UpdatedAt.After(preferences.UpdatedAt) {
+			preferences.UpdatedAt = row.UpdatedAt
+		}
+	}
+
+	var quietHours sqlxQuietHours
+	err = r.db.Get(
+		&quietHours,
+		`SELECT start_minute, end_minute, time_zone, updated_at FROM notification_quiet_hours WHERE user_id = ?`,
+		userID,
+	)
+	switch {
+	case err == nil:
+		preferences.QuietHours = &model.QuietHours{
+			Start:    quietHours.StartMinute,
+			End:      quietHours.EndMinute,
+			TimeZone: quietHours.TimeZone,
+		}
+		if quietHours.UpdatedAt.After(preferences.UpdatedAt) {
+			preferences.UpdatedAt = quietHours.UpdatedAt
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, errors.WithStack(err)
+	}
+	return preferences, nil
+}
+
+// Save перезаписывает настройки в одной транзакции, чтобы параллельная отправка не увидела их частично
+func (r *preferenceRepository) Save(preferences *model.NotificationPreferences) error {
+	return withinTransaction(r.db, func(tx dbExecutor) (err error) {
+		if _, err = tx.Exec(`DELETE FROM notification_channel_preference WHERE user_id = ?`, preferences.UserID); err != nil {
+			return errors.WithStack(err)
+		}
+		for _, preference := range preferences.Channels {
+			_, err = tx.Exec(
+				`INSERT INTO notification_channel_preference (user_id, category, channel, enabled, updated_at)
+				VALUES (?, ?, ?, ?, ?)`,
+				preferences.UserID,
+				preference.Category,
+				preference.Channel,
+				preference.Enabled,
+				preferences.UpdatedAt,
+			)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if _, err = tx.Exec(`DELETE FROM notification_digest_preference WHERE user_id = ?`, preferences.UserID); err != nil {
+			return errors.WithStack(err)
+		}
+		for _, preference := range preferences.Digests {
+			_, err = tx.Exec(
+				`INSERT INTO notification_digest_preference (user_id, category, frequency, updated_at) VALUES (?, ?, ?, ?)`,
+				preferences.UserID,
+				preference.Category,
+				preference.Frequency,
+				preferences.UpdatedAt,
+			)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if preferences.QuietHours == nil {
+			_, err = tx.Exec(`DELETE FROM notification_quiet_hours WHERE user_id = ?`, preferences.UserID)
+		} else {
+			_, err = tx.Exec(
+				`INSERT INTO notification_quiet_hours (user_id, start_minute, end_minute, time_zone, updated_at)
+				VALUES (?, ?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE
+					start_minute = VALUES(start_minute),
+					end_minute = VALUES(end_minute),
+					time_zone = VALUES(time_zone),
+					updated_at = VALUES(updated_at)`,
+				preferences.UserID,
+				preferences.QuietHours.Start,
+				preferences.QuietHours.End,
+				preferences.QuietHours.TimeZone,
+				preferences.UpdatedAt,
+			)
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+}
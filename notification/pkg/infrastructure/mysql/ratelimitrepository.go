@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+func NewRateLimitRepository(db *sqlx.DB) model.RateLimitRepository {
+	return &rateLimitRepository{db: db}
+}
+
+type rateLimitRepository struct {
+	db *sqlx.DB
+}
+
+// Acquire сразу берёт эксклюзивную блокировку счётчика через ON DUPLICATE KEY UPDATE:
+// INSERT IGNORE взял бы разделяемую, и два воркера взаимно блокировали бы друг друга при UPDATE
+func (r *rateLimitRepository) Acquire(buckets []model.RateLimitBucket) (exceeded int, err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for i, bucket := range buckets {
+		_, err = tx.Exec(
+			`INSERT INTO rate_limit_counter (bucket_key, window_start, window_end, count) VALUES (?, ?, ?, 0)
+			ON DUPLICATE KEY UPDATE bucket_key = bucket_key`,
+			bucket.Key,
+			bucket.WindowStart,
+			bucket.WindowEnd(),
+		)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		var count int
+		err = tx.Get(
+			&count,
+			`SELECT count FROM rate_limit_counter WHERE bucket_key = ? AND window_start = ? FOR UPDATE`,
+			bucket.Key,
+			bucket.WindowStart,
+		)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		if count >= bucket.Limit {
+			return i, errors.WithStack(tx.Rollback())
+		}
+	}
+	for _, bucket := range buckets {
+		_, err = tx.Exec(
+			`UPDATE rate_limit_counter SET count = count + 1 WHERE bucket_key = ? AND window_start = ?`,
+			bucket.Key,
+			bucket.WindowStart,
+		)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return -1, nil
+}
+
+func (r *rateLimitRepository) Release(buckets []model.RateLimitBucket) (err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, bucket := range buckets {
+		_, err = tx.Exec(
+			`UPDATE rate_limit_counter SET count = count - 1 WHERE bucket_key = ? AND window_start = ? AND count > 0`,
+			bucket.Key,
+			bucket.WindowStart,
+		)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return errors.WithStack(tx.Commit())
+}
+
+func (r *rateLimitRepository) DeleteExpired(before time.Time) error {
+	_, err := r.db.Exec(`DELETE FROM rate_limit_counter WHERE window_end < ?`, before)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,146 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+func NewTemplateRepository(db *sqlx.DB) model.TemplateRepository {
+	return &templateRepository{db: db}
+}
+
+type templateRepository struct {
+	db dbExecutor
+}
+
+type sqlxTemplate struct {
+	Name      string    `db:"name"`
+	Channel   int       `db:"channel"`
+	Locale    string    `db:"locale"`
+	Category  int       `db:"category"`
+	Subject   string    `db:"subject"`
+	TextBody  string    `db:"text_body"`
+	HTMLBody  string    `db:"html_body"`
+	Variables []byte    `db:"variables"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// jsonTemplateVariable хранит тип строкой, чтобы колонку variables можно было читать без кода
+type jsonTemplateVariable struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+var variableTypeNames = map[model.TemplateVariableType]string{
+	model.VariableString: "string",
+	model.VariableNumber: "number",
+	model.VariableDate:   "date",
+	model.VariableUUID:   "uuid",
+}
+
+const templateColumns = `name, channel, locale, category, subject, text_body, html_body, variables, updated_at`
+
+func (r *templateRepository) Find(name string, channel model.NotificationChannel, locale string) (*model.Template, error) {
+	var row sqlxTemplate
+	err := r.db.Get(
+		&row,
+		`SELECT `+templateColumns+` FROM notification_template WHERE name = ? AND channel = ? AND locale = ?`,
+		name,
+		channel,
+		locale,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrTemplateNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	return fromSQLXTemplate(row)
+}
+
+func (r *templateRepository) Save(template *model.Template) error {
+	variables := make([]jsonTemplateVariable, 0, len(template.Variables))
+	for _, variable := range template.Variables {
+		variables = append(variables, jsonTemplateVariable{Name: variable.Name, Type: variableTypeNames[variable.Type]})
+	}
+	encoded, err := json.Marshal(variables)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = r.db.Exec(
+		`INSERT INTO notification_template (`+templateColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			category = VALUES(category),
+			subject = VALUES(subject),
+			text_body = VALUES(text_body),
+			html_body = VALUES(html_body),
+			variables = VALUES(variables),
+			updated_at = VALUES(updated_at)`,
+		template.Name,
+		template.Channel,
+		template.Locale,
+		template.Category,
+		template.Subject,
+		template.TextBody,
+		template.HTMLBody,
+		encoded,
+		template.UpdatedAt,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *templateRepository) List() ([]model.Template, error) {
+	var rows []sqlxTemplate
+	if err := r.db.Select(&rows, `SELECT `+templateColumns+` FROM notification_template ORDER BY name, channel, locale`); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	templates := make([]model.Template, 0, len(rows))
+	for _, row := range rows {
+		template, err := fromSQLXTemplate(row)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *template)
+	}
+	return templates, nil
+}
+
+func fromSQLXTemplate(row sqlxTemplate) (*model.Template, error) {
+	var variables []jsonTemplateVariable
+	if err := json.Unmarshal(row.Variables, &variables); err != nil {
+		return nil, errors.Wrapf(err, "template %s has malformed variables", row.Name)
+	}
+	template := &model.Template{
+		Name:      row.Name,
+		Channel:   model.NotificationChannel(row.Channel),
+		Locale:    row.Locale,
+		Category:  model.NotificationCategory(row.Category),
+		Subject:   row.Subject,
+		TextBody:  row.TextBody,
+		HTMLBody:  row.HTMLBody,
+		UpdatedAt: row.UpdatedAt,
+	}
+	for _, variable := range variables {
+		variableType, ok := variableTypeByName(variable.Type)
+		if !ok {
+			return nil, errors.Errorf("template %s has unknown variable type %q", row.Name, variable.Type)
+		}
+		template.Variables = append(template.Variables, model.TemplateVariable{Name: variable.Name, Type: variableType})
+	}
+	return template, nil
+}
+
+func variableTypeByName(name string) (model.TemplateVariableType, bool) {
+	for variableType, typeName := range variableTypeNames {
+		if typeName == name {
+			return variableType, true
+		}
+	}
+	return 0, false
+}
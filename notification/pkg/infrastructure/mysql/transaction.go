@@ -0,0 +1,37 @@
+package mysql
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// dbExecutor - общее у *sqlx.DB и *sqlx.Tx, чтобы одни и те же запросы работали внутри транзакции и вне её
+type dbExecutor interface {
+	sqlx.Execer
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+}
+
+// withinTransaction открывает транзакцию на *sqlx.DB; репозиторий, уже привязанный к *sqlx.Tx,
+// продолжает её, и фиксирует изменения тот, кто транзакцию открыл
+func withinTransaction(db dbExecutor, fn func(tx dbExecutor) error) (err error) {
+	conn, ok := db.(*sqlx.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := conn.Beginx()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return errors.WithStack(tx.Commit())
+}
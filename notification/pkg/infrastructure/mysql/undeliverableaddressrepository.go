@@ -0,0 +1,66 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+func NewUndeliverableAddressRepository(db *sqlx.DB) model.UndeliverableAddressRepository {
+	return &undeliverableAddressRepository{db: db}
+}
+
+type undeliverableAddressRepository struct {
+	db *sqlx.DB
+}
+
+type sqlxUndeliverableAddress struct {
+	Channel        int       `db:"channel"`
+	Address        string    `db:"address"`
+	Reason         string    `db:"reason"`
+	NotificationID uuid.UUID `db:"notification_id"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+func (r *undeliverableAddressRepository) Find(channel model.NotificationChannel, address string) (*model.UndeliverableAddress, error) {
+	var row sqlxUndeliverableAddress
+	err := r.db.Get(
+		&row,
+		`SELECT channel, address, reason, notification_id, created_at FROM undeliverable_address
+		WHERE channel = ? AND address_hash = ?`,
+		channel,
+		tokenHash(address),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrUndeliverableAddressNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	return &model.UndeliverableAddress{
+		Channel:        model.NotificationChannel(row.Channel),
+		Address:        row.Address,
+		Reason:         row.Reason,
+		NotificationID: row.NotificationID,
+		CreatedAt:      row.CreatedAt,
+	}, nil
+}
+
+func (r *undeliverableAddressRepository) Save(address *model.UndeliverableAddress) error {
+	_, err := r.db.Exec(
+		`INSERT IGNORE INTO undeliverable_address (channel, address_hash, address, reason, notification_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		address.Channel,
+		tokenHash(address.Address),
+		address.Address,
+		address.Reason,
+		address.NotificationID,
+		address.CreatedAt,
+	)
+	return errors.WithStack(err)
+}
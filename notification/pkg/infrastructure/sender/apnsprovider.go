@@ -0,0 +1,136 @@
+package sender
+
+import (
+	"crypto"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultAPNsURL = "https://api.push.apple.com"
+	// Лимит APNs на тело уведомления
+	apnsMaxPayloadSize = 4096
+	// Apple отклоняет токены старше часа и слишком частое их обновление, поэтому токен живёт 50 минут
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+type APNsConfig struct {
+	// URL по умолчанию - боевой шлюз; для отладочных сборок https://api.sandbox.push.apple.com
+	URL    string
+	KeyID  string
+	TeamID string
+	// Topic - bundle ID приложения
+	Topic string
+	// PrivateKey - содержимое .p8-файла
+	PrivateKey []byte
+	Timeout    time.Duration
+}
+
+// NewAPNsProvider - адаптер к HTTP/2 API Apple Push Notification service с авторизацией по JWT
+func NewAPNsProvider(config APNsConfig) (PushProvider, error) {
+	key, err := parsePrivateKey(config.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid APNs key")
+	}
+	if config.URL == "" {
+		config.URL = defaultAPNsURL
+	}
+	return &apnsProvider{config: config, key: key, client: newHTTPClient(config.Timeout)}, nil
+}
+
+type apnsProvider struct {
+	config APNsConfig
+	key    crypto.Signer
+	client *http.Client
+
+	mu            sync.Mutex
+	token         string
+	tokenIssuedAt time.Time
+}
+
+type apnsAPS struct {
+	Alert apnsAlert `json:"alert"`
+	Sound string    `json:"sound"`
+}
+
+type apnsAlert struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body"`
+}
+
+func (p *apnsProvider) Encode(message PushMessage) ([]byte, error) {
+	// Пользовательские данные APNs принимает ключами верхнего уровня рядом с aps
+	payload := map[string]interface{}{
+		"aps": apnsAPS{Alert: apnsAlert{Title: message.Title, Body: message.Body}, Sound: "default"},
+	}
+	for key, value := range message.Data {
+		payload[key] = value
+	}
+	return marshalJSON(payload)
+}
+
+func (p *apnsProvider) MaxPayloadSize() int {
+	return apnsMaxPayloadSize
+}
+
+func (p *apnsProvider) Push(message PushMessage, payload []byte) error {
+	token, err := p.authToken()
+	if err != nil {
+		return err
+	}
+	_, err = postJSON(p.client, p.config.URL+"/3/device/"+message.Token, map[string]string{
+		"Authorization":  "bearer " + token,
+		"apns-topic":     p.config.Topic,
+		"apns-push-type": "alert",
+		"apns-priority":  "10",
+		"apns-id":        message.NotificationID.String(),
+	}, payload)
+
+	var providerErr *providerError
+	if !errors.As(err, &providerErr) {
+		return err
+	}
+	var response struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.Unmarshal(providerErr.Body, &response)
+	switch {
+	case providerErr.StatusCode == http.StatusGone,
+		response.Reason == "BadDeviceToken",
+		response.Reason == "DeviceTokenNotForTopic":
+		return errors.Wrap(ErrDeviceUnregistered, response.Reason)
+	case providerErr.StatusCode == http.StatusForbidden:
+		// Отозванный или просроченный ключ - ошибка конфигурации, уведомление не виновато
+		p.resetAuthToken()
+		return errors.Wrap(err, "apns rejected provider token")
+	}
+	return classifyHTTPError(err)
+}
+
+func (p *apnsProvider) authToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Since(p.tokenIssuedAt) < apnsTokenLifetime {
+		return p.token, nil
+	}
+	now := time.Now()
+	token, err := signJWT(p.key, map[string]string{"kid": p.config.KeyID}, map[string]interface{}{
+		"iss": p.config.TeamID,
+		"iat": now.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	p.token, p.tokenIssuedAt = token, now
+	return token, nil
+}
+
+func (p *apnsProvider) resetAuthToken() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = ""
+}
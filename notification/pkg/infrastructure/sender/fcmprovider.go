@@ -0,0 +1,180 @@
+package sender
+
+import (
+	"crypto"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultFCMURL      = "https://fcm.googleapis.com"
+	defaultFCMTokenURL = "https://oauth2.googleapis.com/token"
+	fcmScope           = "https://www.googleapis.com/auth/firebase.messaging"
+	// Лимит FCM на notification и data вместе; считаем по всему телу запроса с запасом
+	fcmMaxPayloadSize = 4096
+	fcmAssertionTTL   = time.Hour
+	// Токен доступа обновляется заранее, чтобы не истечь посреди запроса
+	fcmTokenRefreshMargin = time.Minute
+)
+
+type FCMConfig struct {
+	URL       string
+	ProjectID string
+	// ClientEmail и PrivateKey - поля client_email и private_key ключа сервисного аккаунта
+	ClientEmail string
+	PrivateKey  []byte
+	TokenURL    string
+	Timeout     time.Duration
+}
+
+// NewFCMProvider - адаптер к HTTP v1 API Firebase Cloud Messaging. Токен доступа OAuth 2.0
+// выпускается по ключу сервисного аккаунта и кэшируется до истечения
+func NewFCMProvider(config FCMConfig) (PushProvider, error) {
+	key, err := parsePrivateKey(config.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid FCM service account key")
+	}
+	if config.URL == "" {
+		config.URL = defaultFCMURL
+	}
+	if config.TokenURL == "" {
+		config.TokenURL = defaultFCMTokenURL
+	}
+	return &fcmProvider{config: config, key: key, client: newHTTPClient(config.Timeout)}, nil
+}
+
+type fcmProvider struct {
+	config FCMConfig
+	key    crypto.Signer
+	client *http.Client
+
+	mu             sync.Mutex
+	accessToken    string
+	tokenExpiresAt time.Time
+}
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body"`
+}
+
+type fcmErrorResponse struct {
+	Error struct {
+		Status  string `json:"status"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func (p *fcmProvider) Encode(message PushMessage) ([]byte, error) {
+	return marshalJSON(fcmRequest{Message: fcmMessage{
+		Token:        message.Token,
+		Notification: fcmNotification{Title: message.Title, Body: message.Body},
+		Data:         message.Data,
+	}})
+}
+
+func (p *fcmProvider) MaxPayloadSize() int {
+	return fcmMaxPayloadSize
+}
+
+func (p *fcmProvider) Push(_ PushMessage, payload []byte) error {
+	accessToken, err := p.authToken()
+	if err != nil {
+		return err
+	}
+	_, err = postJSON(p.client, p.config.URL+"/v1/projects/"+p.config.ProjectID+"/messages:send", map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}, payload)
+
+	var providerErr *providerError
+	if !errors.As(err, &providerErr) {
+		return err
+	}
+	var response fcmErrorResponse
+	_ = json.Unmarshal(providerErr.Body, &response)
+	errorCode := response.Error.Status
+	for _, detail := range response.Error.Details {
+		if detail.ErrorCode != "" {
+			errorCode = detail.ErrorCode
+		}
+	}
+	switch {
+	case providerErr.StatusCode == http.StatusNotFound, errorCode == "UNREGISTERED", errorCode == "SENDER_ID_MISMATCH":
+		return errors.Wrap(ErrDeviceUnregistered, errorCode)
+	case providerErr.StatusCode == http.StatusUnauthorized:
+		p.resetAuthToken()
+		return errors.Wrap(err, "fcm rejected access token")
+	}
+	return classifyHTTPError(err)
+}
+
+func (p *fcmProvider) authToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accessToken != "" && time.Now().Before(p.tokenExpiresAt) {
+		return p.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := signJWT(p.key, map[string]string{}, map[string]interface{}{
+		"iss":   p.config.ClientEmail,
+		"scope": fcmScope,
+		"aud":   p.config.TokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(fcmAssertionTTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	response, err := p.client.Post(p.config.TokenURL, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain fcm access token")
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", errors.Errorf("fcm token endpoint responded %d: %s", response.StatusCode, body)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", errors.New("fcm token endpoint returned malformed response")
+	}
+	p.accessToken = token.AccessToken
+	p.tokenExpiresAt = now.Add(time.Duration(token.ExpiresIn)*time.Second - fcmTokenRefreshMargin)
+	return p.accessToken, nil
+}
+
+func (p *fcmProvider) resetAuthToken() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accessToken = ""
+}
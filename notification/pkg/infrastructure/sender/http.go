@@ -0,0 +1,84 @@
+package sender
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+const (
+	defaultHTTPTimeout = 10 * time.Second
+	// maxErrorBodySize - сколько байт ответа провайдера сохраняется в тексте ошибки
+	maxErrorBodySize = 512
+)
+
+func newHTTPClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// providerError - ответ провайдера с кодом не из 2xx
+type providerError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *providerError) Error() string {
+	return "provider responded " + http.StatusText(e.StatusCode) + ": " + string(e.Body)
+}
+
+// postJSON отправляет payload и возвращает *providerError для ответов не из 2xx
+func postJSON(client *http.Client, url string, headers map[string]string, payload []byte) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		if len(body) > maxErrorBodySize {
+			body = body[:maxErrorBodySize]
+		}
+		return nil, &providerError{StatusCode: response.StatusCode, Body: body}
+	}
+	return body, nil
+}
+
+// classifyHTTPError помечает постоянными ответы 4xx, кроме таймаута и превышения лимита запросов:
+// повтор того же запроса получит тот же отказ. 5xx и сетевые ошибки остаются временными
+func classifyHTTPError(err error) error {
+	var providerErr *providerError
+	if !errors.As(err, &providerErr) {
+		return err
+	}
+	switch code := providerErr.StatusCode; {
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests, code >= 500:
+		return err
+	default:
+		return errors.Wrap(model.ErrPermanentFailure, err.Error())
+	}
+}
+
+func marshalJSON(value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	return data, errors.WithStack(err)
+}
@@ -0,0 +1,52 @@
+package sender
+
+import (
+	"net/http"
+	"time"
+)
+
+type HTTPSMSProviderConfig struct {
+	// URL принимает POST с JSON-телом httpSMSRequest
+	URL     string
+	APIKey  string
+	Timeout time.Duration
+}
+
+// NewHTTPSMSProvider - адаптер к REST API SMS-шлюза. Reference уходит в Idempotency-Key,
+// поэтому повтор после обрыва соединения не приводит к второму SMS
+func NewHTTPSMSProvider(config HTTPSMSProviderConfig) SMSProvider {
+	return &httpSMSProvider{config: config, client: newHTTPClient(config.Timeout)}
+}
+
+type httpSMSProvider struct {
+	config HTTPSMSProviderConfig
+	client *http.Client
+}
+
+type httpSMSRequest struct {
+	To        string `json:"to"`
+	From      string `json:"from,omitempty"`
+	Text      string `json:"text"`
+	Encoding  string `json:"encoding"`
+	Segments  int    `json:"segments"`
+	Reference string `json:"reference"`
+}
+
+func (p *httpSMSProvider) SendSMS(sms SMS) error {
+	payload, err := marshalJSON(httpSMSRequest{
+		To:        sms.To,
+		From:      sms.From,
+		Text:      sms.Text,
+		Encoding:  string(sms.Encoding),
+		Segments:  sms.Segments,
+		Reference: sms.Reference,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = postJSON(p.client, p.config.URL, map[string]string{
+		"Authorization":   "Bearer " + p.config.APIKey,
+		"Idempotency-Key": sms.Reference,
+	}, payload)
+	return classifyHTTPError(err)
+}
@@ -0,0 +1,79 @@
+package sender
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+)
+
+// parsePrivateKey читает PEM-ключ в PKCS #8: так выдают ключи и Apple (.p8), и Google (service account)
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		rsaKey, rsaErr := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if rsaErr != nil {
+			return nil, errors.Wrap(err, "failed to parse private key")
+		}
+		return rsaKey, nil
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// signJWT подписывает токен ES256 для ключа ECDSA P-256 или RS256 для RSA
+func signJWT(key crypto.Signer, header map[string]string, claims interface{}) (string, error) {
+	switch key.(type) {
+	case *ecdsa.PrivateKey:
+		header["alg"] = "ES256"
+	case *rsa.PrivateKey:
+		header["alg"] = "RS256"
+	default:
+		return "", errors.Errorf("unsupported private key type %T", key)
+	}
+	header["typ"] = "JWT"
+	encodedHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	encodedClaims, err := json.Marshal(claims)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(encodedHeader) + "." +
+		base64.RawURLEncoding.EncodeToString(encodedClaims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		// JWS требует r и s фиксированной длины подряд, а не DER
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,27 @@
+package sender
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"notification/pkg/domain/model"
+)
+
+// NewLogSender только пишет уведомление в лог; используется, пока для канала нет настоящего провайдера
+func NewLogSender(logger *log.Logger, channel model.NotificationChannel) model.NotificationSender {
+	return &logSender{logger: logger, channel: channel}
+}
+
+type logSender struct {
+	logger  *log.Logger
+	channel model.NotificationChannel
+}
+
+func (s *logSender) Send(message model.OutgoingMessage) error {
+	s.logger.WithFields(log.Fields{
+		"channel":        s.channel,
+		"notificationID": message.NotificationID,
+		"recipient":      message.Recipient,
+		"subject":        message.Subject,
+	}).Infof("notification sent to log")
+	return nil
+}
@@ -0,0 +1,184 @@
+package sender
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"notification/pkg/domain/model"
+)
+
+// ErrDeviceUnregistered - провайдер сообщил, что токен больше не действует: приложение удалено или токен обновлён
+var ErrDeviceUnregistered = errors.New("device token is no longer registered")
+
+const truncationMark = "…"
+
+type PushMessage struct {
+	NotificationID uuid.UUID
+	Token          string
+	Title          string
+	Body           string
+	Data           map[string]string
+}
+
+type PushProvider interface {
+	// Encode строит тело запроса к провайдеру, по нему проверяется MaxPayloadSize
+	Encode(message PushMessage) ([]byte, error)
+	MaxPayloadSize() int
+	// Push возвращает ErrDeviceUnregistered для недействительного токена
+	Push(message PushMessage, payload []byte) error
+}
+
+// NewPushSender рассылает уведомление на устройства пользователя через провайдера их платформы.
+// Недействительные токены удаляются из реестра
+func NewPushSender(
+	devices model.DeviceTokenRepository,
+	providers map[model.DevicePlatform]PushProvider,
+	logger *log.Logger,
+) model.NotificationSender {
+	return &pushSender{devices: devices, providers: providers, logger: logger}
+}
+
+type pushSender struct {
+	devices   model.DeviceTokenRepository
+	providers map[model.DevicePlatform]PushProvider
+	logger    *log.Logger
+}
+
+// Send считает уведомление доставленным, если оно дошло хотя бы до одного устройства:
+// повтор ради остальных продублировал бы его на уже получивших
+func (s *pushSender) Send(message model.OutgoingMessage) error {
+	devices, err := s.recipientDevices(message)
+	if err != nil {
+		return err
+	}
+
+	delivered := 0
+	var transientErr, permanentErr error
+	for _, device := range devices {
+		err := s.push(device, message)
+		switch {
+		case err == nil:
+			delivered++
+			continue
+		case errors.Is(err, ErrDeviceUnregistered):
+			if deleteErr := s.devices.Delete(device.Token); deleteErr != nil {
+				s.logger.WithError(deleteErr).Warn("failed to remove unregistered device token")
+			}
+			permanentErr = err
+		case errors.Is(err, model.ErrPermanentFailure):
+			permanentErr = err
+		default:
+			transientErr = err
+		}
+		s.logger.WithError(err).WithFields(log.Fields{
+			"notificationID": message.NotificationID,
+			"deviceID":       device.ID,
+			"platform":       device.Platform,
+		}).Warn("push to device failed")
+	}
+
+	switch {
+	case delivered > 0:
+		return nil
+	case transientErr != nil:
+		return transientErr
+	default:
+		return errors.Wrap(model.ErrPermanentFailure, permanentErr.Error())
+	}
+}
+
+func (s *pushSender) recipientDevices(message model.OutgoingMessage) ([]model.DeviceToken, error) {
+	if message.Recipient == "" {
+		devices, err := s.devices.ListForUser(message.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if len(devices) == 0 {
+			return nil, errors.Wrap(model.ErrPermanentFailure, model.ErrNoDevicesRegistered.Error())
+		}
+		return devices, nil
+	}
+	device, err := s.devices.FindByToken(message.Recipient)
+	if errors.Is(err, model.ErrDeviceTokenNotFound) || (err == nil && device.UserID != message.UserID) {
+		// Токен удалили или он перешёл к другому пользователю после постановки в очередь
+		return nil, errors.Wrap(model.ErrPermanentFailure, ErrDeviceUnregistered.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []model.DeviceToken{*device}, nil
+}
+
+func (s *pushSender) push(device model.DeviceToken, message model.OutgoingMessage) error {
+	provider, ok := s.providers[device.Platform]
+	if !ok {
+		return errors.Wrapf(model.ErrPermanentFailure, "no push provider for platform %d", device.Platform)
+	}
+	pushMessage := PushMessage{
+		NotificationID: message.NotificationID,
+		Token:          device.Token,
+		Title:          message.Subject,
+		Body:           message.TextBody,
+		Data:           map[string]string{"notificationID": message.NotificationID.String()},
+	}
+	payload, err := fitPayload(provider, &pushMessage)
+	if err != nil {
+		return err
+	}
+	return provider.Push(pushMessage, payload)
+}
+
+// fitPayload укорачивает текст, а если этого мало - и заголовок, чтобы тело уложилось в лимит провайдера
+func fitPayload(provider PushProvider, message *PushMessage) ([]byte, error) {
+	payload, err := provider.Encode(*message)
+	if err != nil || len(payload) <= provider.MaxPayloadSize() {
+		return payload, err
+	}
+	for _, field := range []*string{&message.Body, &message.Title} {
+		payload, err = truncateToFit(provider, message, field)
+		if err != nil || payload != nil {
+			return payload, err
+		}
+	}
+	return nil, errors.Wrapf(model.ErrPermanentFailure, "push payload exceeds %d bytes", provider.MaxPayloadSize())
+}
+
+// truncateToFit подбирает двоичным поиском самую длинную обрезку field, при которой тело помещается;
+// если не помещается и пустое поле, возвращает nil
+func truncateToFit(provider PushProvider, message *PushMessage, field *string) ([]byte, error) {
+	original := []rune(*field)
+	var best []byte
+	bestLength := -1
+	low, high := 0, len(original)
+	for low <= high {
+		middle := (low + high) / 2
+		*field = truncated(original, middle)
+		payload, err := provider.Encode(*message)
+		if err != nil {
+			return nil, err
+		}
+		if len(payload) <= provider.MaxPayloadSize() {
+			best, bestLength = payload, middle
+			low = middle + 1
+		} else {
+			high = middle - 1
+		}
+	}
+	if bestLength < 0 {
+		*field = ""
+		return nil, nil
+	}
+	*field = truncated(original, bestLength)
+	return best, nil
+}
+
+func truncated(runes []rune, length int) string {
+	if length == 0 {
+		return ""
+	}
+	if length >= len(runes) {
+		return string(runes)
+	}
+	return string(runes[:length]) + truncationMark
+}
@@ -0,0 +1,141 @@
+package sender
+
+import (
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+type SMSEncoding string
+
+const (
+	// GSM7 - 7-битный алфавит GSM 03.38, 160 символов в одном сегменте
+	GSM7 SMSEncoding = "gsm7"
+	// UCS2 - всё, что не укладывается в GSM7, например кириллица и эмодзи; 70 символов в сегменте
+	UCS2 SMSEncoding = "ucs2"
+)
+
+const (
+	gsm7SingleSegment = 160
+	gsm7MultiSegment  = 153
+	ucs2SingleSegment = 70
+	ucs2MultiSegment  = 67
+
+	maxAlphanumericSenderID = 11
+)
+
+// SMS - сообщение в том виде, в котором его принимает провайдер. Провайдер сам режет текст на сегменты,
+// Segments нужен для проверки лимита и учёта стоимости
+type SMS struct {
+	To        string
+	From      string
+	Text      string
+	Encoding  SMSEncoding
+	Segments  int
+	Reference string
+}
+
+// SMSProvider отправляет одно SMS; ошибки, повтор которых не поможет, оборачивает в model.ErrPermanentFailure
+type SMSProvider interface {
+	SendSMS(sms SMS) error
+}
+
+type SMSConfig struct {
+	// SenderID - буквенное имя до 11 символов, короткий номер или номер в E.164; пустой - номер провайдера по умолчанию
+	SenderID string
+	// MaxSegments ограничивает длину сообщения, 0 - без ограничения
+	MaxSegments int
+}
+
+func NewSMSSender(provider SMSProvider, config SMSConfig) (model.NotificationSender, error) {
+	if config.SenderID != "" && !isValidSenderID(config.SenderID) {
+		return nil, errors.Errorf("invalid SMS sender id %q", config.SenderID)
+	}
+	return &smsSender{provider: provider, config: config}, nil
+}
+
+type smsSender struct {
+	provider SMSProvider
+	config   SMSConfig
+}
+
+func (s *smsSender) Send(message model.OutgoingMessage) error {
+	to, err := model.ParsePhoneNumber(message.Recipient)
+	if err != nil {
+		return errors.Wrapf(model.ErrPermanentFailure, "invalid recipient %q: %v", message.Recipient, err)
+	}
+	// У SMS нет темы, уходит только текст
+	text := strings.TrimSpace(message.TextBody)
+	if text == "" {
+		return errors.Wrap(model.ErrPermanentFailure, "sms text is empty")
+	}
+	encoding, segments := SMSSegments(text)
+	if s.config.MaxSegments > 0 && segments > s.config.MaxSegments {
+		return errors.Wrapf(model.ErrPermanentFailure, "sms takes %d segments, limit is %d", segments, s.config.MaxSegments)
+	}
+	return s.provider.SendSMS(SMS{
+		To:        to,
+		From:      s.config.SenderID,
+		Text:      text,
+		Encoding:  encoding,
+		Segments:  segments,
+		Reference: message.NotificationID.String(),
+	})
+}
+
+// SMSSegments считает, на сколько сегментов разобьётся текст. В длинных сообщениях часть сегмента
+// занимает заголовок склейки, поэтому сегменты короче. Символы расширенной таблицы GSM занимают два места
+func SMSSegments(text string) (SMSEncoding, int) {
+	septets := 0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune(gsm7Basic, r):
+			septets++
+		case strings.ContainsRune(gsm7Extension, r):
+			septets += 2
+		default:
+			return UCS2, segmentCount(len(utf16.Encode([]rune(text))), ucs2SingleSegment, ucs2MultiSegment)
+		}
+	}
+	return GSM7, segmentCount(septets, gsm7SingleSegment, gsm7MultiSegment)
+}
+
+func segmentCount(length, single, multi int) int {
+	if length <= single {
+		return 1
+	}
+	return (length + multi - 1) / multi
+}
+
+const (
+	gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsm7Extension = "\f^{}\\[~]|€"
+)
+
+// isValidSenderID принимает номер в E.164, короткий номер или буквенное имя, в котором есть хотя бы одна буква
+func isValidSenderID(senderID string) bool {
+	if _, err := model.ParsePhoneNumber(senderID); err == nil && strings.HasPrefix(senderID, "+") {
+		return true
+	}
+	if len(senderID) > maxAlphanumericSenderID {
+		return false
+	}
+	hasLetter, allDigits := false, true
+	for _, r := range senderID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			hasLetter, allDigits = true, false
+		case r >= '0' && r <= '9':
+		case r == ' ':
+			allDigits = false
+		default:
+			return false
+		}
+	}
+	// Короткие номера состоят из 3-8 цифр
+	return hasLetter || (allDigits && len(senderID) >= 3 && len(senderID) <= 8)
+}
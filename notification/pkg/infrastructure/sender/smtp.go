@@ -0,0 +1,350 @@
+package sender
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"notification/pkg/domain/model"
+)
+
+type SMTPTLSMode string
+
+const (
+	// SMTPPlain - без шифрования, только для локальной разработки
+	SMTPPlain SMTPTLSMode = "none"
+	// SMTPStartTLS - обычное соединение, переводимое в TLS командой STARTTLS (порт 587)
+	SMTPStartTLS SMTPTLSMode = "starttls"
+	// SMTPImplicitTLS - TLS с момента подключения (порт 465)
+	SMTPImplicitTLS SMTPTLSMode = "tls"
+)
+
+const authFailedCode = 535
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	TLSMode  SMTPTLSMode
+	// TLSConfig необязателен; ServerName по умолчанию берётся из Host
+	TLSConfig *tls.Config
+
+	From     string
+	FromName string
+	// MessageIDDomain по умолчанию - домен адреса From
+	MessageIDDomain string
+	// UnsubscribeURL попадает в List-Unsubscribe; {userID} заменяется на ID получателя
+	UnsubscribeURL    string
+	UnsubscribeMailto string
+
+	Timeout time.Duration
+	// IdleTimeout - сколько держать соединение открытым между письмами
+	IdleTimeout time.Duration
+	// MaxMessagesPerConnection ограничивает число писем на соединение, 0 - без ограничения
+	MaxMessagesPerConnection int
+}
+
+// SMTPSender отправляет email через SMTP. Соединение переиспользуется, пока письма идут чаще IdleTimeout,
+// поэтому пачка уведомлений от воркера уходит через одно подключение
+type SMTPSender interface {
+	model.NotificationSender
+	Close() error
+}
+
+func NewSMTPSender(config SMTPConfig) (SMTPSender, error) {
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid SMTP from address")
+	}
+	from.Name = config.FromName
+	if config.MessageIDDomain == "" {
+		config.MessageIDDomain = from.Address[strings.LastIndexByte(from.Address, '@')+1:]
+	}
+	switch config.TLSMode {
+	case SMTPPlain, SMTPStartTLS, SMTPImplicitTLS:
+	default:
+		return nil, errors.Errorf("unknown SMTP TLS mode %q", config.TLSMode)
+	}
+	return &smtpSender{config: config, from: from}, nil
+}
+
+type smtpSender struct {
+	config SMTPConfig
+	from   *mail.Address
+
+	mu       sync.Mutex
+	conn     net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+	sent     int
+}
+
+func (s *smtpSender) Send(message model.OutgoingMessage) error {
+	recipient, err := mail.ParseAddress(message.Recipient)
+	if err != nil {
+		return errors.Wrapf(model.ErrPermanentFailure, "invalid recipient address: %v", err)
+	}
+	data, err := s.buildMessage(message, recipient)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, err := s.connection()
+	if err != nil {
+		// Сбой подключения или авторизации не связан с письмом, его имеет смысл повторить
+		return errors.Wrap(err, "smtp connection failed")
+	}
+	if err = s.deliver(client, recipient.Address, data); err != nil {
+		var protoErr *textproto.Error
+		if !errors.As(err, &protoErr) || client.Reset() != nil {
+			s.drop()
+		}
+		return classifySMTPError(err)
+	}
+
+	s.lastUsed = time.Now()
+	s.sent++
+	if s.config.MaxMessagesPerConnection > 0 && s.sent >= s.config.MaxMessagesPerConnection {
+		s.quit()
+	}
+	return nil
+}
+
+func (s *smtpSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quit()
+	return nil
+}
+
+func (s *smtpSender) deliver(client *smtp.Client, recipient string, data []byte) error {
+	if err := s.extendDeadline(s.conn); err != nil {
+		return err
+	}
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(recipient); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// connection возвращает живое соединение, при необходимости открывая новое
+func (s *smtpSender) connection() (*smtp.Client, error) {
+	if s.client != nil {
+		if time.Since(s.lastUsed) < s.config.IdleTimeout && s.extendDeadline(s.conn) == nil && s.client.Noop() == nil {
+			return s.client, nil
+		}
+		s.drop()
+	}
+
+	address := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if s.config.TLSMode == SMTPImplicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, s.tlsConfig())
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = s.extendDeadline(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err = s.handshake(client); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	s.conn, s.client, s.sent = conn, client, 0
+	return client, nil
+}
+
+func (s *smtpSender) handshake(client *smtp.Client) error {
+	if err := client.Hello(s.config.MessageIDDomain); err != nil {
+		return err
+	}
+	if s.config.TLSMode == SMTPStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(s.tlsConfig()); err != nil {
+			return err
+		}
+	}
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return errors.Wrap(err, "smtp auth failed")
+		}
+	}
+	return nil
+}
+
+func (s *smtpSender) extendDeadline(conn net.Conn) error {
+	if s.config.Timeout <= 0 {
+		return nil
+	}
+	return conn.SetDeadline(time.Now().Add(s.config.Timeout))
+}
+
+func (s *smtpSender) tlsConfig() *tls.Config {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.config.TLSConfig != nil {
+		config = s.config.TLSConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = s.config.Host
+	}
+	return config
+}
+
+func (s *smtpSender) quit() {
+	if s.client != nil {
+		_ = s.client.Quit()
+	}
+	s.drop()
+}
+
+func (s *smtpSender) drop() {
+	if s.client != nil {
+		_ = s.client.Close()
+	}
+	s.conn, s.client = nil, nil
+}
+
+func (s *smtpSender) buildMessage(message model.OutgoingMessage, recipient *mail.Address) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", s.from.String())
+	header("To", recipient.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", s.messageID(message))
+	if unsubscribe := s.listUnsubscribe(message); unsubscribe != "" {
+		header("List-Unsubscribe", unsubscribe)
+		if s.config.UnsubscribeURL != "" {
+			// RFC 8058: почтовый клиент может отписать одним POST без перехода на страницу
+			header("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+		}
+	}
+	header("MIME-Version", "1.0")
+
+	if message.HTMLBody == "" {
+		header("Content-Type", `text/plain; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, message.TextBody); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{`text/plain; charset="utf-8"`, message.TextBody},
+		{`text/html; charset="utf-8"`, message.HTMLBody},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err = writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	header("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": parts.Boundary()}))
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+// messageID строится из ID уведомления, поэтому повторная отправка после сбоя даёт тот же Message-ID
+// и получатель может отбросить дубликат
+func (s *smtpSender) messageID(message model.OutgoingMessage) string {
+	id := message.NotificationID.String()
+	if message.NotificationID == uuid.Nil {
+		random := make([]byte, 16)
+		_, _ = rand.Read(random)
+		id = hex.EncodeToString(random)
+	}
+	return fmt.Sprintf("<%s@%s>", id, s.config.MessageIDDomain)
+}
+
+func (s *smtpSender) listUnsubscribe(message model.OutgoingMessage) string {
+	var values []string
+	if s.config.UnsubscribeURL != "" {
+		values = append(values, "<"+strings.ReplaceAll(s.config.UnsubscribeURL, "{userID}", message.UserID.String())+">")
+	}
+	if s.config.UnsubscribeMailto != "" {
+		values = append(values, "<mailto:"+s.config.UnsubscribeMailto+">")
+	}
+	return strings.Join(values, ", ")
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(qp.Close())
+}
+
+// classifySMTPError помечает постоянными ответы 5xx на команды письма: адрес не существует, письмо отклонено.
+// Ответы 4xx, сетевые ошибки и таймауты остаются временными
+func classifySMTPError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 && protoErr.Code != authFailedCode {
+		return errors.Wrapf(model.ErrPermanentFailure, "smtp %d: %s", protoErr.Code, protoErr.Msg)
+	}
+	return errors.Wrap(err, "smtp send failed")
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type receivedMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSMTPServer - минимальный SMTP-сервер в том же процессе: STARTTLS, AUTH PLAIN и настраиваемые ответы на RCPT
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	// rcptReplies - ответ на RCPT TO по подстроке адреса, например "550 no such user"
+	rcptReplies map[string]string
+	username    string
+	password    string
+
+	mu          sync.Mutex
+	mails       []receivedMail
+	connections int
+}
+
+func newFakeSMTPServer(t *testing.T, implicitTLS bool) *fakeSMTPServer {
+	t.Helper()
+	server := &fakeSMTPServer{
+		tlsConfig:   &tls.Config{Certificates: []tls.Certificate{selfSignedCertificate(t)}, MinVersion: tls.VersionTLS12},
+		rcptReplies: make(map[string]string),
+		username:    "mailer",
+		password:    "secret",
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if implicitTLS {
+		listener = tls.NewListener(listener, server.tlsConfig)
+	}
+	server.listener = listener
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.connections++
+			server.mu.Unlock()
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) changePassword(password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.password = password
+}
+
+func (s *fakeSMTPServer) received() ([]receivedMail, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.mails...), s.connections
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	_, encrypted := conn.(*tls.Conn)
+	var current receivedMail
+
+	reply("220 fake.smtp ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		argument := strings.TrimSpace(strings.TrimPrefix(line, strings.SplitN(line, " ", 2)[0]))
+
+		switch command {
+		case "EHLO", "HELO":
+			reply("250-fake.smtp")
+			if !encrypted {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, reader, encrypted = tlsConn, bufio.NewReader(tlsConn), true
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(argument, "PLAIN "))
+			s.mu.Lock()
+			expected := "\x00" + s.username + "\x00" + s.password
+			s.mu.Unlock()
+			if string(credentials) == expected {
+				reply("235 authenticated")
+			} else {
+				reply("535 authentication failed")
+			}
+		case "MAIL":
+			current = receivedMail{From: argument}
+			reply("250 ok")
+		case "RCPT":
+			if response := s.rcptReply(argument); response != "" {
+				reply(response)
+				continue
+			}
+			current.To = append(current.To, argument)
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			current.Data = data.String()
+			s.mu.Lock()
+			s.mails = append(s.mails, current)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "RSET":
+			current = receivedMail{}
+			reply("250 ok")
+		case "NOOP":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) rcptReply(argument string) string {
+	for substring, response := range s.rcptReplies {
+		if strings.Contains(argument, substring) {
+			return response
+		}
+	}
+	return ""
+}
+
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
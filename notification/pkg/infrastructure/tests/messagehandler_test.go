@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/domain/service"
+	"notification/pkg/infrastructure/integrationevent"
+)
+
+func newTestMessageHandler() (integrationevent.MessageHandler, *recordingEventHandler, *memoryParkedMessageRepository) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	eventHandler := &recordingEventHandler{}
+	parked := &memoryParkedMessageRepository{}
+	inbox := &memoryInbox{processed: map[string]bool{}, repos: &memoryInboxRepositories{}}
+	newEventHandler := func(repos model.InboxRepositories) service.DomainEventHandler {
+		eventHandler.repos = repos
+		return eventHandler
+	}
+	return integrationevent.NewMessageHandler(newEventHandler, inbox, parked, logger),
+		eventHandler,
+		parked
+}
+
+func TestMessageHandler_MapsEvents(t *testing.T) {
+	handler, eventHandler, parked := newTestMessageHandler()
+	userID := uuid.New()
+	orderID := uuid.New()
+
+	for _, message := range []integrationevent.Message{
+		{RoutingKey: integrationevent.UserCreatedRoutingKey, Body: []byte(`{"user_id":"` + userID.String() + `","status":0,"login":"john","email":"john@example.com","created_at":1}`)},
+		{RoutingKey: integrationevent.UserUpdatedRoutingKey, Body: []byte(`{"user_id":"` + userID.String() + `","updated_fields":{"email":"new@example.com"}}`)},
+		{RoutingKey: integrationevent.UserUpdatedRoutingKey, Body: []byte(`{"user_id":"` + userID.String() + `","updated_fields":{"telegram":"@john"}}`)},
+		{RoutingKey: integrationevent.UserUpdatedRoutingKey, Body: []byte(`{"user_id":"` + userID.String() + `","removed_fields":{"email":true}}`)},
+		{RoutingKey: integrationevent.PaymentFailedRoutingKey, Body: []byte(`{"user_id":"` + userID.String() + `","reference_id":"` + orderID.String() + `","reason":"declined"}`)},
+		{RoutingKey: integrationevent.UserDeletedRoutingKey, Body: []byte(`{"user_id":"` + userID.String() + `","hard":true}`)},
+		{RoutingKey: integrationevent.UserVerificationRequestedRoutingKey, Body: []byte(`{"user_id":"` + userID.String() + `","email":"john@example.com","first_name":"John","token":"raw-token","expires_at":1792413000}`)},
+		{RoutingKey: integrationevent.PasswordResetRequestedRoutingKey, Body: []byte(`{"user_id":"` + userID.String() + `","email":"john@example.com","first_name":"John","token":"reset-token","expires_at":1792413000}`)},
+		{RoutingKey: integrationevent.UserErasedRoutingKey, Body: []byte(`{"user_id":"` + userID.String() + `"}`)},
+	} {
+		message.ID = uuid.NewString()
+		require.NoError(t, handler.Handle(message), message.RoutingKey)
+	}
+
+	assert.Equal(t, []string{
+		"created " + userID.String() + " john john@example.com",
+		"email changed " + userID.String() + " new@example.com",
+		"email removed " + userID.String(),
+		"payment failed " + userID.String() + " " + orderID.String() + " declined",
+		"deleted " + userID.String(),
+		"verification requested " + userID.String() + " john@example.com John raw-token 2026-10-19T12:30:00Z",
+		"password reset requested " + userID.String() + " john@example.com John reset-token 2026-10-19T12:30:00Z",
+		"deleted " + userID.String(),
+	}, eventHandler.calls)
+	assert.Empty(t, parked.messages)
+	// Обработчик пишет через репозитории транзакции inbox
+	assert.IsType(t, &memoryInboxRepositories{}, eventHandler.repos)
+}
+
+func TestMessageHandler_IsIdempotent(t *testing.T) {
+	handler, eventHandler, _ := newTestMessageHandler()
+	message := integrationevent.Message{
+		ID:         uuid.NewString(),
+		RoutingKey: integrationevent.PaymentFailedRoutingKey,
+		Body:       []byte(`{"user_id":"` + uuid.NewString() + `","reference_id":"` + uuid.NewString() + `","reason":"declined"}`),
+	}
+
+	t.Run("Transient failure is redelivered", func(t *testing.T) {
+		eventHandler.err = errors.New("database is down")
+		assert.Error(t, handler.Handle(message))
+		eventHandler.err = nil
+	})
+
+	require.NoError(t, handler.Handle(message))
+	require.NoError(t, handler.Handle(message))
+	assert.Len(t, eventHandler.calls, 2)
+}
+
+func TestMessageHandler_ParksPoisonMessages(t *testing.T) {
+	handler, eventHandler, parked := newTestMessageHandler()
+
+	for _, tc := range []struct {
+		name    string
+		message integrationevent.Message
+	}{
+		{"Invalid JSON", integrationevent.Message{ID: "1", RoutingKey: integrationevent.UserCreatedRoutingKey, Body: []byte(`{`)}},
+		{"Invalid UUID", integrationevent.Message{ID: "2", RoutingKey: integrationevent.PaymentFailedRoutingKey, Body: []byte(`{"user_id":"42"}`)}},
+		{"Unknown routing key", integrationevent.Message{ID: "3", RoutingKey: "order.order_paid", Body: []byte(`{}`)}},
+		{"Missing message id", integrationevent.Message{RoutingKey: integrationevent.UserDeletedRoutingKey, Body: []byte(`{}`)}},
+		{"Missing token", integrationevent.Message{ID: "6", RoutingKey: integrationevent.UserVerificationRequestedRoutingKey, Body: []byte(`{"user_id":"` + uuid.NewString() + `","email":"john@example.com"}`)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, handler.Handle(tc.message))
+		})
+	}
+
+	t.Run("Domain validation error", func(t *testing.T) {
+		eventHandler.err = model.ErrInvalidEmailAddress
+		defer func() { eventHandler.err = nil }()
+		require.NoError(t, handler.Handle(integrationevent.Message{
+			ID:         "5",
+			RoutingKey: integrationevent.UserUpdatedRoutingKey,
+			Body:       []byte(`{"user_id":"` + uuid.NewString() + `","updated_fields":{"email":"invalid"}}`),
+		}))
+	})
+
+	require.Len(t, parked.messages, 6)
+	assert.Equal(t, integrationevent.UserCreatedRoutingKey, parked.messages[0].RoutingKey)
+	assert.Equal(t, []byte(`{`), parked.messages[0].Body)
+	assert.Contains(t, parked.messages[2].Reason, "unknown routing key")
+	assert.Contains(t, parked.messages[4].Reason, "token is empty")
+	assert.Contains(t, parked.messages[5].Reason, model.ErrInvalidEmailAddress.Error())
+}
+
+// recordingEventHandler запоминает вызовы в читаемом виде
+type recordingEventHandler struct {
+	calls []string
+	err   error
+	repos model.InboxRepositories
+}
+
+var _ service.DomainEventHandler = (*recordingEventHandler)(nil)
+
+func (h *recordingEventHandler) record(call string) error {
+	h.calls = append(h.calls, call)
+	return h.err
+}
+
+func (h *recordingEventHandler) HandleUserCreated(userID uuid.UUID, login string, email *string) error {
+	return h.record("created " + userID.String() + " " + login + " " + *email)
+}
+func (h *recordingEventHandler) HandleUserEmailChanged(userID uuid.UUID, email string) error {
+	return h.record("email changed " + userID.String() + " " + email)
+}
+func (h *recordingEventHandler) HandleUserEmailRemoved(userID uuid.UUID) error {
+	return h.record("email removed " + userID.String())
+}
+func (h *recordingEventHandler) HandleUserDeleted(userID uuid.UUID) error {
+	return h.record("deleted " + userID.String())
+}
+func (h *recordingEventHandler) HandlePaymentFailed(userID, orderID uuid.UUID, reason string) error {
+	return h.record("payment failed " + userID.String() + " " + orderID.String() + " " + reason)
+}
+
+func (h *recordingEventHandler) HandleVerificationRequested(
+	userID uuid.UUID,
+	email, firstName, token string,
+	expiresAt time.Time,
+) error {
+	return h.record("verification requested " + userID.String() + " " + email + " " + firstName + " " + token + " " +
+		expiresAt.Format(time.RFC3339))
+}
+
+func (h *recordingEventHandler) HandlePasswordResetRequested(
+	userID uuid.UUID,
+	email, firstName, token string,
+	expiresAt time.Time,
+) error {
+	return h.record("password reset requested " + userID.String() + " " + email + " " + firstName + " " + token + " " +
+		expiresAt.Format(time.RFC3339))
+}
+
+// memoryInbox повторяет поведение таблицы inbox_message: запись остаётся только после успешной обработки
+type memoryInbox struct {
+	processed map[string]bool
+	repos     model.InboxRepositories
+}
+
+func (i *memoryInbox) Process(messageID string, handle func(repos model.InboxRepositories) error) error {
+	if i.processed[messageID] {
+		return model.ErrMessageAlreadyProcessed
+	}
+	if err := handle(i.repos); err != nil {
+		return err
+	}
+	i.processed[messageID] = true
+	return nil
+}
+
+// memoryInboxRepositories помечает репозитории транзакции; recordingEventHandler к ним не обращается
+type memoryInboxRepositories struct {
+	model.InboxRepositories
+}
+
+type memoryParkedMessageRepository struct {
+	messages []model.ParkedMessage
+}
+
+func (r *memoryParkedMessageRepository) Park(message model.ParkedMessage) error {
+	r.messages = append(r.messages, message)
+	return nil
+}
@@ -0,0 +1,368 @@
+package tests
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/infrastructure/sender"
+)
+
+const (
+	apnsTestToken = "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+	fcmTestToken  = "fcm:device_token-1"
+)
+
+type receivedPush struct {
+	Path    string
+	Headers http.Header
+	Payload []byte
+}
+
+// fakePushGateway записывает запросы и отвечает по токену устройства из responses, по умолчанию 200
+type fakePushGateway struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	received  []receivedPush
+	responses map[string]fakePushResponse
+}
+
+type fakePushResponse struct {
+	status int
+	body   string
+}
+
+func (g *fakePushGateway) record(r *http.Request, token string, w http.ResponseWriter) {
+	payload, _ := io.ReadAll(r.Body)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.received = append(g.received, receivedPush{Path: r.URL.Path, Headers: r.Header.Clone(), Payload: payload})
+	response, ok := g.responses[token]
+	if !ok {
+		response = fakePushResponse{status: http.StatusOK, body: "{}"}
+	}
+	w.WriteHeader(response.status)
+	_, _ = w.Write([]byte(response.body))
+}
+
+func (g *fakePushGateway) respond(token string, status int, body string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.responses[token] = fakePushResponse{status: status, body: body}
+}
+
+func (g *fakePushGateway) pushes() []receivedPush {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]receivedPush(nil), g.received...)
+}
+
+// newFakeAPNs проверяет подпись JWT ключом, парным выданному провайдеру
+func newFakeAPNs(t *testing.T, publicKey *ecdsa.PublicKey) *fakePushGateway {
+	gateway := &fakePushGateway{responses: make(map[string]fakePushResponse)}
+	gateway.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "bearer ")
+		if !ok || !verifyJWT(token, func(digest, signature []byte) bool {
+			return len(signature) == 64 && ecdsa.Verify(publicKey, digest,
+				new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:]))
+		}) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"reason":"InvalidProviderToken"}`))
+			return
+		}
+		gateway.record(r, strings.TrimPrefix(r.URL.Path, "/3/device/"), w)
+	}))
+	t.Cleanup(gateway.Close)
+	return gateway
+}
+
+// newFakeFCM обслуживает и выдачу токена доступа по подписанному assertion, и отправку сообщений
+func newFakeFCM(t *testing.T, publicKey *rsa.PublicKey) *fakePushGateway {
+	gateway := &fakePushGateway{responses: make(map[string]fakePushResponse)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assertion := r.FormValue("assertion")
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" ||
+			!verifyJWT(assertion, func(digest, signature []byte) bool {
+				return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest, signature) == nil
+			}) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"fcm-access-token","expires_in":3600,"token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("/v1/projects/shop/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fcm-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var request struct {
+			Message struct {
+				Token string `json:"token"`
+			} `json:"message"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &request)
+		gateway.mu.Lock()
+		gateway.received = append(gateway.received, receivedPush{Path: r.URL.Path, Headers: r.Header.Clone(), Payload: body})
+		response, ok := gateway.responses[request.Message.Token]
+		gateway.mu.Unlock()
+		if !ok {
+			response = fakePushResponse{status: http.StatusOK, body: `{"name":"projects/shop/messages/1"}`}
+		}
+		w.WriteHeader(response.status)
+		_, _ = w.Write([]byte(response.body))
+	})
+	gateway.Server = httptest.NewServer(mux)
+	t.Cleanup(gateway.Close)
+	return gateway
+}
+
+func verifyJWT(token string, verify func(digest, signature []byte) bool) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	return verify(digest[:], signature)
+}
+
+func pemPKCS8(t *testing.T, key crypto.Signer) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+type pushFixture struct {
+	sender  model.NotificationSender
+	devices *memoryDeviceTokenRepository
+	apns    *fakePushGateway
+	fcm     *fakePushGateway
+	userID  uuid.UUID
+}
+
+func setupPush(t *testing.T) *pushFixture {
+	t.Helper()
+	apnsKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	fcmKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	apns := newFakeAPNs(t, &apnsKey.PublicKey)
+	fcm := newFakeFCM(t, &fcmKey.PublicKey)
+
+	apnsProvider, err := sender.NewAPNsProvider(sender.APNsConfig{
+		URL:        apns.URL,
+		KeyID:      "KEY123",
+		TeamID:     "TEAM123",
+		Topic:      "com.example.shop",
+		PrivateKey: pemPKCS8(t, apnsKey),
+	})
+	require.NoError(t, err)
+	fcmProvider, err := sender.NewFCMProvider(sender.FCMConfig{
+		URL:         fcm.URL,
+		ProjectID:   "shop",
+		ClientEmail: "push@shop.iam.gserviceaccount.com",
+		PrivateKey:  pemPKCS8(t, fcmKey),
+		TokenURL:    fcm.URL + "/token",
+	})
+	require.NoError(t, err)
+
+	devices := &memoryDeviceTokenRepository{store: make(map[string]model.DeviceToken)}
+	userID := uuid.New()
+	devices.store[apnsTestToken] = model.DeviceToken{ID: uuid.New(), UserID: userID, Platform: model.IOS, Token: apnsTestToken}
+	devices.store[fcmTestToken] = model.DeviceToken{ID: uuid.New(), UserID: userID, Platform: model.Android, Token: fcmTestToken}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return &pushFixture{
+		sender: sender.NewPushSender(devices, map[model.DevicePlatform]sender.PushProvider{
+			model.IOS:     apnsProvider,
+			model.Android: fcmProvider,
+		}, logger),
+		devices: devices,
+		apns:    apns,
+		fcm:     fcm,
+		userID:  userID,
+	}
+}
+
+func TestPushSender_FansOutToDevices(t *testing.T) {
+	f := setupPush(t)
+	notificationID := uuid.New()
+
+	require.NoError(t, f.sender.Send(model.OutgoingMessage{
+		NotificationID: notificationID,
+		UserID:         f.userID,
+		Subject:        "Order confirmed",
+		TextBody:       "We have received your order",
+	}))
+
+	apnsPushes := f.apns.pushes()
+	require.Len(t, apnsPushes, 1)
+	assert.Equal(t, "/3/device/"+apnsTestToken, apnsPushes[0].Path)
+	assert.Equal(t, "com.example.shop", apnsPushes[0].Headers.Get("apns-topic"))
+	assert.Equal(t, notificationID.String(), apnsPushes[0].Headers.Get("apns-id"))
+	assert.JSONEq(t, `{
+		"aps": {"alert": {"title": "Order confirmed", "body": "We have received your order"}, "sound": "default"},
+		"notificationID": "`+notificationID.String()+`"
+	}`, string(apnsPushes[0].Payload))
+
+	fcmPushes := f.fcm.pushes()
+	require.Len(t, fcmPushes, 1)
+	assert.JSONEq(t, `{"message": {
+		"token": "`+fcmTestToken+`",
+		"notification": {"title": "Order confirmed", "body": "We have received your order"},
+		"data": {"notificationID": "`+notificationID.String()+`"}
+	}}`, string(fcmPushes[0].Payload))
+
+	t.Run("Explicit token targets one device", func(t *testing.T) {
+		require.NoError(t, f.sender.Send(model.OutgoingMessage{
+			NotificationID: uuid.New(),
+			UserID:         f.userID,
+			Recipient:      fcmTestToken,
+			TextBody:       "Only Android",
+		}))
+		assert.Len(t, f.apns.pushes(), 1)
+		assert.Len(t, f.fcm.pushes(), 2)
+	})
+}
+
+func TestPushSender_TruncatesToPayloadLimit(t *testing.T) {
+	f := setupPush(t)
+	delete(f.devices.store, fcmTestToken)
+
+	require.NoError(t, f.sender.Send(model.OutgoingMessage{
+		NotificationID: uuid.New(),
+		UserID:         f.userID,
+		Subject:        "Длинное уведомление",
+		TextBody:       strings.Repeat("Очень длинный текст. ", 400),
+	}))
+
+	pushes := f.apns.pushes()
+	require.Len(t, pushes, 1)
+	assert.LessOrEqual(t, len(pushes[0].Payload), 4096)
+	assert.Greater(t, len(pushes[0].Payload), 4000)
+	var payload struct {
+		APS struct {
+			Alert struct {
+				Title string `json:"title"`
+				Body  string `json:"body"`
+			} `json:"alert"`
+		} `json:"aps"`
+	}
+	require.NoError(t, json.Unmarshal(pushes[0].Payload, &payload))
+	assert.Equal(t, "Длинное уведомление", payload.APS.Alert.Title)
+	assert.True(t, strings.HasSuffix(payload.APS.Alert.Body, "…"))
+}
+
+func TestPushSender_HandlesProviderErrors(t *testing.T) {
+	t.Run("Unregistered tokens are removed", func(t *testing.T) {
+		f := setupPush(t)
+		f.apns.respond(apnsTestToken, http.StatusGone, `{"reason":"Unregistered"}`)
+		f.fcm.respond(fcmTestToken, http.StatusNotFound,
+			`{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`)
+
+		err := f.sender.Send(model.OutgoingMessage{NotificationID: uuid.New(), UserID: f.userID, TextBody: "Hi"})
+		assert.ErrorIs(t, err, model.ErrPermanentFailure)
+		assert.Empty(t, f.devices.store)
+
+		err = f.sender.Send(model.OutgoingMessage{NotificationID: uuid.New(), UserID: f.userID, TextBody: "Hi"})
+		assert.ErrorIs(t, err, model.ErrPermanentFailure)
+	})
+
+	t.Run("One delivered device is enough", func(t *testing.T) {
+		f := setupPush(t)
+		f.fcm.respond(fcmTestToken, http.StatusServiceUnavailable, `{"error":{"status":"UNAVAILABLE"}}`)
+
+		require.NoError(t, f.sender.Send(model.OutgoingMessage{NotificationID: uuid.New(), UserID: f.userID, TextBody: "Hi"}))
+		assert.Len(t, f.devices.store, 2)
+	})
+
+	t.Run("Transient failure is retried", func(t *testing.T) {
+		f := setupPush(t)
+		f.apns.respond(apnsTestToken, http.StatusTooManyRequests, `{"reason":"TooManyRequests"}`)
+		f.fcm.respond(fcmTestToken, http.StatusBadRequest, `{"error":{"status":"INVALID_ARGUMENT"}}`)
+
+		err := f.sender.Send(model.OutgoingMessage{NotificationID: uuid.New(), UserID: f.userID, TextBody: "Hi"})
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, model.ErrPermanentFailure)
+	})
+
+	t.Run("Token of another user is not used", func(t *testing.T) {
+		f := setupPush(t)
+		err := f.sender.Send(model.OutgoingMessage{
+			NotificationID: uuid.New(),
+			UserID:         uuid.New(),
+			Recipient:      apnsTestToken,
+			TextBody:       "Hi",
+		})
+		assert.ErrorIs(t, err, model.ErrPermanentFailure)
+		assert.Empty(t, f.apns.pushes())
+	})
+}
+
+type memoryDeviceTokenRepository struct {
+	mu    sync.Mutex
+	store map[string]model.DeviceToken
+}
+
+func (r *memoryDeviceTokenRepository) NextID() (uuid.UUID, error) { return uuid.New(), nil }
+func (r *memoryDeviceTokenRepository) FindByToken(token string) (*model.DeviceToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if deviceToken, ok := r.store[token]; ok {
+		return &deviceToken, nil
+	}
+	return nil, model.ErrDeviceTokenNotFound
+}
+func (r *memoryDeviceTokenRepository) Save(deviceToken *model.DeviceToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deviceToken.LastSeenAt = time.Now()
+	r.store[deviceToken.Token] = *deviceToken
+	return nil
+}
+func (r *memoryDeviceTokenRepository) ListForUser(userID uuid.UUID) ([]model.DeviceToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deviceTokens []model.DeviceToken
+	for _, deviceToken := range r.store {
+		if deviceToken.UserID == userID {
+			deviceTokens = append(deviceTokens, deviceToken)
+		}
+	}
+	return deviceTokens, nil
+}
+func (r *memoryDeviceTokenRepository) Delete(token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.store, token)
+	return nil
+}
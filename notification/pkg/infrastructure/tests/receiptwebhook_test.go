@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/infrastructure/transport"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+// recordingReceiptService отвечает ошибкой на квитанции уведомлений из errs
+type recordingReceiptService struct {
+	receipts []model.DeliveryReceipt
+	errs     map[uuid.UUID]error
+}
+
+func (s *recordingReceiptService) Apply(receipt model.DeliveryReceipt) error {
+	if err := s.errs[receipt.NotificationID]; err != nil {
+		return err
+	}
+	s.receipts = append(s.receipts, receipt)
+	return nil
+}
+
+func newTestReceiptWebhook() (http.Handler, *recordingReceiptService) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	receiptService := &recordingReceiptService{errs: map[uuid.UUID]error{}}
+	return transport.NewReceiptWebhook(receiptService, testWebhookSecret, logger), receiptService
+}
+
+func newSignedReceiptRequest(body string, timestamp time.Time, secret string) *http.Request {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "." + body))
+
+	request := httptest.NewRequest(http.MethodPost, "/webhooks/receipts", strings.NewReader(body))
+	request.Header.Set(transport.ReceiptTimestampHeader, ts)
+	request.Header.Set(transport.ReceiptSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	return request
+}
+
+func serveReceipts(handler http.Handler, request *http.Request) int {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+func TestReceiptWebhook_MapsEvents(t *testing.T) {
+	handler, receiptService := newTestReceiptWebhook()
+	delivered, bounced, complained := uuid.New(), uuid.New(), uuid.New()
+	body := `{"events":[
+		{"type":"delivered","reference":"` + delivered.String() + `","timestamp":1714564800},
+		{"type":"bounced","reference":"<` + bounced.String() + `@example.com>","bounceType":"hard","reason":"550 no such user"},
+		{"type":"complained","reference":"` + complained.String() + `"},
+		{"type":"opened","reference":"` + delivered.String() + `"},
+		{"type":"delivered","reference":"not-a-notification"}
+	]}`
+
+	code := serveReceipts(handler, newSignedReceiptRequest(body, time.Now(), testWebhookSecret))
+	assert.Equal(t, http.StatusNoContent, code)
+
+	// Неизвестные типы и ссылки пропускаются, остальная пачка применяется
+	assert.Equal(t, []model.DeliveryReceipt{
+		{NotificationID: delivered, Type: model.ReceiptDelivered, OccurredAt: time.Unix(1714564800, 0).UTC()},
+		{NotificationID: bounced, Type: model.ReceiptBounced, HardBounce: true, Reason: "550 no such user"},
+		{NotificationID: complained, Type: model.ReceiptComplained},
+	}, receiptService.receipts)
+}
+
+func TestReceiptWebhook_RejectsInvalidSignature(t *testing.T) {
+	handler, receiptService := newTestReceiptWebhook()
+	body := `{"events":[{"type":"delivered","reference":"` + uuid.NewString() + `"}]}`
+
+	for name, request := range map[string]*http.Request{
+		"wrong secret": newSignedReceiptRequest(body, time.Now(), "other-secret"),
+		"stale":        newSignedReceiptRequest(body, time.Now().Add(-time.Hour), testWebhookSecret),
+		"unsigned":     httptest.NewRequest(http.MethodPost, "/webhooks/receipts", strings.NewReader(body)),
+	} {
+		assert.Equal(t, http.StatusUnauthorized, serveReceipts(handler, request), name)
+	}
+
+	tampered := newSignedReceiptRequest(body, time.Now(), testWebhookSecret)
+	tampered.Body = io.NopCloser(strings.NewReader(strings.Replace(body, "delivered", "bounced", 1)))
+	assert.Equal(t, http.StatusUnauthorized, serveReceipts(handler, tampered))
+	assert.Empty(t, receiptService.receipts)
+}
+
+func TestReceiptWebhook_Errors(t *testing.T) {
+	handler, receiptService := newTestReceiptWebhook()
+	unknown, failing := uuid.New(), uuid.New()
+	receiptService.errs[unknown] = model.ErrNotificationNotFound
+	receiptService.errs[failing] = errors.New("database is down")
+
+	body := `{"events":[{"type":"delivered","reference":"` + unknown.String() + `"}]}`
+	assert.Equal(t, http.StatusNoContent, serveReceipts(handler, newSignedReceiptRequest(body, time.Now(), testWebhookSecret)))
+
+	// Ошибка хранилища просит провайдера повторить пачку
+	body = `{"events":[{"type":"delivered","reference":"` + failing.String() + `"}]}`
+	assert.Equal(t, http.StatusInternalServerError, serveReceipts(handler, newSignedReceiptRequest(body, time.Now(), testWebhookSecret)))
+
+	assert.Equal(t, http.StatusBadRequest, serveReceipts(handler, newSignedReceiptRequest(`{"events":`, time.Now(), testWebhookSecret)))
+
+	request := httptest.NewRequest(http.MethodGet, "/webhooks/receipts", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, serveReceipts(handler, request))
+	require.Empty(t, receiptService.receipts)
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/infrastructure/sender"
+)
+
+type receivedSMS struct {
+	Request        map[string]interface{}
+	Authorization  string
+	IdempotencyKey string
+}
+
+// fakeSMSGateway - локальная замена SMS-шлюза; следующие ответы задаются очередью статусов
+type fakeSMSGateway struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	received []receivedSMS
+	statuses []int
+}
+
+func newFakeSMSGateway(t *testing.T) *fakeSMSGateway {
+	gateway := &fakeSMSGateway{}
+	gateway.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		gateway.mu.Lock()
+		defer gateway.mu.Unlock()
+		gateway.received = append(gateway.received, receivedSMS{
+			Request:        request,
+			Authorization:  r.Header.Get("Authorization"),
+			IdempotencyKey: r.Header.Get("Idempotency-Key"),
+		})
+		status := http.StatusAccepted
+		if len(gateway.statuses) > 0 {
+			status, gateway.statuses = gateway.statuses[0], gateway.statuses[1:]
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"status":"` + http.StatusText(status) + `"}`))
+	}))
+	t.Cleanup(gateway.Close)
+	return gateway
+}
+
+func (g *fakeSMSGateway) respondWith(statuses ...int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.statuses = statuses
+}
+
+func (g *fakeSMSGateway) messages() []receivedSMS {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]receivedSMS(nil), g.received...)
+}
+
+func newTestSMSSender(t *testing.T, gateway *fakeSMSGateway) model.NotificationSender {
+	t.Helper()
+	smsSender, err := sender.NewSMSSender(
+		sender.NewHTTPSMSProvider(sender.HTTPSMSProviderConfig{URL: gateway.URL, APIKey: "sms-key"}),
+		sender.SMSConfig{SenderID: "Shop", MaxSegments: 3},
+	)
+	require.NoError(t, err)
+	return smsSender
+}
+
+func TestSMSSegments(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		text     string
+		encoding sender.SMSEncoding
+		segments int
+	}{
+		{"GSM single", strings.Repeat("a", 160), sender.GSM7, 1},
+		{"GSM concatenated", strings.Repeat("a", 161), sender.GSM7, 2},
+		{"GSM three parts", strings.Repeat("a", 307), sender.GSM7, 3},
+		{"Extension characters take two septets", strings.Repeat("€", 80), sender.GSM7, 1},
+		{"Extension overflow", strings.Repeat("€", 81), sender.GSM7, 2},
+		{"Cyrillic single", strings.Repeat("я", 70), sender.UCS2, 1},
+		{"Cyrillic concatenated", strings.Repeat("я", 71), sender.UCS2, 2},
+		// Эмодзи вне BMP занимает две позиции UCS-2
+		{"Emoji", strings.Repeat("😀", 35), sender.UCS2, 1},
+		{"One non-GSM character switches encoding", strings.Repeat("a", 100) + "ё", sender.UCS2, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			encoding, segments := sender.SMSSegments(tc.text)
+			assert.Equal(t, tc.encoding, encoding)
+			assert.Equal(t, tc.segments, segments)
+		})
+	}
+}
+
+func TestNewSMSSender_ValidatesSenderID(t *testing.T) {
+	provider := sender.NewHTTPSMSProvider(sender.HTTPSMSProviderConfig{URL: "http://127.0.0.1"})
+	for _, valid := range []string{"", "Shop", "Shop 24", "+15550100000", "12345"} {
+		_, err := sender.NewSMSSender(provider, sender.SMSConfig{SenderID: valid})
+		assert.NoError(t, err, valid)
+	}
+	for _, invalid := range []string{"VeryLongShopName", "12", "Shop!", "123456789"} {
+		_, err := sender.NewSMSSender(provider, sender.SMSConfig{SenderID: invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSMSSender_Send(t *testing.T) {
+	gateway := newFakeSMSGateway(t)
+	smsSender := newTestSMSSender(t, gateway)
+	notificationID := uuid.New()
+
+	require.NoError(t, smsSender.Send(model.OutgoingMessage{
+		NotificationID: notificationID,
+		Recipient:      "+15550100000",
+		Subject:        "ignored",
+		TextBody:       "Ваш заказ подтверждён",
+	}))
+
+	messages := gateway.messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Bearer sms-key", messages[0].Authorization)
+	assert.Equal(t, notificationID.String(), messages[0].IdempotencyKey)
+	assert.Equal(t, map[string]interface{}{
+		"to":        "+15550100000",
+		"from":      "Shop",
+		"text":      "Ваш заказ подтверждён",
+		"encoding":  "ucs2",
+		"segments":  float64(1),
+		"reference": notificationID.String(),
+	}, messages[0].Request)
+}
+
+func TestSMSSender_ClassifiesErrors(t *testing.T) {
+	gateway := newFakeSMSGateway(t)
+	smsSender := newTestSMSSender(t, gateway)
+	message := model.OutgoingMessage{NotificationID: uuid.New(), Recipient: "+15550100000", TextBody: "Hi"}
+
+	t.Run("Invalid recipient is permanent and not sent", func(t *testing.T) {
+		invalid := message
+		invalid.Recipient = "555-0100"
+		assert.ErrorIs(t, smsSender.Send(invalid), model.ErrPermanentFailure)
+		assert.Empty(t, gateway.messages())
+	})
+
+	t.Run("Too many segments is permanent", func(t *testing.T) {
+		long := message
+		long.TextBody = strings.Repeat("a", 153*3+1)
+		assert.ErrorIs(t, smsSender.Send(long), model.ErrPermanentFailure)
+		assert.Empty(t, gateway.messages())
+	})
+
+	for _, tc := range []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusUnprocessableEntity, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusServiceUnavailable, false},
+	} {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			gateway.respondWith(tc.status)
+			err := smsSender.Send(message)
+			require.Error(t, err)
+			assert.Equal(t, tc.permanent, errors.Is(err, model.ErrPermanentFailure))
+		})
+	}
+}
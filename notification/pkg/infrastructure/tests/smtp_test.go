@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/infrastructure/sender"
+)
+
+func newTestSMTPSender(t *testing.T, server *fakeSMTPServer, mode sender.SMTPTLSMode) sender.SMTPSender {
+	t.Helper()
+	roots := x509.NewCertPool()
+	certificate, err := x509.ParseCertificate(server.tlsConfig.Certificates[0].Certificate[0])
+	require.NoError(t, err)
+	roots.AddCert(certificate)
+
+	smtpSender, err := sender.NewSMTPSender(sender.SMTPConfig{
+		Host:           "127.0.0.1",
+		Port:           server.port(),
+		Username:       server.username,
+		Password:       server.password,
+		TLSMode:        mode,
+		TLSConfig:      &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+		From:           "noreply@shop.example",
+		FromName:       "Магазин",
+		UnsubscribeURL: "https://shop.example/unsubscribe/{userID}",
+		Timeout:        5 * time.Second,
+		IdleTimeout:    time.Minute,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = smtpSender.Close() })
+	return smtpSender
+}
+
+func testMessage(recipient string) model.OutgoingMessage {
+	return model.OutgoingMessage{
+		NotificationID: uuid.New(),
+		UserID:         uuid.New(),
+		Recipient:      recipient,
+		Subject:        "Заказ подтверждён",
+		TextBody:       "Hi John, thanks for joining us!",
+		HTMLBody:       "<p>Hi John, thanks for joining us!</p>",
+	}
+}
+
+func TestSMTPSender_StartTLSMultipart(t *testing.T) {
+	server := newFakeSMTPServer(t, false)
+	smtpSender := newTestSMTPSender(t, server, sender.SMTPStartTLS)
+	message := testMessage("john@example.com")
+
+	require.NoError(t, smtpSender.Send(message))
+
+	mails, _ := server.received()
+	require.Len(t, mails, 1)
+	assert.Equal(t, "FROM:<noreply@shop.example>", mails[0].From)
+	assert.Equal(t, []string{"TO:<john@example.com>"}, mails[0].To)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(mails[0].Data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Заказ подтверждён", subject)
+	assert.Equal(t, "<"+message.NotificationID.String()+"@shop.example>", parsed.Header.Get("Message-ID"))
+	assert.Equal(t, "<https://shop.example/unsubscribe/"+message.UserID.String()+">", parsed.Header.Get("List-Unsubscribe"))
+	assert.Equal(t, "List-Unsubscribe=One-Click", parsed.Header.Get("List-Unsubscribe-Post"))
+	assert.Equal(t, "1.0", parsed.Header.Get("MIME-Version"))
+	_, err = parsed.Header.Date()
+	assert.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		// multipart.Reader сам снимает quoted-printable и убирает заголовок Content-Transfer-Encoding
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		bodies = append(bodies, part.Header.Get("Content-Type")+"|"+string(content))
+	}
+	assert.Equal(t, []string{
+		`text/plain; charset="utf-8"|` + message.TextBody,
+		`text/html; charset="utf-8"|` + message.HTMLBody,
+	}, bodies)
+}
+
+func TestSMTPSender_ImplicitTLSPlainText(t *testing.T) {
+	server := newFakeSMTPServer(t, true)
+	smtpSender := newTestSMTPSender(t, server, sender.SMTPImplicitTLS)
+	message := testMessage("john@example.com")
+	message.HTMLBody = ""
+	message.TextBody = "Цена: 100 ₽"
+
+	require.NoError(t, smtpSender.Send(message))
+
+	mails, _ := server.received()
+	require.Len(t, mails, 1)
+	parsed, err := mail.ReadMessage(strings.NewReader(mails[0].Data))
+	require.NoError(t, err)
+	assert.Equal(t, `text/plain; charset="utf-8"`, parsed.Header.Get("Content-Type"))
+	assert.Equal(t, "quoted-printable", parsed.Header.Get("Content-Transfer-Encoding"))
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	require.NoError(t, err)
+	// Завершающий CRLF добавляет SMTP-клиент перед точкой конца DATA
+	assert.Equal(t, "Цена: 100 ₽", strings.TrimSuffix(string(body), "\r\n"))
+}
+
+func TestSMTPSender_ReusesConnection(t *testing.T) {
+	server := newFakeSMTPServer(t, false)
+	smtpSender := newTestSMTPSender(t, server, sender.SMTPStartTLS)
+	server.rcptReplies["missing"] = "550 no such user"
+
+	require.NoError(t, smtpSender.Send(testMessage("first@example.com")))
+	require.Error(t, smtpSender.Send(testMessage("missing@example.com")))
+	require.NoError(t, smtpSender.Send(testMessage("second@example.com")))
+
+	mails, connections := server.received()
+	assert.Len(t, mails, 2)
+	// Отказ по адресу сбрасывает транзакцию через RSET, соединение остаётся
+	assert.Equal(t, 1, connections)
+}
+
+func TestSMTPSender_ClassifiesErrors(t *testing.T) {
+	server := newFakeSMTPServer(t, false)
+	smtpSender := newTestSMTPSender(t, server, sender.SMTPStartTLS)
+	server.rcptReplies["missing"] = "550 no such user"
+	server.rcptReplies["busy"] = "451 try again later"
+
+	err := smtpSender.Send(testMessage("missing@example.com"))
+	assert.ErrorIs(t, err, model.ErrPermanentFailure)
+
+	err = smtpSender.Send(testMessage("busy@example.com"))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, model.ErrPermanentFailure)
+
+	err = smtpSender.Send(testMessage("not an address"))
+	assert.ErrorIs(t, err, model.ErrPermanentFailure)
+
+	t.Run("Auth failure is transient", func(t *testing.T) {
+		staleSender := newTestSMTPSender(t, server, sender.SMTPStartTLS)
+		server.changePassword("rotated")
+		err := staleSender.Send(testMessage("john@example.com"))
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, model.ErrPermanentFailure)
+	})
+
+	t.Run("Unreachable server is transient", func(t *testing.T) {
+		_ = server.listener.Close()
+		err := newTestSMTPSender(t, server, sender.SMTPStartTLS).Send(testMessage("john@example.com"))
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, model.ErrPermanentFailure)
+	})
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
+
+	"notification/pkg/domain/model"
 )
 
 type errorSet map[error]struct{}
@@ -22,9 +24,39 @@ func (s errorSet) Has(err error) bool {
 	return ok
 }
 
-var badRequestErrorCodes = newErrorSet()
+var badRequestErrorCodes = newErrorSet(
+	model.ErrInvalidTemplate,
+	model.ErrMissingTemplateVariable,
+	model.ErrInvalidTemplateVariable,
+	model.ErrEmptyRecipient,
+	model.ErrInvalidPhoneNumber,
+	model.ErrInvalidDeviceToken,
+	model.ErrUnknownDevicePlatform,
+	model.ErrCategoryNotSuppressible,
+	model.ErrUnknownCategory,
+	model.ErrUnknownChannel,
+	model.ErrInvalidQuietHours,
+	model.ErrInvalidTimeZone,
+	model.ErrCategoryNotBatchable,
+	model.ErrUnknownDigestFrequency,
+	ErrInvalidUserID,
+	ErrInvalidOrderID,
+	ErrInvalidNotificationID,
+)
 
-var notFoundErrorCodes = newErrorSet()
+var notFoundErrorCodes = newErrorSet(
+	model.ErrNotificationNotFound,
+	model.ErrTemplateNotFound,
+	model.ErrPhoneNumberNotFound,
+	model.ErrDeviceTokenNotFound,
+)
+
+// failedPreconditionErrorCodes - запрос корректен, но не выполним в текущем состоянии сервиса или уведомления
+var failedPreconditionErrorCodes = newErrorSet(
+	model.ErrNoSenderConfigured,
+	model.ErrNotificationNotRequeued,
+	model.ErrNoDevicesRegistered,
+)
 
 var unauthorizedErrorCodes = newErrorSet()
 
@@ -43,6 +75,8 @@ func getGRPCCode(err error) codes.Code {
 		return codes.InvalidArgument
 	case isNotFoundError(cause):
 		return codes.NotFound
+	case isFailedPreconditionError(cause):
+		return codes.FailedPrecondition
 	case isUnauthorizedError(cause):
 		return codes.Unauthenticated
 	case isPermissionDeniedError(cause):
@@ -84,6 +118,10 @@ func isNotFoundError(cause error) bool {
 	return notFoundErrorCodes.Has(cause)
 }
 
+func isFailedPreconditionError(cause error) bool {
+	return failedPreconditionErrorCodes.Has(cause)
+}
+
 func isUnauthorizedError(cause error) bool {
 	return unauthorizedErrorCodes.Has(cause)
 }
@@ -2,15 +2,45 @@ package transport
 
 import (
 	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
 
 	api "notification/api/server/notificationinternal"
+	"notification/pkg/domain/model"
+	"notification/pkg/domain/service"
+)
+
+var (
+	ErrInvalidUserID         = errors.New("invalid user id")
+	ErrInvalidOrderID        = errors.New("invalid order id")
+	ErrInvalidNotificationID = errors.New("invalid notification id")
 )
 
-func NewInternalAPI() api.NotificationInternalServiceServer {
-	return &internalAPI{}
+func NewInternalAPI(
+	notificationService service.NotificationService,
+	deliveryService service.DeliveryService,
+	templateService service.TemplateService,
+	contactService service.ContactService,
+	preferenceService service.PreferenceService,
+) api.NotificationInternalServiceServer {
+	return &internalAPI{
+		notificationService: notificationService,
+		deliveryService:     deliveryService,
+		templateService:     templateService,
+		contactService:      contactService,
+		preferenceService:   preferenceService,
+	}
 }
 
 type internalAPI struct {
+	notificationService service.NotificationService
+	deliveryService     service.DeliveryService
+	templateService     service.TemplateService
+	contactService      service.ContactService
+	preferenceService   service.PreferenceService
 }
 
 func (i *internalAPI) Ping(_ context.Context, _ *api.PingRequest) (*api.PingResponse, error) {
@@ -18,3 +48,482 @@ func (i *internalAPI) Ping(_ context.Context, _ *api.PingRequest) (*api.PingResp
 		Message: "pong",
 	}, nil
 }
+
+func (i *internalAPI) SendWelcomeEmail(
+	_ context.Context,
+	request *api.SendWelcomeEmailRequest,
+) (*api.SendNotificationResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	notificationID, err := i.notificationService.SendWelcomeEmail(
+		userID,
+		request.Email,
+		request.FirstName,
+		request.Locale,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &api.SendNotificationResponse{NotificationID: notificationID.String()}, nil
+}
+
+func (i *internalAPI) NotifyOrderConfirmation(
+	_ context.Context,
+	request *api.NotifyOrderConfirmationRequest,
+) (*api.SendNotificationResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	orderID, err := parseID(request.OrderID, ErrInvalidOrderID)
+	if err != nil {
+		return nil, err
+	}
+	notificationID, err := i.notificationService.NotifyOrderConfirmation(
+		userID,
+		request.Email,
+		orderID,
+		request.Locale,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &api.SendNotificationResponse{NotificationID: notificationID.String()}, nil
+}
+
+func (i *internalAPI) NotifyPaymentFailed(
+	_ context.Context,
+	request *api.NotifyPaymentFailedRequest,
+) (*api.SendNotificationResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	orderID, err := parseID(request.OrderID, ErrInvalidOrderID)
+	if err != nil {
+		return nil, err
+	}
+	notificationID, err := i.notificationService.NotifyPaymentFailed(
+		userID,
+		request.Email,
+		orderID,
+		request.Reason,
+		request.Locale,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &api.SendNotificationResponse{NotificationID: notificationID.String()}, nil
+}
+
+func (i *internalAPI) SendNotification(
+	_ context.Context,
+	request *api.SendNotificationRequest,
+) (*api.SendNotificationResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	notificationID, err := i.notificationService.SendNotification(
+		userID,
+		request.Template,
+		model.NotificationChannel(request.Channel),
+		request.Recipient,
+		request.Locale,
+		request.Variables,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &api.SendNotificationResponse{NotificationID: notificationID.String()}, nil
+}
+
+func (i *internalAPI) GetNotification(
+	_ context.Context,
+	request *api.GetNotificationRequest,
+) (*api.GetNotificationResponse, error) {
+	notificationID, err := parseID(request.NotificationID, ErrInvalidNotificationID)
+	if err != nil {
+		return nil, err
+	}
+	notification, err := i.notificationService.GetNotification(notificationID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.GetNotificationResponse{Notification: toAPINotification(notification)}, nil
+}
+
+func (i *internalAPI) ListNotificationsForUser(
+	_ context.Context,
+	request *api.ListNotificationsForUserRequest,
+) (*api.ListNotificationsForUserResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	notifications, err := i.notificationService.ListNotificationsForUser(userID, int(request.Limit))
+	if err != nil {
+		return nil, err
+	}
+	return &api.ListNotificationsForUserResponse{Notifications: toAPINotifications(notifications)}, nil
+}
+
+func (i *internalAPI) ListDeadLetteredNotifications(
+	_ context.Context,
+	request *api.ListDeadLetteredNotificationsRequest,
+) (*api.ListDeadLetteredNotificationsResponse, error) {
+	notifications, err := i.deliveryService.ListDeadLettered(int(request.Limit))
+	if err != nil {
+		return nil, err
+	}
+	return &api.ListDeadLetteredNotificationsResponse{Notifications: toAPINotifications(notifications)}, nil
+}
+
+func (i *internalAPI) RequeueNotification(
+	_ context.Context,
+	request *api.RequeueNotificationRequest,
+) (*api.RequeueNotificationResponse, error) {
+	notificationID, err := parseID(request.NotificationID, ErrInvalidNotificationID)
+	if err != nil {
+		return nil, err
+	}
+	if err = i.deliveryService.Requeue(notificationID); err != nil {
+		return nil, err
+	}
+	return &api.RequeueNotificationResponse{}, nil
+}
+
+func (i *internalAPI) ListDeliveryAttempts(
+	_ context.Context,
+	request *api.ListDeliveryAttemptsRequest,
+) (*api.ListDeliveryAttemptsResponse, error) {
+	notificationID, err := parseID(request.NotificationID, ErrInvalidNotificationID)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := i.deliveryService.ListAttempts(notificationID)
+	if err != nil {
+		return nil, err
+	}
+	apiAttempts := make([]*api.DeliveryAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		apiAttempts = append(apiAttempts, &api.DeliveryAttempt{
+			AttemptedAt: attempt.AttemptedAt.Unix(),
+			Error:       attempt.Error,
+			Permanent:   attempt.Permanent,
+		})
+	}
+	return &api.ListDeliveryAttemptsResponse{Attempts: apiAttempts}, nil
+}
+
+func (i *internalAPI) SaveTemplate(_ context.Context, request *api.SaveTemplateRequest) (*api.SaveTemplateResponse, error) {
+	if request.Template == nil {
+		return nil, errors.WithStack(model.ErrInvalidTemplate)
+	}
+	template := model.Template{
+		Name:     request.Template.Name,
+		Channel:  model.NotificationChannel(request.Template.Channel),
+		Locale:   request.Template.Locale,
+		Category: model.NotificationCategory(request.Template.Category),
+		Subject:  request.Template.Subject,
+		TextBody: request.Template.TextBody,
+		HTMLBody: request.Template.HtmlBody,
+	}
+	for _, variable := range request.Template.Variables {
+		template.Variables = append(template.Variables, model.TemplateVariable{
+			Name: variable.Name,
+			Type: model.TemplateVariableType(variable.Type),
+		})
+	}
+	if err := i.templateService.SaveTemplate(template); err != nil {
+		return nil, err
+	}
+	return &api.SaveTemplateResponse{}, nil
+}
+
+func (i *internalAPI) ListTemplates(_ context.Context, _ *api.ListTemplatesRequest) (*api.ListTemplatesResponse, error) {
+	templates, err := i.templateService.ListTemplates()
+	if err != nil {
+		return nil, err
+	}
+	apiTemplates := make([]*api.Template, 0, len(templates))
+	for _, template := range templates {
+		apiTemplate := &api.Template{
+			Name:      template.Name,
+			Channel:   api.NotificationChannel(template.Channel), // nolint:gosec
+			Locale:    template.Locale,
+			Category:  api.NotificationCategory(template.Category), // nolint:gosec
+			Subject:   template.Subject,
+			TextBody:  template.TextBody,
+			HtmlBody:  template.HTMLBody,
+			UpdatedAt: template.UpdatedAt.Unix(),
+		}
+		for _, variable := range template.Variables {
+			apiTemplate.Variables = append(apiTemplate.Variables, &api.TemplateVariable{
+				Name: variable.Name,
+				Type: api.TemplateVariableType(variable.Type), // nolint:gosec
+			})
+		}
+		apiTemplates = append(apiTemplates, apiTemplate)
+	}
+	return &api.ListTemplatesResponse{Templates: apiTemplates}, nil
+}
+
+func (i *internalAPI) SetPhoneNumber(
+	_ context.Context,
+	request *api.SetPhoneNumberRequest,
+) (*api.SetPhoneNumberResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	number, err := i.contactService.SetPhoneNumber(userID, request.Number)
+	if err != nil {
+		return nil, err
+	}
+	return &api.SetPhoneNumberResponse{Number: number}, nil
+}
+
+func (i *internalAPI) GetPhoneNumber(
+	_ context.Context,
+	request *api.GetPhoneNumberRequest,
+) (*api.GetPhoneNumberResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	phoneNumber, err := i.contactService.GetPhoneNumber(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.GetPhoneNumberResponse{Number: phoneNumber.Number, UpdatedAt: phoneNumber.UpdatedAt.Unix()}, nil
+}
+
+func (i *internalAPI) RemovePhoneNumber(
+	_ context.Context,
+	request *api.RemovePhoneNumberRequest,
+) (*api.RemovePhoneNumberResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = i.contactService.RemovePhoneNumber(userID); err != nil {
+		return nil, err
+	}
+	return &api.RemovePhoneNumberResponse{}, nil
+}
+
+func (i *internalAPI) RegisterDevice(
+	_ context.Context,
+	request *api.RegisterDeviceRequest,
+) (*api.RegisterDeviceResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	device, err := i.contactService.RegisterDevice(userID, model.DevicePlatform(request.Platform), request.Token)
+	if err != nil {
+		return nil, err
+	}
+	return &api.RegisterDeviceResponse{Device: toAPIDevice(*device)}, nil
+}
+
+func (i *internalAPI) UnregisterDevice(
+	_ context.Context,
+	request *api.UnregisterDeviceRequest,
+) (*api.UnregisterDeviceResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = i.contactService.UnregisterDevice(userID, request.Token); err != nil {
+		return nil, err
+	}
+	return &api.UnregisterDeviceResponse{}, nil
+}
+
+func (i *internalAPI) ListDevices(_ context.Context, request *api.ListDevicesRequest) (*api.ListDevicesResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := i.contactService.ListDevices(userID)
+	if err != nil {
+		return nil, err
+	}
+	apiDevices := make([]*api.Device, 0, len(devices))
+	for _, device := range devices {
+		apiDevices = append(apiDevices, toAPIDevice(device))
+	}
+	return &api.ListDevicesResponse{Devices: apiDevices}, nil
+}
+
+func (i *internalAPI) GetPreferences(
+	_ context.Context,
+	request *api.GetPreferencesRequest,
+) (*api.GetPreferencesResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	preferences, err := i.preferenceService.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	response := &api.GetPreferencesResponse{}
+	for _, preference := range preferences.Channels {
+		response.Channels = append(response.Channels, &api.ChannelPreference{
+			Category: api.NotificationCategory(preference.Category), // nolint:gosec
+			Channel:  api.NotificationChannel(preference.Channel),   // nolint:gosec
+			Enabled:  preference.Enabled,
+		})
+	}
+	for _, preference := range preferences.Digests {
+		response.Digests = append(response.Digests, &api.DigestPreference{
+			Category:  api.NotificationCategory(preference.Category), // nolint:gosec
+			Frequency: api.DigestFrequency(preference.Frequency),     // nolint:gosec
+		})
+	}
+	if quietHours := preferences.QuietHours; quietHours != nil {
+		response.QuietHours = &api.QuietHours{
+			Start:    formatClock(quietHours.Start),
+			End:      formatClock(quietHours.End),
+			TimeZone: quietHours.TimeZone,
+		}
+	}
+	return response, nil
+}
+
+func (i *internalAPI) SetChannelPreferences(
+	_ context.Context,
+	request *api.SetChannelPreferencesRequest,
+) (*api.SetChannelPreferencesResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	preferences := make([]model.ChannelPreference, 0, len(request.Channels))
+	for _, preference := range request.Channels {
+		preferences = append(preferences, model.ChannelPreference{
+			Category: model.NotificationCategory(preference.Category),
+			Channel:  model.NotificationChannel(preference.Channel),
+			Enabled:  preference.Enabled,
+		})
+	}
+	if err = i.preferenceService.SetChannelPreferences(userID, preferences); err != nil {
+		return nil, err
+	}
+	return &api.SetChannelPreferencesResponse{}, nil
+}
+
+func (i *internalAPI) SetQuietHours(
+	_ context.Context,
+	request *api.SetQuietHoursRequest,
+) (*api.SetQuietHoursResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	var quietHours *model.QuietHours
+	if request.QuietHours != nil {
+		start, err := parseClock(request.QuietHours.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(request.QuietHours.End)
+		if err != nil {
+			return nil, err
+		}
+		quietHours = &model.QuietHours{Start: start, End: end, TimeZone: request.QuietHours.TimeZone}
+	}
+	if err = i.preferenceService.SetQuietHours(userID, quietHours); err != nil {
+		return nil, err
+	}
+	return &api.SetQuietHoursResponse{}, nil
+}
+
+func (i *internalAPI) SetDigestFrequency(
+	_ context.Context,
+	request *api.SetDigestFrequencyRequest,
+) (*api.SetDigestFrequencyResponse, error) {
+	userID, err := parseID(request.UserID, ErrInvalidUserID)
+	if err != nil {
+		return nil, err
+	}
+	err = i.preferenceService.SetDigestFrequency(
+		userID,
+		model.NotificationCategory(request.Category),
+		model.DigestFrequency(request.Frequency),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &api.SetDigestFrequencyResponse{}, nil
+}
+
+func parseID(value string, invalidErr error) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, errors.WithStack(invalidErr)
+	}
+	return id, nil
+}
+
+// toAPINotification не переносит тело: оно может содержать персональные данные
+func toAPINotification(notification *model.Notification) *api.Notification {
+	result := &api.Notification{
+		NotificationID:    notification.ID.String(),
+		UserID:            notification.UserID.String(),
+		Channel:           api.NotificationChannel(notification.Channel),   // nolint:gosec
+		Category:          api.NotificationCategory(notification.Category), // nolint:gosec
+		Recipient:         notification.RecipientAddress,
+		Subject:           notification.Subject,
+		Status:            api.NotificationStatus(notification.Status), // nolint:gosec
+		FailureReason:     notification.FailureReason,
+		CreatedAt:         notification.CreatedAt.Unix(),
+		Attempts:          int32(notification.Attempts), // nolint:gosec
+		NextAttemptAt:     notification.NextAttemptAt.Unix(),
+		RateLimitDecision: api.RateLimitDecision(notification.RateLimitDecision), // nolint:gosec
+		RateLimitReason:   notification.RateLimitReason,
+	}
+	if notification.SentAt != nil {
+		result.SentAt = notification.SentAt.Unix()
+	}
+	if notification.DigestID != nil {
+		result.DigestID = notification.DigestID.String()
+	}
+	return result
+}
+
+func toAPINotifications(notifications []model.Notification) []*api.Notification {
+	result := make([]*api.Notification, 0, len(notifications))
+	for _, notification := range notifications {
+		result = append(result, toAPINotification(&notification))
+	}
+	return result
+}
+
+func toAPIDevice(device model.DeviceToken) *api.Device {
+	return &api.Device{
+		DeviceID:   device.ID.String(),
+		Platform:   api.DevicePlatform(device.Platform), // nolint:gosec
+		Token:      device.Token,
+		CreatedAt:  device.CreatedAt.Unix(),
+		LastSeenAt: device.LastSeenAt.Unix(),
+	}
+}
+
+// parseClock переводит "HH:MM" в минуты от полуночи
+func parseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.Wrapf(model.ErrInvalidQuietHours, "time %q", value)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
@@ -0,0 +1,158 @@
+package transport
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"notification/pkg/domain/model"
+	"notification/pkg/domain/service"
+)
+
+const (
+	// ReceiptSignatureHeader - hex HMAC-SHA256 от "<timestamp>.<тело запроса>" на общем секрете
+	ReceiptSignatureHeader = "X-Signature"
+	// ReceiptTimestampHeader - unix-время подписи; старые подписи отклоняются, чтобы запрос нельзя было повторить
+	ReceiptTimestampHeader = "X-Signature-Timestamp"
+
+	receiptSignatureTolerance = 5 * time.Minute
+	maxReceiptBodySize        = 1 << 20
+)
+
+var errInvalidReceiptSignature = errors.New("receipt signature is invalid")
+
+type receiptWebhookRequest struct {
+	Events []receiptWebhookEvent `json:"events"`
+}
+
+type receiptWebhookEvent struct {
+	// Type - delivered, bounced или complained
+	Type string `json:"type"`
+	// Reference - ID уведомления или Message-ID письма, который строится из него
+	Reference string `json:"reference"`
+	// BounceType - hard или soft, только для bounced
+	BounceType string `json:"bounceType"`
+	Reason     string `json:"reason"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+// NewReceiptWebhook принимает колбэки провайдеров пачками. Ошибка хранилища возвращает 500, и провайдер
+// повторяет всю пачку: уже применённые квитанции ReceiptService проигнорирует
+func NewReceiptWebhook(receiptService service.ReceiptService, secret string, logger *log.Logger) http.Handler {
+	return &receiptWebhook{
+		receiptService: receiptService,
+		secret:         []byte(secret),
+		logger:         logger,
+	}
+}
+
+type receiptWebhook struct {
+	receiptService service.ReceiptService
+	secret         []byte
+	logger         *log.Logger
+}
+
+func (h *receiptWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxReceiptBodySize))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if err = h.verify(r.Header, body, time.Now()); err != nil {
+		h.logger.Warnf("rejected receipt webhook: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var request receiptWebhookRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	for _, event := range request.Events {
+		l := h.logger.WithFields(log.Fields{"reference": event.Reference, "type": event.Type})
+		receipt, err := toDeliveryReceipt(event)
+		if err != nil {
+			// Повтор не исправит квитанцию, поэтому она пропускается, а не валит всю пачку
+			l.Warnf("skipped receipt: %v", err)
+			continue
+		}
+		err = h.receiptService.Apply(receipt)
+		if errors.Is(err, model.ErrNotificationNotFound) {
+			l.Warnf("skipped receipt for unknown notification")
+			continue
+		}
+		if err != nil {
+			l.Errorf("failed to apply receipt: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *receiptWebhook) verify(header http.Header, body []byte, now time.Time) error {
+	timestamp, err := strconv.ParseInt(header.Get(ReceiptTimestampHeader), 10, 64)
+	if err != nil {
+		return errors.Wrap(errInvalidReceiptSignature, "timestamp is missing")
+	}
+	if age := now.Sub(time.Unix(timestamp, 0)); age > receiptSignatureTolerance || age < -receiptSignatureTolerance {
+		return errors.Wrapf(errInvalidReceiptSignature, "timestamp is %v off", age)
+	}
+	signature, err := hex.DecodeString(header.Get(ReceiptSignatureHeader))
+	if err != nil {
+		return errors.Wrap(errInvalidReceiptSignature, "signature is not hex")
+	}
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return errors.WithStack(errInvalidReceiptSignature)
+	}
+	return nil
+}
+
+func toDeliveryReceipt(event receiptWebhookEvent) (model.DeliveryReceipt, error) {
+	// Message-ID письма имеет вид <id@domain>
+	reference := strings.TrimPrefix(event.Reference, "<")
+	if i := strings.IndexByte(reference, '@'); i >= 0 {
+		reference = reference[:i]
+	}
+	notificationID, err := uuid.Parse(reference)
+	if err != nil {
+		return model.DeliveryReceipt{}, errors.Wrap(ErrInvalidNotificationID, event.Reference)
+	}
+	receipt := model.DeliveryReceipt{
+		NotificationID: notificationID,
+		Reason:         event.Reason,
+	}
+	if event.Timestamp > 0 {
+		receipt.OccurredAt = time.Unix(event.Timestamp, 0).UTC()
+	}
+	switch event.Type {
+	case "delivered":
+		receipt.Type = model.ReceiptDelivered
+	case "bounced":
+		receipt.Type = model.ReceiptBounced
+		receipt.HardBounce = event.BounceType == "hard"
+	case "complained":
+		receipt.Type = model.ReceiptComplained
+	default:
+		return model.DeliveryReceipt{}, errors.Errorf("unknown receipt type %q", event.Type)
+	}
+	return receipt, nil
+}
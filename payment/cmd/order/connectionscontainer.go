@@ -9,6 +9,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+
+	"payment/pkg/infrastructure/integrationevent"
 )
 
 type multiCloser struct {
@@ -47,7 +49,6 @@ func newConnectionsContainer(
 		if err != nil {
 			return fmt.Errorf("failed to init DB for migrations: %w", err)
 		}
-		defer db.Close()
 
 		if err = applyMigrations(db.DB, pathToMigrations); err != nil {
 			return fmt.Errorf("migration failed: %w", err)
@@ -68,6 +69,10 @@ func newConnectionsContainer(
 		multiCloser.Add(testConnection)
 		container.testConnection = testConnection
 
+		publisher := integrationevent.NewPublisher(config.AMQPURL)
+		multiCloser.Add(publisher)
+		container.publisher = publisher
+
 		return nil
 	}
 
@@ -77,6 +82,7 @@ func newConnectionsContainer(
 type connectionsContainer struct {
 	db             *sqlx.DB
 	testConnection grpc.ClientConnInterface
+	publisher      integrationevent.Publisher
 }
 
 func initMySQL(cfg *config) (db *sqlx.DB, err error) {
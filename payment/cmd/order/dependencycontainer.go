@@ -1,18 +1,83 @@
 package main
 
-import "github.com/jmoiron/sqlx"
+import (
+	"net/http"
+	"strings"
+	"time"
 
-// TODO: добавить зависимости
+	"github.com/jmoiron/sqlx"
+	log "github.com/sirupsen/logrus"
+
+	api "payment/api/server/paymentinternal"
+	"payment/pkg/domain/model"
+	domainservice "payment/pkg/domain/service"
+	"payment/pkg/infrastructure/event"
+	"payment/pkg/infrastructure/gateway"
+	"payment/pkg/infrastructure/integrationevent"
+	"payment/pkg/infrastructure/mysql"
+	"payment/pkg/infrastructure/transport"
+)
 
 func newDependencyContainer(
-	_ *config,
+	config *config,
+	logger *log.Logger,
 	connContainer *connectionsContainer,
 ) (*dependencyContainer, error) {
+	paymentRepository := mysql.NewPaymentRepository(connContainer.db)
+	eventDispatcher := integrationevent.NewEventDispatcher(
+		connContainer.publisher,
+		event.NewLogEventDispatcher(logger),
+		logger,
+	)
+
+	riskRules := []model.RiskRule{domainservice.NewSpendingLimitRule(paymentRepository)}
+	if config.RiskVelocityMaxPayments > 0 {
+		decision, err := config.riskVelocityDecision()
+		if err != nil {
+			return nil, err
+		}
+		riskRules = append(riskRules, domainservice.NewVelocityRule(
+			paymentRepository,
+			config.RiskVelocityMaxPayments,
+			config.RiskVelocityWindow,
+			decision,
+		))
+	}
+	paymentService := domainservice.NewPaymentService(paymentRepository, eventDispatcher, riskRules...)
+
+	webhookSecret := []byte(config.GatewayWebhookSecret)
+	fakeGateway := gateway.NewFakeGateway(config.GatewayRedirectBaseURL, webhookSecret)
+	topUpService := domainservice.NewTopUpService(
+		mysql.NewTopUpIntentRepository(connContainer.db),
+		fakeGateway,
+		paymentService,
+		eventDispatcher,
+	)
+
+	// Страница оплаты заглушки живёт на том же HTTP-сервере, что и вебхук
+	webhookURL := strings.TrimRight(config.GatewayRedirectBaseURL, "/") + gatewayWebhookPath
+
 	return &dependencyContainer{
-		db: connContainer.db,
+		db:                    connContainer.db,
+		paymentService:        paymentService,
+		internalAPI:           transport.NewInternalAPI(topUpService, paymentService),
+		gatewayWebhookHandler: transport.NewGatewayWebhookHandler(topUpService, webhookSecret, logger),
+		fakeCheckoutHandler: gateway.NewFakeCheckoutHandler(
+			fakeGateway,
+			webhookURL,
+			&http.Client{Timeout: fakeCheckoutWebhookTimeout},
+			logger,
+		),
 	}, nil
 }
 
+const fakeCheckoutWebhookTimeout = 10 * time.Second
+
 type dependencyContainer struct {
 	db *sqlx.DB
+
+	paymentService        domainservice.PaymentService
+	internalAPI           api.PaymentInternalServiceServer
+	gatewayWebhookHandler http.Handler
+	fakeCheckoutHandler   http.Handler
 }
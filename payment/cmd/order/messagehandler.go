@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	domainservice "payment/pkg/domain/service"
+	"payment/pkg/infrastructure/integrationevent"
+)
+
+// messageHandler замораживает кошельки по событиям сервиса пользователей из domain_event_exchange
+func messageHandler(
+	config *config,
+	logger *log.Logger,
+	closer *multiCloser,
+) *cli.Command {
+	return &cli.Command{
+		Name:  "message-handler",
+		Usage: "Freezes wallets of suspended and deactivated users",
+		Action: func(c *cli.Context) error {
+			connContainer, err := newConnectionsContainer(config, logger, closer)
+			if err != nil {
+				return errors.Wrap(err, "failed to init connections")
+			}
+
+			container, err := newDependencyContainer(config, logger, connContainer)
+			if err != nil {
+				return errors.Wrap(err, "failed to init dependencies")
+			}
+
+			handler := integrationevent.NewMessageHandler(
+				domainservice.NewUserEventHandler(container.paymentService),
+				logger,
+			)
+			consumer := integrationevent.NewConsumer(integrationevent.ConsumerConfig{
+				URL:        config.AMQPURL,
+				Queue:      config.AMQPQueue,
+				Prefetch:   config.AMQPPrefetch,
+				RetryDelay: config.AMQPRetryDelay,
+			}, handler, logger)
+			return consumer.Run(c.Context)
+		},
+	}
+}
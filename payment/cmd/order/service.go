@@ -3,17 +3,23 @@ package main
 import (
 	"context"
 	"net"
+	"net/http"
 	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
 	"google.golang.org/grpc"
 
 	api "payment/api/server/paymentinternal"
+	"payment/pkg/infrastructure/gateway"
 	"payment/pkg/infrastructure/transport"
 )
 
-const shutdownTimeout = 30 * time.Second
+const (
+	shutdownTimeout    = 30 * time.Second
+	gatewayWebhookPath = "/webhooks/gateway"
+)
 
 func service(
 	config *config,
@@ -29,11 +35,19 @@ func service(
 				return errors.Wrap(err, "failed to init connections")
 			}
 
-			container, err := newDependencyContainer(config, connContainer)
+			container, err := newDependencyContainer(config, logger, connContainer)
 			if err != nil {
 				return errors.Wrap(err, "failed to init dependencies")
 			}
-			return startGRPCServer(c.Context, config, logger, container)
+
+			errCh := make(chan error, 2)
+			go func() {
+				errCh <- startHTTPServer(c.Context, config, logger, container)
+			}()
+			go func() {
+				errCh <- startGRPCServer(c.Context, config, logger, container)
+			}()
+			return <-errCh
 		},
 	}
 }
@@ -42,12 +56,11 @@ func startGRPCServer(
 	ctx context.Context,
 	config *config,
 	logger *log.Logger,
-	_ *dependencyContainer,
+	container *dependencyContainer,
 ) error {
 	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(makeGrpcUnaryInterceptor(logger)))
 
-	// TODO: зарегистрировать свой сервер вместо шаблонного
-	api.RegisterPaymentInternalServiceServer(grpcServer, transport.NewInternalAPI())
+	api.RegisterPaymentInternalServiceServer(grpcServer, container.internalAPI)
 
 	listener, err := net.Listen("tcp", config.ServeGRPCAddress)
 	if err != nil {
@@ -70,6 +83,38 @@ func startGRPCServer(
 	}
 }
 
+func startHTTPServer(
+	ctx context.Context,
+	config *config,
+	logger *log.Logger,
+	container *dependencyContainer,
+) error {
+	router := http.NewServeMux()
+	router.Handle(gatewayWebhookPath, container.gatewayWebhookHandler)
+	router.Handle(gateway.FakeCheckoutPath, container.fakeCheckoutHandler)
+
+	server := &http.Server{
+		Addr:              config.ServeHTTPAddress,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	logger.Infof("HTTP server listening on %s", config.ServeHTTPAddress)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
 func shutdownGRPCServer(server *grpc.Server, logger *log.Logger) {
 	done := make(chan struct{})
 	go func() {
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS top_up_intent;
+DROP TABLE IF EXISTS wallet_status_change;
+DROP TABLE IF EXISTS wallet_transaction;
+DROP TABLE IF EXISTS wallet;
@@ -0,0 +1,73 @@
+CREATE TABLE IF NOT EXISTS wallet
+(
+    `id`                          VARCHAR(64)  NOT NULL,
+    `user_id`                     VARCHAR(64)  NOT NULL,
+    `balance_cents`               BIGINT       NOT NULL,
+    `currency`                    VARCHAR(32)  NOT NULL,
+    `version`                     INT          NOT NULL,
+    `status`                      INT          NOT NULL DEFAULT 0,
+    `status_reason`               VARCHAR(255) NOT NULL DEFAULT '',
+    `per_transaction_limit_cents` BIGINT       NOT NULL DEFAULT 0,
+    `daily_limit_cents`           BIGINT       NOT NULL DEFAULT 0,
+    `monthly_limit_cents`         BIGINT       NOT NULL DEFAULT 0,
+    `created_at`                  DATETIME     NOT NULL,
+    `updated_at`                  DATETIME     NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_wallet_user_id` (`user_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+CREATE TABLE IF NOT EXISTS wallet_transaction
+(
+    `id`            VARCHAR(64)  NOT NULL,
+    `wallet_id`     VARCHAR(64)  NOT NULL,
+    `type`          INT          NOT NULL,
+    `amount_cents`  BIGINT       NOT NULL,
+    `reference_id`  VARCHAR(255) NOT NULL,
+    `status`        INT          NOT NULL,
+    `error_message` VARCHAR(255) NOT NULL DEFAULT '',
+    `reason_code`   VARCHAR(64)  NOT NULL DEFAULT '',
+    `created_at`    DATETIME     NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_wallet_transaction_reference` (`wallet_id`, `reference_id`),
+    KEY `idx_wallet_transaction_stats` (`wallet_id`, `type`, `status`, `created_at`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+CREATE TABLE IF NOT EXISTS wallet_status_change
+(
+    `id`         VARCHAR(64)  NOT NULL,
+    `wallet_id`  VARCHAR(64)  NOT NULL,
+    `old_status` INT          NOT NULL,
+    `new_status` INT          NOT NULL,
+    `reason`     VARCHAR(255) NOT NULL,
+    `created_at` DATETIME     NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_wallet_status_change_wallet` (`wallet_id`, `created_at`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+CREATE TABLE IF NOT EXISTS top_up_intent
+(
+    `id`                 VARCHAR(64)   NOT NULL,
+    `user_id`            VARCHAR(64)   NOT NULL,
+    `amount_cents`       BIGINT        NOT NULL,
+    `currency`           VARCHAR(32)   NOT NULL,
+    `provider_reference` VARCHAR(255)  NOT NULL,
+    `redirect_url`       VARCHAR(1024) NOT NULL,
+    `status`             INT           NOT NULL,
+    `failure_reason`     VARCHAR(255)  NOT NULL DEFAULT '',
+    `created_at`         DATETIME      NOT NULL,
+    `updated_at`         DATETIME      NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_top_up_intent_user` (`user_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
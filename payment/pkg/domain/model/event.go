@@ -23,8 +23,75 @@ func (e FundsWithdrawn) Type() string { return "FundsWithdrawn" }
 
 type PaymentFailed struct {
 	WalletID    uuid.UUID
+	UserID      uuid.UUID
 	ReferenceID string
 	Reason      string
+	ReasonCode  string
 }
 
 func (e PaymentFailed) Type() string { return "PaymentFailed" }
+
+type PaymentParkedForReview struct {
+	WalletID      uuid.UUID
+	TransactionID uuid.UUID
+	ReferenceID   string
+	AmountCents   int64
+	ReasonCode    string
+}
+
+func (e PaymentParkedForReview) Type() string { return "PaymentParkedForReview" }
+
+type FundsRefunded struct {
+	WalletID    uuid.UUID
+	UserID      uuid.UUID
+	AmountCents int64
+	ReferenceID string
+	NewBalance  int64
+}
+
+func (e FundsRefunded) Type() string { return "FundsRefunded" }
+
+type WalletFrozen struct {
+	WalletID uuid.UUID
+	UserID   uuid.UUID
+	Reason   string
+}
+
+func (e WalletFrozen) Type() string { return "WalletFrozen" }
+
+type WalletUnfrozen struct {
+	WalletID uuid.UUID
+	UserID   uuid.UUID
+	Reason   string
+}
+
+func (e WalletUnfrozen) Type() string { return "WalletUnfrozen" }
+
+type WalletClosed struct {
+	WalletID        uuid.UUID
+	UserID          uuid.UUID
+	Reason          string
+	PaidOutCents    int64
+	PayoutReference string
+}
+
+func (e WalletClosed) Type() string { return "WalletClosed" }
+
+type TopUpFailed struct {
+	IntentID    uuid.UUID
+	UserID      uuid.UUID
+	AmountCents int64
+	Reason      string
+}
+
+func (e TopUpFailed) Type() string { return "TopUpFailed" }
+
+type TopUpRefundRequired struct {
+	IntentID          uuid.UUID
+	UserID            uuid.UUID
+	AmountCents       int64
+	ProviderReference string
+	ReasonCode        string
+}
+
+func (e TopUpRefundRequired) Type() string { return "TopUpRefundRequired" }
@@ -8,11 +8,21 @@ import (
 )
 
 var (
-	ErrWalletNotFound       = errors.New("wallet not found")
-	ErrInsufficientFunds    = errors.New("insufficient funds")
-	ErrDuplicateTransaction = errors.New("transaction with this reference already exists")
-	ErrInvalidAmount        = errors.New("amount must be positive")
-	ErrOptimisticLock       = errors.New("wallet was modified by another transaction")
+	ErrWalletNotFound          = errors.New("wallet not found")
+	ErrInsufficientFunds       = errors.New("insufficient funds")
+	ErrDuplicateTransaction    = errors.New("transaction with this reference already exists")
+	ErrInvalidAmount           = errors.New("amount must be positive")
+	ErrOptimisticLock          = errors.New("wallet was modified by another transaction")
+	ErrTransactionNotFound     = errors.New("transaction not found")
+	ErrPaymentDenied           = errors.New("payment denied by risk rules")
+	ErrPaymentUnderReview      = errors.New("payment is parked for manual review")
+	ErrTransactionNotOnHold    = errors.New("transaction is not awaiting review")
+	ErrInvalidLimits           = errors.New("spending limits must not be negative")
+	ErrWalletFrozen            = errors.New("wallet is frozen")
+	ErrWalletClosed            = errors.New("wallet is closed")
+	ErrWalletNotEmpty          = errors.New("wallet can only be closed at zero balance")
+	ErrReasonRequired          = errors.New("reason for wallet status change is required")
+	ErrPayoutReferenceRequired = errors.New("payout reference is required")
 )
 
 type TransactionType int
@@ -20,6 +30,8 @@ type TransactionType int
 const (
 	Deposit TransactionType = iota
 	Withdrawal
+	Refund
+	Payout
 )
 
 type TransactionStatus int
@@ -28,6 +40,15 @@ const (
 	TxPending TransactionStatus = iota
 	TxCommitted
 	TxFailed
+	TxOnReview
+)
+
+type WalletStatus int
+
+const (
+	WalletStatusActive WalletStatus = iota
+	WalletStatusFrozen
+	WalletStatusClosed
 )
 
 type Wallet struct {
@@ -36,6 +57,9 @@ type Wallet struct {
 	BalanceCents int64
 	Currency     string // e.g., "USD", "COINS"
 	Version      int    // Для оптимистической блокировки
+	Limits       SpendingLimits
+	Status       WalletStatus
+	StatusReason string
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -48,16 +72,41 @@ type Transaction struct {
 	ReferenceID  string // ID заказа или пополнения для идемпотентности
 	Status       TransactionStatus
 	ErrorMessage string
+	ReasonCode   string // Машиночитаемая причина отказа, см. Reason*
 	CreatedAt    time.Time
 }
 
+// WalletStatusChange - запись аудита смены статуса кошелька
+type WalletStatusChange struct {
+	ID        uuid.UUID
+	WalletID  uuid.UUID
+	OldStatus WalletStatus
+	NewStatus WalletStatus
+	Reason    string
+	CreatedAt time.Time
+}
+
 type PaymentRepository interface {
 	NextID() (uuid.UUID, error)
+	// WithinTransaction выполняет fn в одной транзакции БД: если fn вернула ошибку,
+	// все записи через переданный ей repo откатываются
+	WithinTransaction(fn func(repo PaymentRepository) error) error
 
 	CreateWallet(wallet *Wallet) error
+	GetWalletByID(walletID uuid.UUID) (*Wallet, error)
 	GetWalletByUserID(userID uuid.UUID) (*Wallet, error)
 	UpdateWallet(wallet *Wallet) error
+	SaveWalletStatusChange(change *WalletStatusChange) error
 
 	SaveTransaction(tx *Transaction) error
+	// ResolveTransaction сохраняет решение по транзакции, только пока она ждёт проверки (TxOnReview).
+	// Если решение уже принято параллельно, возвращает ErrTransactionNotOnHold
+	ResolveTransaction(tx *Transaction) error
+	FindTransaction(id uuid.UUID) (*Transaction, error)
 	FindTransactionByRef(walletID uuid.UUID, referenceID string) (*Transaction, error)
+
+	// SumWithdrawalsSince и CountWithdrawalsSince учитывают проведённые (TxCommitted) списания и ждущие
+	// ручной проверки (TxOnReview): иначе серия отложенных платежей обходила бы лимиты
+	SumWithdrawalsSince(walletID uuid.UUID, since time.Time) (int64, error)
+	CountWithdrawalsSince(walletID uuid.UUID, since time.Time) (int, error)
 }
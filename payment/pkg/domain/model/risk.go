@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+const (
+	ReasonInsufficientFunds      = "insufficient_funds"
+	ReasonPerTransactionLimit    = "per_transaction_limit_exceeded"
+	ReasonDailyLimit             = "daily_limit_exceeded"
+	ReasonMonthlyLimit           = "monthly_limit_exceeded"
+	ReasonVelocityLimit          = "velocity_limit_exceeded"
+	ReasonRejectedByManualReview = "rejected_by_manual_review"
+	ReasonWalletFrozen           = "wallet_frozen"
+	ReasonWalletClosed           = "wallet_closed"
+)
+
+// SpendingLimits задаются на кошелёк, нулевое значение означает отсутствие ограничения
+type SpendingLimits struct {
+	PerTransactionCents int64
+	DailyCents          int64
+	MonthlyCents        int64
+}
+
+type RiskDecision int
+
+const (
+	RiskAllow RiskDecision = iota
+	RiskReview
+	RiskDeny
+)
+
+type RiskVerdict struct {
+	Decision   RiskDecision
+	ReasonCode string
+}
+
+func Allow() RiskVerdict { return RiskVerdict{Decision: RiskAllow} }
+
+func Review(reasonCode string) RiskVerdict {
+	return RiskVerdict{Decision: RiskReview, ReasonCode: reasonCode}
+}
+
+func Deny(reasonCode string) RiskVerdict {
+	return RiskVerdict{Decision: RiskDeny, ReasonCode: reasonCode}
+}
+
+type PaymentAttempt struct {
+	Wallet      Wallet
+	AmountCents int64
+	ReferenceID string
+	At          time.Time
+}
+
+// RiskRule - подключаемое правило антифрода, вызывается перед каждым списанием
+type RiskRule interface {
+	Evaluate(attempt PaymentAttempt) (RiskVerdict, error)
+}
+
+type RiskRuleFunc func(attempt PaymentAttempt) (RiskVerdict, error)
+
+func (f RiskRuleFunc) Evaluate(attempt PaymentAttempt) (RiskVerdict, error) {
+	return f(attempt)
+}
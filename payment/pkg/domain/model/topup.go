@@ -0,0 +1,57 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTopUpIntentNotFound  = errors.New("top-up intent not found")
+	ErrTopUpIntentFinalized = errors.New("top-up intent is already finalized")
+	// ErrProviderReferenceMismatch - вебхук подписан верно, но относится к другому платежу провайдера
+	ErrProviderReferenceMismatch = errors.New("provider reference does not match top-up intent")
+)
+
+type TopUpIntentStatus int
+
+const (
+	IntentPending TopUpIntentStatus = iota
+	IntentSucceeded
+	IntentFailed
+	// IntentRefundRequired - провайдер списал деньги, но зачислить их нельзя: кошелёк заморожен или закрыт.
+	// Статус финальный, деньги возвращаются пользователю через провайдера
+	IntentRefundRequired
+)
+
+// TopUpIntent - пополнение кошелька картой через внешнего платёжного провайдера (PSP)
+type TopUpIntent struct {
+	ID                uuid.UUID
+	UserID            uuid.UUID
+	AmountCents       int64
+	Currency          string
+	ProviderReference string
+	RedirectURL       string
+	Status            TopUpIntentStatus
+	FailureReason     string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+type TopUpIntentRepository interface {
+	NextID() (uuid.UUID, error)
+	Create(intent *TopUpIntent) error
+	Update(intent *TopUpIntent) error
+	Find(id uuid.UUID) (*TopUpIntent, error)
+}
+
+type GatewayIntent struct {
+	ProviderReference string
+	RedirectURL       string
+}
+
+// Gateway - адаптер платёжного провайдера. Результат оплаты приходит асинхронно через вебхук
+type Gateway interface {
+	CreateIntent(intentID uuid.UUID, amountCents int64, currency string) (GatewayIntent, error)
+}
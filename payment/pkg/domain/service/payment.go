@@ -15,15 +15,27 @@ type PaymentService interface {
 	Deposit(userID uuid.UUID, amountCents int64, referenceID string) (*model.Wallet, error)
 	PayForOrder(userID uuid.UUID, orderID uuid.UUID, amountCents int64) error
 	GetBalance(userID uuid.UUID) (int64, error)
+
+	RefundOrder(userID uuid.UUID, orderID uuid.UUID, amountCents int64) (*model.Wallet, error)
+
+	SetSpendingLimits(userID uuid.UUID, limits model.SpendingLimits) error
+	ApproveTransaction(transactionID uuid.UUID) error
+	RejectTransaction(transactionID uuid.UUID) error
+
+	FreezeWallet(userID uuid.UUID, reason string) error
+	UnfreezeWallet(userID uuid.UUID, reason string) error
+	CloseWallet(userID uuid.UUID, reason string) error
+	ForceCloseWallet(userID uuid.UUID, payoutReference, reason string) error
 }
 
-func NewPaymentService(repo model.PaymentRepository, dispatcher EventDispatcher) PaymentService {
-	return &paymentService{repo: repo, dispatcher: dispatcher}
+func NewPaymentService(repo model.PaymentRepository, dispatcher EventDispatcher, rules ...model.RiskRule) PaymentService {
+	return &paymentService{repo: repo, dispatcher: dispatcher, rules: rules}
 }
 
 type paymentService struct {
 	repo       model.PaymentRepository
 	dispatcher EventDispatcher
+	rules      []model.RiskRule
 }
 
 func (s *paymentService) CreateWallet(userID uuid.UUID) (*model.Wallet, error) {
@@ -66,6 +78,15 @@ func (s *paymentService) PayForOrder(userID uuid.UUID, orderID uuid.UUID, amount
 	return err
 }
 
+// RefundOrder возвращает средства за заказ, в том числе на замороженный кошелёк
+func (s *paymentService) RefundOrder(userID uuid.UUID, orderID uuid.UUID, amountCents int64) (*model.Wallet, error) {
+	if amountCents <= 0 {
+		return nil, model.ErrInvalidAmount
+	}
+
+	return s.processTransaction(userID, amountCents, refundReference(orderID), model.Refund)
+}
+
 func (s *paymentService) GetBalance(userID uuid.UUID) (int64, error) {
 	wallet, err := s.repo.GetWalletByUserID(userID)
 	if err != nil {
@@ -74,6 +95,92 @@ func (s *paymentService) GetBalance(userID uuid.UUID) (int64, error) {
 	return wallet.BalanceCents, nil
 }
 
+func (s *paymentService) SetSpendingLimits(userID uuid.UUID, limits model.SpendingLimits) error {
+	if limits.PerTransactionCents < 0 || limits.DailyCents < 0 || limits.MonthlyCents < 0 {
+		return model.ErrInvalidLimits
+	}
+
+	wallet, err := s.repo.GetWalletByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	wallet.Limits = limits
+	wallet.Version++
+	wallet.UpdatedAt = time.Now().UTC()
+
+	return s.repo.UpdateWallet(wallet)
+}
+
+// ApproveTransaction проводит отложенное на ручную проверку списание.
+// Баланс проверяется заново, так как он мог измениться за время проверки. Параллельные Approve и Reject
+// одной транзакции не проходят оба: ResolveTransaction меняет только транзакцию, которая ещё ждёт решения
+func (s *paymentService) ApproveTransaction(transactionID uuid.UUID) error {
+	tx, wallet, err := s.findTransactionOnReview(transactionID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkWalletStatus(wallet, tx.Type); err != nil {
+		if failErr := s.failTransaction(wallet, tx, statusReasonCode(err), err, s.repo.ResolveTransaction); failErr != nil {
+			return failErr
+		}
+		return err
+	}
+
+	if wallet.BalanceCents < tx.AmountCents {
+		if err := s.failTransaction(wallet, tx, model.ReasonInsufficientFunds, model.ErrInsufficientFunds, s.repo.ResolveTransaction); err != nil {
+			return err
+		}
+		return model.ErrInsufficientFunds
+	}
+
+	wallet.BalanceCents -= tx.AmountCents
+	wallet.Version++
+	wallet.UpdatedAt = time.Now().UTC()
+	tx.Status = model.TxCommitted
+	tx.ReasonCode = ""
+
+	if err := s.repo.WithinTransaction(func(repo model.PaymentRepository) error {
+		if err := repo.ResolveTransaction(tx); err != nil {
+			return err
+		}
+		return repo.UpdateWallet(wallet)
+	}); err != nil {
+		return err
+	}
+
+	_ = s.dispatcher.Dispatch(model.FundsWithdrawn{
+		WalletID: wallet.ID, UserID: wallet.UserID, AmountCents: tx.AmountCents, ReferenceID: tx.ReferenceID,
+	})
+	return nil
+}
+
+func (s *paymentService) RejectTransaction(transactionID uuid.UUID) error {
+	tx, wallet, err := s.findTransactionOnReview(transactionID)
+	if err != nil {
+		return err
+	}
+
+	return s.failTransaction(wallet, tx, model.ReasonRejectedByManualReview, model.ErrPaymentDenied, s.repo.ResolveTransaction)
+}
+
+func (s *paymentService) findTransactionOnReview(transactionID uuid.UUID) (*model.Transaction, *model.Wallet, error) {
+	tx, err := s.repo.FindTransaction(transactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tx.Status != model.TxOnReview {
+		return nil, nil, model.ErrTransactionNotOnHold
+	}
+
+	wallet, err := s.repo.GetWalletByID(tx.WalletID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, wallet, nil
+}
+
 func (s *paymentService) processTransaction(userID uuid.UUID, amount int64, refID string, txType model.TransactionType) (*model.Wallet, error) {
 	wallet, err := s.repo.GetWalletByUserID(userID)
 	if err != nil {
@@ -82,7 +189,7 @@ func (s *paymentService) processTransaction(userID uuid.UUID, amount int64, refI
 
 	existingTx, err := s.repo.FindTransactionByRef(wallet.ID, refID)
 	if err == nil && existingTx != nil {
-		return wallet, nil
+		return replayTransaction(wallet, existingTx)
 	}
 
 	txID, err := s.repo.NextID()
@@ -100,18 +207,47 @@ func (s *paymentService) processTransaction(userID uuid.UUID, amount int64, refI
 		CreatedAt:   time.Now().UTC(),
 	}
 
+	if err := checkWalletStatus(wallet, txType); err != nil {
+		if txType == model.Withdrawal {
+			if failErr := s.failTransaction(wallet, tx, statusReasonCode(err), err, s.repo.SaveTransaction); failErr != nil {
+				return nil, failErr
+			}
+		}
+		return nil, err
+	}
+
 	if txType == model.Withdrawal {
 		if wallet.BalanceCents < amount {
 			// Фиксируем неудачную транзакцию (audit log)
-			tx.Status = model.TxFailed
-			tx.ErrorMessage = model.ErrInsufficientFunds.Error()
-			_ = s.repo.SaveTransaction(tx)
-
-			_ = s.dispatcher.Dispatch(model.PaymentFailed{
-				WalletID: wallet.ID, ReferenceID: refID, Reason: model.ErrInsufficientFunds.Error(),
-			})
+			if err := s.failTransaction(wallet, tx, model.ReasonInsufficientFunds, model.ErrInsufficientFunds, s.repo.SaveTransaction); err != nil {
+				return nil, err
+			}
 			return nil, model.ErrInsufficientFunds
 		}
+
+		verdict, err := evaluateRisk(s.rules, model.PaymentAttempt{
+			Wallet:      *wallet,
+			AmountCents: amount,
+			ReferenceID: refID,
+			At:          tx.CreatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		switch verdict.Decision {
+		case model.RiskDeny:
+			if err := s.failTransaction(wallet, tx, verdict.ReasonCode, model.ErrPaymentDenied, s.repo.SaveTransaction); err != nil {
+				return nil, err
+			}
+			return nil, model.ErrPaymentDenied
+		case model.RiskReview:
+			if err := s.parkTransaction(wallet, tx, verdict.ReasonCode); err != nil {
+				return nil, err
+			}
+			return nil, model.ErrPaymentUnderReview
+		}
+
 		wallet.BalanceCents -= amount
 	} else {
 		wallet.BalanceCents += amount
@@ -121,19 +257,27 @@ func (s *paymentService) processTransaction(userID uuid.UUID, amount int64, refI
 	wallet.UpdatedAt = time.Now().UTC()
 	tx.Status = model.TxCommitted
 
-	if err := s.repo.SaveTransaction(tx); err != nil {
-		return nil, err
-	}
-
-	if err := s.repo.UpdateWallet(wallet); err != nil {
+	// Запись транзакции и баланс сохраняются вместе: если бы транзакция осталась без зачисления,
+	// повтор с тем же reference счёл бы операцию проведённой
+	if err := s.repo.WithinTransaction(func(repo model.PaymentRepository) error {
+		if err := repo.SaveTransaction(tx); err != nil {
+			return err
+		}
+		return repo.UpdateWallet(wallet)
+	}); err != nil {
 		return nil, err
 	}
 
-	if txType == model.Withdrawal {
+	switch txType {
+	case model.Withdrawal:
 		_ = s.dispatcher.Dispatch(model.FundsWithdrawn{
 			WalletID: wallet.ID, UserID: userID, AmountCents: amount, ReferenceID: refID,
 		})
-	} else {
+	case model.Refund:
+		_ = s.dispatcher.Dispatch(model.FundsRefunded{
+			WalletID: wallet.ID, UserID: userID, AmountCents: amount, ReferenceID: refID, NewBalance: wallet.BalanceCents,
+		})
+	default:
 		_ = s.dispatcher.Dispatch(model.FundsDeposited{
 			WalletID: wallet.ID, UserID: userID, AmountCents: amount, ReferenceID: refID, NewBalance: wallet.BalanceCents,
 		})
@@ -141,3 +285,75 @@ func (s *paymentService) processTransaction(userID uuid.UUID, amount int64, refI
 
 	return wallet, nil
 }
+
+// replayTransaction возвращает повторному запросу тот же результат, что получил первый
+func replayTransaction(wallet *model.Wallet, tx *model.Transaction) (*model.Wallet, error) {
+	switch tx.Status {
+	case model.TxOnReview:
+		return nil, model.ErrPaymentUnderReview
+	case model.TxFailed:
+		switch tx.ReasonCode {
+		case model.ReasonInsufficientFunds:
+			return nil, model.ErrInsufficientFunds
+		case model.ReasonWalletFrozen:
+			return nil, model.ErrWalletFrozen
+		case model.ReasonWalletClosed:
+			return nil, model.ErrWalletClosed
+		default:
+			return nil, model.ErrPaymentDenied
+		}
+	default:
+		return wallet, nil
+	}
+}
+
+// failTransaction фиксирует отказ с причиной cause, сохраняя транзакцию через persist
+func (s *paymentService) failTransaction(
+	wallet *model.Wallet,
+	tx *model.Transaction,
+	reasonCode string,
+	cause error,
+	persist func(tx *model.Transaction) error,
+) error {
+	tx.Status = model.TxFailed
+	tx.ErrorMessage = cause.Error()
+	tx.ReasonCode = reasonCode
+	if err := persist(tx); err != nil {
+		return err
+	}
+
+	_ = s.dispatcher.Dispatch(model.PaymentFailed{
+		WalletID:    tx.WalletID,
+		UserID:      wallet.UserID,
+		ReferenceID: tx.ReferenceID,
+		Reason:      cause.Error(),
+		ReasonCode:  reasonCode,
+	})
+	return nil
+}
+
+// parkTransaction меняет версию кошелька, как и проведённое списание: отложенный платёж учитывается
+// в лимитах, и параллельный платёж, проверивший правила по старым суммам, получит ErrOptimisticLock
+func (s *paymentService) parkTransaction(wallet *model.Wallet, tx *model.Transaction, reasonCode string) error {
+	tx.Status = model.TxOnReview
+	tx.ReasonCode = reasonCode
+	wallet.Version++
+	wallet.UpdatedAt = time.Now().UTC()
+	if err := s.repo.WithinTransaction(func(repo model.PaymentRepository) error {
+		if err := repo.SaveTransaction(tx); err != nil {
+			return err
+		}
+		return repo.UpdateWallet(wallet)
+	}); err != nil {
+		return err
+	}
+
+	_ = s.dispatcher.Dispatch(model.PaymentParkedForReview{
+		WalletID:      tx.WalletID,
+		TransactionID: tx.ID,
+		ReferenceID:   tx.ReferenceID,
+		AmountCents:   tx.AmountCents,
+		ReasonCode:    reasonCode,
+	})
+	return nil
+}
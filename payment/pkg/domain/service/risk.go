@@ -0,0 +1,98 @@
+package service
+
+import (
+	"time"
+
+	"payment/pkg/domain/model"
+)
+
+// NewSpendingLimitRule проверяет лимиты кошелька: на одну операцию, за календарные сутки и месяц (UTC)
+func NewSpendingLimitRule(repo model.PaymentRepository) model.RiskRule {
+	return &spendingLimitRule{repo: repo}
+}
+
+type spendingLimitRule struct {
+	repo model.PaymentRepository
+}
+
+func (r *spendingLimitRule) Evaluate(attempt model.PaymentAttempt) (model.RiskVerdict, error) {
+	limits := attempt.Wallet.Limits
+	if limits.PerTransactionCents > 0 && attempt.AmountCents > limits.PerTransactionCents {
+		return model.Deny(model.ReasonPerTransactionLimit), nil
+	}
+
+	at := attempt.At.UTC()
+	if limits.DailyCents > 0 {
+		dayStart := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+		spent, err := r.repo.SumWithdrawalsSince(attempt.Wallet.ID, dayStart)
+		if err != nil {
+			return model.RiskVerdict{}, err
+		}
+		if spent+attempt.AmountCents > limits.DailyCents {
+			return model.Deny(model.ReasonDailyLimit), nil
+		}
+	}
+
+	if limits.MonthlyCents > 0 {
+		monthStart := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+		spent, err := r.repo.SumWithdrawalsSince(attempt.Wallet.ID, monthStart)
+		if err != nil {
+			return model.RiskVerdict{}, err
+		}
+		if spent+attempt.AmountCents > limits.MonthlyCents {
+			return model.Deny(model.ReasonMonthlyLimit), nil
+		}
+	}
+
+	return model.Allow(), nil
+}
+
+// NewVelocityRule срабатывает, если за последние window уже было maxPayments списаний.
+// decision определяет реакцию: отказ (RiskDeny) или ручная проверка (RiskReview)
+func NewVelocityRule(repo model.PaymentRepository, maxPayments int, window time.Duration, decision model.RiskDecision) model.RiskRule {
+	return &velocityRule{
+		repo:        repo,
+		maxPayments: maxPayments,
+		window:      window,
+		decision:    decision,
+	}
+}
+
+type velocityRule struct {
+	repo        model.PaymentRepository
+	maxPayments int
+	window      time.Duration
+	decision    model.RiskDecision
+}
+
+func (r *velocityRule) Evaluate(attempt model.PaymentAttempt) (model.RiskVerdict, error) {
+	count, err := r.repo.CountWithdrawalsSince(attempt.Wallet.ID, attempt.At.Add(-r.window))
+	if err != nil {
+		return model.RiskVerdict{}, err
+	}
+	if count < r.maxPayments {
+		return model.Allow(), nil
+	}
+	return model.RiskVerdict{Decision: r.decision, ReasonCode: model.ReasonVelocityLimit}, nil
+}
+
+// evaluateRisk прогоняет все правила: первый отказ прерывает проверку,
+// иначе побеждает первое требование ручной проверки
+func evaluateRisk(rules []model.RiskRule, attempt model.PaymentAttempt) (model.RiskVerdict, error) {
+	result := model.Allow()
+	for _, rule := range rules {
+		verdict, err := rule.Evaluate(attempt)
+		if err != nil {
+			return model.RiskVerdict{}, err
+		}
+		switch verdict.Decision {
+		case model.RiskDeny:
+			return verdict, nil
+		case model.RiskReview:
+			if result.Decision == model.RiskAllow {
+				result = verdict
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"payment/pkg/domain/model"
+)
+
+const topUpCurrency = "INTERNAL_COIN"
+
+type TopUpService interface {
+	CreateTopUpIntent(userID uuid.UUID, amountCents int64) (*model.TopUpIntent, error)
+	// CompleteTopUp идемпотентно зачисляет средства: ID интента используется как reference для Deposit.
+	// Если кошелёк заморожен или закрыт, интент переводится в IntentRefundRequired, а не остаётся в ожидании
+	CompleteTopUp(intentID uuid.UUID, providerReference string) error
+	FailTopUp(intentID uuid.UUID, providerReference, reason string) error
+}
+
+func NewTopUpService(
+	repo model.TopUpIntentRepository,
+	gateway model.Gateway,
+	paymentService PaymentService,
+	dispatcher EventDispatcher,
+) TopUpService {
+	return &topUpService{
+		repo:           repo,
+		gateway:        gateway,
+		paymentService: paymentService,
+		dispatcher:     dispatcher,
+	}
+}
+
+type topUpService struct {
+	repo           model.TopUpIntentRepository
+	gateway        model.Gateway
+	paymentService PaymentService
+	dispatcher     EventDispatcher
+}
+
+func (s *topUpService) CreateTopUpIntent(userID uuid.UUID, amountCents int64) (*model.TopUpIntent, error) {
+	if amountCents <= 0 {
+		return nil, model.ErrInvalidAmount
+	}
+
+	intentID, err := s.repo.NextID()
+	if err != nil {
+		return nil, err
+	}
+
+	gatewayIntent, err := s.gateway.CreateIntent(intentID, amountCents, topUpCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	intent := &model.TopUpIntent{
+		ID:                intentID,
+		UserID:            userID,
+		AmountCents:       amountCents,
+		Currency:          topUpCurrency,
+		ProviderReference: gatewayIntent.ProviderReference,
+		RedirectURL:       gatewayIntent.RedirectURL,
+		Status:            model.IntentPending,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	if err := s.repo.Create(intent); err != nil {
+		return nil, err
+	}
+	return intent, nil
+}
+
+func (s *topUpService) CompleteTopUp(intentID uuid.UUID, providerReference string) error {
+	intent, err := s.findIntent(intentID, providerReference)
+	if err != nil {
+		return err
+	}
+
+	switch intent.Status {
+	case model.IntentSucceeded, model.IntentRefundRequired:
+		return nil
+	case model.IntentFailed:
+		return model.ErrTopUpIntentFinalized
+	}
+
+	// Сначала зачисляем, потом меняем статус: при повторе вебхука Deposit не задвоит сумму
+	_, err = s.paymentService.Deposit(intent.UserID, intent.AmountCents, intent.ID.String())
+	if errors.Is(err, model.ErrWalletFrozen) || errors.Is(err, model.ErrWalletClosed) {
+		// Повтор вебхука не разморозит кошелёк, поэтому интент завершается, а не ждёт вечно
+		return s.requireRefund(intent, statusReasonCode(err))
+	}
+	if err != nil {
+		return err
+	}
+
+	intent.Status = model.IntentSucceeded
+	intent.UpdatedAt = time.Now().UTC()
+	return s.repo.Update(intent)
+}
+
+func (s *topUpService) FailTopUp(intentID uuid.UUID, providerReference, reason string) error {
+	intent, err := s.findIntent(intentID, providerReference)
+	if err != nil {
+		return err
+	}
+
+	switch intent.Status {
+	case model.IntentFailed:
+		return nil
+	case model.IntentSucceeded, model.IntentRefundRequired:
+		return model.ErrTopUpIntentFinalized
+	}
+
+	intent.Status = model.IntentFailed
+	intent.FailureReason = reason
+	intent.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(intent); err != nil {
+		return err
+	}
+
+	_ = s.dispatcher.Dispatch(model.TopUpFailed{
+		IntentID: intent.ID, UserID: intent.UserID, AmountCents: intent.AmountCents, Reason: reason,
+	})
+	return nil
+}
+
+// findIntent отклоняет вебхук о чужом платеже провайдера, даже если ID интента совпал
+func (s *topUpService) findIntent(intentID uuid.UUID, providerReference string) (*model.TopUpIntent, error) {
+	intent, err := s.repo.Find(intentID)
+	if err != nil {
+		return nil, err
+	}
+	if intent.ProviderReference != providerReference {
+		return nil, errors.Wrapf(model.ErrProviderReferenceMismatch, "intent %s", intentID)
+	}
+	return intent, nil
+}
+
+// requireRefund завершает интент, деньги по которому списаны, но не могут быть зачислены
+func (s *topUpService) requireRefund(intent *model.TopUpIntent, reasonCode string) error {
+	intent.Status = model.IntentRefundRequired
+	intent.FailureReason = reasonCode
+	intent.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(intent); err != nil {
+		return err
+	}
+
+	_ = s.dispatcher.Dispatch(model.TopUpRefundRequired{
+		IntentID:          intent.ID,
+		UserID:            intent.UserID,
+		AmountCents:       intent.AmountCents,
+		ProviderReference: intent.ProviderReference,
+		ReasonCode:        reasonCode,
+	})
+	return nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"payment/pkg/domain/model"
+)
+
+const (
+	reasonUserDeactivated = "user deactivated"
+	reasonUserSuspended   = "user suspended"
+)
+
+// UserEventHandler реагирует на события сервиса пользователей
+type UserEventHandler interface {
+	// HandleUserDeactivated вызывается при удалении пользователя
+	HandleUserDeactivated(userID uuid.UUID) error
+	// HandleUserSuspended вызывается при блокировке пользователя
+	HandleUserSuspended(userID uuid.UUID) error
+}
+
+func NewUserEventHandler(paymentService PaymentService) UserEventHandler {
+	return &userEventHandler{paymentService: paymentService}
+}
+
+type userEventHandler struct {
+	paymentService PaymentService
+}
+
+func (h *userEventHandler) HandleUserDeactivated(userID uuid.UUID) error {
+	return h.freeze(userID, reasonUserDeactivated)
+}
+
+func (h *userEventHandler) HandleUserSuspended(userID uuid.UUID) error {
+	return h.freeze(userID, reasonUserSuspended)
+}
+
+// freeze игнорирует пользователей без кошелька и уже закрытые кошельки, чтобы повторная доставка события не падала
+func (h *userEventHandler) freeze(userID uuid.UUID, reason string) error {
+	err := h.paymentService.FreezeWallet(userID, reason)
+	if errors.Is(err, model.ErrWalletNotFound) || errors.Is(err, model.ErrWalletClosed) {
+		return nil
+	}
+	return err
+}
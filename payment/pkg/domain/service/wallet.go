@@ -0,0 +1,193 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"payment/pkg/domain/model"
+)
+
+func (s *paymentService) FreezeWallet(userID uuid.UUID, reason string) error {
+	wallet, err := s.findWalletForStatusChange(userID, reason)
+	if err != nil {
+		return err
+	}
+	if wallet.Status == model.WalletStatusFrozen {
+		return nil
+	}
+
+	return s.changeWalletStatus(wallet, model.WalletStatusFrozen, reason, nil, func() Event {
+		return model.WalletFrozen{WalletID: wallet.ID, UserID: wallet.UserID, Reason: reason}
+	})
+}
+
+func (s *paymentService) UnfreezeWallet(userID uuid.UUID, reason string) error {
+	wallet, err := s.findWalletForStatusChange(userID, reason)
+	if err != nil {
+		return err
+	}
+	if wallet.Status == model.WalletStatusActive {
+		return nil
+	}
+
+	return s.changeWalletStatus(wallet, model.WalletStatusActive, reason, nil, func() Event {
+		return model.WalletUnfrozen{WalletID: wallet.ID, UserID: wallet.UserID, Reason: reason}
+	})
+}
+
+// CloseWallet закрывает кошелёк с нулевым балансом, для непустого кошелька используется ForceCloseWallet
+func (s *paymentService) CloseWallet(userID uuid.UUID, reason string) error {
+	wallet, err := s.findWalletForStatusChange(userID, reason)
+	if err != nil {
+		return err
+	}
+	if wallet.BalanceCents != 0 {
+		return model.ErrWalletNotEmpty
+	}
+
+	return s.changeWalletStatus(wallet, model.WalletStatusClosed, reason, nil, func() Event {
+		return model.WalletClosed{WalletID: wallet.ID, UserID: wallet.UserID, Reason: reason}
+	})
+}
+
+// ForceCloseWallet выплачивает весь остаток транзакцией Payout и закрывает кошелёк.
+// Выплата не проходит через правила антифрода и допускается для замороженного кошелька.
+// Payout пишется и при нулевом остатке: так повтор с тем же payoutReference распознаётся и ничего не делает
+func (s *paymentService) ForceCloseWallet(userID uuid.UUID, payoutReference, reason string) error {
+	if payoutReference == "" {
+		return model.ErrPayoutReferenceRequired
+	}
+
+	wallet, err := s.findWalletForStatusChange(userID, reason)
+	if errors.Is(err, model.ErrWalletClosed) {
+		return s.replayForceClose(userID, payoutReference)
+	}
+	if err != nil {
+		return err
+	}
+
+	txID, err := s.repo.NextID()
+	if err != nil {
+		return err
+	}
+
+	paidOut := wallet.BalanceCents
+	payout := &model.Transaction{
+		ID:          txID,
+		WalletID:    wallet.ID,
+		Type:        model.Payout,
+		AmountCents: paidOut,
+		ReferenceID: payoutReference,
+		Status:      model.TxCommitted,
+		CreatedAt:   time.Now().UTC(),
+	}
+	wallet.BalanceCents = 0
+
+	return s.changeWalletStatus(wallet, model.WalletStatusClosed, reason, payout, func() Event {
+		return model.WalletClosed{
+			WalletID:        wallet.ID,
+			UserID:          wallet.UserID,
+			Reason:          reason,
+			PaidOutCents:    paidOut,
+			PayoutReference: payoutReference,
+		}
+	})
+}
+
+// replayForceClose считает повтор выполненным, только если кошелёк закрыт выплатой с тем же reference
+func (s *paymentService) replayForceClose(userID uuid.UUID, payoutReference string) error {
+	wallet, err := s.repo.GetWalletByUserID(userID)
+	if err != nil {
+		return err
+	}
+	payout, err := s.repo.FindTransactionByRef(wallet.ID, payoutReference)
+	if err == nil && payout != nil && payout.Type == model.Payout {
+		return nil
+	}
+	return model.ErrWalletClosed
+}
+
+func (s *paymentService) findWalletForStatusChange(userID uuid.UUID, reason string) (*model.Wallet, error) {
+	if reason == "" {
+		return nil, model.ErrReasonRequired
+	}
+
+	wallet, err := s.repo.GetWalletByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.Status == model.WalletStatusClosed {
+		return nil, model.ErrWalletClosed
+	}
+	return wallet, nil
+}
+
+// changeWalletStatus сохраняет кошелёк, запись аудита и выплату payout, если она есть, в одной транзакции БД
+func (s *paymentService) changeWalletStatus(
+	wallet *model.Wallet,
+	newStatus model.WalletStatus,
+	reason string,
+	payout *model.Transaction,
+	event func() Event,
+) error {
+	oldStatus := wallet.Status
+	now := time.Now().UTC()
+
+	wallet.Status = newStatus
+	wallet.StatusReason = reason
+	wallet.Version++
+	wallet.UpdatedAt = now
+
+	changeID, err := s.repo.NextID()
+	if err != nil {
+		return err
+	}
+	if err := s.repo.WithinTransaction(func(repo model.PaymentRepository) error {
+		if payout != nil {
+			if err := repo.SaveTransaction(payout); err != nil {
+				return err
+			}
+		}
+		if err := repo.UpdateWallet(wallet); err != nil {
+			return err
+		}
+		return repo.SaveWalletStatusChange(&model.WalletStatusChange{
+			ID:        changeID,
+			WalletID:  wallet.ID,
+			OldStatus: oldStatus,
+			NewStatus: newStatus,
+			Reason:    reason,
+			CreatedAt: now,
+		})
+	}); err != nil {
+		return err
+	}
+
+	_ = s.dispatcher.Dispatch(event())
+	return nil
+}
+
+// checkWalletStatus: замороженный кошелёк принимает только возвраты, закрытый - ничего
+func checkWalletStatus(wallet *model.Wallet, txType model.TransactionType) error {
+	switch wallet.Status {
+	case model.WalletStatusClosed:
+		return model.ErrWalletClosed
+	case model.WalletStatusFrozen:
+		if txType != model.Refund {
+			return model.ErrWalletFrozen
+		}
+	}
+	return nil
+}
+
+func statusReasonCode(err error) string {
+	if errors.Is(err, model.ErrWalletClosed) {
+		return model.ReasonWalletClosed
+	}
+	return model.ReasonWalletFrozen
+}
+
+func refundReference(orderID uuid.UUID) string {
+	return "refund:" + orderID.String()
+}
@@ -8,6 +8,7 @@ import (
 	"payment/pkg/domain/model"
 	"payment/pkg/domain/service"
 	"testing"
+	"time"
 )
 
 // --- Setup ---
@@ -133,6 +134,7 @@ func TestPayForOrder_InsufficientFunds(t *testing.T) {
 	event, ok := dispatcher.events[0].(model.PaymentFailed)
 	assert.True(t, ok)
 	assert.Equal(t, orderID.String(), event.ReferenceID)
+	assert.Equal(t, userID, event.UserID)
 }
 
 func TestOptimisticLocking_Fail(t *testing.T) {
@@ -156,11 +158,42 @@ func TestOptimisticLocking_Fail(t *testing.T) {
 	assert.ErrorIs(t, err, model.ErrOptimisticLock)
 }
 
+func TestDeposit_OptimisticLockLeavesNoTransaction(t *testing.T) {
+	svc, repo, dispatcher := setupPaymentTest(t)
+	userID := uuid.New()
+	wallet, _ := svc.CreateWallet(userID)
+	dispatcher.Reset()
+
+	refID := "ref_conflict_1"
+	repo.conflictOnNextUpdate = true
+
+	_, err := svc.Deposit(userID, 700, refID)
+	require.ErrorIs(t, err, model.ErrOptimisticLock)
+
+	// Транзакция откатилась вместе с балансом, поэтому повтор не примет её за проведённую
+	tx, err := repo.FindTransactionByRef(wallet.ID, refID)
+	require.NoError(t, err)
+	assert.Nil(t, tx)
+	assert.Empty(t, dispatcher.events)
+
+	updatedWallet, err := svc.Deposit(userID, 700, refID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(700), updatedWallet.BalanceCents)
+	require.Len(t, repo.storeTxs, 1)
+	assert.Equal(t, model.TxCommitted, repo.storeTxs[0].Status)
+}
+
 // --- Mocks ---
 
 type mockPaymentRepository struct {
-	storeWallets map[uuid.UUID]*model.Wallet
-	storeTxs     []*model.Transaction
+	storeWallets       map[uuid.UUID]*model.Wallet
+	storeTxs           []*model.Transaction
+	storeStatusChanges []*model.WalletStatusChange
+
+	// conflictOnNextUpdate имитирует параллельное изменение кошелька при следующем UpdateWallet
+	conflictOnNextUpdate bool
+	// afterFindTransaction вызывается один раз после следующего FindTransaction, чтобы вклинить параллельный запрос
+	afterFindTransaction func()
 }
 
 func newMockPaymentRepository() *mockPaymentRepository {
@@ -174,16 +207,40 @@ func (m *mockPaymentRepository) NextID() (uuid.UUID, error) {
 	return uuid.New(), nil
 }
 
+func (m *mockPaymentRepository) WithinTransaction(fn func(repo model.PaymentRepository) error) error {
+	wallets := make(map[uuid.UUID]*model.Wallet, len(m.storeWallets))
+	for id, w := range m.storeWallets {
+		wallets[id] = w
+	}
+	txs := append([]*model.Transaction(nil), m.storeTxs...)
+	statusChanges := append([]*model.WalletStatusChange(nil), m.storeStatusChanges...)
+
+	if err := fn(m); err != nil {
+		m.storeWallets = wallets
+		m.storeTxs = txs
+		m.storeStatusChanges = statusChanges
+		return err
+	}
+	return nil
+}
+
 func (m *mockPaymentRepository) CreateWallet(w *model.Wallet) error {
 	if _, exists := m.storeWallets[w.ID]; exists {
 		return errors.New("wallet already exists")
 	}
 	val := *w
 	m.storeWallets[w.ID] = &val
-	m.storeWallets[w.UserID] = &val
 	return nil
 }
 
+func (m *mockPaymentRepository) GetWalletByID(walletID uuid.UUID) (*model.Wallet, error) {
+	if w, ok := m.storeWallets[walletID]; ok {
+		val := *w
+		return &val, nil
+	}
+	return nil, model.ErrWalletNotFound
+}
+
 func (m *mockPaymentRepository) GetWalletByUserID(userID uuid.UUID) (*model.Wallet, error) {
 	for _, w := range m.storeWallets {
 		if w.UserID == userID {
@@ -200,6 +257,10 @@ func (m *mockPaymentRepository) UpdateWallet(w *model.Wallet) error {
 		return model.ErrWalletNotFound
 	}
 
+	if m.conflictOnNextUpdate {
+		m.conflictOnNextUpdate = false
+		return model.ErrOptimisticLock
+	}
 	if existing.Version != w.Version-1 {
 		return model.ErrOptimisticLock
 	}
@@ -209,12 +270,69 @@ func (m *mockPaymentRepository) UpdateWallet(w *model.Wallet) error {
 	return nil
 }
 
+func (m *mockPaymentRepository) SaveWalletStatusChange(change *model.WalletStatusChange) error {
+	val := *change
+	m.storeStatusChanges = append(m.storeStatusChanges, &val)
+	return nil
+}
+
 func (m *mockPaymentRepository) SaveTransaction(tx *model.Transaction) error {
 	val := *tx
 	m.storeTxs = append(m.storeTxs, &val)
 	return nil
 }
 
+func (m *mockPaymentRepository) ResolveTransaction(tx *model.Transaction) error {
+	for i, stored := range m.storeTxs {
+		if stored.ID == tx.ID {
+			if stored.Status != model.TxOnReview {
+				return model.ErrTransactionNotOnHold
+			}
+			val := *tx
+			m.storeTxs[i] = &val
+			return nil
+		}
+	}
+	return model.ErrTransactionNotFound
+}
+
+func (m *mockPaymentRepository) FindTransaction(id uuid.UUID) (*model.Transaction, error) {
+	for _, tx := range m.storeTxs {
+		if tx.ID == id {
+			val := *tx
+			if hook := m.afterFindTransaction; hook != nil {
+				m.afterFindTransaction = nil
+				hook()
+			}
+			return &val, nil
+		}
+	}
+	return nil, model.ErrTransactionNotFound
+}
+
+func (m *mockPaymentRepository) SumWithdrawalsSince(walletID uuid.UUID, since time.Time) (int64, error) {
+	var sum int64
+	for _, tx := range m.countedWithdrawalsSince(walletID, since) {
+		sum += tx.AmountCents
+	}
+	return sum, nil
+}
+
+func (m *mockPaymentRepository) CountWithdrawalsSince(walletID uuid.UUID, since time.Time) (int, error) {
+	return len(m.countedWithdrawalsSince(walletID, since)), nil
+}
+
+func (m *mockPaymentRepository) countedWithdrawalsSince(walletID uuid.UUID, since time.Time) []*model.Transaction {
+	var result []*model.Transaction
+	for _, tx := range m.storeTxs {
+		if tx.WalletID == walletID && tx.Type == model.Withdrawal &&
+			(tx.Status == model.TxCommitted || tx.Status == model.TxOnReview) && !tx.CreatedAt.Before(since) {
+			result = append(result, tx)
+		}
+	}
+	return result
+}
+
 func (m *mockPaymentRepository) FindTransactionByRef(walletID uuid.UUID, refID string) (*model.Transaction, error) {
 	for _, tx := range m.storeTxs {
 		if tx.WalletID == walletID && tx.ReferenceID == refID {
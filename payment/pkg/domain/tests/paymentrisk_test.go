@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"payment/pkg/domain/model"
+	"payment/pkg/domain/service"
+)
+
+func setupRiskTest(t *testing.T, rules ...func(repo model.PaymentRepository) model.RiskRule) (service.PaymentService, *mockPaymentRepository, *mockEventDispatcher) {
+	repo := newMockPaymentRepository()
+	dispatcher := &mockEventDispatcher{}
+	riskRules := make([]model.RiskRule, 0, len(rules))
+	for _, rule := range rules {
+		riskRules = append(riskRules, rule(repo))
+	}
+	svc := service.NewPaymentService(repo, dispatcher, riskRules...)
+	return svc, repo, dispatcher
+}
+
+func TestSpendingLimits_PerTransactionCap(t *testing.T) {
+	svc, repo, dispatcher := setupRiskTest(t, service.NewSpendingLimitRule)
+	userID := uuid.New()
+	wallet, _ := svc.CreateWallet(userID)
+	svc.Deposit(userID, 10000, "topup")
+	require.NoError(t, svc.SetSpendingLimits(userID, model.SpendingLimits{PerTransactionCents: 1000}))
+	dispatcher.Reset()
+
+	orderID := uuid.New()
+	err := svc.PayForOrder(userID, orderID, 1500)
+
+	assert.ErrorIs(t, err, model.ErrPaymentDenied)
+	balance, _ := svc.GetBalance(userID)
+	assert.Equal(t, int64(10000), balance)
+
+	tx, findErr := repo.FindTransactionByRef(wallet.ID, orderID.String())
+	require.NoError(t, findErr)
+	assert.Equal(t, model.TxFailed, tx.Status)
+	assert.Equal(t, model.ReasonPerTransactionLimit, tx.ReasonCode)
+
+	require.Len(t, dispatcher.events, 1)
+	event, ok := dispatcher.events[0].(model.PaymentFailed)
+	require.True(t, ok)
+	assert.Equal(t, model.ReasonPerTransactionLimit, event.ReasonCode)
+}
+
+func TestSpendingLimits_Daily(t *testing.T) {
+	svc, _, _ := setupRiskTest(t, service.NewSpendingLimitRule)
+	userID := uuid.New()
+	svc.CreateWallet(userID)
+	svc.Deposit(userID, 10000, "topup")
+	require.NoError(t, svc.SetSpendingLimits(userID, model.SpendingLimits{DailyCents: 1000, MonthlyCents: 5000}))
+
+	require.NoError(t, svc.PayForOrder(userID, uuid.New(), 600))
+	assert.ErrorIs(t, svc.PayForOrder(userID, uuid.New(), 600), model.ErrPaymentDenied)
+	require.NoError(t, svc.PayForOrder(userID, uuid.New(), 400))
+
+	balance, _ := svc.GetBalance(userID)
+	assert.Equal(t, int64(9000), balance)
+}
+
+func TestSpendingLimits_DailyCountsParkedPayments(t *testing.T) {
+	svc, _, _ := setupRiskTest(t, service.NewSpendingLimitRule, func(_ model.PaymentRepository) model.RiskRule {
+		return model.RiskRuleFunc(func(attempt model.PaymentAttempt) (model.RiskVerdict, error) {
+			if attempt.AmountCents >= 500 {
+				return model.Review("large_amount"), nil
+			}
+			return model.Allow(), nil
+		})
+	})
+	userID := uuid.New()
+	svc.CreateWallet(userID)
+	svc.Deposit(userID, 10000, "topup")
+	require.NoError(t, svc.SetSpendingLimits(userID, model.SpendingLimits{DailyCents: 1000}))
+
+	require.ErrorIs(t, svc.PayForOrder(userID, uuid.New(), 600), model.ErrPaymentUnderReview)
+	// Отложенный платёж уже занимает часть дневного лимита
+	assert.ErrorIs(t, svc.PayForOrder(userID, uuid.New(), 450), model.ErrPaymentDenied)
+	require.NoError(t, svc.PayForOrder(userID, uuid.New(), 400))
+}
+
+func TestPayForOrder_ReplaysFailure(t *testing.T) {
+	svc, repo, dispatcher := setupRiskTest(t, service.NewSpendingLimitRule)
+	userID := uuid.New()
+	svc.CreateWallet(userID)
+	svc.Deposit(userID, 1000, "topup")
+
+	insufficient, denied := uuid.New(), uuid.New()
+	require.ErrorIs(t, svc.PayForOrder(userID, insufficient, 5000), model.ErrInsufficientFunds)
+	require.NoError(t, svc.SetSpendingLimits(userID, model.SpendingLimits{PerTransactionCents: 100}))
+	require.ErrorIs(t, svc.PayForOrder(userID, denied, 500), model.ErrPaymentDenied)
+	dispatcher.Reset()
+
+	// Повтор возвращает сохранённый отказ, даже если теперь платёж прошёл бы
+	require.NoError(t, svc.SetSpendingLimits(userID, model.SpendingLimits{}))
+	assert.ErrorIs(t, svc.PayForOrder(userID, insufficient, 5000), model.ErrInsufficientFunds)
+	assert.ErrorIs(t, svc.PayForOrder(userID, denied, 500), model.ErrPaymentDenied)
+
+	assert.Empty(t, dispatcher.events)
+	assert.Len(t, repo.storeTxs, 3)
+	balance, _ := svc.GetBalance(userID)
+	assert.Equal(t, int64(1000), balance)
+}
+
+func TestSetSpendingLimits_Negative(t *testing.T) {
+	svc, _, _ := setupRiskTest(t)
+	userID := uuid.New()
+	svc.CreateWallet(userID)
+
+	err := svc.SetSpendingLimits(userID, model.SpendingLimits{DailyCents: -1})
+	assert.ErrorIs(t, err, model.ErrInvalidLimits)
+}
+
+func TestVelocityRule_Deny(t *testing.T) {
+	svc, _, _ := setupRiskTest(t, func(repo model.PaymentRepository) model.RiskRule {
+		return service.NewVelocityRule(repo, 2, 10*time.Minute, model.RiskDeny)
+	})
+	userID := uuid.New()
+	svc.CreateWallet(userID)
+	svc.Deposit(userID, 10000, "topup")
+
+	require.NoError(t, svc.PayForOrder(userID, uuid.New(), 100))
+	require.NoError(t, svc.PayForOrder(userID, uuid.New(), 100))
+	assert.ErrorIs(t, svc.PayForOrder(userID, uuid.New(), 100), model.ErrPaymentDenied)
+}
+
+func TestRiskReview_Approve(t *testing.T) {
+	svc, repo, dispatcher := setupRiskTest(t, func(_ model.PaymentRepository) model.RiskRule {
+		return model.RiskRuleFunc(func(attempt model.PaymentAttempt) (model.RiskVerdict, error) {
+			if attempt.AmountCents >= 1000 {
+				return model.Review("large_amount"), nil
+			}
+			return model.Allow(), nil
+		})
+	})
+	userID := uuid.New()
+	wallet, _ := svc.CreateWallet(userID)
+	svc.Deposit(userID, 5000, "topup")
+	dispatcher.Reset()
+
+	orderID := uuid.New()
+	err := svc.PayForOrder(userID, orderID, 1000)
+	assert.ErrorIs(t, err, model.ErrPaymentUnderReview)
+
+	balance, _ := svc.GetBalance(userID)
+	assert.Equal(t, int64(5000), balance)
+
+	tx, _ := repo.FindTransactionByRef(wallet.ID, orderID.String())
+	require.NotNil(t, tx)
+	assert.Equal(t, model.TxOnReview, tx.Status)
+	require.Len(t, dispatcher.events, 1)
+	_, ok := dispatcher.events[0].(model.PaymentParkedForReview)
+	assert.True(t, ok)
+
+	// Повторная оплата того же заказа не создаёт новую транзакцию и сообщает, что платёж всё ещё на проверке
+	assert.ErrorIs(t, svc.PayForOrder(userID, orderID, 1000), model.ErrPaymentUnderReview)
+	require.Len(t, dispatcher.events, 1)
+
+	dispatcher.Reset()
+	require.NoError(t, svc.ApproveTransaction(tx.ID))
+
+	balance, _ = svc.GetBalance(userID)
+	assert.Equal(t, int64(4000), balance)
+	tx, _ = repo.FindTransaction(tx.ID)
+	assert.Equal(t, model.TxCommitted, tx.Status)
+	require.Len(t, dispatcher.events, 1)
+	_, ok = dispatcher.events[0].(model.FundsWithdrawn)
+	assert.True(t, ok)
+
+	assert.ErrorIs(t, svc.ApproveTransaction(tx.ID), model.ErrTransactionNotOnHold)
+}
+
+func TestRiskReview_Reject(t *testing.T) {
+	svc, repo, dispatcher := setupRiskTest(t, func(_ model.PaymentRepository) model.RiskRule {
+		return model.RiskRuleFunc(func(_ model.PaymentAttempt) (model.RiskVerdict, error) {
+			return model.Review("manual"), nil
+		})
+	})
+	userID := uuid.New()
+	wallet, _ := svc.CreateWallet(userID)
+	svc.Deposit(userID, 5000, "topup")
+
+	orderID := uuid.New()
+	require.ErrorIs(t, svc.PayForOrder(userID, orderID, 1000), model.ErrPaymentUnderReview)
+	tx, _ := repo.FindTransactionByRef(wallet.ID, orderID.String())
+	dispatcher.Reset()
+
+	require.NoError(t, svc.RejectTransaction(tx.ID))
+
+	tx, _ = repo.FindTransaction(tx.ID)
+	assert.Equal(t, model.TxFailed, tx.Status)
+	assert.Equal(t, model.ReasonRejectedByManualReview, tx.ReasonCode)
+	require.Len(t, dispatcher.events, 1)
+	_, ok := dispatcher.events[0].(model.PaymentFailed)
+	assert.True(t, ok)
+
+	balance, _ := svc.GetBalance(userID)
+	assert.Equal(t, int64(5000), balance)
+}
+
+func TestRiskReview_ConcurrentApproveAndReject(t *testing.T) {
+	svc, repo, _ := setupRiskTest(t, func(_ model.PaymentRepository) model.RiskRule {
+		return model.RiskRuleFunc(func(_ model.PaymentAttempt) (model.RiskVerdict, error) {
+			return model.Review("manual"), nil
+		})
+	})
+	userID := uuid.New()
+	wallet, _ := svc.CreateWallet(userID)
+	svc.Deposit(userID, 5000, "topup")
+
+	orderID := uuid.New()
+	require.ErrorIs(t, svc.PayForOrder(userID, orderID, 1000), model.ErrPaymentUnderReview)
+	tx, _ := repo.FindTransactionByRef(wallet.ID, orderID.String())
+
+	// Reject успевает между чтением транзакции и записью решения в Approve
+	repo.afterFindTransaction = func() {
+		require.NoError(t, svc.RejectTransaction(tx.ID))
+	}
+	assert.ErrorIs(t, svc.ApproveTransaction(tx.ID), model.ErrTransactionNotOnHold)
+
+	balance, _ := svc.GetBalance(userID)
+	assert.Equal(t, int64(5000), balance)
+	tx, _ = repo.FindTransaction(tx.ID)
+	assert.Equal(t, model.TxFailed, tx.Status)
+	assert.Equal(t, model.ReasonRejectedByManualReview, tx.ReasonCode)
+}
+
+func TestRiskReview_ParkingConflictsWithConcurrentPayment(t *testing.T) {
+	var svc service.PaymentService
+	userID := uuid.New()
+	concurrentOrderID := uuid.New()
+	concurrent := true
+	svc, repo, _ := setupRiskTest(t, func(_ model.PaymentRepository) model.RiskRule {
+		return model.RiskRuleFunc(func(_ model.PaymentAttempt) (model.RiskVerdict, error) {
+			// Второй платёж паркуется, пока первый проверяет правила по той же версии кошелька
+			if concurrent {
+				concurrent = false
+				require.ErrorIs(t, svc.PayForOrder(userID, concurrentOrderID, 1000), model.ErrPaymentUnderReview)
+			}
+			return model.Review("manual"), nil
+		})
+	})
+	wallet, _ := svc.CreateWallet(userID)
+	svc.Deposit(userID, 5000, "topup")
+
+	orderID := uuid.New()
+	assert.ErrorIs(t, svc.PayForOrder(userID, orderID, 1000), model.ErrOptimisticLock)
+
+	tx, _ := repo.FindTransactionByRef(wallet.ID, orderID.String())
+	assert.Nil(t, tx)
+	tx, _ = repo.FindTransactionByRef(wallet.ID, concurrentOrderID.String())
+	require.NotNil(t, tx)
+	assert.Equal(t, model.TxOnReview, tx.Status)
+}
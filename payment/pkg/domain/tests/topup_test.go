@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"payment/pkg/domain/model"
+	"payment/pkg/domain/service"
+)
+
+func setupTopUpTest(t *testing.T) (service.TopUpService, service.PaymentService, *mockTopUpIntentRepository, *mockEventDispatcher) {
+	paymentService, _, dispatcher := setupPaymentTest(t)
+	repo := &mockTopUpIntentRepository{store: make(map[uuid.UUID]*model.TopUpIntent)}
+	topUpService := service.NewTopUpService(repo, &mockGateway{}, paymentService, dispatcher)
+	return topUpService, paymentService, repo, dispatcher
+}
+
+func TestCreateTopUpIntent(t *testing.T) {
+	topUpService, _, repo, _ := setupTopUpTest(t)
+	userID := uuid.New()
+
+	intent, err := topUpService.CreateTopUpIntent(userID, 2500)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.IntentPending, intent.Status)
+	assert.Equal(t, "psp_"+intent.ID.String(), intent.ProviderReference)
+	assert.NotEmpty(t, intent.RedirectURL)
+
+	saved, err := repo.Find(intent.ID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, saved.UserID)
+
+	_, err = topUpService.CreateTopUpIntent(userID, 0)
+	assert.ErrorIs(t, err, model.ErrInvalidAmount)
+}
+
+func TestCompleteTopUp_Idempotent(t *testing.T) {
+	topUpService, paymentService, repo, dispatcher := setupTopUpTest(t)
+	userID := uuid.New()
+	paymentService.CreateWallet(userID)
+	intent, _ := topUpService.CreateTopUpIntent(userID, 2500)
+	dispatcher.Reset()
+
+	require.NoError(t, topUpService.CompleteTopUp(intent.ID, intent.ProviderReference))
+	require.NoError(t, topUpService.CompleteTopUp(intent.ID, intent.ProviderReference))
+
+	balance, _ := paymentService.GetBalance(userID)
+	assert.Equal(t, int64(2500), balance)
+	saved, _ := repo.Find(intent.ID)
+	assert.Equal(t, model.IntentSucceeded, saved.Status)
+
+	require.Len(t, dispatcher.events, 1)
+	event, ok := dispatcher.events[0].(model.FundsDeposited)
+	require.True(t, ok)
+	assert.Equal(t, intent.ID.String(), event.ReferenceID)
+
+	assert.ErrorIs(t, topUpService.FailTopUp(intent.ID, intent.ProviderReference, "late failure"), model.ErrTopUpIntentFinalized)
+}
+
+func TestFailTopUp(t *testing.T) {
+	topUpService, paymentService, repo, dispatcher := setupTopUpTest(t)
+	userID := uuid.New()
+	paymentService.CreateWallet(userID)
+	intent, _ := topUpService.CreateTopUpIntent(userID, 2500)
+	dispatcher.Reset()
+
+	require.NoError(t, topUpService.FailTopUp(intent.ID, intent.ProviderReference, "card declined"))
+
+	saved, _ := repo.Find(intent.ID)
+	assert.Equal(t, model.IntentFailed, saved.Status)
+	assert.Equal(t, "card declined", saved.FailureReason)
+	require.Len(t, dispatcher.events, 1)
+	_, ok := dispatcher.events[0].(model.TopUpFailed)
+	assert.True(t, ok)
+
+	assert.ErrorIs(t, topUpService.CompleteTopUp(intent.ID, intent.ProviderReference), model.ErrTopUpIntentFinalized)
+	balance, _ := paymentService.GetBalance(userID)
+	assert.Equal(t, int64(0), balance)
+}
+
+func TestCompleteTopUp_UnknownIntent(t *testing.T) {
+	topUpService, _, _, _ := setupTopUpTest(t)
+
+	assert.ErrorIs(t, topUpService.CompleteTopUp(uuid.New(), "psp_unknown"), model.ErrTopUpIntentNotFound)
+}
+
+func TestCompleteTopUp_FrozenWalletRequiresRefund(t *testing.T) {
+	topUpService, paymentService, repo, dispatcher := setupTopUpTest(t)
+	userID := uuid.New()
+	paymentService.CreateWallet(userID)
+	intent, _ := topUpService.CreateTopUpIntent(userID, 2500)
+	require.NoError(t, paymentService.FreezeWallet(userID, "fraud check"))
+	dispatcher.Reset()
+
+	// Вебхук подтверждается, чтобы провайдер не повторял его, а интент не остаётся в ожидании
+	require.NoError(t, topUpService.CompleteTopUp(intent.ID, intent.ProviderReference))
+	require.NoError(t, topUpService.CompleteTopUp(intent.ID, intent.ProviderReference))
+
+	saved, _ := repo.Find(intent.ID)
+	assert.Equal(t, model.IntentRefundRequired, saved.Status)
+	assert.Equal(t, model.ReasonWalletFrozen, saved.FailureReason)
+	balance, _ := paymentService.GetBalance(userID)
+	assert.Equal(t, int64(0), balance)
+
+	require.Len(t, dispatcher.events, 1)
+	event, ok := dispatcher.events[0].(model.TopUpRefundRequired)
+	require.True(t, ok)
+	assert.Equal(t, intent.ProviderReference, event.ProviderReference)
+	assert.Equal(t, int64(2500), event.AmountCents)
+
+	assert.ErrorIs(t, topUpService.FailTopUp(intent.ID, intent.ProviderReference, "late failure"), model.ErrTopUpIntentFinalized)
+}
+
+func TestCompleteTopUp_ProviderReferenceMismatch(t *testing.T) {
+	topUpService, paymentService, repo, _ := setupTopUpTest(t)
+	userID := uuid.New()
+	paymentService.CreateWallet(userID)
+	intent, _ := topUpService.CreateTopUpIntent(userID, 2500)
+
+	assert.ErrorIs(t, topUpService.CompleteTopUp(intent.ID, "psp_other"), model.ErrProviderReferenceMismatch)
+	assert.ErrorIs(t, topUpService.FailTopUp(intent.ID, "psp_other", "card declined"), model.ErrProviderReferenceMismatch)
+
+	saved, _ := repo.Find(intent.ID)
+	assert.Equal(t, model.IntentPending, saved.Status)
+	balance, _ := paymentService.GetBalance(userID)
+	assert.Equal(t, int64(0), balance)
+}
+
+type mockTopUpIntentRepository struct {
+	store map[uuid.UUID]*model.TopUpIntent
+}
+
+func (m *mockTopUpIntentRepository) NextID() (uuid.UUID, error) { return uuid.New(), nil }
+
+func (m *mockTopUpIntentRepository) Create(intent *model.TopUpIntent) error {
+	val := *intent
+	m.store[intent.ID] = &val
+	return nil
+}
+
+func (m *mockTopUpIntentRepository) Update(intent *model.TopUpIntent) error {
+	if _, ok := m.store[intent.ID]; !ok {
+		return model.ErrTopUpIntentNotFound
+	}
+	val := *intent
+	m.store[intent.ID] = &val
+	return nil
+}
+
+func (m *mockTopUpIntentRepository) Find(id uuid.UUID) (*model.TopUpIntent, error) {
+	if intent, ok := m.store[id]; ok {
+		val := *intent
+		return &val, nil
+	}
+	return nil, model.ErrTopUpIntentNotFound
+}
+
+type mockGateway struct{}
+
+func (m *mockGateway) CreateIntent(intentID uuid.UUID, amountCents int64, _ string) (model.GatewayIntent, error) {
+	if amountCents <= 0 {
+		return model.GatewayIntent{}, errors.New("invalid amount")
+	}
+	return model.GatewayIntent{
+		ProviderReference: "psp_" + intentID.String(),
+		RedirectURL:       "https://psp.example.com/checkout/" + intentID.String(),
+	}, nil
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"payment/pkg/domain/model"
+	"payment/pkg/domain/service"
+)
+
+func TestFreezeWallet_RejectsWithdrawalsButAcceptsRefunds(t *testing.T) {
+	svc, repo, dispatcher := setupPaymentTest(t)
+	userID := uuid.New()
+	wallet, _ := svc.CreateWallet(userID)
+	svc.Deposit(userID, 1000, "topup")
+	dispatcher.Reset()
+
+	require.NoError(t, svc.FreezeWallet(userID, "compromised account"))
+
+	require.Len(t, dispatcher.events, 1)
+	frozen, ok := dispatcher.events[0].(model.WalletFrozen)
+	require.True(t, ok)
+	assert.Equal(t, "compromised account", frozen.Reason)
+	require.Len(t, repo.storeStatusChanges, 1)
+	assert.Equal(t, model.WalletStatusActive, repo.storeStatusChanges[0].OldStatus)
+	assert.Equal(t, model.WalletStatusFrozen, repo.storeStatusChanges[0].NewStatus)
+
+	orderID := uuid.New()
+	err := svc.PayForOrder(userID, orderID, 100)
+	assert.ErrorIs(t, err, model.ErrWalletFrozen)
+	tx, _ := repo.FindTransactionByRef(wallet.ID, orderID.String())
+	require.NotNil(t, tx)
+	assert.Equal(t, model.TxFailed, tx.Status)
+	assert.Equal(t, model.ReasonWalletFrozen, tx.ReasonCode)
+
+	_, err = svc.Deposit(userID, 100, "topup_2")
+	assert.ErrorIs(t, err, model.ErrWalletFrozen)
+
+	updated, err := svc.RefundOrder(userID, uuid.New(), 300)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1300), updated.BalanceCents)
+
+	require.NoError(t, svc.UnfreezeWallet(userID, "owner verified"))
+	require.NoError(t, svc.PayForOrder(userID, uuid.New(), 100))
+}
+
+func TestFreezeWallet_RequiresReason(t *testing.T) {
+	svc, _, _ := setupPaymentTest(t)
+	userID := uuid.New()
+	svc.CreateWallet(userID)
+
+	assert.ErrorIs(t, svc.FreezeWallet(userID, ""), model.ErrReasonRequired)
+}
+
+func TestCloseWallet(t *testing.T) {
+	svc, _, dispatcher := setupPaymentTest(t)
+	userID := uuid.New()
+	svc.CreateWallet(userID)
+	svc.Deposit(userID, 500, "topup")
+
+	assert.ErrorIs(t, svc.CloseWallet(userID, "user request"), model.ErrWalletNotEmpty)
+
+	require.NoError(t, svc.PayForOrder(userID, uuid.New(), 500))
+	dispatcher.Reset()
+	require.NoError(t, svc.CloseWallet(userID, "user request"))
+
+	require.Len(t, dispatcher.events, 1)
+	_, ok := dispatcher.events[0].(model.WalletClosed)
+	assert.True(t, ok)
+
+	_, err := svc.RefundOrder(userID, uuid.New(), 100)
+	assert.ErrorIs(t, err, model.ErrWalletClosed)
+	assert.ErrorIs(t, svc.UnfreezeWallet(userID, "mistake"), model.ErrWalletClosed)
+}
+
+func TestForceCloseWallet_PaysOutBalance(t *testing.T) {
+	svc, repo, dispatcher := setupPaymentTest(t)
+	userID := uuid.New()
+	wallet, _ := svc.CreateWallet(userID)
+	svc.Deposit(userID, 700, "topup")
+	require.NoError(t, svc.FreezeWallet(userID, "fraud investigation"))
+	dispatcher.Reset()
+
+	require.NoError(t, svc.ForceCloseWallet(userID, "payout_1", "account terminated"))
+
+	balance, _ := svc.GetBalance(userID)
+	assert.Equal(t, int64(0), balance)
+	tx, _ := repo.FindTransactionByRef(wallet.ID, "payout_1")
+	require.NotNil(t, tx)
+	assert.Equal(t, model.Payout, tx.Type)
+	assert.Equal(t, int64(700), tx.AmountCents)
+
+	require.Len(t, dispatcher.events, 1)
+	event, ok := dispatcher.events[0].(model.WalletClosed)
+	require.True(t, ok)
+	assert.Equal(t, int64(700), event.PaidOutCents)
+	assert.Equal(t, "payout_1", event.PayoutReference)
+}
+
+func TestForceCloseWallet_RepeatIsNoOp(t *testing.T) {
+	svc, repo, dispatcher := setupPaymentTest(t)
+	userID := uuid.New()
+	wallet, _ := svc.CreateWallet(userID)
+
+	assert.ErrorIs(t, svc.ForceCloseWallet(userID, "", "account terminated"), model.ErrPayoutReferenceRequired)
+
+	require.NoError(t, svc.ForceCloseWallet(userID, "payout_1", "account terminated"))
+	dispatcher.Reset()
+
+	// Нулевой остаток тоже фиксируется выплатой, по которой узнаётся повтор
+	require.NoError(t, svc.ForceCloseWallet(userID, "payout_1", "account terminated"))
+	assert.ErrorIs(t, svc.ForceCloseWallet(userID, "payout_2", "account terminated"), model.ErrWalletClosed)
+
+	assert.Empty(t, dispatcher.events)
+	require.Len(t, repo.storeTxs, 1)
+	assert.Equal(t, model.Payout, repo.storeTxs[0].Type)
+	assert.Equal(t, wallet.ID, repo.storeTxs[0].WalletID)
+	assert.Zero(t, repo.storeTxs[0].AmountCents)
+}
+
+func TestUserEventHandler_FreezesWallet(t *testing.T) {
+	svc, _, dispatcher := setupPaymentTest(t)
+	handler := service.NewUserEventHandler(svc)
+	userID := uuid.New()
+	svc.CreateWallet(userID)
+	dispatcher.Reset()
+
+	require.NoError(t, handler.HandleUserSuspended(userID))
+	require.NoError(t, handler.HandleUserDeactivated(userID))
+	require.NoError(t, handler.HandleUserDeactivated(uuid.New()))
+
+	require.Len(t, dispatcher.events, 1)
+	_, ok := dispatcher.events[0].(model.WalletFrozen)
+	assert.True(t, ok)
+	assert.ErrorIs(t, svc.PayForOrder(userID, uuid.New(), 1), model.ErrWalletFrozen)
+}
@@ -0,0 +1,56 @@
+package gateway
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"payment/pkg/domain/model"
+)
+
+// FakeCheckoutPath - путь страницы оплаты заглушки, её обслуживает NewFakeCheckoutHandler
+const FakeCheckoutPath = "/fake-checkout/"
+
+// NewFakeGateway - локальная заглушка PSP для разработки и тестов.
+// Вместо реальной оплаты страница redirectBaseURL вызывает вебхук, подписанный тем же секретом
+func NewFakeGateway(redirectBaseURL string, webhookSecret []byte) *FakeGateway {
+	return &FakeGateway{
+		redirectBaseURL: strings.TrimRight(redirectBaseURL, "/"),
+		webhookSecret:   webhookSecret,
+	}
+}
+
+type FakeGateway struct {
+	redirectBaseURL string
+	webhookSecret   []byte
+}
+
+func (g *FakeGateway) CreateIntent(intentID uuid.UUID, amountCents int64, _ string) (model.GatewayIntent, error) {
+	if amountCents <= 0 {
+		return model.GatewayIntent{}, model.ErrInvalidAmount
+	}
+	return model.GatewayIntent{
+		ProviderReference: g.providerReference(intentID),
+		RedirectURL:       g.redirectBaseURL + FakeCheckoutPath + intentID.String(),
+	}, nil
+}
+
+// Webhook собирает тело и подпись вебхука так, как их прислал бы провайдер
+func (g *FakeGateway) Webhook(intentID uuid.UUID, status, failureReason string) (body []byte, signature string, err error) {
+	body, err = json.Marshal(WebhookPayload{
+		IntentID:          intentID.String(),
+		ProviderReference: g.providerReference(intentID),
+		Status:            status,
+		FailureReason:     failureReason,
+	})
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	return body, Sign(g.webhookSecret, body), nil
+}
+
+func (g *FakeGateway) providerReference(intentID uuid.UUID) string {
+	return "fake_" + intentID.String()
+}
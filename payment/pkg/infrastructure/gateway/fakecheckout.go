@@ -0,0 +1,83 @@
+package gateway
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// NewFakeCheckoutHandler обслуживает страницу оплаты заглушки: запрос FakeCheckoutPath + {intentID}
+// отправляет на webhookURL подписанный вебхук, как это сделал бы провайдер после оплаты.
+// ?status=failed&reason=... имитирует отказ, по умолчанию оплата успешна
+func NewFakeCheckoutHandler(gateway *FakeGateway, webhookURL string, client *http.Client, logger *log.Logger) http.Handler {
+	return &fakeCheckoutHandler{
+		gateway:    gateway,
+		webhookURL: webhookURL,
+		client:     client,
+		logger:     logger,
+	}
+}
+
+type fakeCheckoutHandler struct {
+	gateway    *FakeGateway
+	webhookURL string
+	client     *http.Client
+	logger     *log.Logger
+}
+
+func (h *fakeCheckoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	intentID, err := uuid.Parse(strings.TrimPrefix(r.URL.Path, FakeCheckoutPath))
+	if err != nil {
+		http.Error(w, "invalid intent id", http.StatusBadRequest)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = WebhookStatusSucceeded
+	}
+	if status != WebhookStatusSucceeded && status != WebhookStatusFailed {
+		http.Error(w, "status must be succeeded or failed", http.StatusBadRequest)
+		return
+	}
+
+	body, signature, err := h.gateway.Webhook(intentID, status, r.URL.Query().Get("reason"))
+	if err != nil {
+		h.logger.WithField("intentID", intentID).Errorf("fake checkout failed to build webhook: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	request, err := http.NewRequestWithContext(r.Context(), http.MethodPost, h.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		h.logger.WithField("intentID", intentID).Errorf("fake checkout failed to build webhook request: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, signature)
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		h.logger.WithField("intentID", intentID).Errorf("fake checkout failed to deliver webhook: %v", err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	_ = response.Body.Close()
+
+	// Ответ вебхука показывается как есть, чтобы при ручной проверке было видно, принят ли платёж
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		http.Error(w, fmt.Sprintf("payment %s, webhook answered %d", status, response.StatusCode), http.StatusBadGateway)
+		return
+	}
+	_, _ = fmt.Fprintf(w, "payment %s\n", status)
+}
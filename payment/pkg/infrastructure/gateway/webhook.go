@@ -0,0 +1,14 @@
+package gateway
+
+const (
+	WebhookStatusSucceeded = "succeeded"
+	WebhookStatusFailed    = "failed"
+)
+
+// WebhookPayload - тело уведомления провайдера о результате оплаты интента
+type WebhookPayload struct {
+	IntentID          string `json:"intent_id"`
+	ProviderReference string `json:"provider_reference"`
+	Status            string `json:"status"`
+	FailureReason     string `json:"failure_reason,omitempty"`
+}
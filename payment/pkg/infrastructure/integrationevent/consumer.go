@@ -0,0 +1,129 @@
+package integrationevent
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+	log "github.com/sirupsen/logrus"
+)
+
+type ConsumerConfig struct {
+	URL      string
+	Queue    string
+	Prefetch int
+	// RetryDelay - пауза перед повторной доставкой сообщения и переподключением к брокеру
+	RetryDelay time.Duration
+}
+
+type Consumer interface {
+	// Run читает очередь до отмены ctx, переподключаясь при обрыве соединения
+	Run(ctx context.Context) error
+}
+
+func NewConsumer(config ConsumerConfig, handler MessageHandler, logger *log.Logger) Consumer {
+	return &consumer{
+		config:  config,
+		handler: handler,
+		logger:  logger,
+	}
+}
+
+type consumer struct {
+	config  ConsumerConfig
+	handler MessageHandler
+	logger  *log.Logger
+}
+
+func (c *consumer) Run(ctx context.Context) error {
+	for {
+		err := c.consume(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		c.logger.WithError(err).Errorf("amqp consumer stopped, reconnecting in %v", c.config.RetryDelay)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.config.RetryDelay):
+		}
+	}
+}
+
+func (c *consumer) consume(ctx context.Context) error {
+	connection, err := amqp.Dial(c.config.URL)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to amqp")
+	}
+	defer connection.Close()
+
+	channel, err := connection.Channel()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer channel.Close()
+
+	deliveries, err := c.setup(channel)
+	if err != nil {
+		return err
+	}
+	c.logger.Infof("consuming %s bound to %s", c.config.Queue, ExchangeName)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return errors.New("amqp delivery channel closed")
+			}
+			if err = c.handle(ctx, delivery); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *consumer) setup(channel *amqp.Channel) (<-chan amqp.Delivery, error) {
+	err := channel.ExchangeDeclare(ExchangeName, ExchangeKind, true, false, false, false, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	queue, err := channel.QueueDeclare(c.config.Queue, true, false, false, false, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, routingKey := range RoutingKeys {
+		if err = channel.QueueBind(queue.Name, routingKey, ExchangeName, false, nil); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if err = channel.Qos(c.config.Prefetch, 0, false); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	deliveries, err := channel.Consume(queue.Name, "", false, false, false, false, nil)
+	return deliveries, errors.WithStack(err)
+}
+
+// handle подтверждает сообщение, если обработчик его принял, иначе возвращает в очередь после паузы
+func (c *consumer) handle(ctx context.Context, delivery amqp.Delivery) error {
+	// Транспорт outbox в других сервисах не проставляет MessageId, поэтому берётся correlation id события
+	id := delivery.MessageId
+	if id == "" {
+		id = delivery.CorrelationId
+	}
+	err := c.handler.Handle(Message{
+		ID:         id,
+		RoutingKey: delivery.RoutingKey,
+		Body:       delivery.Body,
+	})
+	if err == nil {
+		return errors.WithStack(delivery.Ack(false))
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(c.config.RetryDelay):
+	}
+	return errors.WithStack(delivery.Nack(false, true))
+}
@@ -0,0 +1,63 @@
+package integrationevent
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"payment/pkg/domain/model"
+	"payment/pkg/domain/service"
+)
+
+// NewEventDispatcher публикует в domain_event_exchange события, на которые подписаны другие сервисы,
+// и передаёт все события в next. Outbox у сервиса нет: при недоступном брокере событие теряется и
+// остаётся только в логе
+func NewEventDispatcher(publisher Publisher, next service.EventDispatcher, logger *log.Logger) service.EventDispatcher {
+	return &eventDispatcher{
+		publisher: publisher,
+		next:      next,
+		logger:    logger,
+	}
+}
+
+type eventDispatcher struct {
+	publisher Publisher
+	next      service.EventDispatcher
+	logger    *log.Logger
+}
+
+func (d *eventDispatcher) Dispatch(event service.Event) error {
+	if err := d.next.Dispatch(event); err != nil {
+		return err
+	}
+
+	routingKey, payload, ok := toIntegrationEvent(event)
+	if !ok {
+		return nil
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = d.publisher.Publish(routingKey, body); err != nil {
+		d.logger.WithError(err).WithField("routingKey", routingKey).Error("failed to publish integration event")
+		return err
+	}
+	return nil
+}
+
+func toIntegrationEvent(event service.Event) (string, interface{}, bool) {
+	switch e := event.(type) {
+	case model.PaymentFailed:
+		return PaymentFailedRoutingKey, PaymentFailed{
+			WalletID:    e.WalletID.String(),
+			UserID:      e.UserID.String(),
+			ReferenceID: e.ReferenceID,
+			Reason:      e.Reason,
+			ReasonCode:  e.ReasonCode,
+		}, true
+	default:
+		return "", nil, false
+	}
+}
@@ -0,0 +1,46 @@
+package integrationevent
+
+const (
+	ExchangeName = "domain_event_exchange"
+	ExchangeKind = "topic"
+
+	// События публикует outbox сервиса user2 (integrationevent.NewTransport): "user." + тип события
+	UserUpdatedRoutingKey = "user.user_updated"
+	UserDeletedRoutingKey = "user.user_deleted"
+
+	PaymentFailedRoutingKey = "payment.payment_failed"
+)
+
+// RoutingKeys - события, на которые подписана очередь сервиса
+var RoutingKeys = []string{
+	UserUpdatedRoutingKey,
+	UserDeletedRoutingKey,
+}
+
+// userStatusBlocked совпадает с model.Blocked сервиса user2
+const userStatusBlocked = 0
+
+// Форматы событий совпадают с сериализатором user2; поля, которые сервису не нужны, не разбираются
+
+// UserUpdated - в UpdatedFields приходят только изменившиеся поля
+type UserUpdated struct {
+	UserID        string `json:"user_id"`
+	UpdatedFields *struct {
+		Status *int `json:"status,omitempty"`
+	} `json:"updated_fields,omitempty"`
+}
+
+type UserDeleted struct {
+	UserID string `json:"user_id"`
+}
+
+// Публикуемые сервисом события; формат совпадает с тем, что ждёт сервис уведомлений
+
+// PaymentFailed - ReferenceID платежа за заказ равен идентификатору заказа
+type PaymentFailed struct {
+	WalletID    string `json:"wallet_id"`
+	UserID      string `json:"user_id"`
+	ReferenceID string `json:"reference_id"`
+	Reason      string `json:"reason"`
+	ReasonCode  string `json:"reason_code"`
+}
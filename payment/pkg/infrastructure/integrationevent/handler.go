@@ -0,0 +1,105 @@
+package integrationevent
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"payment/pkg/domain/service"
+)
+
+// ErrPoisonMessage - сообщение не разбирается или не проходит проверку, повторная доставка не поможет
+var ErrPoisonMessage = errors.New("message cannot be processed")
+
+type Message struct {
+	ID         string
+	RoutingKey string
+	Body       []byte
+}
+
+type MessageHandler interface {
+	// Handle возвращает ошибку, только если сообщение нужно доставить повторно
+	Handle(message Message) error
+}
+
+// NewMessageHandler не ведёт inbox: заморозка кошелька идемпотентна, и повторная доставка ничего не меняет
+func NewMessageHandler(userEventHandler service.UserEventHandler, logger *log.Logger) MessageHandler {
+	return &messageHandler{
+		userEventHandler: userEventHandler,
+		logger:           logger,
+	}
+}
+
+type messageHandler struct {
+	userEventHandler service.UserEventHandler
+	logger           *log.Logger
+}
+
+func (h *messageHandler) Handle(message Message) error {
+	l := h.logger.WithFields(log.Fields{
+		"messageID":  message.ID,
+		"routingKey": message.RoutingKey,
+	})
+
+	err := h.dispatch(message)
+	switch {
+	case err == nil:
+		l.Info("message processed")
+		return nil
+	case errors.Is(err, ErrPoisonMessage):
+		// Сообщение подтверждается, чтобы не блокировать очередь; разбирать его придётся по логу
+		l.WithError(err).Warn("message skipped")
+		return nil
+	default:
+		l.WithError(err).Error("failed to process message")
+		return err
+	}
+}
+
+func (h *messageHandler) dispatch(message Message) error {
+	switch message.RoutingKey {
+	case UserUpdatedRoutingKey:
+		var event UserUpdated
+		if err := decode(message.Body, &event); err != nil {
+			return err
+		}
+		userID, err := parseUUID(event.UserID)
+		if err != nil {
+			return err
+		}
+		// Кошелёк замораживается только блокировкой; разблокировка и смена контактов его не трогают
+		if event.UpdatedFields == nil || event.UpdatedFields.Status == nil || *event.UpdatedFields.Status != userStatusBlocked {
+			return nil
+		}
+		return h.userEventHandler.HandleUserSuspended(userID)
+	case UserDeletedRoutingKey:
+		var event UserDeleted
+		if err := decode(message.Body, &event); err != nil {
+			return err
+		}
+		userID, err := parseUUID(event.UserID)
+		if err != nil {
+			return err
+		}
+		return h.userEventHandler.HandleUserDeactivated(userID)
+	default:
+		return errors.Wrapf(ErrPoisonMessage, "unknown routing key %q", message.RoutingKey)
+	}
+}
+
+func decode(body []byte, event interface{}) error {
+	if err := json.Unmarshal(body, event); err != nil {
+		return errors.Wrapf(ErrPoisonMessage, "invalid payload: %v", err)
+	}
+	return nil
+}
+
+func parseUUID(value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, errors.Wrapf(ErrPoisonMessage, "invalid id %q", value)
+	}
+	return id, nil
+}
@@ -0,0 +1,95 @@
+package integrationevent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const publishTimeout = 5 * time.Second
+
+type Publisher interface {
+	Publish(routingKey string, body []byte) error
+	Close() error
+}
+
+// NewPublisher подключается к брокеру при первой публикации и переподключается после ошибки,
+// чтобы сервис запускался и без доступного брокера
+func NewPublisher(url string) Publisher {
+	return &publisher{url: url}
+}
+
+type publisher struct {
+	url string
+
+	mu         sync.Mutex
+	connection *amqp.Connection
+	channel    *amqp.Channel
+}
+
+func (p *publisher) Publish(routingKey string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	channel, err := p.ensureChannel()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	err = channel.PublishWithContext(ctx, ExchangeName, routingKey, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		// Получатели отсеивают повторные доставки по MessageId
+		MessageId: uuid.NewString(),
+		Timestamp: time.Now().UTC(),
+		Body:      body,
+	})
+	if err != nil {
+		p.reset()
+		return errors.Wrapf(err, "failed to publish %s", routingKey)
+	}
+	return nil
+}
+
+func (p *publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset()
+	return nil
+}
+
+func (p *publisher) ensureChannel() (*amqp.Channel, error) {
+	if p.channel != nil && !p.channel.IsClosed() {
+		return p.channel, nil
+	}
+	p.reset()
+
+	connection, err := amqp.Dial(p.url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to amqp")
+	}
+	channel, err := connection.Channel()
+	if err != nil {
+		_ = connection.Close()
+		return nil, errors.WithStack(err)
+	}
+	if err = channel.ExchangeDeclare(ExchangeName, ExchangeKind, true, false, false, false, nil); err != nil {
+		_ = connection.Close()
+		return nil, errors.WithStack(err)
+	}
+	p.connection, p.channel = connection, channel
+	return channel, nil
+}
+
+func (p *publisher) reset() {
+	if p.connection != nil {
+		_ = p.connection.Close()
+	}
+	p.connection, p.channel = nil, nil
+}
@@ -0,0 +1,295 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"payment/pkg/domain/model"
+)
+
+func NewPaymentRepository(db *sqlx.DB) model.PaymentRepository {
+	return &paymentRepository{db: db, executor: db}
+}
+
+// dbExecutor - общее у *sqlx.DB и *sqlx.Tx, чтобы одни и те же запросы работали внутри транзакции и вне её
+type dbExecutor interface {
+	sqlx.Execer
+	Get(dest interface{}, query string, args ...interface{}) error
+}
+
+type paymentRepository struct {
+	// db равен nil у репозитория, привязанного к транзакции
+	db       *sqlx.DB
+	executor dbExecutor
+}
+
+type sqlxWallet struct {
+	ID                       uuid.UUID `db:"id"`
+	UserID                   uuid.UUID `db:"user_id"`
+	BalanceCents             int64     `db:"balance_cents"`
+	Currency                 string    `db:"currency"`
+	Version                  int       `db:"version"`
+	Status                   int       `db:"status"`
+	StatusReason             string    `db:"status_reason"`
+	PerTransactionLimitCents int64     `db:"per_transaction_limit_cents"`
+	DailyLimitCents          int64     `db:"daily_limit_cents"`
+	MonthlyLimitCents        int64     `db:"monthly_limit_cents"`
+	CreatedAt                time.Time `db:"created_at"`
+	UpdatedAt                time.Time `db:"updated_at"`
+}
+
+type sqlxTransaction struct {
+	ID           uuid.UUID `db:"id"`
+	WalletID     uuid.UUID `db:"wallet_id"`
+	Type         int       `db:"type"`
+	AmountCents  int64     `db:"amount_cents"`
+	ReferenceID  string    `db:"reference_id"`
+	Status       int       `db:"status"`
+	ErrorMessage string    `db:"error_message"`
+	ReasonCode   string    `db:"reason_code"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+const walletColumns = `id, user_id, balance_cents, currency, version, status, status_reason,
+	per_transaction_limit_cents, daily_limit_cents, monthly_limit_cents, created_at, updated_at`
+
+const transactionColumns = `id, wallet_id, type, amount_cents, reference_id, status, error_message, reason_code, created_at`
+
+func (r *paymentRepository) NextID() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+func (r *paymentRepository) WithinTransaction(fn func(repo model.PaymentRepository) error) (err error) {
+	// Вложенный вызов продолжает уже открытую транзакцию
+	if r.db == nil {
+		return fn(r)
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(&paymentRepository{executor: tx}); err != nil {
+		return err
+	}
+	return errors.WithStack(tx.Commit())
+}
+
+func (r *paymentRepository) CreateWallet(wallet *model.Wallet) error {
+	_, err := r.executor.Exec(
+		`INSERT INTO wallet (`+walletColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		wallet.ID,
+		wallet.UserID,
+		wallet.BalanceCents,
+		wallet.Currency,
+		wallet.Version,
+		wallet.Status,
+		wallet.StatusReason,
+		wallet.Limits.PerTransactionCents,
+		wallet.Limits.DailyCents,
+		wallet.Limits.MonthlyCents,
+		wallet.CreatedAt,
+		wallet.UpdatedAt,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *paymentRepository) GetWalletByID(walletID uuid.UUID) (*model.Wallet, error) {
+	return r.findWallet(`id = ?`, walletID)
+}
+
+func (r *paymentRepository) GetWalletByUserID(userID uuid.UUID) (*model.Wallet, error) {
+	return r.findWallet(`user_id = ?`, userID)
+}
+
+// UpdateWallet сохраняет кошелёк, только если в БД лежит предыдущая версия (wallet.Version - 1)
+func (r *paymentRepository) UpdateWallet(wallet *model.Wallet) error {
+	result, err := r.executor.Exec(
+		`UPDATE wallet SET
+			balance_cents = ?,
+			version = ?,
+			status = ?,
+			status_reason = ?,
+			per_transaction_limit_cents = ?,
+			daily_limit_cents = ?,
+			monthly_limit_cents = ?,
+			updated_at = ?
+		WHERE id = ? AND version = ?`,
+		wallet.BalanceCents,
+		wallet.Version,
+		wallet.Status,
+		wallet.StatusReason,
+		wallet.Limits.PerTransactionCents,
+		wallet.Limits.DailyCents,
+		wallet.Limits.MonthlyCents,
+		wallet.UpdatedAt,
+		wallet.ID,
+		wallet.Version-1,
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if affected == 0 {
+		return errors.WithStack(model.ErrOptimisticLock)
+	}
+	return nil
+}
+
+func (r *paymentRepository) SaveWalletStatusChange(change *model.WalletStatusChange) error {
+	_, err := r.executor.Exec(
+		`INSERT INTO wallet_status_change (id, wallet_id, old_status, new_status, reason, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		change.ID,
+		change.WalletID,
+		change.OldStatus,
+		change.NewStatus,
+		change.Reason,
+		change.CreatedAt,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *paymentRepository) SaveTransaction(tx *model.Transaction) error {
+	_, err := r.executor.Exec(
+		`INSERT INTO wallet_transaction (`+transactionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		tx.ID,
+		tx.WalletID,
+		tx.Type,
+		tx.AmountCents,
+		tx.ReferenceID,
+		tx.Status,
+		tx.ErrorMessage,
+		tx.ReasonCode,
+		tx.CreatedAt,
+	)
+	if isDuplicateKeyError(err) {
+		return errors.WithStack(model.ErrDuplicateTransaction)
+	}
+	return errors.WithStack(err)
+}
+
+func (r *paymentRepository) ResolveTransaction(tx *model.Transaction) error {
+	result, err := r.executor.Exec(
+		`UPDATE wallet_transaction SET status = ?, error_message = ?, reason_code = ? WHERE id = ? AND status = ?`,
+		tx.Status,
+		tx.ErrorMessage,
+		tx.ReasonCode,
+		tx.ID,
+		model.TxOnReview,
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if affected == 0 {
+		return errors.WithStack(model.ErrTransactionNotOnHold)
+	}
+	return nil
+}
+
+func (r *paymentRepository) FindTransaction(id uuid.UUID) (*model.Transaction, error) {
+	return r.findTransaction(`id = ?`, id)
+}
+
+func (r *paymentRepository) FindTransactionByRef(walletID uuid.UUID, referenceID string) (*model.Transaction, error) {
+	return r.findTransaction(`wallet_id = ? AND reference_id = ?`, walletID, referenceID)
+}
+
+func (r *paymentRepository) SumWithdrawalsSince(walletID uuid.UUID, since time.Time) (int64, error) {
+	var sum int64
+	err := r.executor.Get(
+		&sum,
+		`SELECT COALESCE(SUM(amount_cents), 0) FROM wallet_transaction
+		WHERE wallet_id = ? AND type = ? AND status IN (?, ?) AND created_at >= ?`,
+		walletID,
+		model.Withdrawal,
+		model.TxCommitted,
+		model.TxOnReview,
+		since,
+	)
+	return sum, errors.WithStack(err)
+}
+
+func (r *paymentRepository) CountWithdrawalsSince(walletID uuid.UUID, since time.Time) (int, error) {
+	var count int
+	err := r.executor.Get(
+		&count,
+		`SELECT COUNT(*) FROM wallet_transaction
+		WHERE wallet_id = ? AND type = ? AND status IN (?, ?) AND created_at >= ?`,
+		walletID,
+		model.Withdrawal,
+		model.TxCommitted,
+		model.TxOnReview,
+		since,
+	)
+	return count, errors.WithStack(err)
+}
+
+func (r *paymentRepository) findWallet(condition string, args ...interface{}) (*model.Wallet, error) {
+	var wallet sqlxWallet
+	err := r.executor.Get(&wallet, `SELECT `+walletColumns+` FROM wallet WHERE `+condition, args...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrWalletNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	return &model.Wallet{
+		ID:           wallet.ID,
+		UserID:       wallet.UserID,
+		BalanceCents: wallet.BalanceCents,
+		Currency:     wallet.Currency,
+		Version:      wallet.Version,
+		Limits: model.SpendingLimits{
+			PerTransactionCents: wallet.PerTransactionLimitCents,
+			DailyCents:          wallet.DailyLimitCents,
+			MonthlyCents:        wallet.MonthlyLimitCents,
+		},
+		Status:       model.WalletStatus(wallet.Status),
+		StatusReason: wallet.StatusReason,
+		CreatedAt:    wallet.CreatedAt,
+		UpdatedAt:    wallet.UpdatedAt,
+	}, nil
+}
+
+func (r *paymentRepository) findTransaction(condition string, args ...interface{}) (*model.Transaction, error) {
+	var tx sqlxTransaction
+	err := r.executor.Get(&tx, `SELECT `+transactionColumns+` FROM wallet_transaction WHERE `+condition, args...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrTransactionNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	return &model.Transaction{
+		ID:           tx.ID,
+		WalletID:     tx.WalletID,
+		Type:         model.TransactionType(tx.Type),
+		AmountCents:  tx.AmountCents,
+		ReferenceID:  tx.ReferenceID,
+		Status:       model.TransactionStatus(tx.Status),
+		ErrorMessage: tx.ErrorMessage,
+		ReasonCode:   tx.ReasonCode,
+		CreatedAt:    tx.CreatedAt,
+	}, nil
+}
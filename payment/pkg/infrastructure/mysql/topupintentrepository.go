@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"payment/pkg/domain/model"
+)
+
+func NewTopUpIntentRepository(db *sqlx.DB) model.TopUpIntentRepository {
+	return &topUpIntentRepository{db: db}
+}
+
+type topUpIntentRepository struct {
+	db *sqlx.DB
+}
+
+type sqlxTopUpIntent struct {
+	ID                uuid.UUID `db:"id"`
+	UserID            uuid.UUID `db:"user_id"`
+	AmountCents       int64     `db:"amount_cents"`
+	Currency          string    `db:"currency"`
+	ProviderReference string    `db:"provider_reference"`
+	RedirectURL       string    `db:"redirect_url"`
+	Status            int       `db:"status"`
+	FailureReason     string    `db:"failure_reason"`
+	CreatedAt         time.Time `db:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at"`
+}
+
+func (r *topUpIntentRepository) NextID() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+func (r *topUpIntentRepository) Create(intent *model.TopUpIntent) error {
+	_, err := r.db.Exec(
+		`INSERT INTO top_up_intent
+			(id, user_id, amount_cents, currency, provider_reference, redirect_url, status, failure_reason, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		intent.ID,
+		intent.UserID,
+		intent.AmountCents,
+		intent.Currency,
+		intent.ProviderReference,
+		intent.RedirectURL,
+		intent.Status,
+		intent.FailureReason,
+		intent.CreatedAt,
+		intent.UpdatedAt,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *topUpIntentRepository) Update(intent *model.TopUpIntent) error {
+	_, err := r.db.Exec(
+		`UPDATE top_up_intent SET status = ?, failure_reason = ?, updated_at = ? WHERE id = ?`,
+		intent.Status,
+		intent.FailureReason,
+		intent.UpdatedAt,
+		intent.ID,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *topUpIntentRepository) Find(id uuid.UUID) (*model.TopUpIntent, error) {
+	var intent sqlxTopUpIntent
+	err := r.db.Get(
+		&intent,
+		`SELECT id, user_id, amount_cents, currency, provider_reference, redirect_url, status, failure_reason, created_at, updated_at
+		FROM top_up_intent WHERE id = ?`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrTopUpIntentNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	return &model.TopUpIntent{
+		ID:                intent.ID,
+		UserID:            intent.UserID,
+		AmountCents:       intent.AmountCents,
+		Currency:          intent.Currency,
+		ProviderReference: intent.ProviderReference,
+		RedirectURL:       intent.RedirectURL,
+		Status:            model.TopUpIntentStatus(intent.Status),
+		FailureReason:     intent.FailureReason,
+		CreatedAt:         intent.CreatedAt,
+		UpdatedAt:         intent.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"payment/pkg/domain/model"
+	"payment/pkg/infrastructure/gateway"
+	"payment/pkg/infrastructure/transport"
+)
+
+var testWebhookSecret = []byte("webhook-secret")
+
+// recordingTopUpService запоминает, какие интенты вебхук завершил
+type recordingTopUpService struct {
+	completed []uuid.UUID
+	failed    map[uuid.UUID]string
+}
+
+func (s *recordingTopUpService) CreateTopUpIntent(uuid.UUID, int64) (*model.TopUpIntent, error) {
+	return nil, nil
+}
+
+func (s *recordingTopUpService) CompleteTopUp(intentID uuid.UUID, providerReference string) error {
+	if providerReference != "fake_"+intentID.String() {
+		return model.ErrProviderReferenceMismatch
+	}
+	s.completed = append(s.completed, intentID)
+	return nil
+}
+
+func (s *recordingTopUpService) FailTopUp(intentID uuid.UUID, providerReference, reason string) error {
+	if providerReference != "fake_"+intentID.String() {
+		return model.ErrProviderReferenceMismatch
+	}
+	s.failed[intentID] = reason
+	return nil
+}
+
+// newTestCheckout поднимает сервер с вебхуком и страницей оплаты так же, как это делает команда service
+func newTestCheckout(t *testing.T) (*httptest.Server, *recordingTopUpService) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	topUpService := &recordingTopUpService{failed: map[uuid.UUID]string{}}
+
+	router := http.NewServeMux()
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	fakeGateway := gateway.NewFakeGateway(server.URL, testWebhookSecret)
+	router.Handle("/webhooks/gateway", transport.NewGatewayWebhookHandler(topUpService, testWebhookSecret, logger))
+	router.Handle(gateway.FakeCheckoutPath, gateway.NewFakeCheckoutHandler(
+		fakeGateway,
+		server.URL+"/webhooks/gateway",
+		server.Client(),
+		logger,
+	))
+	return server, topUpService
+}
+
+func checkout(t *testing.T, url string) int {
+	t.Helper()
+	response, err := http.Get(url)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	return response.StatusCode
+}
+
+func TestFakeCheckout_PostsSignedWebhook(t *testing.T) {
+	server, topUpService := newTestCheckout(t)
+	paid, declined := uuid.New(), uuid.New()
+
+	intent, err := gateway.NewFakeGateway(server.URL, testWebhookSecret).CreateIntent(paid, 2500, "INTERNAL_COIN")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, checkout(t, intent.RedirectURL))
+
+	url := server.URL + gateway.FakeCheckoutPath + declined.String() + "?status=failed&reason=card+declined"
+	assert.Equal(t, http.StatusOK, checkout(t, url))
+
+	assert.Equal(t, []uuid.UUID{paid}, topUpService.completed)
+	assert.Equal(t, map[uuid.UUID]string{declined: "card declined"}, topUpService.failed)
+}
+
+func TestFakeCheckout_RejectsInvalidRequest(t *testing.T) {
+	server, topUpService := newTestCheckout(t)
+
+	assert.Equal(t, http.StatusBadRequest, checkout(t, server.URL+gateway.FakeCheckoutPath+"not-an-id"))
+	assert.Equal(t, http.StatusBadRequest, checkout(t, server.URL+gateway.FakeCheckoutPath+uuid.NewString()+"?status=refunded"))
+	assert.Empty(t, topUpService.completed)
+	assert.Empty(t, topUpService.failed)
+}
+
+func TestGatewayWebhook_RejectsForeignProviderReference(t *testing.T) {
+	server, topUpService := newTestCheckout(t)
+	intentID := uuid.New()
+
+	body := []byte(`{"intent_id":"` + intentID.String() + `","provider_reference":"fake_other","status":"succeeded"}`)
+	request, err := http.NewRequest(http.MethodPost, server.URL+"/webhooks/gateway", bytes.NewReader(body))
+	require.NoError(t, err)
+	request.Header.Set(gateway.SignatureHeader, gateway.Sign(testWebhookSecret, body))
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Empty(t, topUpService.completed)
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"payment/pkg/domain/model"
+	"payment/pkg/domain/service"
+	"payment/pkg/infrastructure/integrationevent"
+)
+
+// recordingUserEventHandler запоминает пользователей, чьи кошельки нужно заморозить
+type recordingUserEventHandler struct {
+	suspended   []uuid.UUID
+	deactivated []uuid.UUID
+	err         error
+}
+
+func (h *recordingUserEventHandler) HandleUserDeactivated(userID uuid.UUID) error {
+	h.deactivated = append(h.deactivated, userID)
+	return h.err
+}
+
+func (h *recordingUserEventHandler) HandleUserSuspended(userID uuid.UUID) error {
+	h.suspended = append(h.suspended, userID)
+	return h.err
+}
+
+func newTestMessageHandler() (integrationevent.MessageHandler, *recordingUserEventHandler) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	userEventHandler := &recordingUserEventHandler{}
+	return integrationevent.NewMessageHandler(userEventHandler, logger), userEventHandler
+}
+
+func TestMessageHandler_FreezesOnUserEvents(t *testing.T) {
+	handler, userEventHandler := newTestMessageHandler()
+	blocked, activated, renamed, deleted := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	// Тела сообщений - вывод сериализатора user2 как есть
+	for _, message := range []integrationevent.Message{
+		{
+			RoutingKey: integrationevent.UserUpdatedRoutingKey,
+			Body:       []byte(`{"user_id":"` + blocked.String() + `","updated_fields":{"status":0},"updated_at":1714564800}`),
+		},
+		// Разблокировка и смена контактов кошелёк не трогают
+		{
+			RoutingKey: integrationevent.UserUpdatedRoutingKey,
+			Body:       []byte(`{"user_id":"` + activated.String() + `","updated_fields":{"status":1},"updated_at":1714564800}`),
+		},
+		{
+			RoutingKey: integrationevent.UserUpdatedRoutingKey,
+			Body: []byte(`{"user_id":"` + renamed.String() + `","updated_fields":{"email":"new@example.com"},` +
+				`"removed_fields":{"telegram":true},"updated_at":1714564800}`),
+		},
+		{
+			RoutingKey: integrationevent.UserDeletedRoutingKey,
+			Body:       []byte(`{"user_id":"` + deleted.String() + `","status":2,"deleted_at":1714564800,"hard":false}`),
+		},
+	} {
+		assert.NoError(t, handler.Handle(message), string(message.Body))
+	}
+
+	assert.Equal(t, []uuid.UUID{blocked}, userEventHandler.suspended)
+	assert.Equal(t, []uuid.UUID{deleted}, userEventHandler.deactivated)
+	assert.ElementsMatch(t, []string{"user.user_updated", "user.user_deleted"}, integrationevent.RoutingKeys)
+}
+
+func TestMessageHandler_SkipsPoisonAndRetriesFailures(t *testing.T) {
+	handler, userEventHandler := newTestMessageHandler()
+
+	for _, message := range []integrationevent.Message{
+		{RoutingKey: integrationevent.UserDeletedRoutingKey, Body: []byte(`{"user_id":`)},
+		{RoutingKey: integrationevent.UserDeletedRoutingKey, Body: []byte(`{"user_id":"not-a-user"}`)},
+		{RoutingKey: "user.user_created", Body: []byte(`{}`)},
+	} {
+		assert.NoError(t, handler.Handle(message), message.RoutingKey)
+	}
+	assert.Empty(t, userEventHandler.deactivated)
+
+	// Сбой хранилища возвращает сообщение в очередь
+	userEventHandler.err = errors.New("database is down")
+	assert.Error(t, handler.Handle(integrationevent.Message{
+		RoutingKey: integrationevent.UserDeletedRoutingKey,
+		Body:       []byte(`{"user_id":"` + uuid.NewString() + `","status":2,"deleted_at":1714564800,"hard":true}`),
+	}))
+}
+
+// recordingPublisher запоминает опубликованные сообщения вместо отправки в брокер
+type recordingPublisher struct {
+	routingKeys []string
+	bodies      []string
+}
+
+func (p *recordingPublisher) Publish(routingKey string, body []byte) error {
+	p.routingKeys = append(p.routingKeys, routingKey)
+	p.bodies = append(p.bodies, string(body))
+	return nil
+}
+
+func (p *recordingPublisher) Close() error { return nil }
+
+type countingDispatcher struct {
+	count int
+}
+
+func (d *countingDispatcher) Dispatch(service.Event) error {
+	d.count++
+	return nil
+}
+
+func TestEventDispatcher_PublishesPaymentFailed(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	publisher := &recordingPublisher{}
+	next := &countingDispatcher{}
+	dispatcher := integrationevent.NewEventDispatcher(publisher, next, logger)
+	walletID, userID, orderID := uuid.New(), uuid.New(), uuid.New()
+
+	require.NoError(t, dispatcher.Dispatch(model.PaymentFailed{
+		WalletID:    walletID,
+		UserID:      userID,
+		ReferenceID: orderID.String(),
+		Reason:      "insufficient funds",
+		ReasonCode:  model.ReasonInsufficientFunds,
+	}))
+	// Остальные события только пишутся в журнал
+	require.NoError(t, dispatcher.Dispatch(model.WalletFrozen{WalletID: walletID, UserID: userID}))
+
+	assert.Equal(t, 2, next.count)
+	assert.Equal(t, []string{integrationevent.PaymentFailedRoutingKey}, publisher.routingKeys)
+	require.Len(t, publisher.bodies, 1)
+	assert.JSONEq(t, `{
+		"wallet_id":"`+walletID.String()+`",
+		"user_id":"`+userID.String()+`",
+		"reference_id":"`+orderID.String()+`",
+		"reason":"insufficient funds",
+		"reason_code":"insufficient_funds"
+	}`, publisher.bodies[0])
+}
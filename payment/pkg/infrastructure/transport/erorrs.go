@@ -5,6 +5,8 @@ import (
 
 	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
+
+	"payment/pkg/domain/model"
 )
 
 type errorSet map[error]struct{}
@@ -22,9 +24,25 @@ func (s errorSet) Has(err error) bool {
 	return ok
 }
 
-var badRequestErrorCodes = newErrorSet()
+var badRequestErrorCodes = newErrorSet(
+	ErrInvalidUserID,
+	ErrInvalidTransactionID,
+	model.ErrInvalidAmount,
+	model.ErrInvalidLimits,
+)
 
-var notFoundErrorCodes = newErrorSet()
+var notFoundErrorCodes = newErrorSet(
+	model.ErrWalletNotFound,
+	model.ErrTransactionNotFound,
+)
+
+var failedPreconditionErrorCodes = newErrorSet(
+	model.ErrTransactionNotOnHold,
+	model.ErrInsufficientFunds,
+	model.ErrWalletFrozen,
+	model.ErrWalletClosed,
+	model.ErrOptimisticLock,
+)
 
 var unauthorizedErrorCodes = newErrorSet()
 
@@ -43,6 +61,8 @@ func getGRPCCode(err error) codes.Code {
 		return codes.InvalidArgument
 	case isNotFoundError(cause):
 		return codes.NotFound
+	case isFailedPreconditionError(cause):
+		return codes.FailedPrecondition
 	case isUnauthorizedError(cause):
 		return codes.Unauthenticated
 	case isPermissionDeniedError(cause):
@@ -84,6 +104,10 @@ func isNotFoundError(cause error) bool {
 	return notFoundErrorCodes.Has(cause)
 }
 
+func isFailedPreconditionError(cause error) bool {
+	return failedPreconditionErrorCodes.Has(cause)
+}
+
 func isUnauthorizedError(cause error) bool {
 	return unauthorizedErrorCodes.Has(cause)
 }
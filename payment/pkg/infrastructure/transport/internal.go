@@ -3,14 +3,29 @@ package transport
 import (
 	"context"
 
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
 	api "payment/api/server/paymentinternal"
+	"payment/pkg/domain/model"
+	"payment/pkg/domain/service"
+)
+
+var (
+	ErrInvalidUserID        = errors.New("invalid user id")
+	ErrInvalidTransactionID = errors.New("invalid transaction id")
 )
 
-func NewInternalAPI() api.PaymentInternalServiceServer {
-	return &internalAPI{}
+func NewInternalAPI(topUpService service.TopUpService, paymentService service.PaymentService) api.PaymentInternalServiceServer {
+	return &internalAPI{
+		topUpService:   topUpService,
+		paymentService: paymentService,
+	}
 }
 
 type internalAPI struct {
+	topUpService   service.TopUpService
+	paymentService service.PaymentService
 }
 
 func (i *internalAPI) Ping(_ context.Context, _ *api.PingRequest) (*api.PingResponse, error) {
@@ -18,3 +33,68 @@ func (i *internalAPI) Ping(_ context.Context, _ *api.PingRequest) (*api.PingResp
 		Message: "pong",
 	}, nil
 }
+
+func (i *internalAPI) CreateTopUpIntent(
+	_ context.Context,
+	request *api.CreateTopUpIntentRequest,
+) (*api.CreateTopUpIntentResponse, error) {
+	userID, err := uuid.Parse(request.UserID)
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidUserID)
+	}
+	intent, err := i.topUpService.CreateTopUpIntent(userID, request.AmountCents)
+	if err != nil {
+		return nil, err
+	}
+	return &api.CreateTopUpIntentResponse{
+		IntentID:    intent.ID.String(),
+		RedirectURL: intent.RedirectURL,
+	}, nil
+}
+
+func (i *internalAPI) SetSpendingLimits(
+	_ context.Context,
+	request *api.SetSpendingLimitsRequest,
+) (*api.SetSpendingLimitsResponse, error) {
+	userID, err := uuid.Parse(request.UserID)
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidUserID)
+	}
+	err = i.paymentService.SetSpendingLimits(userID, model.SpendingLimits{
+		PerTransactionCents: request.PerTransactionCents,
+		DailyCents:          request.DailyCents,
+		MonthlyCents:        request.MonthlyCents,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.SetSpendingLimitsResponse{}, nil
+}
+
+func (i *internalAPI) ApproveTransaction(
+	_ context.Context,
+	request *api.ApproveTransactionRequest,
+) (*api.ApproveTransactionResponse, error) {
+	transactionID, err := uuid.Parse(request.TransactionID)
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidTransactionID)
+	}
+	if err = i.paymentService.ApproveTransaction(transactionID); err != nil {
+		return nil, err
+	}
+	return &api.ApproveTransactionResponse{}, nil
+}
+
+func (i *internalAPI) RejectTransaction(
+	_ context.Context,
+	request *api.RejectTransactionRequest,
+) (*api.RejectTransactionResponse, error) {
+	transactionID, err := uuid.Parse(request.TransactionID)
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidTransactionID)
+	}
+	if err = i.paymentService.RejectTransaction(transactionID); err != nil {
+		return nil, err
+	}
+	return &api.RejectTransactionResponse{}, nil
+}
@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"payment/pkg/domain/model"
+	"payment/pkg/domain/service"
+	"payment/pkg/infrastructure/gateway"
+)
+
+const maxWebhookBodySize = 1 << 20
+
+// NewGatewayWebhookHandler принимает уведомления PSP о результате оплаты интента.
+// Ответ не 2xx заставляет провайдера повторить доставку, поэтому 5xx возвращается только на временные ошибки
+func NewGatewayWebhookHandler(topUpService service.TopUpService, secret []byte, logger *log.Logger) http.Handler {
+	return &gatewayWebhookHandler{
+		topUpService: topUpService,
+		secret:       secret,
+		logger:       logger,
+	}
+}
+
+type gatewayWebhookHandler struct {
+	topUpService service.TopUpService
+	secret       []byte
+	logger       *log.Logger
+}
+
+func (h *gatewayWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !gateway.VerifySignature(h.secret, body, r.Header.Get(gateway.SignatureHeader)) {
+		h.logger.Warnf("gateway webhook rejected: invalid signature")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var payload gateway.WebhookPayload
+	if err = json.Unmarshal(body, &payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	intentID, err := uuid.Parse(payload.IntentID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch payload.Status {
+	case gateway.WebhookStatusSucceeded:
+		err = h.topUpService.CompleteTopUp(intentID, payload.ProviderReference)
+	case gateway.WebhookStatusFailed:
+		err = h.topUpService.FailTopUp(intentID, payload.ProviderReference, payload.FailureReason)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(h.statusCode(intentID, err))
+}
+
+func (h *gatewayWebhookHandler) statusCode(intentID uuid.UUID, err error) int {
+	cause := errors.Cause(err)
+	switch cause {
+	case nil:
+		return http.StatusOK
+	case model.ErrTopUpIntentNotFound, model.ErrWalletNotFound:
+		return http.StatusNotFound
+	case model.ErrProviderReferenceMismatch:
+		h.logger.WithField("intentID", intentID).Warnf("gateway webhook rejected: %v", err)
+		return http.StatusBadRequest
+	case model.ErrTopUpIntentFinalized:
+		h.logger.WithField("intentID", intentID).Warnf("gateway webhook conflict: %v", err)
+		return http.StatusConflict
+	default:
+		h.logger.WithField("intentID", intentID).Errorf("gateway webhook failed: %v", err)
+		return http.StatusInternalServerError
+	}
+}
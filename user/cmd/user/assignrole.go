@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// assignRole выдаёт роль из командной строки, чтобы назначить первого администратора
+func assignRole(
+	config *config,
+	logger *log.Logger,
+	closer *multiCloser,
+) *cli.Command {
+	return &cli.Command{
+		Name:  "assign-role",
+		Usage: "Assigns a role to the user with the given email",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "email", Required: true},
+			&cli.StringFlag{Name: "role", Required: true},
+		},
+		Action: func(c *cli.Context) error {
+			connContainer, err := newConnectionsContainer(config, logger, closer)
+			if err != nil {
+				return errors.Wrap(err, "failed to init connections")
+			}
+
+			container, err := newDependencyContainer(config, logger, connContainer)
+			if err != nil {
+				return errors.Wrap(err, "failed to init dependencies")
+			}
+
+			user, err := container.userService.GetUserByEmail(c.String("email"))
+			if err != nil {
+				return err
+			}
+			if err := container.roleService.AssignRole(user.ID, c.String("role")); err != nil {
+				return err
+			}
+
+			logger.Infof("role %s assigned to user %s", c.String("role"), user.ID)
+			return nil
+		},
+	}
+}
@@ -1,18 +1,183 @@
 package main
 
-import "github.com/jmoiron/sqlx"
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
 
-// TODO: добавить зависимости
+	"user/pkg/authtoken"
+	domainservice "user/pkg/domain/service"
+	"user/pkg/infrastructure/event"
+	"user/pkg/infrastructure/integrationevent"
+	"user/pkg/infrastructure/mysql"
+	"user/pkg/infrastructure/password"
+	"user/pkg/infrastructure/token"
+)
 
 func newDependencyContainer(
-	_ *config,
+	config *config,
+	logger *log.Logger,
 	connContainer *connectionsContainer,
 ) (*dependencyContainer, error) {
+	if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+		return nil, errors.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	privateKey, err := config.tokenPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	totpEncryptionKey, err := config.totpEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	secretCipher, err := password.NewSecretCipher(totpEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	userRepository := mysql.NewUserRepository(connContainer.db)
+	refreshTokenRepository := mysql.NewRefreshTokenRepository(connContainer.db)
+	roleRepository := mysql.NewRoleRepository(connContainer.db)
+	verificationRepository := mysql.NewEmailVerificationTokenRepository(connContainer.db)
+	resetTokenRepository := mysql.NewPasswordResetTokenRepository(connContainer.db)
+	passwordHistoryRepository := mysql.NewPasswordHistoryRepository(connContainer.db)
+	loginFailureRepository := mysql.NewLoginFailureRepository(connContainer.db)
+	sessionRepository := mysql.NewSessionRepository(connContainer.db)
+	twoFactorRepository := mysql.NewTwoFactorRepository(connContainer.db, secretCipher)
+	recoveryCodeRepository := mysql.NewRecoveryCodeRepository(connContainer.db)
+	passwordManager := password.NewBcryptPasswordManager(config.BcryptCost)
+	passwordBlocklist, err := password.LoadBlocklist(config.PasswordBlocklistPath)
+	if err != nil {
+		return nil, err
+	}
+	passwordPolicy := domainservice.NewPasswordPolicy(
+		domainservice.PasswordPolicyConfig{
+			MinLength:        config.PasswordMinLength,
+			RequireUppercase: config.PasswordRequireUppercase,
+			RequireLowercase: config.PasswordRequireLowercase,
+			RequireDigit:     config.PasswordRequireDigit,
+			RequireSpecial:   config.PasswordRequireSpecial,
+			HistorySize:      config.PasswordHistorySize,
+		},
+		passwordBlocklist,
+		passwordHistoryRepository,
+		passwordManager,
+	)
+	tokenIssuer := token.NewEd25519Issuer(privateKey, config.TokenKeyID, config.TokenIssuer)
+	eventDispatcher := integrationevent.NewEventDispatcher(
+		connContainer.publisher,
+		event.NewLogEventDispatcher(logger),
+		logger,
+	)
+
+	loginThrottle := domainservice.LoginThrottleConfig{
+		FreeAttempts:     config.LoginFreeAttempts,
+		BaseDelay:        config.LoginBaseDelay,
+		MaxDelay:         config.LoginMaxDelay,
+		LockoutThreshold: config.LoginLockoutThreshold,
+		LockoutDuration:  config.LoginLockoutDuration,
+		FailureWindow:    config.LoginFailureWindow,
+	}
+
+	sessionService := domainservice.NewSessionService(sessionRepository, refreshTokenRepository)
+	twoFactorService := domainservice.NewTwoFactorService(
+		userRepository,
+		twoFactorRepository,
+		recoveryCodeRepository,
+		loginFailureRepository,
+		password.NewTOTPManager(config.TOTPIssuer),
+		eventDispatcher,
+		loginThrottle,
+	)
+	userService := domainservice.NewUserService(
+		userRepository,
+		verificationRepository,
+		loginFailureRepository,
+		sessionService,
+		passwordManager,
+		passwordPolicy,
+		eventDispatcher,
+		domainservice.VerificationConfig{
+			TokenTTL:       config.VerificationTokenTTL,
+			ResendInterval: config.VerificationResendInterval,
+		},
+	)
+	authService := domainservice.NewAuthService(
+		userRepository,
+		refreshTokenRepository,
+		sessionRepository,
+		roleRepository,
+		loginFailureRepository,
+		mysql.NewTwoFactorChallengeRepository(connContainer.db),
+		passwordManager,
+		tokenIssuer,
+		twoFactorService,
+		eventDispatcher,
+		domainservice.AuthConfig{
+			AccessTokenTTL:        config.AccessTokenTTL,
+			RefreshTokenTTL:       config.RefreshTokenTTL,
+			TwoFactorChallengeTTL: config.TwoFactorChallengeTTL,
+			LoginThrottle:         loginThrottle,
+		},
+	)
+
+	passwordService := domainservice.NewPasswordService(
+		userRepository,
+		resetTokenRepository,
+		loginFailureRepository,
+		sessionService,
+		passwordManager,
+		passwordPolicy,
+		eventDispatcher,
+		domainservice.PasswordResetConfig{
+			TokenTTL:    config.PasswordResetTokenTTL,
+			EmailLimit:  config.PasswordResetEmailLimit,
+			IPLimit:     config.PasswordResetIPLimit,
+			LimitWindow: config.PasswordResetLimitWindow,
+		},
+	)
+
+	roleService := domainservice.NewRoleService(userRepository, roleRepository, eventDispatcher)
+	privacyService := domainservice.NewPrivacyService(
+		userRepository,
+		roleRepository,
+		refreshTokenRepository,
+		sessionRepository,
+		verificationRepository,
+		resetTokenRepository,
+		passwordHistoryRepository,
+		loginFailureRepository,
+		twoFactorRepository,
+		recoveryCodeRepository,
+		mysql.NewUserErasureRepository(connContainer.db, secretCipher),
+		eventDispatcher,
+	)
+
 	return &dependencyContainer{
-		db: connContainer.db,
+		db:               connContainer.db,
+		userService:      userService,
+		authService:      authService,
+		passwordService:  passwordService,
+		roleService:      roleService,
+		privacyService:   privacyService,
+		sessionService:   sessionService,
+		twoFactorService: twoFactorService,
+		keySet:           tokenIssuer.KeySet(),
+		keySource:        tokenIssuer.KeySource(),
 	}, nil
 }
 
 type dependencyContainer struct {
 	db *sqlx.DB
+
+	userService      domainservice.UserService
+	authService      domainservice.AuthService
+	passwordService  domainservice.PasswordService
+	roleService      domainservice.RoleService
+	privacyService   domainservice.PrivacyService
+	sessionService   domainservice.SessionService
+	twoFactorService domainservice.TwoFactorService
+	keySet           authtoken.JWKSet
+	keySource        authtoken.KeySource
 }
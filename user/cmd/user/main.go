@@ -9,6 +9,7 @@ import (
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
 )
 
 // TODO:  appID используется как префикс для env-переменных
@@ -57,6 +58,7 @@ func runApp(
 		Commands: []*cli.Command{
 			service(config, logger, closer),
 			migrate(config, logger),
+			assignRole(config, logger, closer),
 		},
 	}
 
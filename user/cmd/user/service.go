@@ -3,13 +3,16 @@ package main
 import (
 	"context"
 	"net"
+	"net/http"
 	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
 	"google.golang.org/grpc"
 
 	api "user/api/server/userinternal"
+	"user/pkg/authtoken"
 	"user/pkg/infrastructure/transport"
 )
 
@@ -29,11 +32,19 @@ func service(
 				return errors.Wrap(err, "failed to init connections")
 			}
 
-			container, err := newDependencyContainer(config, connContainer)
+			container, err := newDependencyContainer(config, logger, connContainer)
 			if err != nil {
 				return errors.Wrap(err, "failed to init dependencies")
 			}
-			return startGRPCServer(c.Context, config, logger, container)
+
+			errCh := make(chan error, 2)
+			go func() {
+				errCh <- startHTTPServer(c.Context, config, logger, container)
+			}()
+			go func() {
+				errCh <- startGRPCServer(c.Context, config, logger, container)
+			}()
+			return <-errCh
 		},
 	}
 }
@@ -42,12 +53,33 @@ func startGRPCServer(
 	ctx context.Context,
 	config *config,
 	logger *log.Logger,
-	_ *dependencyContainer,
+	container *dependencyContainer,
 ) error {
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(makeGrpcUnaryInterceptor(logger)))
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		makeGrpcUnaryInterceptor(logger),
+		authtoken.NewAuthInterceptor(
+			authtoken.NewSessionVerifier(
+				authtoken.NewVerifier(container.keySource, config.TokenIssuer),
+				container.sessionService,
+			),
+			transport.MethodPermissions(),
+		),
+	))
 
-	// TODO: зарегистрировать свой сервер вместо шаблонного
-	api.RegisterUserInternalServiceServer(grpcServer, transport.NewInternalAPI())
+	trustedProxies, err := config.trustedProxies()
+	if err != nil {
+		return err
+	}
+	api.RegisterUserInternalServiceServer(grpcServer, transport.NewInternalAPI(
+		container.userService,
+		container.authService,
+		container.passwordService,
+		container.roleService,
+		container.privacyService,
+		container.sessionService,
+		container.twoFactorService,
+		trustedProxies,
+	))
 
 	listener, err := net.Listen("tcp", config.ServeGRPCAddress)
 	if err != nil {
@@ -70,6 +102,37 @@ func startGRPCServer(
 	}
 }
 
+func startHTTPServer(
+	ctx context.Context,
+	config *config,
+	logger *log.Logger,
+	container *dependencyContainer,
+) error {
+	router := http.NewServeMux()
+	router.Handle(transport.JWKSPath, transport.NewJWKSHandler(container.keySet))
+
+	server := &http.Server{
+		Addr:              config.ServeHTTPAddress,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	logger.Infof("HTTP server listening on %s", config.ServeHTTPAddress)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
 func shutdownGRPCServer(server *grpc.Server, logger *log.Logger) {
 	done := make(chan struct{})
 	go func() {
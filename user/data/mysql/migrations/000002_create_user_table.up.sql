@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS user
+(
+    `id`              VARCHAR(64)  NOT NULL,
+    `email`           VARCHAR(255) NOT NULL,
+    `hashed_password` VARCHAR(255) NOT NULL,
+    `first_name`      VARCHAR(255) NOT NULL,
+    `last_name`       VARCHAR(255) NOT NULL,
+    `status`          INT          NOT NULL,
+    `created_at`      DATETIME     NOT NULL,
+    `updated_at`      DATETIME     NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_user_email` (`email`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
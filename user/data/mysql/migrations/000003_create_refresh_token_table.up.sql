@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS refresh_token
+(
+    `id`         VARCHAR(64) NOT NULL,
+    `user_id`    VARCHAR(64) NOT NULL,
+    `family_id`  VARCHAR(64) NOT NULL,
+    `token_hash` CHAR(64)    NOT NULL,
+    `expires_at` DATETIME    NOT NULL,
+    `created_at` DATETIME    NOT NULL,
+    `revoked_at` DATETIME,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_refresh_token_hash` (`token_hash`),
+    KEY `idx_refresh_token_family` (`family_id`),
+    KEY `idx_refresh_token_user` (`user_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
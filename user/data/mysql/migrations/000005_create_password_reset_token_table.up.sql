@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS password_reset_token
+(
+    `id`         VARCHAR(64) NOT NULL,
+    `user_id`    VARCHAR(64) NOT NULL,
+    `token_hash` CHAR(64)    NOT NULL,
+    `expires_at` DATETIME    NOT NULL,
+    `created_at` DATETIME    NOT NULL,
+    `used_at`    DATETIME,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_password_reset_token_hash` (`token_hash`),
+    KEY `idx_password_reset_token_user` (`user_id`, `created_at`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
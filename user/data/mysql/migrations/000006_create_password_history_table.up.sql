@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS password_history
+(
+    `id`              BIGINT       NOT NULL AUTO_INCREMENT,
+    `user_id`         VARCHAR(64)  NOT NULL,
+    `hashed_password` VARCHAR(255) NOT NULL,
+    `created_at`      DATETIME(6)  NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_password_history_user` (`user_id`, `created_at`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS role
+(
+    `name` VARCHAR(64) NOT NULL,
+    PRIMARY KEY (`name`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+CREATE TABLE IF NOT EXISTS role_permission
+(
+    `role`       VARCHAR(64) NOT NULL,
+    `permission` VARCHAR(64) NOT NULL,
+    PRIMARY KEY (`role`, `permission`),
+    CONSTRAINT `fk_role_permission_role` FOREIGN KEY (`role`) REFERENCES role (`name`) ON DELETE CASCADE
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+CREATE TABLE IF NOT EXISTS user_role
+(
+    `user_id`    VARCHAR(64) NOT NULL,
+    `role`       VARCHAR(64) NOT NULL,
+    `created_at` DATETIME    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`user_id`, `role`),
+    CONSTRAINT `fk_user_role_role` FOREIGN KEY (`role`) REFERENCES role (`name`) ON DELETE CASCADE
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+INSERT INTO role (`name`)
+VALUES ('admin'),
+       ('support');
+
+INSERT INTO role_permission (`role`, `permission`)
+VALUES ('admin', 'users:read'),
+       ('admin', 'users:admin'),
+       ('support', 'users:read');
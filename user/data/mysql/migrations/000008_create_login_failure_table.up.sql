@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS login_failure
+(
+    `scope`           VARCHAR(16)  NOT NULL,
+    `key`             VARCHAR(255) NOT NULL,
+    `failures`        INT          NOT NULL,
+    `last_failure_at` DATETIME(6)  NOT NULL,
+    `locked_until`    DATETIME(6),
+    PRIMARY KEY (`scope`, `key`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS user_erasure
+(
+    `id`           VARCHAR(64)  NOT NULL,
+    `user_id`      VARCHAR(64)  NOT NULL,
+    `requested_by` VARCHAR(64)  NOT NULL,
+    `reason`       VARCHAR(1024) NOT NULL,
+    `erased_at`    DATETIME     NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_user_erasure_user` (`user_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS user_session
+(
+    `id`           VARCHAR(64)  NOT NULL,
+    `user_id`      VARCHAR(64)  NOT NULL,
+    `user_agent`   VARCHAR(512) NOT NULL,
+    `ip_address`   VARCHAR(64)  NOT NULL,
+    `created_at`   DATETIME     NOT NULL,
+    `last_seen_at` DATETIME     NOT NULL,
+    `expires_at`   DATETIME     NOT NULL,
+    `revoked_at`   DATETIME,
+    PRIMARY KEY (`id`),
+    KEY `idx_user_session_user` (`user_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
@@ -0,0 +1,45 @@
+-- secret хранится зашифрованным: префикс версии, nonce и тег GCM вместе с base64 не помещаются в 64 символа
+CREATE TABLE IF NOT EXISTS two_factor
+(
+    `user_id`        VARCHAR(64)  NOT NULL,
+    `secret`         VARCHAR(255) NOT NULL,
+    `created_at`     DATETIME     NOT NULL,
+    `enabled_at`     DATETIME,
+    `last_used_step` BIGINT       NOT NULL DEFAULT 0,
+    PRIMARY KEY (`user_id`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+CREATE TABLE IF NOT EXISTS recovery_code
+(
+    `id`         BIGINT      NOT NULL AUTO_INCREMENT,
+    `user_id`    VARCHAR(64) NOT NULL,
+    `code_hash`  CHAR(64)    NOT NULL,
+    `created_at` DATETIME    NOT NULL,
+    `used_at`    DATETIME,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_recovery_code_user_hash` (`user_id`, `code_hash`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
+
+CREATE TABLE IF NOT EXISTS two_factor_challenge
+(
+    `id`         VARCHAR(64)  NOT NULL,
+    `user_id`    VARCHAR(64)  NOT NULL,
+    `token_hash` CHAR(64)     NOT NULL,
+    `ip_address` VARCHAR(64)  NOT NULL,
+    `user_agent` VARCHAR(512) NOT NULL,
+    `attempts`   INT          NOT NULL,
+    `expires_at` DATETIME     NOT NULL,
+    `created_at` DATETIME     NOT NULL,
+    `used_at`    DATETIME,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_two_factor_challenge_hash` (`token_hash`)
+) ENGINE = InnoDB
+  CHARACTER SET = utf8mb4
+  COLLATE utf8mb4_unicode_ci
+;
@@ -0,0 +1,4 @@
+ALTER TABLE user
+    DROP KEY `uq_user_email_normalized`,
+    DROP COLUMN `email_normalized`,
+    ADD KEY `idx_user_email` (`email`);
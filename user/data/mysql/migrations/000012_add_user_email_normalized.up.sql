@@ -0,0 +1,10 @@
+ALTER TABLE user ADD COLUMN `email_normalized` VARCHAR(255) NULL AFTER `email`;
+
+-- Домены в IDN-форме здесь не переводятся в punycode: SQL этого не умеет.
+-- Если миграция падает на уникальном индексе, дубликаты нужно разобрать вручную
+UPDATE user SET `email_normalized` = LOWER(TRIM(`email`));
+
+ALTER TABLE user
+    MODIFY COLUMN `email_normalized` VARCHAR(255) NOT NULL,
+    DROP KEY `idx_user_email`,
+    ADD UNIQUE KEY `uq_user_email_normalized` (`email_normalized`);
@@ -0,0 +1,6 @@
+DELETE FROM role_permission WHERE `permission` = 'users:search';
+
+ALTER TABLE user
+    DROP KEY `idx_user_first_name`,
+    DROP KEY `idx_user_last_name`,
+    DROP KEY `idx_user_created_at`;
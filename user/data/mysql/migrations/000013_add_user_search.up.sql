@@ -0,0 +1,8 @@
+ALTER TABLE user
+    ADD KEY `idx_user_first_name` (`first_name`),
+    ADD KEY `idx_user_last_name` (`last_name`, `id`),
+    ADD KEY `idx_user_created_at` (`created_at`, `id`);
+
+INSERT INTO role_permission (`role`, `permission`)
+VALUES ('admin', 'users:search'),
+       ('support', 'users:search');
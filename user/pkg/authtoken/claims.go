@@ -0,0 +1,29 @@
+// Package authtoken проверяет access-токены сервиса пользователей.
+// Пакет не зависит от домена и предназначен для использования в других сервисах
+package authtoken
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const SigningAlgorithm = "EdDSA"
+
+type Claims struct {
+	jwt.RegisteredClaims
+	SessionID   string   `json:"sid,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+func (c Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+func (c Claims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
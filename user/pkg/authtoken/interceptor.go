@@ -0,0 +1,89 @@
+package authtoken
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	ErrUnauthenticated  = errors.New("request is not authenticated")
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+const (
+	AuthorizationMetadataKey = "authorization"
+	bearerPrefix             = "bearer "
+)
+
+const (
+	// Anonymous - метод доступен без токена
+	Anonymous = "anonymous"
+	// Authenticated - достаточно любого валидного токена
+	Authenticated = "authenticated"
+)
+
+// MethodPermissions сопоставляет полное имя gRPC-метода требуемому разрешению,
+// Anonymous или Authenticated. Методы, которых нет в карте, запрещены
+type MethodPermissions map[string]string
+
+type claimsContextKey struct{}
+
+// NewAuthInterceptor проверяет bearer-токен из метаданных и разрешение на вызываемый метод.
+// Возвращает ErrUnauthenticated или ErrPermissionDenied, преобразование в gRPC-статус остаётся за сервисом
+func NewAuthInterceptor(verifier Verifier, permissions MethodPermissions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		required, ok := permissions[info.FullMethod]
+		if !ok {
+			return nil, errors.Wrap(ErrPermissionDenied, info.FullMethod)
+		}
+		if required == Anonymous {
+			return handler(ctx, req)
+		}
+
+		claims, err := authenticate(ctx, verifier)
+		if err != nil {
+			return nil, err
+		}
+		if required != Authenticated && !claims.HasPermission(required) {
+			return nil, errors.Wrapf(ErrPermissionDenied, "%s requires %s", info.FullMethod, required)
+		}
+
+		return handler(ContextWithClaims(ctx, claims), req)
+	}
+}
+
+func ContextWithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext возвращает утверждения токена, положенные в контекст NewAuthInterceptor
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*Claims)
+	return claims, ok
+}
+
+func authenticate(ctx context.Context, verifier Verifier) (*Claims, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(AuthorizationMetadataKey)
+	if len(values) == 0 {
+		return nil, errors.WithStack(ErrUnauthenticated)
+	}
+
+	value := values[0]
+	if len(value) <= len(bearerPrefix) || !strings.EqualFold(value[:len(bearerPrefix)], bearerPrefix) {
+		return nil, errors.WithStack(ErrUnauthenticated)
+	}
+
+	claims, err := verifier.Verify(value[len(bearerPrefix):])
+	if errors.Is(err, ErrInvalidToken) {
+		return nil, errors.Wrap(ErrUnauthenticated, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
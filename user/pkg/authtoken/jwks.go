@@ -0,0 +1,167 @@
+package authtoken
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// JWK - открытый ключ Ed25519 в формате RFC 8037
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	X         string `json:"x"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewJWK(keyID string, key ed25519.PublicKey) JWK {
+	return JWK{
+		KeyType:   "OKP",
+		Curve:     "Ed25519",
+		KeyID:     keyID,
+		Use:       "sig",
+		Algorithm: SigningAlgorithm,
+		X:         base64.RawURLEncoding.EncodeToString(key),
+	}
+}
+
+func (k JWK) PublicKey() (ed25519.PublicKey, error) {
+	if k.KeyType != "OKP" || k.Curve != "Ed25519" {
+		return nil, errors.Errorf("unsupported key type %s/%s", k.KeyType, k.Curve)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(x) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 public key size")
+	}
+	return x, nil
+}
+
+type KeySource interface {
+	Key(keyID string) (ed25519.PublicKey, error)
+}
+
+// StaticKeySet - набор ключей, известный заранее (например, в тестах)
+type StaticKeySet map[string]ed25519.PublicKey
+
+func (s StaticKeySet) Key(keyID string) (ed25519.PublicKey, error) {
+	key, ok := s[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// NewJWKSFetcher загружает опубликованный набор ключей по url и кеширует его на refreshInterval.
+// Неизвестный kid вызывает внеочередную загрузку, чтобы подхватить ротацию ключей, но не чаще
+// minRefetchInterval: иначе токены со случайными kid заставляли бы ходить за ключами на каждый запрос
+func NewJWKSFetcher(url string, client *http.Client, refreshInterval, minRefetchInterval time.Duration) KeySource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &jwksFetcher{
+		url:                url,
+		client:             client,
+		refreshInterval:    refreshInterval,
+		minRefetchInterval: minRefetchInterval,
+	}
+}
+
+type jwksFetcher struct {
+	url                string
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefetchInterval time.Duration
+
+	// group собирает параллельные загрузки в одну; mu не держится на время HTTP-запроса
+	group     singleflight.Group
+	mu        sync.RWMutex
+	keys      StaticKeySet
+	fetchedAt time.Time
+	// attemptedAt - время последней попытки загрузки, в том числе неудачной
+	attemptedAt time.Time
+}
+
+func (f *jwksFetcher) Key(keyID string) (ed25519.PublicKey, error) {
+	f.mu.RLock()
+	keys, fetchedAt, attemptedAt := f.keys, f.fetchedAt, f.attemptedAt
+	f.mu.RUnlock()
+
+	if keys != nil && time.Since(fetchedAt) < f.refreshInterval {
+		if key, err := keys.Key(keyID); err == nil {
+			return key, nil
+		}
+		if time.Since(attemptedAt) < f.minRefetchInterval {
+			return nil, errors.WithStack(ErrUnknownKey)
+		}
+	}
+
+	keys, err := f.refresh()
+	if err != nil {
+		return nil, err
+	}
+	return keys.Key(keyID)
+}
+
+func (f *jwksFetcher) refresh() (StaticKeySet, error) {
+	keys, err, _ := f.group.Do(f.url, func() (interface{}, error) {
+		keys, err := f.fetch()
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.attemptedAt = time.Now()
+		if err != nil {
+			return nil, err
+		}
+		f.keys = keys
+		f.fetchedAt = f.attemptedAt
+		return keys, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys.(StaticKeySet), nil
+}
+
+func (f *jwksFetcher) fetch() (StaticKeySet, error) {
+	resp, err := f.client.Get(f.url)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to fetch key set: unexpected status %d", resp.StatusCode)
+	}
+
+	var set JWKSet
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	keys := make(StaticKeySet, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, keyErr := jwk.PublicKey()
+		if keyErr != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	return keys, nil
+}
@@ -0,0 +1,77 @@
+package authtoken
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidToken = errors.New("access token is invalid")
+
+type Verifier interface {
+	Verify(accessToken string) (*Claims, error)
+}
+
+func NewVerifier(keys KeySource, issuer string) Verifier {
+	return &verifier{
+		keys: keys,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{SigningAlgorithm}),
+			jwt.WithIssuer(issuer),
+			jwt.WithExpirationRequired(),
+		),
+	}
+}
+
+type verifier struct {
+	keys   KeySource
+	parser *jwt.Parser
+}
+
+func (v *verifier) Verify(accessToken string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := v.parser.ParseWithClaims(accessToken, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		return v.keys.Key(keyID)
+	})
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, err.Error())
+	}
+	return claims, nil
+}
+
+// SessionChecker сообщает, действует ли сессия, к которой привязан токен
+type SessionChecker interface {
+	IsSessionActive(sessionID uuid.UUID) (bool, error)
+}
+
+// NewSessionVerifier дополняет проверку подписи проверкой сессии,
+// поэтому отзыв сессии действует сразу, не дожидаясь истечения access-токена
+func NewSessionVerifier(verifier Verifier, sessions SessionChecker) Verifier {
+	return &sessionVerifier{verifier: verifier, sessions: sessions}
+}
+
+type sessionVerifier struct {
+	verifier Verifier
+	sessions SessionChecker
+}
+
+func (v *sessionVerifier) Verify(accessToken string) (*Claims, error) {
+	claims, err := v.verifier.Verify(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, "token has no session")
+	}
+	active, err := v.sessions.IsSessionActive(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, errors.Wrap(ErrInvalidToken, "session is revoked")
+	}
+	return claims, nil
+}
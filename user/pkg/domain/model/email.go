@@ -0,0 +1,61 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+var ErrInvalidEmail = errors.New("email is invalid")
+
+const (
+	maxEmailLength     = 254
+	maxEmailLocalPart  = 64
+	emailDomainDivider = "@"
+)
+
+// NormalizeEmail возвращает форму адреса, по которой сравниваются пользователи:
+// без пробелов по краям, в нижнем регистре, с доменом в ASCII (punycode) по правилам IDNA
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, emailDomainDivider)
+	if at <= 0 || at == len(email)-1 {
+		return "", ErrInvalidEmail
+	}
+
+	local := strings.ToLower(email[:at])
+	if len(local) > maxEmailLocalPart || strings.IndexFunc(local, invalidEmailRune) >= 0 || strings.Contains(local, emailDomainDivider) {
+		return "", ErrInvalidEmail
+	}
+
+	domain, err := idna.Lookup.ToASCII(email[at+1:])
+	if err != nil || !validEmailDomain(domain) {
+		return "", ErrInvalidEmail
+	}
+
+	normalized := local + emailDomainDivider + domain
+	if len(normalized) > maxEmailLength {
+		return "", ErrInvalidEmail
+	}
+	return normalized, nil
+}
+
+func invalidEmailRune(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r)
+}
+
+// validEmailDomain требует хотя бы две непустые метки
+func validEmailDomain(domain string) bool {
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,10 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type UserRegistered struct {
 	UserID    uuid.UUID
@@ -29,3 +33,110 @@ type UserDeactivated struct {
 }
 
 func (e UserDeactivated) Type() string { return "UserDeactivated" }
+
+// UserVerificationRequested - единственное место, где передаётся исходный токен подтверждения
+type UserVerificationRequested struct {
+	UserID    uuid.UUID
+	Email     string
+	FirstName string
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (e UserVerificationRequested) Type() string { return "UserVerificationRequested" }
+
+// Redacted возвращает копию события без токена, пригодную для логирования
+func (e UserVerificationRequested) Redacted() interface{} {
+	e.Token = ""
+	return e
+}
+
+type UserEmailVerified struct {
+	UserID uuid.UUID
+	Email  string
+}
+
+func (e UserEmailVerified) Type() string { return "UserEmailVerified" }
+
+// PasswordResetRequested - единственное место, где передаётся исходный токен сброса пароля
+type PasswordResetRequested struct {
+	UserID    uuid.UUID
+	Email     string
+	FirstName string
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (e PasswordResetRequested) Type() string { return "PasswordResetRequested" }
+
+// Redacted возвращает копию события без токена, пригодную для логирования
+func (e PasswordResetRequested) Redacted() interface{} {
+	e.Token = ""
+	return e
+}
+
+type PasswordChanged struct {
+	UserID uuid.UUID
+}
+
+func (e PasswordChanged) Type() string { return "PasswordChanged" }
+
+type RoleAssigned struct {
+	UserID uuid.UUID
+	Role   string
+}
+
+func (e RoleAssigned) Type() string { return "RoleAssigned" }
+
+type RoleRevoked struct {
+	UserID uuid.UUID
+	Role   string
+}
+
+func (e RoleRevoked) Type() string { return "RoleRevoked" }
+
+type UserLockedOut struct {
+	UserID      uuid.UUID
+	Failures    int
+	LockedUntil time.Time
+}
+
+func (e UserLockedOut) Type() string { return "UserLockedOut" }
+
+const (
+	UnlockReasonExpired = "expired"
+	UnlockReasonAdmin   = "admin"
+)
+
+type UserUnlocked struct {
+	UserID uuid.UUID
+	Reason string
+}
+
+func (e UserUnlocked) Type() string { return "UserUnlocked" }
+
+// UserErased сообщает другим сервисам, что копии персональных данных пользователя нужно удалить
+type UserErased struct {
+	UserID uuid.UUID
+}
+
+func (e UserErased) Type() string { return "UserErased" }
+
+type TwoFactorEnabled struct {
+	UserID uuid.UUID
+}
+
+func (e TwoFactorEnabled) Type() string { return "TwoFactorEnabled" }
+
+type TwoFactorDisabled struct {
+	UserID uuid.UUID
+}
+
+func (e TwoFactorDisabled) Type() string { return "TwoFactorDisabled" }
+
+type RecoveryCodeUsed struct {
+	UserID    uuid.UUID
+	Remaining int
+}
+
+func (e RecoveryCodeUsed) Type() string { return "RecoveryCodeUsed" }
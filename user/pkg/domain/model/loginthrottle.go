@@ -0,0 +1,49 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrLoginThrottled = errors.New("too many failed login attempts, try again later")
+	ErrAccountLocked  = errors.New("account is temporarily locked after too many failed login attempts")
+)
+
+type LoginFailureScope string
+
+const (
+	LoginFailureScopeAccount LoginFailureScope = "account"
+	LoginFailureScopeIP      LoginFailureScope = "ip"
+	// LoginFailureScopeVerificationEmail считает запросы повторного письма подтверждения по email
+	LoginFailureScopeVerificationEmail LoginFailureScope = "verify_email"
+	// LoginFailureScopeResetEmail и LoginFailureScopeResetIP считают запросы сброса пароля
+	LoginFailureScopeResetEmail LoginFailureScope = "reset_email"
+	LoginFailureScopeResetIP    LoginFailureScope = "reset_ip"
+)
+
+// LoginFailureCounter считает неудачные попытки входа по аккаунту или по IP.
+// Блокировка хранится здесь же и не затрагивает UserStatus
+type LoginFailureCounter struct {
+	Scope         LoginFailureScope
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+func (c *LoginFailureCounter) IsLocked(at time.Time) bool {
+	return c.LockedUntil != nil && at.Before(*c.LockedUntil)
+}
+
+type LoginFailureRepository interface {
+	// Get возвращает пустой счётчик, если неудачных попыток не было
+	Get(scope LoginFailureScope, key string) (*LoginFailureCounter, error)
+	// Increment атомарно добавляет неудачу в момент at и возвращает счётчик после изменения.
+	// Неудачи раньше resetBefore отбрасываются, действующая блокировка сохраняется, истёкшая снимается
+	Increment(scope LoginFailureScope, key string, at, resetBefore time.Time) (*LoginFailureCounter, error)
+	// Lock ставит блокировку до lockedUntil и обнуляет неудачи, только если их не меньше threshold.
+	// false означает, что блокировку уже поставил параллельный запрос
+	Lock(scope LoginFailureScope, key string, threshold int, lockedUntil time.Time) (bool, error)
+	Delete(scope LoginFailureScope, key string) error
+}
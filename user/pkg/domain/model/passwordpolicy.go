@@ -0,0 +1,59 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrPasswordPolicyViolation = errors.New("password does not satisfy the password policy")
+
+type PasswordRule string
+
+const (
+	PasswordRuleMinLength    PasswordRule = "min_length"
+	PasswordRuleUppercase    PasswordRule = "uppercase"
+	PasswordRuleLowercase    PasswordRule = "lowercase"
+	PasswordRuleDigit        PasswordRule = "digit"
+	PasswordRuleSpecial      PasswordRule = "special"
+	PasswordRulePersonalData PasswordRule = "personal_data"
+	PasswordRuleCommon       PasswordRule = "common_password"
+	PasswordRuleRecentlyUsed PasswordRule = "recently_used"
+)
+
+// PasswordPolicyError перечисляет все нарушенные правила сразу.
+// errors.Is(err, ErrPasswordPolicyViolation) истинно для любого PasswordPolicyError
+type PasswordPolicyError struct {
+	FailedRules []PasswordRule
+}
+
+func (e *PasswordPolicyError) Error() string {
+	rules := make([]string, 0, len(e.FailedRules))
+	for _, rule := range e.FailedRules {
+		rules = append(rules, string(rule))
+	}
+	return ErrPasswordPolicyViolation.Error() + ": " + strings.Join(rules, ", ")
+}
+
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrPasswordPolicyViolation
+}
+
+type PasswordBlocklist interface {
+	Contains(plainTextPassword string) bool
+}
+
+type PasswordHistoryEntry struct {
+	UserID         uuid.UUID
+	HashedPassword string
+	CreatedAt      time.Time
+}
+
+type PasswordHistoryRepository interface {
+	Add(entry PasswordHistoryEntry) error
+	// ListRecent возвращает последние limit хешей, начиная с самого нового
+	ListRecent(userID uuid.UUID, limit int) ([]PasswordHistoryEntry, error)
+	DeleteForUser(userID uuid.UUID) error
+}
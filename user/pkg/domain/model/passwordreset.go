@@ -0,0 +1,31 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidPasswordResetToken = errors.New("password reset token is invalid, expired or already used")
+
+// PasswordResetToken одноразовый, хранится только в виде хеша
+type PasswordResetToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+type PasswordResetTokenRepository interface {
+	NextID() (uuid.UUID, error)
+	Create(token *PasswordResetToken) error
+	Update(token *PasswordResetToken) error
+	// FindByHash возвращает ErrInvalidPasswordResetToken, если токен не найден
+	FindByHash(tokenHash string) (*PasswordResetToken, error)
+	// InvalidateForUser помечает все неиспользованные токены пользователя использованными
+	InvalidateForUser(userID uuid.UUID, at time.Time) error
+	ListForUser(userID uuid.UUID) ([]PasswordResetToken, error)
+}
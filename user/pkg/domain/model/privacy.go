@@ -0,0 +1,92 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUserAlreadyErased     = errors.New("user has already been erased")
+	ErrErasureReasonRequired = errors.New("erasure reason is required")
+)
+
+// UserErasure - запись аудита удаления персональных данных, хранится бессрочно
+type UserErasure struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	RequestedBy uuid.UUID
+	Reason      string
+	ErasedAt    time.Time
+}
+
+type UserErasureRepository interface {
+	NextID() (uuid.UUID, error)
+	Create(erasure *UserErasure) error
+	// FindByUserID возвращает ErrUserNotFound, если данные пользователя не удалялись
+	FindByUserID(userID uuid.UUID) (*UserErasure, error)
+	// WithinTransaction выполняет fn в одной транзакции: изменения всех репозиториев из repos
+	// фиксируются вместе или откатываются, если fn вернула ошибку
+	WithinTransaction(fn func(repos ErasureRepositories) error) error
+}
+
+// ErasureRepositories - репозитории, привязанные к транзакции удаления данных
+type ErasureRepositories interface {
+	Users() UserRepository
+	Roles() RoleRepository
+	RefreshTokens() RefreshTokenRepository
+	Sessions() SessionRepository
+	Verifications() EmailVerificationTokenRepository
+	PasswordResets() PasswordResetTokenRepository
+	PasswordHistory() PasswordHistoryRepository
+	LoginFailures() LoginFailureRepository
+	TwoFactor() TwoFactorRepository
+	RecoveryCodes() RecoveryCodeRepository
+	Erasures() UserErasureRepository
+}
+
+// UserDataExport - всё, что сервис хранит о пользователе, кроме секретов (хешей пароля и токенов)
+type UserDataExport struct {
+	ExportedAt      time.Time              `json:"exportedAt"`
+	Profile         ExportedProfile        `json:"profile"`
+	Roles           []string               `json:"roles"`
+	RefreshTokens   []ExportedToken        `json:"refreshTokens"`
+	Sessions        []ExportedSession      `json:"sessions"`
+	Verifications   []ExportedToken        `json:"emailVerifications"`
+	PasswordResets  []ExportedToken        `json:"passwordResets"`
+	PasswordChanges []time.Time            `json:"passwordChanges"`
+	LoginFailures   *ExportedLoginFailures `json:"loginFailures,omitempty"`
+	// TwoFactorEnabledAt - только факт подключения, секрет и коды восстановления не выгружаются
+	TwoFactorEnabledAt *time.Time `json:"twoFactorEnabledAt,omitempty"`
+}
+
+type ExportedProfile struct {
+	UserID    uuid.UUID `json:"userID"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type ExportedToken struct {
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+}
+
+type ExportedSession struct {
+	UserAgent  string     `json:"userAgent"`
+	IPAddress  string     `json:"ipAddress"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+type ExportedLoginFailures struct {
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"lastFailureAt"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrRoleNotFound = errors.New("role not found")
+
+type Permission string
+
+const (
+	// PermissionUsersRead - просмотр чужих профилей
+	PermissionUsersRead Permission = "users:read"
+	// PermissionUsersAdmin - смена статуса пользователей и управление ролями
+	PermissionUsersAdmin Permission = "users:admin"
+	// PermissionUsersSearch - поиск и просмотр списка пользователей
+	PermissionUsersSearch Permission = "users:search"
+)
+
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+)
+
+type Role struct {
+	Name        string
+	Permissions []Permission
+}
+
+type RoleRepository interface {
+	// FindRole возвращает ErrRoleNotFound для неизвестной роли
+	FindRole(name string) (*Role, error)
+	ListUserRoles(userID uuid.UUID) ([]Role, error)
+	AssignRole(userID uuid.UUID, roleName string) error
+	RevokeRole(userID uuid.UUID, roleName string) error
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// ClientInfo описывает устройство, с которого выполняется вход
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+// Session создаётся при входе и совпадает с цепочкой ротации refresh-токенов (ID == RefreshToken.FamilyID).
+// LastSeenAt обновляется при каждом обновлении токенов
+type Session struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	UserAgent  string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
+
+func (s *Session) IsActive(at time.Time) bool {
+	return s.RevokedAt == nil && at.Before(s.ExpiresAt)
+}
+
+type SessionRepository interface {
+	Create(session *Session) error
+	Update(session *Session) error
+	// Find возвращает ErrSessionNotFound, если сессии нет
+	Find(id uuid.UUID) (*Session, error)
+	ListForUser(userID uuid.UUID) ([]Session, error)
+	RevokeAllForUser(userID uuid.UUID, revokedAt time.Time) error
+	DeleteForUser(userID uuid.UUID) error
+}
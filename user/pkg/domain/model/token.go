@@ -0,0 +1,47 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrUserNotActive       = errors.New("user is not active")
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid, expired or revoked")
+)
+
+// RefreshToken хранится только в виде хеша. Токены одной цепочки ротации объединены FamilyID
+type RefreshToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+type RefreshTokenRepository interface {
+	NextID() (uuid.UUID, error)
+	Create(token *RefreshToken) error
+	Update(token *RefreshToken) error
+	FindByHash(tokenHash string) (*RefreshToken, error)
+	RevokeFamily(familyID uuid.UUID, revokedAt time.Time) error
+	RevokeAllForUser(userID uuid.UUID, revokedAt time.Time) error
+	ListForUser(userID uuid.UUID) ([]RefreshToken, error)
+}
+
+type TokenPair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+type AccessTokenIssuer interface {
+	// Issue включает sessionID в токен, чтобы отзыв сессии действовал на уже выданные access-токены
+	Issue(user *User, sessionID uuid.UUID, permissions []Permission, expiresAt time.Time) (string, error)
+}
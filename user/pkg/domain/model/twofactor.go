@@ -0,0 +1,84 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTwoFactorNotEnrolled      = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrInvalidTwoFactorCode      = errors.New("two-factor code is invalid")
+	ErrInvalidTwoFactorChallenge = errors.New("two-factor challenge is invalid, expired or used")
+)
+
+// TwoFactor - TOTP-секрет пользователя. До подтверждения первым кодом EnabledAt пуст и вход не меняется.
+// LastUsedStep не даёт повторно использовать уже принятый код
+type TwoFactor struct {
+	UserID       uuid.UUID
+	Secret       string
+	CreatedAt    time.Time
+	EnabledAt    *time.Time
+	LastUsedStep int64
+}
+
+func (t *TwoFactor) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+type TwoFactorRepository interface {
+	// Find возвращает ErrTwoFactorNotEnrolled, если пользователь не начинал подключение
+	Find(userID uuid.UUID) (*TwoFactor, error)
+	Save(twoFactor *TwoFactor) error
+	Delete(userID uuid.UUID) error
+}
+
+// RecoveryCodeRepository хранит только хеши одноразовых кодов восстановления
+type RecoveryCodeRepository interface {
+	// Replace заменяет все коды пользователя новыми
+	Replace(userID uuid.UUID, codeHashes []string, createdAt time.Time) error
+	// Consume помечает код использованным и сообщает, был ли он действующим
+	Consume(userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error)
+	CountUnused(userID uuid.UUID) (int, error)
+	DeleteForUser(userID uuid.UUID) error
+}
+
+// TwoFactorChallenge - промежуточное состояние входа после проверки пароля.
+// Хранится только хеш токена, ClientInfo переносится в создаваемую сессию
+type TwoFactorChallenge struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	Client    ClientInfo
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+type TwoFactorChallengeRepository interface {
+	NextID() (uuid.UUID, error)
+	Create(challenge *TwoFactorChallenge) error
+	Update(challenge *TwoFactorChallenge) error
+	// FindByHash возвращает ErrInvalidTwoFactorChallenge, если токен неизвестен
+	FindByHash(tokenHash string) (*TwoFactorChallenge, error)
+}
+
+// TOTPEnrollment возвращается один раз при подключении и не хранится в открытом виде нигде, кроме TwoFactor.Secret
+type TOTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// LoginResult содержит либо токены, либо вызов второго фактора
+type LoginResult struct {
+	Tokens    *TokenPair
+	Challenge *TwoFactorChallengeToken
+}
+
+type TwoFactorChallengeToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
@@ -21,26 +21,56 @@ const (
 	Deactivated
 )
 
+func (s UserStatus) String() string {
+	switch s {
+	case PendingVerification:
+		return "pending_verification"
+	case Active:
+		return "active"
+	case Suspended:
+		return "suspended"
+	case Deactivated:
+		return "deactivated"
+	default:
+		return "unknown"
+	}
+}
+
+// User.Email хранится в том виде, как его ввёл пользователь, уникальность обеспечивается по NormalizedEmail
 type User struct {
-	ID             uuid.UUID
-	Email          string
-	HashedPassword string
-	FirstName      string
-	LastName       string
-	Status         UserStatus
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	ID              uuid.UUID
+	Email           string
+	NormalizedEmail string
+	HashedPassword  string
+	FirstName       string
+	LastName        string
+	Status          UserStatus
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 type UserRepository interface {
 	NextID() (uuid.UUID, error)
+	// Create и Update возвращают ErrEmailTaken, если NormalizedEmail уже занят
 	Create(user *User) error
 	Update(user *User) error
 	Find(id uuid.UUID) (*User, error)
-	FindByEmail(email string) (*User, error)
+	// FindByEmail ищет по результату NormalizeEmail
+	FindByEmail(normalizedEmail string) (*User, error)
+	// Search возвращает не больше criteria.Limit пользователей в порядке criteria.SortBy
+	Search(criteria UserSearchCriteria) ([]User, error)
 }
 
 type PasswordManager interface {
 	Hash(plainTextPassword string) (string, error)
 	Check(hashedPassword, plainTextPassword string) (bool, error)
 }
+
+// TOTPManager реализует одноразовые пароли RFC 6238
+type TOTPManager interface {
+	GenerateSecret() (string, error)
+	// ProvisioningURI возвращает otpauth:// URI для приложения-аутентификатора
+	ProvisioningURI(secret, accountName string) string
+	// Validate возвращает номер временного шага принятого кода, чтобы не принимать его повторно
+	Validate(secret, code string, at time.Time) (step int64, ok bool)
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidSearchQuery  = errors.New("user search query is invalid")
+	ErrInvalidSearchCursor = errors.New("user search cursor is invalid")
+)
+
+type UserSortField int
+
+const (
+	SortByCreatedAt UserSortField = iota
+	SortByEmail
+	SortByLastName
+)
+
+// UserSearchQuery - запрос администратора. Пустые поля не ограничивают выборку
+type UserSearchQuery struct {
+	// Prefix ищется в начале имени, фамилии и email
+	Prefix      string
+	Statuses    []UserStatus
+	CreatedFrom *time.Time
+	// CreatedTo не включается в диапазон
+	CreatedTo  *time.Time
+	SortBy     UserSortField
+	Descending bool
+	PageSize   int
+	// Cursor - непрозрачное значение UserSearchPage.NextCursor предыдущей страницы
+	Cursor string
+}
+
+type UserSearchPage struct {
+	Users []User
+	// NextCursor пуст на последней странице
+	NextCursor string
+}
+
+// UserSearchCriteria - разобранный запрос для репозитория
+type UserSearchCriteria struct {
+	Prefix      string
+	Statuses    []UserStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	SortBy      UserSortField
+	Descending  bool
+	// After - позиция последней строки предыдущей страницы, сортировка всегда дополняется ID
+	After *UserSearchPosition
+	Limit int
+}
+
+type UserSearchPosition struct {
+	CreatedAt time.Time
+	Email     string
+	LastName  string
+	ID        uuid.UUID
+}
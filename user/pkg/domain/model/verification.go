@@ -0,0 +1,36 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidVerificationToken  = errors.New("verification token is invalid, expired or already used")
+	ErrVerificationTokenNotFound = errors.New("verification token not found")
+)
+
+// EmailVerificationToken одноразовый, хранится только в виде хеша
+type EmailVerificationToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+type EmailVerificationTokenRepository interface {
+	NextID() (uuid.UUID, error)
+	Create(token *EmailVerificationToken) error
+	Update(token *EmailVerificationToken) error
+	// FindByHash возвращает ErrInvalidVerificationToken, если токен не найден
+	FindByHash(tokenHash string) (*EmailVerificationToken, error)
+	// FindLatestForUser возвращает ErrVerificationTokenNotFound, если токенов нет
+	FindLatestForUser(userID uuid.UUID) (*EmailVerificationToken, error)
+	// InvalidateForUser помечает все неиспользованные токены пользователя использованными
+	InvalidateForUser(userID uuid.UUID, at time.Time) error
+	ListForUser(userID uuid.UUID) ([]EmailVerificationToken, error)
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"user/pkg/domain/model"
+)
+
+const opaqueTokenBytes = 32
+
+// maxTwoFactorAttempts - сколько неверных кодов допускается в рамках одного вызова второго фактора
+const maxTwoFactorAttempts = 5
+
+type AuthConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// TwoFactorChallengeTTL - сколько действует вызов второго фактора после проверки пароля
+	TwoFactorChallengeTTL time.Duration
+	LoginThrottle         LoginThrottleConfig
+}
+
+type AuthService interface {
+	// Authenticate учитывает неудачные попытки по email и по client.IPAddress,
+	// пустой адрес отключает ограничение по адресу. Успешный вход создаёт сессию.
+	// При включённом втором факторе вместо токенов возвращается вызов для CompleteTwoFactorLogin
+	Authenticate(email, plainTextPassword string, client model.ClientInfo) (*model.LoginResult, error)
+	// CompleteTwoFactorLogin принимает TOTP-код или код восстановления.
+	// Неверные коды учитываются ограничением попыток входа наравне с неверными паролями
+	CompleteTwoFactorLogin(challengeToken, code string) (*model.TokenPair, error)
+	// Refresh выдаёт новую пару токенов и отзывает предъявленный refresh-токен.
+	// Повторное предъявление отозванного токена отзывает всю цепочку ротации
+	Refresh(refreshToken string) (*model.TokenPair, error)
+	// Revoke отзывает сессию, к которой относится refresh-токен
+	Revoke(refreshToken string) error
+	// UnlockUser снимает блокировку входа, не меняя UserStatus
+	UnlockUser(userID uuid.UUID) error
+}
+
+func NewAuthService(
+	userRepo model.UserRepository,
+	tokenRepo model.RefreshTokenRepository,
+	sessionRepo model.SessionRepository,
+	roleRepo model.RoleRepository,
+	loginFailureRepo model.LoginFailureRepository,
+	challengeRepo model.TwoFactorChallengeRepository,
+	passManager model.PasswordManager,
+	issuer model.AccessTokenIssuer,
+	twoFactorService TwoFactorService,
+	dispatcher EventDispatcher,
+	config AuthConfig,
+) AuthService {
+	return &authService{
+		userRepo:      userRepo,
+		tokenRepo:     tokenRepo,
+		sessionRepo:   sessionRepo,
+		roleRepo:      roleRepo,
+		challengeRepo: challengeRepo,
+		passManager:   passManager,
+		issuer:        issuer,
+		twoFactor:     twoFactorService,
+		throttler: &loginThrottler{
+			repo:       loginFailureRepo,
+			dispatcher: dispatcher,
+			config:     config.LoginThrottle,
+		},
+		config: config,
+	}
+}
+
+type authService struct {
+	userRepo      model.UserRepository
+	tokenRepo     model.RefreshTokenRepository
+	sessionRepo   model.SessionRepository
+	roleRepo      model.RoleRepository
+	challengeRepo model.TwoFactorChallengeRepository
+	passManager   model.PasswordManager
+	issuer        model.AccessTokenIssuer
+	twoFactor     TwoFactorService
+	throttler     *loginThrottler
+	config        AuthConfig
+
+	dummyHashOnce sync.Once
+	dummyHash     string
+}
+
+func (s *authService) Authenticate(email, plainTextPassword string, client model.ClientInfo) (*model.LoginResult, error) {
+	now := time.Now().UTC()
+	counters, err := s.throttler.check(email, client.IPAddress, now)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := findUserByEmail(s.userRepo, email)
+	if errors.Is(err, model.ErrUserNotFound) {
+		// Проверяем пароль против фиктивного хеша, чтобы время ответа не выдавало существование email
+		_, _ = s.passManager.Check(s.getDummyHash(), plainTextPassword)
+		if err := s.throttler.registerFailure(counters, nil, now); err != nil {
+			return nil, err
+		}
+		return nil, model.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.passManager.Check(user.HashedPassword, plainTextPassword)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.throttler.registerFailure(counters, user, now); err != nil {
+			return nil, err
+		}
+		return nil, model.ErrInvalidCredentials
+	}
+
+	twoFactorEnabled, err := s.twoFactor.IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	// При втором факторе счётчик неудач сбрасывается только после проверки кода, иначе пароль позволил бы перебирать коды
+	if !twoFactorEnabled {
+		if err := s.throttler.registerSuccess(counters, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.Status != model.Active {
+		return nil, model.ErrUserNotActive
+	}
+
+	if twoFactorEnabled {
+		challenge, err := s.createChallenge(user, client, now)
+		if err != nil {
+			return nil, err
+		}
+		return &model.LoginResult{Challenge: challenge}, nil
+	}
+	tokens, err := s.startSession(user, client, now)
+	if err != nil {
+		return nil, err
+	}
+	return &model.LoginResult{Tokens: tokens}, nil
+}
+
+func (s *authService) CompleteTwoFactorLogin(challengeToken, code string) (*model.TokenPair, error) {
+	challenge, err := s.challengeRepo.FindByHash(hashToken(challengeToken))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if challenge.UsedAt != nil || !now.Before(challenge.ExpiresAt) || challenge.Attempts >= maxTwoFactorAttempts {
+		return nil, model.ErrInvalidTwoFactorChallenge
+	}
+
+	user, err := s.userRepo.Find(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	counters, err := s.throttler.check(user.Email, challenge.Client.IPAddress, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.twoFactor.VerifyCode(user.ID, code)
+	if errors.Is(err, model.ErrInvalidTwoFactorCode) {
+		challenge.Attempts++
+		if err := s.challengeRepo.Update(challenge); err != nil {
+			return nil, err
+		}
+		if err := s.throttler.registerFailure(counters, user, now); err != nil {
+			return nil, err
+		}
+		return nil, model.ErrInvalidTwoFactorCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	challenge.UsedAt = &now
+	if err := s.challengeRepo.Update(challenge); err != nil {
+		return nil, err
+	}
+	if err := s.throttler.registerSuccess(counters, user); err != nil {
+		return nil, err
+	}
+
+	if user.Status != model.Active {
+		return nil, model.ErrUserNotActive
+	}
+	return s.startSession(user, challenge.Client, now)
+}
+
+func (s *authService) createChallenge(
+	user *model.User,
+	client model.ClientInfo,
+	now time.Time,
+) (*model.TwoFactorChallengeToken, error) {
+	rawToken, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.challengeRepo.NextID()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &model.TwoFactorChallenge{
+		ID:        id,
+		UserID:    user.ID,
+		TokenHash: hashToken(rawToken),
+		Client:    client,
+		ExpiresAt: now.Add(s.config.TwoFactorChallengeTTL),
+		CreatedAt: now,
+	}
+	if err := s.challengeRepo.Create(challenge); err != nil {
+		return nil, err
+	}
+	return &model.TwoFactorChallengeToken{Token: rawToken, ExpiresAt: challenge.ExpiresAt}, nil
+}
+
+func (s *authService) startSession(user *model.User, client model.ClientInfo, now time.Time) (*model.TokenPair, error) {
+	// Сессия совпадает с цепочкой ротации refresh-токенов
+	sessionID, err := s.tokenRepo.NextID()
+	if err != nil {
+		return nil, err
+	}
+	session := &model.Session{
+		ID:         sessionID,
+		UserID:     user.ID,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.config.RefreshTokenTTL),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+	return s.issueTokenPair(user, session.ID)
+}
+
+func (s *authService) Refresh(refreshToken string) (*model.TokenPair, error) {
+	token, err := s.findRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if token.RevokedAt != nil {
+		if err := s.tokenRepo.RevokeFamily(token.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, model.ErrInvalidRefreshToken
+	}
+	if !now.Before(token.ExpiresAt) {
+		return nil, model.ErrInvalidRefreshToken
+	}
+
+	// Токены, выданные до появления сессий, сессии не имеют и требуют повторного входа
+	session, err := s.sessionRepo.Find(token.FamilyID)
+	if errors.Is(err, model.ErrSessionNotFound) {
+		return nil, model.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil {
+		return nil, model.ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.Find(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	token.RevokedAt = &now
+	if err := s.tokenRepo.Update(token); err != nil {
+		return nil, err
+	}
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(s.config.RefreshTokenTTL)
+	if err := s.sessionRepo.Update(session); err != nil {
+		return nil, err
+	}
+
+	if user.Status != model.Active {
+		return nil, model.ErrUserNotActive
+	}
+	return s.issueTokenPair(user, token.FamilyID)
+}
+
+func (s *authService) Revoke(refreshToken string) error {
+	token, err := s.findRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	session, err := s.sessionRepo.Find(token.FamilyID)
+	if err != nil && !errors.Is(err, model.ErrSessionNotFound) {
+		return err
+	}
+	if session != nil && session.RevokedAt == nil {
+		session.RevokedAt = &now
+		if err := s.sessionRepo.Update(session); err != nil {
+			return err
+		}
+	}
+	return s.tokenRepo.RevokeFamily(token.FamilyID, now)
+}
+
+func (s *authService) UnlockUser(userID uuid.UUID) error {
+	user, err := s.userRepo.Find(userID)
+	if err != nil {
+		return err
+	}
+	return s.throttler.unlock(user)
+}
+
+// findRefreshToken ищет токен по хешу, неизвестный токен - model.ErrInvalidRefreshToken
+func (s *authService) findRefreshToken(refreshToken string) (*model.RefreshToken, error) {
+	return s.tokenRepo.FindByHash(hashToken(refreshToken))
+}
+
+func (s *authService) issueTokenPair(user *model.User, sessionID uuid.UUID) (*model.TokenPair, error) {
+	now := time.Now().UTC()
+	accessTokenExpiresAt := now.Add(s.config.AccessTokenTTL)
+	permissions, err := userPermissions(s.roleRepo, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.issuer.Issue(user, sessionID, permissions, accessTokenExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rawRefreshToken, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	tokenID, err := s.tokenRepo.NextID()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken := &model.RefreshToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		FamilyID:  sessionID,
+		TokenHash: hashToken(rawRefreshToken),
+		ExpiresAt: now.Add(s.config.RefreshTokenTTL),
+		CreatedAt: now,
+	}
+	if err := s.tokenRepo.Create(refreshToken); err != nil {
+		return nil, err
+	}
+
+	return &model.TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessTokenExpiresAt,
+		RefreshToken:          rawRefreshToken,
+		RefreshTokenExpiresAt: refreshToken.ExpiresAt,
+	}, nil
+}
+
+func (s *authService) getDummyHash() string {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = s.passManager.Hash("dummy-password-for-timing")
+	})
+	return s.dummyHash
+}
+
+// generateToken возвращает случайный непрозрачный токен, пригодный для передачи в URL
+func generateToken() (string, error) {
+	b := make([]byte, opaqueTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
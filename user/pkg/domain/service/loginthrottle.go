@@ -0,0 +1,160 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"user/pkg/domain/model"
+)
+
+type LoginThrottleConfig struct {
+	// FreeAttempts - сколько неудач подряд допускается без задержки
+	FreeAttempts int
+	// BaseDelay удваивается с каждой следующей неудачей, но не превышает MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutThreshold - после стольких неудач аккаунт блокируется на LockoutDuration, 0 отключает блокировку
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// FailureWindow - через столько времени после последней неудачи счётчик обнуляется
+	FailureWindow time.Duration
+}
+
+// loginThrottler учитывает неудачные попытки входа по аккаунту и по IP
+type loginThrottler struct {
+	repo       model.LoginFailureRepository
+	dispatcher EventDispatcher
+	config     LoginThrottleConfig
+}
+
+type loginCounters struct {
+	account *model.LoginFailureCounter
+	ip      *model.LoginFailureCounter
+}
+
+// check загружает счётчики и возвращает ErrAccountLocked или ErrLoginThrottled, если попытку выполнять рано
+func (t *loginThrottler) check(email, ip string, now time.Time) (*loginCounters, error) {
+	account, err := t.load(model.LoginFailureScopeAccount, accountThrottleKey(email), now)
+	if err != nil {
+		return nil, err
+	}
+	counters := &loginCounters{account: account}
+	if ip != "" {
+		if counters.ip, err = t.load(model.LoginFailureScopeIP, ip, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if account.IsLocked(now) {
+		return nil, model.ErrAccountLocked
+	}
+	if now.Before(t.nextAttemptAt(account)) || (counters.ip != nil && now.Before(t.nextAttemptAt(counters.ip))) {
+		return nil, model.ErrLoginThrottled
+	}
+	return counters, nil
+}
+
+// registerFailure увеличивает счётчики; user равен nil, если email не зарегистрирован.
+// Решение о блокировке принимается по значению, которое вернуло атомарное увеличение,
+// поэтому параллельные попытки не проскакивают порог
+func (t *loginThrottler) registerFailure(counters *loginCounters, user *model.User, now time.Time) error {
+	account, err := t.repo.Increment(model.LoginFailureScopeAccount, counters.account.Key, now, t.resetBefore(now))
+	if err != nil {
+		return err
+	}
+	if counters.ip != nil {
+		if _, err := t.repo.Increment(model.LoginFailureScopeIP, counters.ip.Key, now, t.resetBefore(now)); err != nil {
+			return err
+		}
+	}
+
+	if t.config.LockoutThreshold <= 0 || account.Failures < t.config.LockoutThreshold {
+		return nil
+	}
+	// После блокировки отсчёт задержек начинается заново
+	lockedUntil := now.Add(t.config.LockoutDuration)
+	locked, err := t.repo.Lock(model.LoginFailureScopeAccount, account.Key, t.config.LockoutThreshold, lockedUntil)
+	if err != nil {
+		return err
+	}
+
+	if locked && user != nil {
+		_ = t.dispatcher.Dispatch(model.UserLockedOut{
+			UserID:      user.ID,
+			Failures:    account.Failures,
+			LockedUntil: lockedUntil,
+		})
+	}
+	return nil
+}
+
+// registerSuccess сбрасывает счётчик аккаунта. Счётчик IP не сбрасывается,
+// чтобы вход в свой аккаунт не обнулял перебор чужих с того же адреса
+func (t *loginThrottler) registerSuccess(counters *loginCounters, user *model.User) error {
+	account := counters.account
+	if account.Failures == 0 && account.LockedUntil == nil {
+		return nil
+	}
+	if err := t.repo.Delete(account.Scope, account.Key); err != nil {
+		return err
+	}
+
+	if account.LockedUntil != nil {
+		_ = t.dispatcher.Dispatch(model.UserUnlocked{UserID: user.ID, Reason: model.UnlockReasonExpired})
+	}
+	return nil
+}
+
+func (t *loginThrottler) unlock(user *model.User) error {
+	if err := t.repo.Delete(model.LoginFailureScopeAccount, accountThrottleKey(user.Email)); err != nil {
+		return err
+	}
+
+	_ = t.dispatcher.Dispatch(model.UserUnlocked{UserID: user.ID, Reason: model.UnlockReasonAdmin})
+	return nil
+}
+
+// load возвращает счётчик, обнуляя неудачи старше FailureWindow. Действующая блокировка сохраняется
+func (t *loginThrottler) load(scope model.LoginFailureScope, key string, now time.Time) (*model.LoginFailureCounter, error) {
+	counter, err := t.repo.Get(scope, key)
+	if err != nil {
+		return nil, err
+	}
+	if counter.Failures > 0 && t.config.FailureWindow > 0 && now.Sub(counter.LastFailureAt) > t.config.FailureWindow {
+		counter.Failures = 0
+	}
+	return counter, nil
+}
+
+// resetBefore - неудачи раньше этого момента выпали из FailureWindow; нулевое время, если окна нет
+func (t *loginThrottler) resetBefore(now time.Time) time.Time {
+	if t.config.FailureWindow <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-t.config.FailureWindow)
+}
+
+func (t *loginThrottler) nextAttemptAt(counter *model.LoginFailureCounter) time.Time {
+	excess := counter.Failures - t.config.FreeAttempts
+	if excess <= 0 {
+		return time.Time{}
+	}
+
+	delay := t.config.BaseDelay
+	for i := 1; i < excess && delay < t.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > t.config.MaxDelay {
+		delay = t.config.MaxDelay
+	}
+	return counter.LastFailureAt.Add(delay)
+}
+
+// accountThrottleKey строится по email, а не по ID, чтобы незарегистрированные адреса
+// ограничивались так же, как существующие, и ответы не раскрывали наличие аккаунта
+func accountThrottleKey(email string) string {
+	if normalizedEmail, err := model.NormalizeEmail(email); err == nil {
+		return normalizedEmail
+	}
+	return strings.ToLower(strings.TrimSpace(email))
+}
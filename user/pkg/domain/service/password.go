@@ -0,0 +1,191 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"user/pkg/domain/model"
+)
+
+type PasswordResetConfig struct {
+	TokenTTL time.Duration
+	// EmailLimit и IPLimit - сколько запросов сброса допускается на email и на IP за LimitWindow, 0 отключает
+	EmailLimit  int
+	IPLimit     int
+	LimitWindow time.Duration
+}
+
+type PasswordService interface {
+	ChangePassword(userID uuid.UUID, currentPassword, newPassword string) error
+	// RequestPasswordReset не сообщает, зарегистрирован ли email, и молча пропускает лишние запросы.
+	// ip необязателен
+	RequestPasswordReset(email, ip string) error
+	ConfirmPasswordReset(token, newPassword string) error
+}
+
+func NewPasswordService(
+	userRepo model.UserRepository,
+	resetTokenRepo model.PasswordResetTokenRepository,
+	loginFailureRepo model.LoginFailureRepository,
+	sessionService SessionService,
+	passManager model.PasswordManager,
+	passwordPolicy PasswordPolicy,
+	dispatcher EventDispatcher,
+	config PasswordResetConfig,
+) PasswordService {
+	return &passwordService{
+		userRepo:       userRepo,
+		resetTokenRepo: resetTokenRepo,
+		sessionService: sessionService,
+		passManager:    passManager,
+		passwordPolicy: passwordPolicy,
+		dispatcher:     dispatcher,
+		config:         config,
+		emailLimiter:   &requestLimiter{repo: loginFailureRepo, limit: config.EmailLimit, window: config.LimitWindow},
+		ipLimiter:      &requestLimiter{repo: loginFailureRepo, limit: config.IPLimit, window: config.LimitWindow},
+	}
+}
+
+type passwordService struct {
+	userRepo       model.UserRepository
+	resetTokenRepo model.PasswordResetTokenRepository
+	sessionService SessionService
+	passManager    model.PasswordManager
+	passwordPolicy PasswordPolicy
+	dispatcher     EventDispatcher
+	config         PasswordResetConfig
+	emailLimiter   *requestLimiter
+	ipLimiter      *requestLimiter
+}
+
+func (s *passwordService) ChangePassword(userID uuid.UUID, currentPassword, newPassword string) error {
+	user, err := s.userRepo.Find(userID)
+	if err != nil {
+		return err
+	}
+	if user.Status == model.Deactivated {
+		return ErrUserCannotBeChanged
+	}
+
+	ok, err := s.passManager.Check(user.HashedPassword, currentPassword)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return model.ErrInvalidCredentials
+	}
+
+	return s.setPassword(user, newPassword)
+}
+
+func (s *passwordService) RequestPasswordReset(email, ip string) error {
+	now := time.Now().UTC()
+	// Ограничения проверяются до поиска пользователя, чтобы ответ не зависел от наличия аккаунта.
+	// Запрос, отклонённый по IP, не расходует лимит email
+	allowed, err := s.ipLimiter.allow(model.LoginFailureScopeResetIP, ip, now)
+	if err != nil || !allowed {
+		return err
+	}
+	allowed, err = s.emailLimiter.allow(model.LoginFailureScopeResetEmail, accountThrottleKey(email), now)
+	if err != nil || !allowed {
+		return err
+	}
+
+	user, err := findUserByEmail(s.userRepo, email)
+	if errors.Is(err, model.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Status == model.Deactivated {
+		return nil
+	}
+
+	if err := s.resetTokenRepo.InvalidateForUser(user.ID, now); err != nil {
+		return err
+	}
+
+	rawToken, err := generateToken()
+	if err != nil {
+		return err
+	}
+	tokenID, err := s.resetTokenRepo.NextID()
+	if err != nil {
+		return err
+	}
+
+	token := &model.PasswordResetToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: now.Add(s.config.TokenTTL),
+		CreatedAt: now,
+	}
+	if err := s.resetTokenRepo.Create(token); err != nil {
+		return err
+	}
+
+	_ = s.dispatcher.Dispatch(model.PasswordResetRequested{
+		UserID:    user.ID,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		Token:     rawToken,
+		ExpiresAt: token.ExpiresAt,
+	})
+	return nil
+}
+
+func (s *passwordService) ConfirmPasswordReset(rawToken, newPassword string) error {
+	token, err := s.resetTokenRepo.FindByHash(hashToken(rawToken))
+	if err != nil {
+		return err
+	}
+	if token.UsedAt != nil || !time.Now().UTC().Before(token.ExpiresAt) {
+		return model.ErrInvalidPasswordResetToken
+	}
+
+	user, err := s.userRepo.Find(token.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Status == model.Deactivated {
+		return model.ErrInvalidPasswordResetToken
+	}
+
+	return s.setPassword(user, newPassword)
+}
+
+// setPassword проверяет пароль по политике, сохраняет его, погашает токены сброса
+// и отзывает все refresh-токены пользователя
+func (s *passwordService) setPassword(user *model.User, newPassword string) error {
+	if err := s.passwordPolicy.Validate(user, newPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.passManager.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	user.HashedPassword = hashedPassword
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	if err := s.passwordPolicy.Remember(user); err != nil {
+		return err
+	}
+
+	if err := s.resetTokenRepo.InvalidateForUser(user.ID, now); err != nil {
+		return err
+	}
+	if err := s.sessionService.RevokeAllSessions(user.ID); err != nil {
+		return err
+	}
+
+	_ = s.dispatcher.Dispatch(model.PasswordChanged{UserID: user.ID})
+	return nil
+}
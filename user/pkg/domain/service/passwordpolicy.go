@@ -0,0 +1,167 @@
+package service
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"user/pkg/domain/model"
+)
+
+// minPersonalDataLength - более короткие фрагменты имени и email не проверяются, иначе под запрет попадёт слишком много паролей
+const minPersonalDataLength = 3
+
+type PasswordPolicyConfig struct {
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSpecial   bool
+	// HistorySize - сколько последних паролей нельзя использовать повторно, 0 отключает проверку
+	HistorySize int
+}
+
+type PasswordPolicy interface {
+	// Validate возвращает *model.PasswordPolicyError со всеми нарушенными правилами.
+	// Для ещё не созданного пользователя история паролей не проверяется
+	Validate(user *model.User, plainTextPassword string) error
+	// Remember сохраняет текущий хеш пароля пользователя в историю
+	Remember(user *model.User) error
+}
+
+func NewPasswordPolicy(
+	config PasswordPolicyConfig,
+	blocklist model.PasswordBlocklist,
+	historyRepo model.PasswordHistoryRepository,
+	passManager model.PasswordManager,
+) PasswordPolicy {
+	return &passwordPolicy{
+		config:      config,
+		blocklist:   blocklist,
+		historyRepo: historyRepo,
+		passManager: passManager,
+	}
+}
+
+type passwordPolicy struct {
+	config      PasswordPolicyConfig
+	blocklist   model.PasswordBlocklist
+	historyRepo model.PasswordHistoryRepository
+	passManager model.PasswordManager
+}
+
+func (p *passwordPolicy) Validate(user *model.User, plainTextPassword string) error {
+	var failed []model.PasswordRule
+
+	if utf8.RuneCountInString(plainTextPassword) < p.config.MinLength {
+		failed = append(failed, model.PasswordRuleMinLength)
+	}
+	failed = append(failed, p.checkCharacterClasses(plainTextPassword)...)
+
+	if containsPersonalData(user, plainTextPassword) {
+		failed = append(failed, model.PasswordRulePersonalData)
+	}
+	if p.blocklist.Contains(plainTextPassword) {
+		failed = append(failed, model.PasswordRuleCommon)
+	}
+
+	reused, err := p.isRecentlyUsed(user, plainTextPassword)
+	if err != nil {
+		return err
+	}
+	if reused {
+		failed = append(failed, model.PasswordRuleRecentlyUsed)
+	}
+
+	if len(failed) > 0 {
+		return &model.PasswordPolicyError{FailedRules: failed}
+	}
+	return nil
+}
+
+func (p *passwordPolicy) Remember(user *model.User) error {
+	if p.config.HistorySize <= 0 {
+		return nil
+	}
+	return p.historyRepo.Add(model.PasswordHistoryEntry{
+		UserID:         user.ID,
+		HashedPassword: user.HashedPassword,
+		CreatedAt:      time.Now().UTC(),
+	})
+}
+
+func (p *passwordPolicy) checkCharacterClasses(password string) []model.PasswordRule {
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+
+	var failed []model.PasswordRule
+	if p.config.RequireUppercase && !hasUpper {
+		failed = append(failed, model.PasswordRuleUppercase)
+	}
+	if p.config.RequireLowercase && !hasLower {
+		failed = append(failed, model.PasswordRuleLowercase)
+	}
+	if p.config.RequireDigit && !hasDigit {
+		failed = append(failed, model.PasswordRuleDigit)
+	}
+	if p.config.RequireSpecial && !hasSpecial {
+		failed = append(failed, model.PasswordRuleSpecial)
+	}
+	return failed
+}
+
+// isRecentlyUsed сверяет пароль с текущим хешем и последними хешами из истории
+func (p *passwordPolicy) isRecentlyUsed(user *model.User, password string) (bool, error) {
+	if p.config.HistorySize <= 0 || user.ID == uuid.Nil {
+		return false, nil
+	}
+
+	hashes := []string{user.HashedPassword}
+	entries, err := p.historyRepo.ListRecent(user.ID, p.config.HistorySize)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		hashes = append(hashes, entry.HashedPassword)
+	}
+
+	for _, hash := range hashes {
+		if hash == "" {
+			continue
+		}
+		ok, err := p.passManager.Check(hash, password)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func containsPersonalData(user *model.User, password string) bool {
+	password = strings.ToLower(password)
+	localPart, _, _ := strings.Cut(user.Email, "@")
+
+	for _, fragment := range []string{localPart, user.FirstName, user.LastName} {
+		fragment = strings.ToLower(strings.TrimSpace(fragment))
+		if utf8.RuneCountInString(fragment) >= minPersonalDataLength && strings.Contains(password, fragment) {
+			return true
+		}
+	}
+	return false
+}
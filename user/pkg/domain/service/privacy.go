@@ -0,0 +1,297 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"user/pkg/domain/model"
+)
+
+// maxExportedPasswordChanges ограничивает историю смен пароля в выгрузке
+const maxExportedPasswordChanges = 1000
+
+type PrivacyService interface {
+	ExportUserData(userID uuid.UUID) (*model.UserDataExport, error)
+	// EraseUser необратимо обезличивает пользователя, сохраняя строку для ссылочной целостности
+	EraseUser(userID, requestedBy uuid.UUID, reason string) error
+}
+
+func NewPrivacyService(
+	userRepo model.UserRepository,
+	roleRepo model.RoleRepository,
+	refreshTokenRepo model.RefreshTokenRepository,
+	sessionRepo model.SessionRepository,
+	verificationRepo model.EmailVerificationTokenRepository,
+	resetTokenRepo model.PasswordResetTokenRepository,
+	historyRepo model.PasswordHistoryRepository,
+	loginFailureRepo model.LoginFailureRepository,
+	twoFactorRepo model.TwoFactorRepository,
+	recoveryCodeRepo model.RecoveryCodeRepository,
+	erasureRepo model.UserErasureRepository,
+	dispatcher EventDispatcher,
+) PrivacyService {
+	return &privacyService{
+		userRepo:         userRepo,
+		roleRepo:         roleRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		sessionRepo:      sessionRepo,
+		verificationRepo: verificationRepo,
+		resetTokenRepo:   resetTokenRepo,
+		historyRepo:      historyRepo,
+		loginFailureRepo: loginFailureRepo,
+		twoFactorRepo:    twoFactorRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		erasureRepo:      erasureRepo,
+		dispatcher:       dispatcher,
+	}
+}
+
+type privacyService struct {
+	userRepo         model.UserRepository
+	roleRepo         model.RoleRepository
+	refreshTokenRepo model.RefreshTokenRepository
+	sessionRepo      model.SessionRepository
+	verificationRepo model.EmailVerificationTokenRepository
+	resetTokenRepo   model.PasswordResetTokenRepository
+	historyRepo      model.PasswordHistoryRepository
+	loginFailureRepo model.LoginFailureRepository
+	twoFactorRepo    model.TwoFactorRepository
+	recoveryCodeRepo model.RecoveryCodeRepository
+	erasureRepo      model.UserErasureRepository
+	dispatcher       EventDispatcher
+}
+
+func (s *privacyService) ExportUserData(userID uuid.UUID) (*model.UserDataExport, error) {
+	user, err := s.userRepo.Find(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &model.UserDataExport{
+		ExportedAt: time.Now().UTC(),
+		Profile: model.ExportedProfile{
+			UserID:    user.ID,
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Status:    user.Status.String(),
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		},
+		Roles:           []string{},
+		PasswordChanges: []time.Time{},
+	}
+
+	roles, err := s.roleRepo.ListUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		export.Roles = append(export.Roles, role.Name)
+	}
+
+	refreshTokens, err := s.refreshTokenRepo.ListForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	export.RefreshTokens = make([]model.ExportedToken, 0, len(refreshTokens))
+	for _, token := range refreshTokens {
+		export.RefreshTokens = append(export.RefreshTokens, model.ExportedToken{
+			CreatedAt: token.CreatedAt, ExpiresAt: token.ExpiresAt, UsedAt: token.RevokedAt,
+		})
+	}
+
+	sessions, err := s.sessionRepo.ListForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	export.Sessions = make([]model.ExportedSession, 0, len(sessions))
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, model.ExportedSession{
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			RevokedAt:  session.RevokedAt,
+		})
+	}
+
+	verifications, err := s.verificationRepo.ListForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	export.Verifications = make([]model.ExportedToken, 0, len(verifications))
+	for _, token := range verifications {
+		export.Verifications = append(export.Verifications, model.ExportedToken{
+			CreatedAt: token.CreatedAt, ExpiresAt: token.ExpiresAt, UsedAt: token.UsedAt,
+		})
+	}
+
+	resets, err := s.resetTokenRepo.ListForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	export.PasswordResets = make([]model.ExportedToken, 0, len(resets))
+	for _, token := range resets {
+		export.PasswordResets = append(export.PasswordResets, model.ExportedToken{
+			CreatedAt: token.CreatedAt, ExpiresAt: token.ExpiresAt, UsedAt: token.UsedAt,
+		})
+	}
+
+	history, err := s.historyRepo.ListRecent(userID, maxExportedPasswordChanges)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range history {
+		export.PasswordChanges = append(export.PasswordChanges, entry.CreatedAt)
+	}
+
+	failures, err := s.loginFailureRepo.Get(model.LoginFailureScopeAccount, accountThrottleKey(user.Email))
+	if err != nil {
+		return nil, err
+	}
+	if failures.Failures > 0 || failures.LockedUntil != nil {
+		export.LoginFailures = &model.ExportedLoginFailures{
+			Failures:      failures.Failures,
+			LastFailureAt: failures.LastFailureAt,
+			LockedUntil:   failures.LockedUntil,
+		}
+	}
+
+	twoFactor, err := s.twoFactorRepo.Find(userID)
+	if err != nil && !errors.Is(err, model.ErrTwoFactorNotEnrolled) {
+		return nil, err
+	}
+	if twoFactor != nil {
+		export.TwoFactorEnabledAt = twoFactor.EnabledAt
+	}
+
+	return export, nil
+}
+
+func (s *privacyService) EraseUser(userID, requestedBy uuid.UUID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return model.ErrErasureReasonRequired
+	}
+
+	var oldStatus model.UserStatus
+	err := s.erasureRepo.WithinTransaction(func(repos model.ErasureRepositories) error {
+		var err error
+		oldStatus, err = eraseUser(repos, userID, requestedBy, reason)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// События отправляются только после фиксации, чтобы другие сервисы не удалили копии данных,
+	// которые здесь остались после отката
+	if oldStatus != model.Deactivated {
+		_ = s.dispatcher.Dispatch(model.UserStatusChanged{
+			UserID:    userID,
+			OldStatus: oldStatus,
+			NewStatus: model.Deactivated,
+		})
+		_ = s.dispatcher.Dispatch(model.UserDeactivated{UserID: userID})
+	}
+	_ = s.dispatcher.Dispatch(model.UserErased{UserID: userID})
+	return nil
+}
+
+// eraseUser обезличивает пользователя и удаляет связанные записи; возвращает статус до удаления
+func eraseUser(repos model.ErasureRepositories, userID, requestedBy uuid.UUID, reason string) (model.UserStatus, error) {
+	user, err := repos.Users().Find(userID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := repos.Erasures().FindByUserID(userID); err == nil {
+		return 0, model.ErrUserAlreadyErased
+	} else if !errors.Is(err, model.ErrUserNotFound) {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	originalEmail := user.Email
+	oldStatus := user.Status
+
+	// Обезличенный адрес уникален и заведомо недоставляем (RFC 2606)
+	user.Email = fmt.Sprintf("erased-%s@erased.invalid", user.ID)
+	user.NormalizedEmail = user.Email
+	user.FirstName = ""
+	user.LastName = ""
+	user.HashedPassword = ""
+	user.Status = model.Deactivated
+	user.UpdatedAt = now
+	if err := repos.Users().Update(user); err != nil {
+		return 0, err
+	}
+
+	if err := purgeRelatedData(repos, user, originalEmail, now); err != nil {
+		return 0, err
+	}
+
+	erasureID, err := repos.Erasures().NextID()
+	if err != nil {
+		return 0, err
+	}
+	// Уникальный индекс по user_id отклоняет параллельное удаление того же пользователя
+	err = repos.Erasures().Create(&model.UserErasure{
+		ID:          erasureID,
+		UserID:      userID,
+		RequestedBy: requestedBy,
+		Reason:      reason,
+		ErasedAt:    now,
+	})
+	return oldStatus, err
+}
+
+// purgeRelatedData отзывает токены и удаляет связанные с пользователем записи
+func purgeRelatedData(repos model.ErasureRepositories, user *model.User, originalEmail string, now time.Time) error {
+	if err := repos.RefreshTokens().RevokeAllForUser(user.ID, now); err != nil {
+		return err
+	}
+	// Удалённая сессия считается отозванной, а её IP и user agent не сохраняются
+	if err := repos.Sessions().DeleteForUser(user.ID); err != nil {
+		return err
+	}
+	if err := repos.Verifications().InvalidateForUser(user.ID, now); err != nil {
+		return err
+	}
+	if err := repos.PasswordResets().InvalidateForUser(user.ID, now); err != nil {
+		return err
+	}
+	if err := repos.PasswordHistory().DeleteForUser(user.ID); err != nil {
+		return err
+	}
+	if err := repos.TwoFactor().Delete(user.ID); err != nil {
+		return err
+	}
+	if err := repos.RecoveryCodes().DeleteForUser(user.ID); err != nil {
+		return err
+	}
+	// Счётчики попыток хранятся по адресу, поэтому удаляются вместе с ним
+	for _, scope := range []model.LoginFailureScope{
+		model.LoginFailureScopeAccount,
+		model.LoginFailureScopeVerificationEmail,
+		model.LoginFailureScopeResetEmail,
+	} {
+		if err := repos.LoginFailures().Delete(scope, accountThrottleKey(originalEmail)); err != nil {
+			return err
+		}
+	}
+
+	roles, err := repos.Roles().ListUserRoles(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if err := repos.Roles().RevokeRole(user.ID, role.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"time"
+
+	"user/pkg/domain/model"
+)
+
+// requestLimiter ограничивает запросы, которые отправляют письма, независимо от того, есть ли аккаунт.
+// Счётчики лежат в таблице неудачных входов в отдельных областях
+type requestLimiter struct {
+	repo model.LoginFailureRepository
+	// limit - сколько запросов допускается на ключ, 0 отключает ограничение
+	limit int
+	// window отсчитывается от последнего запроса, поэтому частые повторы продлевают ограничение
+	window time.Duration
+}
+
+// allow учитывает запрос и возвращает false, если для ключа он лишний
+func (l *requestLimiter) allow(scope model.LoginFailureScope, key string, now time.Time) (bool, error) {
+	if l.limit <= 0 || key == "" {
+		return true, nil
+	}
+	counter, err := l.repo.Increment(scope, key, now, now.Add(-l.window))
+	if err != nil {
+		return false, err
+	}
+	return counter.Failures <= l.limit, nil
+}
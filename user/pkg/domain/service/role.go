@@ -0,0 +1,88 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+	"user/pkg/domain/model"
+)
+
+type RoleService interface {
+	AssignRole(userID uuid.UUID, roleName string) error
+	RevokeRole(userID uuid.UUID, roleName string) error
+	GetUserRoles(userID uuid.UUID) ([]model.Role, error)
+	// GetUserPermissions объединяет разрешения всех ролей пользователя
+	GetUserPermissions(userID uuid.UUID) ([]model.Permission, error)
+}
+
+func NewRoleService(userRepo model.UserRepository, roleRepo model.RoleRepository, dispatcher EventDispatcher) RoleService {
+	return &roleService{
+		userRepo:   userRepo,
+		roleRepo:   roleRepo,
+		dispatcher: dispatcher,
+	}
+}
+
+type roleService struct {
+	userRepo   model.UserRepository
+	roleRepo   model.RoleRepository
+	dispatcher EventDispatcher
+}
+
+func (s *roleService) AssignRole(userID uuid.UUID, roleName string) error {
+	if _, err := s.userRepo.Find(userID); err != nil {
+		return err
+	}
+	if _, err := s.roleRepo.FindRole(roleName); err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.AssignRole(userID, roleName); err != nil {
+		return err
+	}
+
+	_ = s.dispatcher.Dispatch(model.RoleAssigned{UserID: userID, Role: roleName})
+	return nil
+}
+
+func (s *roleService) RevokeRole(userID uuid.UUID, roleName string) error {
+	if _, err := s.roleRepo.FindRole(roleName); err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.RevokeRole(userID, roleName); err != nil {
+		return err
+	}
+
+	_ = s.dispatcher.Dispatch(model.RoleRevoked{UserID: userID, Role: roleName})
+	return nil
+}
+
+func (s *roleService) GetUserRoles(userID uuid.UUID) ([]model.Role, error) {
+	return s.roleRepo.ListUserRoles(userID)
+}
+
+func (s *roleService) GetUserPermissions(userID uuid.UUID) ([]model.Permission, error) {
+	return userPermissions(s.roleRepo, userID)
+}
+
+func userPermissions(roleRepo model.RoleRepository, userID uuid.UUID) ([]model.Permission, error) {
+	roles, err := roleRepo.ListUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := make(map[model.Permission]struct{})
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			unique[permission] = struct{}{}
+		}
+	}
+
+	permissions := make([]model.Permission, 0, len(unique))
+	for permission := range unique {
+		permissions = append(permissions, permission)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions, nil
+}
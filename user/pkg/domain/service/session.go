@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"user/pkg/domain/model"
+)
+
+type SessionService interface {
+	// ListSessions возвращает только действующие сессии
+	ListSessions(userID uuid.UUID) ([]model.Session, error)
+	RevokeSession(userID, sessionID uuid.UUID) error
+	RevokeAllSessions(userID uuid.UUID) error
+	// IsSessionActive используется при проверке access-токенов
+	IsSessionActive(sessionID uuid.UUID) (bool, error)
+}
+
+func NewSessionService(sessionRepo model.SessionRepository, tokenRepo model.RefreshTokenRepository) SessionService {
+	return &sessionService{
+		sessionRepo: sessionRepo,
+		tokenRepo:   tokenRepo,
+	}
+}
+
+type sessionService struct {
+	sessionRepo model.SessionRepository
+	tokenRepo   model.RefreshTokenRepository
+}
+
+func (s *sessionService) ListSessions(userID uuid.UUID) ([]model.Session, error) {
+	sessions, err := s.sessionRepo.ListForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	active := make([]model.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if session.IsActive(now) {
+			active = append(active, session)
+		}
+	}
+	return active, nil
+}
+
+func (s *sessionService) RevokeSession(userID, sessionID uuid.UUID) error {
+	session, err := s.sessionRepo.Find(sessionID)
+	if err != nil {
+		return err
+	}
+	// Чужая сессия неотличима от несуществующей
+	if session.UserID != userID {
+		return model.ErrSessionNotFound
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	session.RevokedAt = &now
+	if err := s.sessionRepo.Update(session); err != nil {
+		return err
+	}
+	return s.tokenRepo.RevokeFamily(session.ID, now)
+}
+
+func (s *sessionService) RevokeAllSessions(userID uuid.UUID) error {
+	now := time.Now().UTC()
+	if err := s.sessionRepo.RevokeAllForUser(userID, now); err != nil {
+		return err
+	}
+	return s.tokenRepo.RevokeAllForUser(userID, now)
+}
+
+func (s *sessionService) IsSessionActive(sessionID uuid.UUID) (bool, error) {
+	session, err := s.sessionRepo.Find(sessionID)
+	if errors.Is(err, model.ErrSessionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return session.IsActive(time.Now().UTC()), nil
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"user/pkg/domain/model"
+)
+
+const (
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 10
+	// recoveryCodeGroup - длина групп символов, разделённых дефисом, для удобства ввода
+	recoveryCodeGroup = 4
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TwoFactorService interface {
+	// EnrollTOTP создаёт новый секрет, до ConfirmTOTP вход остаётся одношаговым
+	EnrollTOTP(userID uuid.UUID) (*model.TOTPEnrollment, error)
+	// ConfirmTOTP включает второй фактор и возвращает коды восстановления.
+	// Коды хранятся только в виде хешей и больше не показываются
+	ConfirmTOTP(userID uuid.UUID, code string) ([]string, error)
+	// DisableTOTP требует действующий TOTP-код или код восстановления. Неверные коды здесь и в
+	// RegenerateRecoveryCodes считаются неудачами входа: задержки и блокировка общие со входом
+	DisableTOTP(userID uuid.UUID, code string) error
+	RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error)
+
+	IsEnabled(userID uuid.UUID) (bool, error)
+	// VerifyCode принимает TOTP-код или код восстановления, ErrInvalidTwoFactorCode при несовпадении
+	VerifyCode(userID uuid.UUID, code string) error
+}
+
+func NewTwoFactorService(
+	userRepo model.UserRepository,
+	twoFactorRepo model.TwoFactorRepository,
+	recoveryCodeRepo model.RecoveryCodeRepository,
+	loginFailureRepo model.LoginFailureRepository,
+	totp model.TOTPManager,
+	dispatcher EventDispatcher,
+	throttle LoginThrottleConfig,
+) TwoFactorService {
+	return &twoFactorService{
+		userRepo:         userRepo,
+		twoFactorRepo:    twoFactorRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		totp:             totp,
+		dispatcher:       dispatcher,
+		throttler: &loginThrottler{
+			repo:       loginFailureRepo,
+			dispatcher: dispatcher,
+			config:     throttle,
+		},
+	}
+}
+
+type twoFactorService struct {
+	userRepo         model.UserRepository
+	twoFactorRepo    model.TwoFactorRepository
+	recoveryCodeRepo model.RecoveryCodeRepository
+	totp             model.TOTPManager
+	dispatcher       EventDispatcher
+	throttler        *loginThrottler
+}
+
+func (s *twoFactorService) EnrollTOTP(userID uuid.UUID) (*model.TOTPEnrollment, error) {
+	user, err := s.userRepo.Find(userID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.twoFactorRepo.Find(userID)
+	if err != nil && !errors.Is(err, model.ErrTwoFactorNotEnrolled) {
+		return nil, err
+	}
+	if existing != nil && existing.Enabled() {
+		return nil, model.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := s.totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	// Неподтверждённый секрет от прошлой попытки заменяется
+	if err := s.twoFactorRepo.Save(&model.TwoFactor{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &model.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: s.totp.ProvisioningURI(secret, user.Email),
+	}, nil
+}
+
+func (s *twoFactorService) ConfirmTOTP(userID uuid.UUID, code string) ([]string, error) {
+	twoFactor, err := s.twoFactorRepo.Find(userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Enabled() {
+		return nil, model.ErrTwoFactorAlreadyEnabled
+	}
+
+	now := time.Now().UTC()
+	step, ok := s.totp.Validate(twoFactor.Secret, code, now)
+	if !ok {
+		return nil, model.ErrInvalidTwoFactorCode
+	}
+	twoFactor.EnabledAt = &now
+	twoFactor.LastUsedStep = step
+	if err := s.twoFactorRepo.Save(twoFactor); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.dispatcher.Dispatch(model.TwoFactorEnabled{UserID: userID})
+	return codes, nil
+}
+
+func (s *twoFactorService) DisableTOTP(userID uuid.UUID, code string) error {
+	if err := s.verifyThrottled(userID, code); err != nil {
+		return err
+	}
+	if err := s.twoFactorRepo.Delete(userID); err != nil {
+		return err
+	}
+	if err := s.recoveryCodeRepo.DeleteForUser(userID); err != nil {
+		return err
+	}
+
+	_ = s.dispatcher.Dispatch(model.TwoFactorDisabled{UserID: userID})
+	return nil
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error) {
+	if err := s.verifyThrottled(userID, code); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(userID, time.Now().UTC())
+}
+
+func (s *twoFactorService) IsEnabled(userID uuid.UUID) (bool, error) {
+	twoFactor, err := s.twoFactorRepo.Find(userID)
+	if errors.Is(err, model.ErrTwoFactorNotEnrolled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return twoFactor.Enabled(), nil
+}
+
+func (s *twoFactorService) VerifyCode(userID uuid.UUID, code string) error {
+	twoFactor, err := s.twoFactorRepo.Find(userID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.Enabled() {
+		return model.ErrTwoFactorNotEnrolled
+	}
+
+	now := time.Now().UTC()
+	if step, ok := s.totp.Validate(twoFactor.Secret, code, now); ok && step > twoFactor.LastUsedStep {
+		twoFactor.LastUsedStep = step
+		return s.twoFactorRepo.Save(twoFactor)
+	}
+
+	consumed, err := s.recoveryCodeRepo.Consume(userID, hashToken(normalizeRecoveryCode(code)), now)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return model.ErrInvalidTwoFactorCode
+	}
+
+	remaining, err := s.recoveryCodeRepo.CountUnused(userID)
+	if err != nil {
+		return err
+	}
+	_ = s.dispatcher.Dispatch(model.RecoveryCodeUsed{UserID: userID, Remaining: remaining})
+	return nil
+}
+
+// verifyThrottled проверяет код с тем же счётчиком неудач, что и вход по аккаунту. Успех счётчик не сбрасывает:
+// управление вторым фактором не должно снимать задержки, набранные перебором пароля
+func (s *twoFactorService) verifyThrottled(userID uuid.UUID, code string) error {
+	user, err := s.userRepo.Find(userID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	counters, err := s.throttler.check(user.Email, "", now)
+	if err != nil {
+		return err
+	}
+
+	err = s.VerifyCode(userID, code)
+	if errors.Is(err, model.ErrInvalidTwoFactorCode) {
+		if err := s.throttler.registerFailure(counters, user, now); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *twoFactorService) replaceRecoveryCodes(userID uuid.UUID, now time.Time) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	if err := s.recoveryCodeRepo.Replace(userID, hashes, now); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode возвращает код вида abcd-efgh-ijkl-mnop
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+
+	groups := make([]string, 0, len(raw)/recoveryCodeGroup+1)
+	for len(raw) > recoveryCodeGroup {
+		groups = append(groups, raw[:recoveryCodeGroup])
+		raw = raw[recoveryCodeGroup:]
+	}
+	groups = append(groups, raw)
+	return strings.Join(groups, "-"), nil
+}
+
+// normalizeRecoveryCode допускает ввод без дефисов и в любом регистре
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
@@ -2,18 +2,14 @@ package service
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"user/pkg/domain/model"
 )
 
-var (
-	ErrPasswordTooShort    = errors.New("password is too short")
-	ErrUserCannotBeChanged = errors.New("user cannot be changed in its current state")
-)
-
-const minPasswordLength = 8
+var ErrUserCannotBeChanged = errors.New("user cannot be changed in its current state")
 
 type Event interface {
 	Type() string
@@ -26,31 +22,72 @@ type EventDispatcher interface {
 type UserService interface {
 	RegisterNewUser(firstName, lastName, email, plainTextPassword string) (*model.User, error)
 	UpdateUserProfile(userID uuid.UUID, firstName, lastName string) error
+	// SuspendUser и DeactivateUser отзывают все сессии пользователя
 	SuspendUser(userID uuid.UUID) error
 	ActivateUser(userID uuid.UUID) error
 	DeactivateUser(userID uuid.UUID) error
+
+	// VerifyEmail переводит пользователя из PendingVerification в Active
+	VerifyEmail(token string) error
+	ResendVerification(email string) error
+
+	GetUser(userID uuid.UUID) (*model.User, error)
+	GetUserByEmail(email string) (*model.User, error)
+	// SearchUsers ищет по префиксу имени, фамилии и email с постраничной выдачей по курсору
+	SearchUsers(query model.UserSearchQuery) (*model.UserSearchPage, error)
 }
 
-func NewUserService(repo model.UserRepository, passManager model.PasswordManager, dispatcher EventDispatcher) UserService {
+func NewUserService(
+	repo model.UserRepository,
+	verificationRepo model.EmailVerificationTokenRepository,
+	loginFailureRepo model.LoginFailureRepository,
+	sessionService SessionService,
+	passManager model.PasswordManager,
+	passwordPolicy PasswordPolicy,
+	dispatcher EventDispatcher,
+	verificationConfig VerificationConfig,
+) UserService {
 	return &userService{
-		repo:        repo,
-		passManager: passManager,
-		dispatcher:  dispatcher,
+		repo:               repo,
+		verificationRepo:   verificationRepo,
+		sessionService:     sessionService,
+		passManager:        passManager,
+		passwordPolicy:     passwordPolicy,
+		dispatcher:         dispatcher,
+		verificationConfig: verificationConfig,
+		resendLimiter: &requestLimiter{
+			repo:   loginFailureRepo,
+			limit:  1,
+			window: verificationConfig.ResendInterval,
+		},
 	}
 }
 
 type userService struct {
-	repo        model.UserRepository
-	passManager model.PasswordManager
-	dispatcher  EventDispatcher
+	repo               model.UserRepository
+	verificationRepo   model.EmailVerificationTokenRepository
+	sessionService     SessionService
+	passManager        model.PasswordManager
+	passwordPolicy     PasswordPolicy
+	dispatcher         EventDispatcher
+	verificationConfig VerificationConfig
+	resendLimiter      *requestLimiter
 }
 
 func (s *userService) RegisterNewUser(firstName, lastName, email, plainTextPassword string) (*model.User, error) {
-	if len(plainTextPassword) < minPasswordLength {
-		return nil, ErrPasswordTooShort
+	normalizedEmail, err := model.NormalizeEmail(email)
+	if err != nil {
+		return nil, err
 	}
+	email = strings.TrimSpace(email)
 
-	if _, err := s.repo.FindByEmail(email); err == nil {
+	candidate := &model.User{Email: email, FirstName: firstName, LastName: lastName}
+	if err := s.passwordPolicy.Validate(candidate, plainTextPassword); err != nil {
+		return nil, err
+	}
+
+	// Проверка лишь экономит хеширование пароля, от гонки защищает уникальный индекс в репозитории
+	if _, err := s.repo.FindByEmail(normalizedEmail); err == nil {
 		return nil, model.ErrEmailTaken
 	}
 
@@ -66,19 +103,23 @@ func (s *userService) RegisterNewUser(firstName, lastName, email, plainTextPassw
 
 	now := time.Now().UTC()
 	user := &model.User{
-		ID:             userID,
-		Email:          email,
-		HashedPassword: hashedPassword,
-		FirstName:      firstName,
-		LastName:       lastName,
-		Status:         model.Active,
-		CreatedAt:      now,
-		UpdatedAt:      now,
+		ID:              userID,
+		Email:           email,
+		NormalizedEmail: normalizedEmail,
+		HashedPassword:  hashedPassword,
+		FirstName:       firstName,
+		LastName:        lastName,
+		Status:          model.PendingVerification,
+		CreatedAt:       now,
+		UpdatedAt:       now,
 	}
 
 	if err := s.repo.Create(user); err != nil {
 		return nil, err
 	}
+	if err := s.passwordPolicy.Remember(user); err != nil {
+		return nil, err
+	}
 
 	_ = s.dispatcher.Dispatch(model.UserRegistered{
 		UserID:    userID,
@@ -86,6 +127,10 @@ func (s *userService) RegisterNewUser(firstName, lastName, email, plainTextPassw
 		FirstName: firstName,
 	})
 
+	if err := s.requestVerification(user); err != nil {
+		return nil, err
+	}
+
 	return user, nil
 }
 
@@ -123,6 +168,14 @@ func (s *userService) DeactivateUser(userID uuid.UUID) error {
 	return s.changeStatus(userID, model.Deactivated)
 }
 
+func (s *userService) GetUser(userID uuid.UUID) (*model.User, error) {
+	return s.repo.Find(userID)
+}
+
+func (s *userService) GetUserByEmail(email string) (*model.User, error) {
+	return findUserByEmail(s.repo, email)
+}
+
 func (s *userService) changeStatus(userID uuid.UUID, newStatus model.UserStatus) error {
 	user, err := s.repo.Find(userID)
 	if err != nil {
@@ -140,6 +193,11 @@ func (s *userService) changeStatus(userID uuid.UUID, newStatus model.UserStatus)
 	if err := s.repo.Update(user); err != nil {
 		return err
 	}
+	if newStatus == model.Suspended || newStatus == model.Deactivated {
+		if err := s.sessionService.RevokeAllSessions(userID); err != nil {
+			return err
+		}
+	}
 
 	_ = s.dispatcher.Dispatch(model.UserStatusChanged{
 		UserID:    userID,
@@ -153,3 +211,12 @@ func (s *userService) changeStatus(userID uuid.UUID, newStatus model.UserStatus)
 
 	return nil
 }
+
+// findUserByEmail ищет пользователя по адресу в любом написании, некорректный адрес считается ненайденным
+func findUserByEmail(repo model.UserRepository, email string) (*model.User, error) {
+	normalizedEmail, err := model.NormalizeEmail(email)
+	if err != nil {
+		return nil, model.ErrUserNotFound
+	}
+	return repo.FindByEmail(normalizedEmail)
+}
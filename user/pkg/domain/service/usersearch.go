@@ -0,0 +1,128 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"user/pkg/domain/model"
+)
+
+const (
+	defaultSearchPageSize = 50
+	maxSearchPageSize     = 100
+)
+
+// searchCursor привязан к сортировке: курсор другой сортировки не принимается
+type searchCursor struct {
+	SortBy     model.UserSortField `json:"s"`
+	Descending bool                `json:"d"`
+	CreatedAt  time.Time           `json:"c"`
+	Email      string              `json:"e,omitempty"`
+	LastName   string              `json:"l,omitempty"`
+	ID         uuid.UUID           `json:"id"`
+}
+
+func (s *userService) SearchUsers(query model.UserSearchQuery) (*model.UserSearchPage, error) {
+	criteria, err := searchCriteria(query)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := criteria.Limit
+	// Лишняя строка показывает, есть ли следующая страница
+	criteria.Limit++
+
+	users, err := s.repo.Search(*criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	// Хеш пароля не должен попасть в выдачу, даже если репозиторий его вернул
+	for i := range users {
+		users[i].HashedPassword = ""
+	}
+
+	page := &model.UserSearchPage{Users: users}
+	if len(users) > pageSize {
+		page.Users = users[:pageSize]
+		last := page.Users[pageSize-1]
+		page.NextCursor, err = encodeSearchCursor(searchCursor{
+			SortBy:     query.SortBy,
+			Descending: query.Descending,
+			CreatedAt:  last.CreatedAt,
+			Email:      last.NormalizedEmail,
+			LastName:   last.LastName,
+			ID:         last.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func searchCriteria(query model.UserSearchQuery) (*model.UserSearchCriteria, error) {
+	switch query.SortBy {
+	case model.SortByCreatedAt, model.SortByEmail, model.SortByLastName:
+	default:
+		return nil, model.ErrInvalidSearchQuery
+	}
+	if query.PageSize < 0 || query.PageSize > maxSearchPageSize {
+		return nil, model.ErrInvalidSearchQuery
+	}
+	if query.CreatedFrom != nil && query.CreatedTo != nil && !query.CreatedFrom.Before(*query.CreatedTo) {
+		return nil, model.ErrInvalidSearchQuery
+	}
+
+	criteria := &model.UserSearchCriteria{
+		Prefix:      strings.TrimSpace(query.Prefix),
+		Statuses:    query.Statuses,
+		CreatedFrom: query.CreatedFrom,
+		CreatedTo:   query.CreatedTo,
+		SortBy:      query.SortBy,
+		Descending:  query.Descending,
+		Limit:       query.PageSize,
+	}
+	if criteria.Limit == 0 {
+		criteria.Limit = defaultSearchPageSize
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeSearchCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.SortBy != query.SortBy || cursor.Descending != query.Descending {
+			return nil, model.ErrInvalidSearchCursor
+		}
+		criteria.After = &model.UserSearchPosition{
+			CreatedAt: cursor.CreatedAt,
+			Email:     cursor.Email,
+			LastName:  cursor.LastName,
+			ID:        cursor.ID,
+		}
+	}
+	return criteria, nil
+}
+
+func encodeSearchCursor(cursor searchCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeSearchCursor(value string) (*searchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, model.ErrInvalidSearchCursor
+	}
+	var cursor searchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, model.ErrInvalidSearchCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+type VerificationConfig struct {
+	TokenTTL time.Duration
+	// ResendInterval - минимальный интервал между письмами подтверждения одному пользователю
+	ResendInterval time.Duration
+}
+
+// VerifyEmail активирует ожидающего подтверждения пользователя по одноразовому токену
+func (s *userService) VerifyEmail(rawToken string) error {
+	token, err := s.verificationRepo.FindByHash(hashToken(rawToken))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	now := time.Now().UTC()
+	if token.UsedAt != nil || !now.Before(token.ExpiresAt) {
+		return errors.WithStack(model.ErrInvalidVerificationToken)
+	}
+
+	user, err := s.repo.Find(token.UserID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if user.Status != model.PendingVerification {
+		return errors.WithStack(ErrUserCannotBeChanged)
+	}
+
+	token.UsedAt = &now
+	if err := s.verificationRepo.Update(token); err != nil {
+		return errors.WithStack(err)
+	}
+
+	user.Status = model.Active
+	user.UpdatedAt = now
+	if err := s.repo.Update(user); err != nil {
+		return errors.WithStack(err)
+	}
+
+	_ = s.dispatcher.Dispatch(model.UserStatusChanged{
+		UserID:    user.ID,
+		OldStatus: model.PendingVerification,
+		NewStatus: model.Active,
+	})
+	_ = s.dispatcher.Dispatch(model.UserEmailVerified{
+		UserID: user.ID,
+		Email:  user.Email,
+	})
+	return nil
+}
+
+// ResendVerification выпускает новый токен взамен прежних. Ответ одинаков для неизвестного,
+// подтверждённого и слишком часто запрашиваемого email, чтобы не раскрывать существование аккаунта.
+// Ограничение по email проверяется до поиска пользователя
+func (s *userService) ResendVerification(email string) error {
+	now := time.Now().UTC()
+	allowed, err := s.resendLimiter.allow(model.LoginFailureScopeVerificationEmail, accountThrottleKey(email), now)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !allowed {
+		return nil
+	}
+
+	user, err := findUserByEmail(s.repo, email)
+	if errors.Is(err, model.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if user.Status != model.PendingVerification {
+		return nil
+	}
+
+	// Письмо при регистрации счётчик не увеличивает, поэтому интервал проверяется и по последнему токену
+	latest, err := s.verificationRepo.FindLatestForUser(user.ID)
+	if err != nil && !errors.Is(err, model.ErrVerificationTokenNotFound) {
+		return errors.WithStack(err)
+	}
+	if latest != nil && now.Before(latest.CreatedAt.Add(s.verificationConfig.ResendInterval)) {
+		return nil
+	}
+
+	return s.requestVerification(user)
+}
+
+func (s *userService) requestVerification(user *model.User) error {
+	now := time.Now().UTC()
+	if err := s.verificationRepo.InvalidateForUser(user.ID, now); err != nil {
+		return errors.WithStack(err)
+	}
+
+	rawToken, err := generateToken()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	tokenID, err := s.verificationRepo.NextID()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	token := &model.EmailVerificationToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: now.Add(s.verificationConfig.TokenTTL),
+		CreatedAt: now,
+	}
+	if err := s.verificationRepo.Create(token); err != nil {
+		return errors.WithStack(err)
+	}
+
+	_ = s.dispatcher.Dispatch(model.UserVerificationRequested{
+		UserID:    user.ID,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		Token:     rawToken,
+		ExpiresAt: token.ExpiresAt,
+	})
+	return nil
+}
@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"user/pkg/domain/model"
+	"user/pkg/domain/service"
+)
+
+func setupAuth(t *testing.T) (service.AuthService, service.UserService, *mockRefreshTokenRepository) {
+	userService, repo, passManager, _ := setup(t)
+	tokenRepo := &mockRefreshTokenRepository{store: make(map[uuid.UUID]*model.RefreshToken)}
+	authService := service.NewAuthService(
+		repo,
+		tokenRepo,
+		newMockSessionRepository(),
+		newMockRoleRepository(),
+		newMockLoginFailureRepository(),
+		newMockTwoFactorChallengeRepository(),
+		passManager,
+		&mockAccessTokenIssuer{},
+		newTestTwoFactorService(repo).service,
+		&mockEventDispatcher{},
+		service.AuthConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: time.Hour,
+		},
+	)
+	return authService, userService, tokenRepo
+}
+
+// login выполняет вход без второго фактора и возвращает выданные токены
+func login(t *testing.T, authService service.AuthService, email string) *model.TokenPair {
+	t.Helper()
+	result, err := authService.Authenticate(email, "password123", model.ClientInfo{})
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+	return result.Tokens
+}
+
+func TestAuthenticate(t *testing.T) {
+	authService, userService, tokenRepo := setupAuth(t)
+	user := registerActiveUser(t, userService, "auth@example.com")
+
+	t.Run("Success", func(t *testing.T) {
+		result, err := authService.Authenticate("auth@example.com", "password123", model.ClientInfo{})
+
+		require.NoError(t, err)
+		assert.Nil(t, result.Challenge)
+		tokens := result.Tokens
+		assert.Equal(t, "access-"+user.ID.String(), tokens.AccessToken)
+		assert.NotEmpty(t, tokens.RefreshToken)
+		require.Len(t, tokenRepo.store, 1)
+		for _, stored := range tokenRepo.store {
+			assert.NotEqual(t, tokens.RefreshToken, stored.TokenHash)
+		}
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		_, err := authService.Authenticate("auth@example.com", "wrong-password", model.ClientInfo{})
+		assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+	})
+
+	t.Run("Unknown email", func(t *testing.T) {
+		_, err := authService.Authenticate("nobody@example.com", "password123", model.ClientInfo{})
+		assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+	})
+
+	t.Run("Suspended user", func(t *testing.T) {
+		require.NoError(t, userService.SuspendUser(user.ID))
+		_, err := authService.Authenticate("auth@example.com", "password123", model.ClientInfo{})
+		assert.ErrorIs(t, err, model.ErrUserNotActive)
+	})
+}
+
+func TestRefreshToken_Rotation(t *testing.T) {
+	authService, userService, _ := setupAuth(t)
+	registerActiveUser(t, userService, "refresh@example.com")
+	first := login(t, authService, "refresh@example.com")
+
+	second, err := authService.Refresh(first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	// Повторное использование отозванного токена отзывает всю цепочку
+	_, err = authService.Refresh(first.RefreshToken)
+	assert.ErrorIs(t, err, model.ErrInvalidRefreshToken)
+	_, err = authService.Refresh(second.RefreshToken)
+	assert.ErrorIs(t, err, model.ErrInvalidRefreshToken)
+}
+
+func TestRevokeToken(t *testing.T) {
+	authService, userService, _ := setupAuth(t)
+	registerActiveUser(t, userService, "revoke@example.com")
+	tokens := login(t, authService, "revoke@example.com")
+
+	require.NoError(t, authService.Revoke(tokens.RefreshToken))
+
+	_, err := authService.Refresh(tokens.RefreshToken)
+	assert.ErrorIs(t, err, model.ErrInvalidRefreshToken)
+	assert.ErrorIs(t, authService.Revoke("unknown-token"), model.ErrInvalidRefreshToken)
+}
+
+type mockRefreshTokenRepository struct {
+	store map[uuid.UUID]*model.RefreshToken
+}
+
+func (m *mockRefreshTokenRepository) NextID() (uuid.UUID, error) { return uuid.New(), nil }
+func (m *mockRefreshTokenRepository) Create(token *model.RefreshToken) error {
+	m.store[token.ID] = token
+	return nil
+}
+func (m *mockRefreshTokenRepository) Update(token *model.RefreshToken) error {
+	m.store[token.ID] = token
+	return nil
+}
+func (m *mockRefreshTokenRepository) FindByHash(tokenHash string) (*model.RefreshToken, error) {
+	for _, token := range m.store {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return nil, model.ErrInvalidRefreshToken
+}
+func (m *mockRefreshTokenRepository) RevokeFamily(familyID uuid.UUID, revokedAt time.Time) error {
+	for _, token := range m.store {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			at := revokedAt
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (m *mockRefreshTokenRepository) ListForUser(userID uuid.UUID) ([]model.RefreshToken, error) {
+	var tokens []model.RefreshToken
+	for _, token := range m.store {
+		if token.UserID == userID {
+			tokens = append(tokens, *token)
+		}
+	}
+	return tokens, nil
+}
+func (m *mockRefreshTokenRepository) RevokeAllForUser(userID uuid.UUID, revokedAt time.Time) error {
+	for _, token := range m.store {
+		if token.UserID == userID && token.RevokedAt == nil {
+			at := revokedAt
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+type mockAccessTokenIssuer struct {
+	lastSessionID   uuid.UUID
+	lastPermissions []model.Permission
+}
+
+func (m *mockAccessTokenIssuer) Issue(
+	user *model.User,
+	sessionID uuid.UUID,
+	permissions []model.Permission,
+	_ time.Time,
+) (string, error) {
+	m.lastSessionID = sessionID
+	m.lastPermissions = permissions
+	return "access-" + user.ID.String(), nil
+}
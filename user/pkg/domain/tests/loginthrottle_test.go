@@ -0,0 +1,251 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"user/pkg/domain/model"
+	"user/pkg/domain/service"
+)
+
+func setupThrottledAuth(
+	t *testing.T,
+	throttle service.LoginThrottleConfig,
+) (service.AuthService, service.UserService, *mockUserRepository, *mockLoginFailureRepository, *mockEventDispatcher) {
+	userService, repo, passManager, dispatcher := setup(t)
+	failureRepo := newMockLoginFailureRepository()
+	authService := service.NewAuthService(
+		repo,
+		&mockRefreshTokenRepository{store: make(map[uuid.UUID]*model.RefreshToken)},
+		newMockSessionRepository(),
+		newMockRoleRepository(),
+		failureRepo,
+		newMockTwoFactorChallengeRepository(),
+		passManager,
+		&mockAccessTokenIssuer{},
+		newTestTwoFactorService(repo).service,
+		dispatcher,
+		service.AuthConfig{
+			AccessTokenTTL:  time.Minute,
+			RefreshTokenTTL: time.Hour,
+			LoginThrottle:   throttle,
+		},
+	)
+	return authService, userService, repo, failureRepo, dispatcher
+}
+
+func TestLoginThrottle_ExponentialBackoff(t *testing.T) {
+	authService, userService, _, failureRepo, _ := setupThrottledAuth(t, service.LoginThrottleConfig{
+		FreeAttempts: 2,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+	})
+	registerActiveUser(t, userService, "backoff@example.com")
+
+	for i := 0; i < 2; i++ {
+		_, err := authService.Authenticate("backoff@example.com", "wrong-password", model.ClientInfo{IPAddress: "10.0.0.1"})
+		assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+	}
+	_, err := authService.Authenticate("backoff@example.com", "wrong-password", model.ClientInfo{IPAddress: "10.0.0.1"})
+	assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+
+	// Третья неудача требует паузы, даже верный пароль сейчас не проверяется
+	_, err = authService.Authenticate("backoff@example.com", "password123", model.ClientInfo{IPAddress: "10.0.0.2"})
+	assert.ErrorIs(t, err, model.ErrLoginThrottled)
+
+	failureRepo.shift(-2 * time.Minute)
+	_, err = authService.Authenticate("backoff@example.com", "password123", model.ClientInfo{IPAddress: "10.0.0.2"})
+	require.NoError(t, err)
+
+	counter, _ := failureRepo.Get(model.LoginFailureScopeAccount, "backoff@example.com")
+	assert.Equal(t, 0, counter.Failures)
+}
+
+func TestLoginThrottle_PerIP(t *testing.T) {
+	authService, userService, _, _, _ := setupThrottledAuth(t, service.LoginThrottleConfig{
+		FreeAttempts: 2,
+		BaseDelay:    time.Minute,
+		MaxDelay:     time.Hour,
+	})
+	registerActiveUser(t, userService, "victim@example.com")
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		_, err := authService.Authenticate(email, "guess", model.ClientInfo{IPAddress: "10.0.0.1"})
+		assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+	}
+
+	_, err := authService.Authenticate("victim@example.com", "password123", model.ClientInfo{IPAddress: "10.0.0.1"})
+	assert.ErrorIs(t, err, model.ErrLoginThrottled)
+	_, err = authService.Authenticate("victim@example.com", "password123", model.ClientInfo{IPAddress: "10.0.0.9"})
+	assert.NoError(t, err)
+}
+
+func TestLoginThrottle_Lockout(t *testing.T) {
+	authService, userService, repo, failureRepo, dispatcher := setupThrottledAuth(t, service.LoginThrottleConfig{
+		FreeAttempts:     10,
+		LockoutThreshold: 3,
+		LockoutDuration:  time.Hour,
+	})
+	user := registerActiveUser(t, userService, "locked@example.com")
+	dispatcher.Reset()
+
+	for i := 0; i < 3; i++ {
+		_, err := authService.Authenticate("locked@example.com", "wrong-password", model.ClientInfo{})
+		assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+	}
+
+	require.Len(t, dispatcher.events, 1)
+	lockedOut, ok := dispatcher.events[0].(model.UserLockedOut)
+	require.True(t, ok)
+	assert.Equal(t, user.ID, lockedOut.UserID)
+
+	_, err := authService.Authenticate("locked@example.com", "password123", model.ClientInfo{})
+	assert.ErrorIs(t, err, model.ErrAccountLocked)
+
+	saved, _ := repo.Find(user.ID)
+	assert.Equal(t, model.Active, saved.Status, "lockout must not change user status")
+
+	t.Run("Admin unlock", func(t *testing.T) {
+		dispatcher.Reset()
+		require.NoError(t, authService.UnlockUser(user.ID))
+
+		require.Len(t, dispatcher.events, 1)
+		unlocked, ok := dispatcher.events[0].(model.UserUnlocked)
+		require.True(t, ok)
+		assert.Equal(t, model.UnlockReasonAdmin, unlocked.Reason)
+
+		_, err := authService.Authenticate("locked@example.com", "password123", model.ClientInfo{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Lock expires", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			authService.Authenticate("locked@example.com", "wrong-password", model.ClientInfo{})
+		}
+		failureRepo.shift(-2 * time.Hour)
+		dispatcher.Reset()
+
+		login(t, authService, "locked@example.com")
+
+		require.Len(t, dispatcher.events, 1)
+		unlocked, ok := dispatcher.events[0].(model.UserUnlocked)
+		require.True(t, ok)
+		assert.Equal(t, model.UnlockReasonExpired, unlocked.Reason)
+	})
+
+	t.Run("Unknown email is locked the same way", func(t *testing.T) {
+		dispatcher.Reset()
+		for i := 0; i < 3; i++ {
+			authService.Authenticate("ghost@example.com", "wrong-password", model.ClientInfo{})
+		}
+		_, err := authService.Authenticate("ghost@example.com", "wrong-password", model.ClientInfo{})
+		assert.ErrorIs(t, err, model.ErrAccountLocked)
+		assert.Empty(t, dispatcher.events)
+	})
+}
+
+func TestLoginThrottle_ConcurrentFailuresLockOnce(t *testing.T) {
+	authService, userService, _, failureRepo, dispatcher := setupThrottledAuth(t, service.LoginThrottleConfig{
+		FreeAttempts:     10,
+		LockoutThreshold: 3,
+		LockoutDuration:  time.Hour,
+	})
+	registerActiveUser(t, userService, "race@example.com")
+	dispatcher.Reset()
+	failureRepo.staleReads = true
+
+	// Каждая попытка видит пустой счётчик, но порог считается по значению после увеличения
+	for i := 0; i < 4; i++ {
+		_, err := authService.Authenticate("race@example.com", "wrong-password", model.ClientInfo{})
+		assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+	}
+
+	require.Len(t, dispatcher.events, 1)
+	_, ok := dispatcher.events[0].(model.UserLockedOut)
+	assert.True(t, ok)
+
+	failureRepo.staleReads = false
+	_, err := authService.Authenticate("race@example.com", "password123", model.ClientInfo{})
+	assert.ErrorIs(t, err, model.ErrAccountLocked)
+}
+
+type mockLoginFailureRepository struct {
+	store map[model.LoginFailureScope]map[string]model.LoginFailureCounter
+	// staleReads заставляет Get возвращать пустой счётчик, как будто все попытки прочитали его одновременно
+	staleReads bool
+}
+
+func newMockLoginFailureRepository() *mockLoginFailureRepository {
+	return &mockLoginFailureRepository{store: make(map[model.LoginFailureScope]map[string]model.LoginFailureCounter)}
+}
+
+// shift сдвигает время всех неудач и блокировок, имитируя прошедшее время
+func (m *mockLoginFailureRepository) shift(d time.Duration) {
+	for _, counters := range m.store {
+		for key, counter := range counters {
+			counter.LastFailureAt = counter.LastFailureAt.Add(d)
+			if counter.LockedUntil != nil {
+				lockedUntil := counter.LockedUntil.Add(d)
+				counter.LockedUntil = &lockedUntil
+			}
+			counters[key] = counter
+		}
+	}
+}
+
+func (m *mockLoginFailureRepository) Get(scope model.LoginFailureScope, key string) (*model.LoginFailureCounter, error) {
+	if m.staleReads {
+		return &model.LoginFailureCounter{Scope: scope, Key: key}, nil
+	}
+	if counter, ok := m.store[scope][key]; ok {
+		return &counter, nil
+	}
+	return &model.LoginFailureCounter{Scope: scope, Key: key}, nil
+}
+func (m *mockLoginFailureRepository) Increment(
+	scope model.LoginFailureScope,
+	key string,
+	at, resetBefore time.Time,
+) (*model.LoginFailureCounter, error) {
+	if m.store[scope] == nil {
+		m.store[scope] = make(map[string]model.LoginFailureCounter)
+	}
+	counter, ok := m.store[scope][key]
+	if !ok {
+		counter = model.LoginFailureCounter{Scope: scope, Key: key}
+	}
+	if counter.LastFailureAt.Before(resetBefore) {
+		counter.Failures = 0
+	}
+	counter.Failures++
+	if counter.LockedUntil != nil && !counter.LockedUntil.After(at) {
+		counter.LockedUntil = nil
+	}
+	counter.LastFailureAt = at
+	m.store[scope][key] = counter
+	return &counter, nil
+}
+
+func (m *mockLoginFailureRepository) Lock(
+	scope model.LoginFailureScope,
+	key string,
+	threshold int,
+	lockedUntil time.Time,
+) (bool, error) {
+	counter, ok := m.store[scope][key]
+	if !ok || counter.Failures < threshold {
+		return false, nil
+	}
+	counter.Failures = 0
+	counter.LockedUntil = &lockedUntil
+	m.store[scope][key] = counter
+	return true, nil
+}
+
+func (m *mockLoginFailureRepository) Delete(scope model.LoginFailureScope, key string) error {
+	delete(m.store[scope], key)
+	return nil
+}
@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"user/pkg/domain/model"
+	"user/pkg/domain/service"
+)
+
+func setupPassword(t *testing.T) (service.PasswordService, service.UserService, service.AuthService, *mockEventDispatcher) {
+	return setupPasswordWithLimits(t, service.PasswordResetConfig{TokenTTL: time.Hour})
+}
+
+func setupPasswordWithLimits(
+	t *testing.T,
+	config service.PasswordResetConfig,
+) (service.PasswordService, service.UserService, service.AuthService, *mockEventDispatcher) {
+	userService, repo, passManager, dispatcher := setup(t)
+	tokenRepo := &mockRefreshTokenRepository{store: make(map[uuid.UUID]*model.RefreshToken)}
+	sessionRepo := newMockSessionRepository()
+	resetRepo := &mockPasswordResetTokenRepository{store: make(map[uuid.UUID]*model.PasswordResetToken)}
+	authService := service.NewAuthService(
+		repo,
+		tokenRepo,
+		sessionRepo,
+		newMockRoleRepository(),
+		newMockLoginFailureRepository(),
+		newMockTwoFactorChallengeRepository(),
+		passManager,
+		&mockAccessTokenIssuer{},
+		newTestTwoFactorService(repo).service,
+		dispatcher,
+		service.AuthConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: time.Hour,
+		},
+	)
+	passwordService := service.NewPasswordService(
+		repo,
+		resetRepo,
+		newMockLoginFailureRepository(),
+		service.NewSessionService(sessionRepo, tokenRepo),
+		passManager,
+		newTestPasswordPolicy(),
+		dispatcher,
+		config,
+	)
+	return passwordService, userService, authService, dispatcher
+}
+
+func lastPasswordResetRequest(t *testing.T, dispatcher *mockEventDispatcher) model.PasswordResetRequested {
+	for i := len(dispatcher.events) - 1; i >= 0; i-- {
+		if event, ok := dispatcher.events[i].(model.PasswordResetRequested); ok {
+			return event
+		}
+	}
+	require.Fail(t, "PasswordResetRequested was not dispatched")
+	return model.PasswordResetRequested{}
+}
+
+func TestChangePassword(t *testing.T) {
+	passwordService, userService, authService, dispatcher := setupPassword(t)
+	user := registerActiveUser(t, userService, "change@example.com")
+	tokens := login(t, authService, "change@example.com")
+
+	t.Run("Wrong current password", func(t *testing.T) {
+		err := passwordService.ChangePassword(user.ID, "wrong-password", "new-password")
+		assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+	})
+
+	t.Run("Short new password", func(t *testing.T) {
+		err := passwordService.ChangePassword(user.ID, "password123", "short")
+		assert.ErrorIs(t, err, model.ErrPasswordPolicyViolation)
+	})
+
+	t.Run("Current password cannot be reused", func(t *testing.T) {
+		err := passwordService.ChangePassword(user.ID, "password123", "password123")
+		assert.ErrorIs(t, err, model.ErrPasswordPolicyViolation)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		dispatcher.Reset()
+		require.NoError(t, passwordService.ChangePassword(user.ID, "password123", "new-password"))
+
+		require.Len(t, dispatcher.events, 1)
+		_, ok := dispatcher.events[0].(model.PasswordChanged)
+		assert.True(t, ok)
+
+		_, err := authService.Refresh(tokens.RefreshToken)
+		assert.ErrorIs(t, err, model.ErrInvalidRefreshToken)
+		_, err = authService.Authenticate("change@example.com", "new-password", model.ClientInfo{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestPasswordReset(t *testing.T) {
+	passwordService, userService, authService, dispatcher := setupPassword(t)
+	registerActiveUser(t, userService, "reset@example.com")
+	tokens := login(t, authService, "reset@example.com")
+
+	t.Run("Unknown email is indistinguishable", func(t *testing.T) {
+		dispatcher.Reset()
+		assert.NoError(t, passwordService.RequestPasswordReset("nobody@example.com", ""))
+		assert.Empty(t, dispatcher.events)
+	})
+
+	require.NoError(t, passwordService.RequestPasswordReset("reset@example.com", ""))
+	first := lastPasswordResetRequest(t, dispatcher)
+	require.NoError(t, passwordService.RequestPasswordReset("reset@example.com", ""))
+	second := lastPasswordResetRequest(t, dispatcher)
+
+	t.Run("New request invalidates previous token", func(t *testing.T) {
+		err := passwordService.ConfirmPasswordReset(first.Token, "brand-new-password")
+		assert.ErrorIs(t, err, model.ErrInvalidPasswordResetToken)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		dispatcher.Reset()
+		require.NoError(t, passwordService.ConfirmPasswordReset(second.Token, "brand-new-password"))
+
+		require.Len(t, dispatcher.events, 1)
+		_, ok := dispatcher.events[0].(model.PasswordChanged)
+		assert.True(t, ok)
+
+		_, err := authService.Refresh(tokens.RefreshToken)
+		assert.ErrorIs(t, err, model.ErrInvalidRefreshToken)
+		_, err = authService.Authenticate("reset@example.com", "brand-new-password", model.ClientInfo{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Token is single use", func(t *testing.T) {
+		err := passwordService.ConfirmPasswordReset(second.Token, "another-password")
+		assert.ErrorIs(t, err, model.ErrInvalidPasswordResetToken)
+	})
+}
+
+func TestPasswordReset_Throttled(t *testing.T) {
+	passwordService, userService, _, dispatcher := setupPasswordWithLimits(t, service.PasswordResetConfig{
+		TokenTTL:    time.Hour,
+		EmailLimit:  2,
+		IPLimit:     3,
+		LimitWindow: time.Hour,
+	})
+	registerActiveUser(t, userService, "limited@example.com")
+	registerActiveUser(t, userService, "other@example.com")
+	countRequests := func() int {
+		count := 0
+		for _, event := range dispatcher.events {
+			if _, ok := event.(model.PasswordResetRequested); ok {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("By email", func(t *testing.T) {
+		dispatcher.Reset()
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, passwordService.RequestPasswordReset("limited@example.com", ""))
+		}
+		// Лишний запрос выглядит так же, как запрос для неизвестного email
+		assert.Equal(t, 2, countRequests())
+	})
+
+	t.Run("By IP", func(t *testing.T) {
+		dispatcher.Reset()
+		assert.NoError(t, passwordService.RequestPasswordReset("nobody@example.com", "203.0.113.7"))
+		assert.NoError(t, passwordService.RequestPasswordReset("nobody2@example.com", "203.0.113.7"))
+		assert.NoError(t, passwordService.RequestPasswordReset("other@example.com", "203.0.113.7"))
+		assert.NoError(t, passwordService.RequestPasswordReset("other@example.com", "203.0.113.7"))
+		assert.Equal(t, 1, countRequests())
+
+		assert.NoError(t, passwordService.RequestPasswordReset("other@example.com", "198.51.100.1"))
+		assert.Equal(t, 2, countRequests())
+	})
+}
+
+type mockPasswordResetTokenRepository struct {
+	store map[uuid.UUID]*model.PasswordResetToken
+}
+
+func (m *mockPasswordResetTokenRepository) NextID() (uuid.UUID, error) { return uuid.New(), nil }
+func (m *mockPasswordResetTokenRepository) Create(token *model.PasswordResetToken) error {
+	m.store[token.ID] = token
+	return nil
+}
+func (m *mockPasswordResetTokenRepository) Update(token *model.PasswordResetToken) error {
+	m.store[token.ID] = token
+	return nil
+}
+func (m *mockPasswordResetTokenRepository) FindByHash(tokenHash string) (*model.PasswordResetToken, error) {
+	for _, token := range m.store {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return nil, model.ErrInvalidPasswordResetToken
+}
+func (m *mockPasswordResetTokenRepository) ListForUser(userID uuid.UUID) ([]model.PasswordResetToken, error) {
+	var tokens []model.PasswordResetToken
+	for _, token := range m.store {
+		if token.UserID == userID {
+			tokens = append(tokens, *token)
+		}
+	}
+	return tokens, nil
+}
+func (m *mockPasswordResetTokenRepository) InvalidateForUser(userID uuid.UUID, at time.Time) error {
+	for _, token := range m.store {
+		if token.UserID == userID && token.UsedAt == nil {
+			usedAt := at
+			token.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
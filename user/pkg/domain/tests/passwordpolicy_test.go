@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"user/pkg/domain/model"
+	"user/pkg/domain/service"
+)
+
+// newTestPasswordPolicy требует только длину не меньше 8, чтобы не мешать остальным тестам
+func newTestPasswordPolicy() service.PasswordPolicy {
+	return service.NewPasswordPolicy(
+		service.PasswordPolicyConfig{MinLength: 8, HistorySize: 3},
+		mockPasswordBlocklist{"qwerty123": {}},
+		&mockPasswordHistoryRepository{},
+		&mockPasswordManager{},
+	)
+}
+
+func failedRules(t *testing.T, err error) []model.PasswordRule {
+	var policyErr *model.PasswordPolicyError
+	require.True(t, errors.As(err, &policyErr), "expected PasswordPolicyError, got %v", err)
+	return policyErr.FailedRules
+}
+
+func TestPasswordPolicy_CollectsAllFailedRules(t *testing.T) {
+	policy := service.NewPasswordPolicy(
+		service.PasswordPolicyConfig{
+			MinLength:        12,
+			RequireUppercase: true,
+			RequireLowercase: true,
+			RequireDigit:     true,
+			RequireSpecial:   true,
+		},
+		mockPasswordBlocklist{},
+		&mockPasswordHistoryRepository{},
+		&mockPasswordManager{},
+	)
+	user := &model.User{Email: "john.doe@example.com", FirstName: "John", LastName: "Doe"}
+
+	err := policy.Validate(user, "johnny")
+
+	assert.ErrorIs(t, err, model.ErrPasswordPolicyViolation)
+	assert.Equal(t, []model.PasswordRule{
+		model.PasswordRuleMinLength,
+		model.PasswordRuleUppercase,
+		model.PasswordRuleDigit,
+		model.PasswordRuleSpecial,
+		model.PasswordRulePersonalData,
+	}, failedRules(t, err))
+
+	assert.NoError(t, policy.Validate(user, "Correct-Horse-42"))
+}
+
+func TestPasswordPolicy_Blocklist(t *testing.T) {
+	policy := newTestPasswordPolicy()
+
+	err := policy.Validate(&model.User{Email: "a@example.com"}, "QWERTY123")
+
+	assert.Equal(t, []model.PasswordRule{model.PasswordRuleCommon}, failedRules(t, err))
+}
+
+func TestPasswordPolicy_History(t *testing.T) {
+	history := &mockPasswordHistoryRepository{}
+	policy := service.NewPasswordPolicy(
+		service.PasswordPolicyConfig{MinLength: 8, HistorySize: 2},
+		mockPasswordBlocklist{},
+		history,
+		&mockPasswordManager{},
+	)
+	user := &model.User{ID: uuid.New(), Email: "history@example.com"}
+
+	for _, password := range []string{"first-password", "second-password", "third-password"} {
+		user.HashedPassword = password + "-hashed"
+		require.NoError(t, policy.Remember(user))
+	}
+
+	err := policy.Validate(user, "third-password")
+	assert.Equal(t, []model.PasswordRule{model.PasswordRuleRecentlyUsed}, failedRules(t, err))
+	err = policy.Validate(user, "second-password")
+	assert.Equal(t, []model.PasswordRule{model.PasswordRuleRecentlyUsed}, failedRules(t, err))
+
+	// В истории хранятся только два последних пароля
+	assert.NoError(t, policy.Validate(user, "first-password"))
+	// Для нового пользователя история не проверяется
+	assert.NoError(t, policy.Validate(&model.User{Email: "new@example.com"}, "third-password"))
+}
+
+type mockPasswordBlocklist map[string]struct{}
+
+func (m mockPasswordBlocklist) Contains(password string) bool {
+	_, ok := m[strings.ToLower(password)]
+	return ok
+}
+
+type mockPasswordHistoryRepository struct {
+	entries []model.PasswordHistoryEntry
+}
+
+func (m *mockPasswordHistoryRepository) Add(entry model.PasswordHistoryEntry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+func (m *mockPasswordHistoryRepository) ListRecent(userID uuid.UUID, limit int) ([]model.PasswordHistoryEntry, error) {
+	var result []model.PasswordHistoryEntry
+	for i := len(m.entries) - 1; i >= 0 && len(result) < limit; i-- {
+		if m.entries[i].UserID == userID {
+			result = append(result, m.entries[i])
+		}
+	}
+	return result, nil
+}
+func (m *mockPasswordHistoryRepository) DeleteForUser(userID uuid.UUID) error {
+	kept := m.entries[:0]
+	for _, entry := range m.entries {
+		if entry.UserID != userID {
+			kept = append(kept, entry)
+		}
+	}
+	m.entries = kept
+	return nil
+}
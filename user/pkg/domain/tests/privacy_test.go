@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"user/pkg/domain/model"
+	"user/pkg/domain/service"
+)
+
+type privacyFixture struct {
+	privacyService service.PrivacyService
+	userService    service.UserService
+	authService    service.AuthService
+	userRepo       *mockUserRepository
+	roleRepo       *mockRoleRepository
+	historyRepo    *mockPasswordHistoryRepository
+	erasureRepo    *mockUserErasureRepository
+	dispatcher     *mockEventDispatcher
+}
+
+func setupPrivacy(t *testing.T) *privacyFixture {
+	userService, repo, verificationRepo, dispatcher := setupWithVerification(t)
+	tokenRepo := &mockRefreshTokenRepository{store: make(map[uuid.UUID]*model.RefreshToken)}
+	sessionRepo := newMockSessionRepository()
+	roleRepo := newMockRoleRepository()
+	historyRepo := &mockPasswordHistoryRepository{}
+	loginFailureRepo := newMockLoginFailureRepository()
+	twoFactor := newTestTwoFactorService(repo)
+	resetRepo := &mockPasswordResetTokenRepository{store: make(map[uuid.UUID]*model.PasswordResetToken)}
+	erasureRepo := &mockUserErasureRepository{store: make(map[uuid.UUID]*model.UserErasure)}
+	erasureRepo.repos = &mockErasureRepositories{
+		users:           repo,
+		roles:           roleRepo,
+		refreshTokens:   tokenRepo,
+		sessions:        sessionRepo,
+		verifications:   verificationRepo,
+		passwordResets:  resetRepo,
+		passwordHistory: historyRepo,
+		loginFailures:   loginFailureRepo,
+		twoFactor:       twoFactor.repo,
+		recoveryCodes:   twoFactor.recoveryCodes,
+		erasures:        erasureRepo,
+	}
+
+	authService := service.NewAuthService(
+		repo, tokenRepo, sessionRepo, roleRepo, loginFailureRepo, newMockTwoFactorChallengeRepository(),
+		&mockPasswordManager{}, &mockAccessTokenIssuer{}, twoFactor.service, dispatcher,
+		service.AuthConfig{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour},
+	)
+	privacyService := service.NewPrivacyService(
+		repo,
+		roleRepo,
+		tokenRepo,
+		sessionRepo,
+		verificationRepo,
+		resetRepo,
+		historyRepo,
+		loginFailureRepo,
+		twoFactor.repo,
+		twoFactor.recoveryCodes,
+		erasureRepo,
+		dispatcher,
+	)
+	return &privacyFixture{
+		privacyService: privacyService,
+		userService:    userService,
+		authService:    authService,
+		userRepo:       repo,
+		roleRepo:       roleRepo,
+		historyRepo:    historyRepo,
+		erasureRepo:    erasureRepo,
+		dispatcher:     dispatcher,
+	}
+}
+
+func TestExportUserData(t *testing.T) {
+	f := setupPrivacy(t)
+	user := registerActiveUser(t, f.userService, "export@example.com")
+	require.NoError(t, f.roleRepo.AssignRole(user.ID, model.RoleSupport))
+	login(t, f.authService, "export@example.com")
+
+	export, err := f.privacyService.ExportUserData(user.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "export@example.com", export.Profile.Email)
+	assert.Equal(t, "active", export.Profile.Status)
+	assert.Equal(t, []string{model.RoleSupport}, export.Roles)
+	assert.Len(t, export.RefreshTokens, 1)
+	assert.Len(t, export.Sessions, 1)
+	assert.Len(t, export.Verifications, 1)
+
+	data, err := json.Marshal(export)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), user.HashedPassword)
+
+	_, err = f.privacyService.ExportUserData(uuid.New())
+	assert.ErrorIs(t, err, model.ErrUserNotFound)
+}
+
+func TestEraseUser(t *testing.T) {
+	f := setupPrivacy(t)
+	user := registerActiveUser(t, f.userService, "erase@example.com")
+	require.NoError(t, f.roleRepo.AssignRole(user.ID, model.RoleAdmin))
+	require.NoError(t, f.historyRepo.Add(model.PasswordHistoryEntry{UserID: user.ID, HashedPassword: user.HashedPassword}))
+	tokens := login(t, f.authService, "erase@example.com")
+	adminID := uuid.New()
+	f.dispatcher.Reset()
+
+	assert.ErrorIs(t, f.privacyService.EraseUser(user.ID, adminID, " "), model.ErrErasureReasonRequired)
+
+	require.NoError(t, f.privacyService.EraseUser(user.ID, adminID, "GDPR request #42"))
+	assert.Equal(t, 1, f.erasureRepo.transactions)
+
+	erased, _ := f.userRepo.Find(user.ID)
+	assert.NotContains(t, erased.Email, "erase@example.com")
+	assert.Empty(t, erased.FirstName)
+	assert.Empty(t, erased.LastName)
+	assert.Empty(t, erased.HashedPassword)
+	assert.Equal(t, model.Deactivated, erased.Status)
+
+	roles, _ := f.roleRepo.ListUserRoles(user.ID)
+	assert.Empty(t, roles)
+	history, _ := f.historyRepo.ListRecent(user.ID, 10)
+	assert.Empty(t, history)
+	_, err := f.authService.Refresh(tokens.RefreshToken)
+	assert.ErrorIs(t, err, model.ErrInvalidRefreshToken)
+	_, err = f.authService.Authenticate("erase@example.com", "password123", model.ClientInfo{})
+	assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+
+	require.NotEmpty(t, f.dispatcher.events)
+	_, ok := f.dispatcher.events[len(f.dispatcher.events)-1].(model.UserErased)
+	assert.True(t, ok)
+
+	t.Run("Failed erasure publishes nothing", func(t *testing.T) {
+		other := registerActiveUser(t, f.userService, "erase-failed@example.com")
+		f.erasureRepo.createErr = errors.New("database is down")
+		defer func() { f.erasureRepo.createErr = nil }()
+		f.dispatcher.Reset()
+
+		assert.Error(t, f.privacyService.EraseUser(other.ID, adminID, "GDPR request #43"))
+		assert.Empty(t, f.dispatcher.events)
+	})
+
+	t.Run("Erasure is irreversible", func(t *testing.T) {
+		assert.ErrorIs(t, f.privacyService.EraseUser(user.ID, adminID, "again"), model.ErrUserAlreadyErased)
+		require.NoError(t, f.userService.ActivateUser(user.ID))
+		still, _ := f.userRepo.Find(user.ID)
+		assert.Equal(t, model.Deactivated, still.Status)
+	})
+}
+
+type mockUserErasureRepository struct {
+	store        map[uuid.UUID]*model.UserErasure
+	repos        *mockErasureRepositories
+	transactions int
+	createErr    error
+}
+
+func (m *mockUserErasureRepository) NextID() (uuid.UUID, error) { return uuid.New(), nil }
+func (m *mockUserErasureRepository) Create(erasure *model.UserErasure) error {
+	if m.createErr != nil {
+		return m.createErr
+	}
+	m.store[erasure.UserID] = erasure
+	return nil
+}
+
+// WithinTransaction не откатывает изменения: откат проверяется только на уровне MySQL
+func (m *mockUserErasureRepository) WithinTransaction(fn func(repos model.ErasureRepositories) error) error {
+	m.transactions++
+	return fn(m.repos)
+}
+func (m *mockUserErasureRepository) FindByUserID(userID uuid.UUID) (*model.UserErasure, error) {
+	if erasure, ok := m.store[userID]; ok {
+		return erasure, nil
+	}
+	return nil, model.ErrUserNotFound
+}
+
+type mockErasureRepositories struct {
+	users           model.UserRepository
+	roles           model.RoleRepository
+	refreshTokens   model.RefreshTokenRepository
+	sessions        model.SessionRepository
+	verifications   model.EmailVerificationTokenRepository
+	passwordResets  model.PasswordResetTokenRepository
+	passwordHistory model.PasswordHistoryRepository
+	loginFailures   model.LoginFailureRepository
+	twoFactor       model.TwoFactorRepository
+	recoveryCodes   model.RecoveryCodeRepository
+	erasures        model.UserErasureRepository
+}
+
+func (m *mockErasureRepositories) Users() model.UserRepository { return m.users }
+func (m *mockErasureRepositories) Roles() model.RoleRepository { return m.roles }
+func (m *mockErasureRepositories) RefreshTokens() model.RefreshTokenRepository {
+	return m.refreshTokens
+}
+func (m *mockErasureRepositories) Sessions() model.SessionRepository { return m.sessions }
+func (m *mockErasureRepositories) Verifications() model.EmailVerificationTokenRepository {
+	return m.verifications
+}
+func (m *mockErasureRepositories) PasswordResets() model.PasswordResetTokenRepository {
+	return m.passwordResets
+}
+func (m *mockErasureRepositories) PasswordHistory() model.PasswordHistoryRepository {
+	return m.passwordHistory
+}
+func (m *mockErasureRepositories) LoginFailures() model.LoginFailureRepository {
+	return m.loginFailures
+}
+func (m *mockErasureRepositories) TwoFactor() model.TwoFactorRepository { return m.twoFactor }
+func (m *mockErasureRepositories) RecoveryCodes() model.RecoveryCodeRepository {
+	return m.recoveryCodes
+}
+func (m *mockErasureRepositories) Erasures() model.UserErasureRepository { return m.erasures }
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"user/pkg/domain/model"
+	"user/pkg/domain/service"
+)
+
+func TestAssignRole(t *testing.T) {
+	userService, repo, _, dispatcher := setup(t)
+	roleRepo := newMockRoleRepository()
+	roleService := service.NewRoleService(repo, roleRepo, dispatcher)
+	user := registerActiveUser(t, userService, "admin@example.com")
+	dispatcher.Reset()
+
+	require.NoError(t, roleService.AssignRole(user.ID, model.RoleAdmin))
+	require.NoError(t, roleService.AssignRole(user.ID, model.RoleSupport))
+
+	require.Len(t, dispatcher.events, 2)
+	event, ok := dispatcher.events[0].(model.RoleAssigned)
+	require.True(t, ok)
+	assert.Equal(t, model.RoleAdmin, event.Role)
+
+	permissions, err := roleService.GetUserPermissions(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Permission{model.PermissionUsersAdmin, model.PermissionUsersRead, model.PermissionUsersSearch}, permissions)
+
+	require.NoError(t, roleService.RevokeRole(user.ID, model.RoleAdmin))
+	permissions, _ = roleService.GetUserPermissions(user.ID)
+	assert.Equal(t, []model.Permission{model.PermissionUsersRead, model.PermissionUsersSearch}, permissions)
+
+	assert.ErrorIs(t, roleService.AssignRole(user.ID, "superuser"), model.ErrRoleNotFound)
+	assert.ErrorIs(t, roleService.AssignRole(uuid.New(), model.RoleAdmin), model.ErrUserNotFound)
+}
+
+func TestAuthenticate_IssuesPermissions(t *testing.T) {
+	userService, repo, passManager, dispatcher := setup(t)
+	roleRepo := newMockRoleRepository()
+	issuer := &mockAccessTokenIssuer{}
+	authService := service.NewAuthService(
+		repo,
+		&mockRefreshTokenRepository{store: make(map[uuid.UUID]*model.RefreshToken)},
+		newMockSessionRepository(),
+		roleRepo,
+		newMockLoginFailureRepository(),
+		newMockTwoFactorChallengeRepository(),
+		passManager,
+		issuer,
+		newTestTwoFactorService(repo).service,
+		dispatcher,
+		service.AuthConfig{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour},
+	)
+	user := registerActiveUser(t, userService, "support@example.com")
+	require.NoError(t, service.NewRoleService(repo, roleRepo, dispatcher).AssignRole(user.ID, model.RoleSupport))
+
+	_, err := authService.Authenticate("support@example.com", "password123", model.ClientInfo{})
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.Permission{model.PermissionUsersRead, model.PermissionUsersSearch}, issuer.lastPermissions)
+}
+
+type mockRoleRepository struct {
+	roles     map[string]model.Role
+	userRoles map[uuid.UUID]map[string]struct{}
+}
+
+func newMockRoleRepository() *mockRoleRepository {
+	return &mockRoleRepository{
+		roles: map[string]model.Role{
+			model.RoleAdmin: {
+				Name:        model.RoleAdmin,
+				Permissions: []model.Permission{model.PermissionUsersRead, model.PermissionUsersAdmin, model.PermissionUsersSearch},
+			},
+			model.RoleSupport: {
+				Name:        model.RoleSupport,
+				Permissions: []model.Permission{model.PermissionUsersRead, model.PermissionUsersSearch},
+			},
+		},
+		userRoles: make(map[uuid.UUID]map[string]struct{}),
+	}
+}
+
+func (m *mockRoleRepository) FindRole(name string) (*model.Role, error) {
+	role, ok := m.roles[name]
+	if !ok {
+		return nil, model.ErrRoleNotFound
+	}
+	return &role, nil
+}
+func (m *mockRoleRepository) ListUserRoles(userID uuid.UUID) ([]model.Role, error) {
+	var roles []model.Role
+	for name := range m.userRoles[userID] {
+		roles = append(roles, m.roles[name])
+	}
+	return roles, nil
+}
+func (m *mockRoleRepository) AssignRole(userID uuid.UUID, roleName string) error {
+	if m.userRoles[userID] == nil {
+		m.userRoles[userID] = make(map[string]struct{})
+	}
+	m.userRoles[userID][roleName] = struct{}{}
+	return nil
+}
+func (m *mockRoleRepository) RevokeRole(userID uuid.UUID, roleName string) error {
+	delete(m.userRoles[userID], roleName)
+	return nil
+}
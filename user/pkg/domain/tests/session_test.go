@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"user/pkg/domain/model"
+	"user/pkg/domain/service"
+)
+
+type sessionFixture struct {
+	sessionService service.SessionService
+	userService    service.UserService
+	authService    service.AuthService
+	sessionRepo    *mockSessionRepository
+	issuer         *mockAccessTokenIssuer
+}
+
+// setupSessions связывает сервисы общими хранилищами сессий и refresh-токенов
+func setupSessions(t *testing.T) *sessionFixture {
+	t.Helper()
+	repo := &mockUserRepository{store: make(map[uuid.UUID]*model.User)}
+	tokenRepo := &mockRefreshTokenRepository{store: make(map[uuid.UUID]*model.RefreshToken)}
+	sessionRepo := newMockSessionRepository()
+	issuer := &mockAccessTokenIssuer{}
+	dispatcher := &mockEventDispatcher{}
+	passManager := &mockPasswordManager{}
+
+	sessionService := service.NewSessionService(sessionRepo, tokenRepo)
+	userService := service.NewUserService(
+		repo,
+		&mockEmailVerificationTokenRepository{store: make(map[uuid.UUID]*model.EmailVerificationToken)},
+		newMockLoginFailureRepository(),
+		sessionService,
+		passManager,
+		newTestPasswordPolicy(),
+		dispatcher,
+		service.VerificationConfig{TokenTTL: time.Hour, ResendInterval: time.Minute},
+	)
+	authService := service.NewAuthService(
+		repo, tokenRepo, sessionRepo, newMockRoleRepository(), newMockLoginFailureRepository(), newMockTwoFactorChallengeRepository(),
+		passManager, issuer, newTestTwoFactorService(repo).service, dispatcher,
+		service.AuthConfig{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour},
+	)
+	return &sessionFixture{
+		sessionService: sessionService,
+		userService:    userService,
+		authService:    authService,
+		sessionRepo:    sessionRepo,
+		issuer:         issuer,
+	}
+}
+
+func TestAuthenticate_CreatesSession(t *testing.T) {
+	f := setupSessions(t)
+	user := registerActiveUser(t, f.userService, "session@example.com")
+
+	_, err := f.authService.Authenticate("session@example.com", "password123", model.ClientInfo{
+		IPAddress: "10.0.0.1",
+		UserAgent: "Mozilla/5.0",
+	})
+	require.NoError(t, err)
+
+	sessions, err := f.sessionService.ListSessions(user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, f.issuer.lastSessionID, sessions[0].ID)
+	assert.Equal(t, "10.0.0.1", sessions[0].IPAddress)
+	assert.Equal(t, "Mozilla/5.0", sessions[0].UserAgent)
+
+	active, err := f.sessionService.IsSessionActive(sessions[0].ID)
+	require.NoError(t, err)
+	assert.True(t, active)
+}
+
+func TestRefresh_UpdatesLastSeen(t *testing.T) {
+	f := setupSessions(t)
+	registerActiveUser(t, f.userService, "seen@example.com")
+	tokens := login(t, f.authService, "seen@example.com")
+	session := f.sessionRepo.store[f.issuer.lastSessionID]
+	session.LastSeenAt = session.LastSeenAt.Add(-time.Hour)
+
+	_, err := f.authService.Refresh(tokens.RefreshToken)
+	require.NoError(t, err)
+
+	assert.Equal(t, session.ID, f.issuer.lastSessionID)
+	assert.WithinDuration(t, time.Now(), session.LastSeenAt, time.Second)
+}
+
+func TestRevokeSession(t *testing.T) {
+	f := setupSessions(t)
+	user := registerActiveUser(t, f.userService, "revoke-session@example.com")
+	first := login(t, f.authService, "revoke-session@example.com")
+	firstSessionID := f.issuer.lastSessionID
+	_, err := f.authService.Authenticate("revoke-session@example.com", "password123", model.ClientInfo{})
+	require.NoError(t, err)
+
+	t.Run("Foreign session", func(t *testing.T) {
+		err := f.sessionService.RevokeSession(uuid.New(), firstSessionID)
+		assert.ErrorIs(t, err, model.ErrSessionNotFound)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		require.NoError(t, f.sessionService.RevokeSession(user.ID, firstSessionID))
+
+		active, err := f.sessionService.IsSessionActive(firstSessionID)
+		require.NoError(t, err)
+		assert.False(t, active)
+
+		_, err = f.authService.Refresh(first.RefreshToken)
+		assert.ErrorIs(t, err, model.ErrInvalidRefreshToken)
+
+		sessions, err := f.sessionService.ListSessions(user.ID)
+		require.NoError(t, err)
+		assert.Len(t, sessions, 1)
+	})
+
+	t.Run("Unknown session", func(t *testing.T) {
+		active, err := f.sessionService.IsSessionActive(uuid.New())
+		require.NoError(t, err)
+		assert.False(t, active)
+	})
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	f := setupSessions(t)
+	user := registerActiveUser(t, f.userService, "revoke-all@example.com")
+	tokens := login(t, f.authService, "revoke-all@example.com")
+
+	require.NoError(t, f.sessionService.RevokeAllSessions(user.ID))
+
+	sessions, err := f.sessionService.ListSessions(user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	_, err = f.authService.Refresh(tokens.RefreshToken)
+	assert.ErrorIs(t, err, model.ErrInvalidRefreshToken)
+}
+
+func TestStatusChange_RevokesSessions(t *testing.T) {
+	for name, change := range map[string]func(service.UserService, uuid.UUID) error{
+		"Suspend":    service.UserService.SuspendUser,
+		"Deactivate": service.UserService.DeactivateUser,
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := setupSessions(t)
+			user := registerActiveUser(t, f.userService, "status@example.com")
+			login(t, f.authService, "status@example.com")
+			sessionID := f.issuer.lastSessionID
+
+			require.NoError(t, change(f.userService, user.ID))
+
+			active, err := f.sessionService.IsSessionActive(sessionID)
+			require.NoError(t, err)
+			assert.False(t, active)
+		})
+	}
+}
+
+type mockSessionRepository struct {
+	store map[uuid.UUID]*model.Session
+}
+
+func newMockSessionRepository() *mockSessionRepository {
+	return &mockSessionRepository{store: make(map[uuid.UUID]*model.Session)}
+}
+
+func (m *mockSessionRepository) Create(session *model.Session) error {
+	m.store[session.ID] = session
+	return nil
+}
+func (m *mockSessionRepository) Update(session *model.Session) error {
+	m.store[session.ID] = session
+	return nil
+}
+func (m *mockSessionRepository) Find(id uuid.UUID) (*model.Session, error) {
+	if session, ok := m.store[id]; ok {
+		return session, nil
+	}
+	return nil, model.ErrSessionNotFound
+}
+func (m *mockSessionRepository) ListForUser(userID uuid.UUID) ([]model.Session, error) {
+	var sessions []model.Session
+	for _, session := range m.store {
+		if session.UserID == userID {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions, nil
+}
+func (m *mockSessionRepository) RevokeAllForUser(userID uuid.UUID, revokedAt time.Time) error {
+	for _, session := range m.store {
+		if session.UserID == userID && session.RevokedAt == nil {
+			at := revokedAt
+			session.RevokedAt = &at
+		}
+	}
+	return nil
+}
+func (m *mockSessionRepository) DeleteForUser(userID uuid.UUID) error {
+	for id, session := range m.store {
+		if session.UserID == userID {
+			delete(m.store, id)
+		}
+	}
+	return nil
+}
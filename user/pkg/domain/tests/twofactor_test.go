@@ -0,0 +1,361 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"user/pkg/domain/model"
+	"user/pkg/domain/service"
+)
+
+type testTwoFactor struct {
+	service       service.TwoFactorService
+	repo          *mockTwoFactorRepository
+	recoveryCodes *mockRecoveryCodeRepository
+	totp          *mockTOTPManager
+	dispatcher    *mockEventDispatcher
+	failureRepo   *mockLoginFailureRepository
+}
+
+func newTestTwoFactorService(userRepo model.UserRepository) *testTwoFactor {
+	f := &testTwoFactor{
+		repo:          &mockTwoFactorRepository{store: make(map[uuid.UUID]*model.TwoFactor)},
+		recoveryCodes: &mockRecoveryCodeRepository{store: make(map[uuid.UUID]map[string]*time.Time)},
+		totp:          &mockTOTPManager{step: 1},
+		dispatcher:    &mockEventDispatcher{},
+		failureRepo:   newMockLoginFailureRepository(),
+	}
+	f.service = service.NewTwoFactorService(
+		userRepo,
+		f.repo,
+		f.recoveryCodes,
+		f.failureRepo,
+		f.totp,
+		f.dispatcher,
+		service.LoginThrottleConfig{FreeAttempts: 100, LockoutThreshold: 3, LockoutDuration: time.Hour},
+	)
+	return f
+}
+
+type twoFactorLoginFixture struct {
+	twoFactor   *testTwoFactor
+	userService service.UserService
+	authService service.AuthService
+	failureRepo *mockLoginFailureRepository
+}
+
+func setupTwoFactorLogin(t *testing.T) *twoFactorLoginFixture {
+	t.Helper()
+	userService, repo, passManager, dispatcher := setup(t)
+	twoFactor := newTestTwoFactorService(repo)
+	failureRepo := newMockLoginFailureRepository()
+	authService := service.NewAuthService(
+		repo,
+		&mockRefreshTokenRepository{store: make(map[uuid.UUID]*model.RefreshToken)},
+		newMockSessionRepository(),
+		newMockRoleRepository(),
+		failureRepo,
+		newMockTwoFactorChallengeRepository(),
+		passManager,
+		&mockAccessTokenIssuer{},
+		twoFactor.service,
+		dispatcher,
+		service.AuthConfig{
+			AccessTokenTTL:        time.Minute,
+			RefreshTokenTTL:       time.Hour,
+			TwoFactorChallengeTTL: 5 * time.Minute,
+			LoginThrottle:         service.LoginThrottleConfig{FreeAttempts: 100, LockoutThreshold: 100},
+		},
+	)
+	return &twoFactorLoginFixture{
+		twoFactor:   twoFactor,
+		userService: userService,
+		authService: authService,
+		failureRepo: failureRepo,
+	}
+}
+
+// enableTwoFactor подключает TOTP и возвращает коды восстановления
+func enableTwoFactor(t *testing.T, twoFactor *testTwoFactor, userID uuid.UUID) []string {
+	t.Helper()
+	_, err := twoFactor.service.EnrollTOTP(userID)
+	require.NoError(t, err)
+	codes, err := twoFactor.service.ConfirmTOTP(userID, twoFactor.totp.currentCode())
+	require.NoError(t, err)
+	twoFactor.totp.step++
+	return codes
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	userService, repo, _, _ := setup(t)
+	twoFactor := newTestTwoFactorService(repo)
+	user := registerActiveUser(t, userService, "enroll@example.com")
+
+	enrollment, err := twoFactor.service.EnrollTOTP(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth://totp/enroll@example.com?secret="+enrollment.Secret, enrollment.ProvisioningURI)
+
+	enabled, err := twoFactor.service.IsEnabled(user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled, "enrollment alone must not enable two-factor login")
+
+	t.Run("Wrong confirmation code", func(t *testing.T) {
+		_, err := twoFactor.service.ConfirmTOTP(user.ID, "000000")
+		assert.ErrorIs(t, err, model.ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("Confirm", func(t *testing.T) {
+		codes, err := twoFactor.service.ConfirmTOTP(user.ID, twoFactor.totp.currentCode())
+		require.NoError(t, err)
+		assert.Len(t, codes, 10)
+		assert.Len(t, twoFactor.recoveryCodes.store[user.ID], 10)
+		for _, code := range codes {
+			assert.NotContains(t, twoFactor.recoveryCodes.store[user.ID], code, "recovery codes must be stored hashed")
+		}
+		assert.IsType(t, model.TwoFactorEnabled{}, twoFactor.dispatcher.events[len(twoFactor.dispatcher.events)-1])
+	})
+
+	t.Run("Already enabled", func(t *testing.T) {
+		_, err := twoFactor.service.EnrollTOTP(user.ID)
+		assert.ErrorIs(t, err, model.ErrTwoFactorAlreadyEnabled)
+	})
+}
+
+func TestVerifyCode(t *testing.T) {
+	userService, repo, _, _ := setup(t)
+	twoFactor := newTestTwoFactorService(repo)
+	user := registerActiveUser(t, userService, "verify@example.com")
+	codes := enableTwoFactor(t, twoFactor, user.ID)
+
+	t.Run("TOTP code cannot be replayed", func(t *testing.T) {
+		code := twoFactor.totp.currentCode()
+		require.NoError(t, twoFactor.service.VerifyCode(user.ID, code))
+		assert.ErrorIs(t, twoFactor.service.VerifyCode(user.ID, code), model.ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("Recovery code is single use", func(t *testing.T) {
+		twoFactor.dispatcher.Reset()
+		require.NoError(t, twoFactor.service.VerifyCode(user.ID, codes[0]))
+		assert.Equal(t, []service.Event{model.RecoveryCodeUsed{UserID: user.ID, Remaining: 9}}, twoFactor.dispatcher.events)
+		assert.ErrorIs(t, twoFactor.service.VerifyCode(user.ID, codes[0]), model.ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("Recovery code input is normalized", func(t *testing.T) {
+		assert.NoError(t, twoFactor.service.VerifyCode(user.ID, strings.ToUpper(strings.ReplaceAll(codes[1], "-", ""))))
+	})
+}
+
+func TestDisableTOTP(t *testing.T) {
+	userService, repo, _, _ := setup(t)
+	twoFactor := newTestTwoFactorService(repo)
+	user := registerActiveUser(t, userService, "disable@example.com")
+	enableTwoFactor(t, twoFactor, user.ID)
+
+	assert.ErrorIs(t, twoFactor.service.DisableTOTP(user.ID, "000000"), model.ErrInvalidTwoFactorCode)
+
+	twoFactor.dispatcher.Reset()
+	require.NoError(t, twoFactor.service.DisableTOTP(user.ID, twoFactor.totp.currentCode()))
+	assert.Equal(t, []service.Event{model.TwoFactorDisabled{UserID: user.ID}}, twoFactor.dispatcher.events)
+	assert.Empty(t, twoFactor.recoveryCodes.store[user.ID])
+
+	enabled, err := twoFactor.service.IsEnabled(user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	assert.ErrorIs(t, twoFactor.service.DisableTOTP(user.ID, "000000"), model.ErrTwoFactorNotEnrolled)
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	userService, repo, _, _ := setup(t)
+	twoFactor := newTestTwoFactorService(repo)
+	user := registerActiveUser(t, userService, "regenerate@example.com")
+	oldCodes := enableTwoFactor(t, twoFactor, user.ID)
+
+	newCodes, err := twoFactor.service.RegenerateRecoveryCodes(user.ID, oldCodes[0])
+	require.NoError(t, err)
+	assert.Len(t, newCodes, 10)
+	assert.ErrorIs(t, twoFactor.service.VerifyCode(user.ID, oldCodes[1]), model.ErrInvalidTwoFactorCode)
+	assert.NoError(t, twoFactor.service.VerifyCode(user.ID, newCodes[0]))
+}
+
+func TestTwoFactorManagement_WrongCodesAreThrottled(t *testing.T) {
+	userService, repo, _, _ := setup(t)
+	twoFactor := newTestTwoFactorService(repo)
+	user := registerActiveUser(t, userService, "manage@example.com")
+	codes := enableTwoFactor(t, twoFactor, user.ID)
+	twoFactor.dispatcher.Reset()
+
+	assert.ErrorIs(t, twoFactor.service.DisableTOTP(user.ID, "000000"), model.ErrInvalidTwoFactorCode)
+	_, err := twoFactor.service.RegenerateRecoveryCodes(user.ID, "000000")
+	assert.ErrorIs(t, err, model.ErrInvalidTwoFactorCode)
+	assert.ErrorIs(t, twoFactor.service.DisableTOTP(user.ID, "000000"), model.ErrInvalidTwoFactorCode)
+
+	// Третья неудача блокирует аккаунт: даже верный код больше не проверяется
+	require.Len(t, twoFactor.dispatcher.events, 1)
+	assert.IsType(t, model.UserLockedOut{}, twoFactor.dispatcher.events[0])
+	assert.ErrorIs(t, twoFactor.service.DisableTOTP(user.ID, codes[0]), model.ErrAccountLocked)
+	_, err = twoFactor.service.RegenerateRecoveryCodes(user.ID, twoFactor.totp.currentCode())
+	assert.ErrorIs(t, err, model.ErrAccountLocked)
+
+	enabled, err := twoFactor.service.IsEnabled(user.ID)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	f := setupTwoFactorLogin(t)
+	user := registerActiveUser(t, f.userService, "2fa@example.com")
+	enableTwoFactor(t, f.twoFactor, user.ID)
+
+	result, err := f.authService.Authenticate("2fa@example.com", "password123", model.ClientInfo{IPAddress: "10.0.0.1"})
+	require.NoError(t, err)
+	assert.Nil(t, result.Tokens)
+	require.NotNil(t, result.Challenge)
+
+	t.Run("Wrong code", func(t *testing.T) {
+		_, err := f.authService.CompleteTwoFactorLogin(result.Challenge.Token, "000000")
+		assert.ErrorIs(t, err, model.ErrInvalidTwoFactorCode)
+
+		counter, err := f.failureRepo.Get(model.LoginFailureScopeAccount, "2fa@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 1, counter.Failures, "wrong codes count as failed logins")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		tokens, err := f.authService.CompleteTwoFactorLogin(result.Challenge.Token, f.twoFactor.totp.currentCode())
+		require.NoError(t, err)
+		assert.NotEmpty(t, tokens.RefreshToken)
+
+		counter, err := f.failureRepo.Get(model.LoginFailureScopeAccount, "2fa@example.com")
+		require.NoError(t, err)
+		assert.Zero(t, counter.Failures)
+	})
+
+	t.Run("Challenge is single use", func(t *testing.T) {
+		f.twoFactor.totp.step++
+		_, err := f.authService.CompleteTwoFactorLogin(result.Challenge.Token, f.twoFactor.totp.currentCode())
+		assert.ErrorIs(t, err, model.ErrInvalidTwoFactorChallenge)
+	})
+
+	t.Run("Unknown challenge", func(t *testing.T) {
+		_, err := f.authService.CompleteTwoFactorLogin("unknown", f.twoFactor.totp.currentCode())
+		assert.ErrorIs(t, err, model.ErrInvalidTwoFactorChallenge)
+	})
+}
+
+func TestTwoFactorLogin_AttemptsLimit(t *testing.T) {
+	f := setupTwoFactorLogin(t)
+	user := registerActiveUser(t, f.userService, "2fa-limit@example.com")
+	enableTwoFactor(t, f.twoFactor, user.ID)
+	result, err := f.authService.Authenticate("2fa-limit@example.com", "password123", model.ClientInfo{})
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err := f.authService.CompleteTwoFactorLogin(result.Challenge.Token, "000000")
+		require.ErrorIs(t, err, model.ErrInvalidTwoFactorCode)
+	}
+	_, err = f.authService.CompleteTwoFactorLogin(result.Challenge.Token, f.twoFactor.totp.currentCode())
+	assert.ErrorIs(t, err, model.ErrInvalidTwoFactorChallenge)
+}
+
+// mockTOTPManager принимает код, равный номеру текущего шага step
+type mockTOTPManager struct {
+	step int64
+}
+
+func (m *mockTOTPManager) currentCode() string {
+	return fmt.Sprintf("%06d", m.step)
+}
+
+func (m *mockTOTPManager) GenerateSecret() (string, error) { return "SECRET" + uuid.NewString(), nil }
+func (m *mockTOTPManager) ProvisioningURI(secret, accountName string) string {
+	return "otpauth://totp/" + accountName + "?secret=" + secret
+}
+func (m *mockTOTPManager) Validate(_, code string, _ time.Time) (int64, bool) {
+	return m.step, code == m.currentCode()
+}
+
+type mockTwoFactorRepository struct {
+	store map[uuid.UUID]*model.TwoFactor
+}
+
+func (m *mockTwoFactorRepository) Find(userID uuid.UUID) (*model.TwoFactor, error) {
+	if twoFactor, ok := m.store[userID]; ok {
+		copied := *twoFactor
+		return &copied, nil
+	}
+	return nil, model.ErrTwoFactorNotEnrolled
+}
+func (m *mockTwoFactorRepository) Save(twoFactor *model.TwoFactor) error {
+	copied := *twoFactor
+	m.store[twoFactor.UserID] = &copied
+	return nil
+}
+func (m *mockTwoFactorRepository) Delete(userID uuid.UUID) error {
+	delete(m.store, userID)
+	return nil
+}
+
+// mockRecoveryCodeRepository хранит время использования по хешу кода, nil - код не использован
+type mockRecoveryCodeRepository struct {
+	store map[uuid.UUID]map[string]*time.Time
+}
+
+func (m *mockRecoveryCodeRepository) Replace(userID uuid.UUID, codeHashes []string, _ time.Time) error {
+	m.store[userID] = make(map[string]*time.Time, len(codeHashes))
+	for _, codeHash := range codeHashes {
+		m.store[userID][codeHash] = nil
+	}
+	return nil
+}
+func (m *mockRecoveryCodeRepository) Consume(userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error) {
+	usedBefore, ok := m.store[userID][codeHash]
+	if !ok || usedBefore != nil {
+		return false, nil
+	}
+	m.store[userID][codeHash] = &usedAt
+	return true, nil
+}
+func (m *mockRecoveryCodeRepository) CountUnused(userID uuid.UUID) (int, error) {
+	count := 0
+	for _, usedAt := range m.store[userID] {
+		if usedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+func (m *mockRecoveryCodeRepository) DeleteForUser(userID uuid.UUID) error {
+	delete(m.store, userID)
+	return nil
+}
+
+type mockTwoFactorChallengeRepository struct {
+	store map[uuid.UUID]*model.TwoFactorChallenge
+}
+
+func newMockTwoFactorChallengeRepository() *mockTwoFactorChallengeRepository {
+	return &mockTwoFactorChallengeRepository{store: make(map[uuid.UUID]*model.TwoFactorChallenge)}
+}
+
+func (m *mockTwoFactorChallengeRepository) NextID() (uuid.UUID, error) { return uuid.New(), nil }
+func (m *mockTwoFactorChallengeRepository) Create(challenge *model.TwoFactorChallenge) error {
+	m.store[challenge.ID] = challenge
+	return nil
+}
+func (m *mockTwoFactorChallengeRepository) Update(challenge *model.TwoFactorChallenge) error {
+	m.store[challenge.ID] = challenge
+	return nil
+}
+func (m *mockTwoFactorChallengeRepository) FindByHash(tokenHash string) (*model.TwoFactorChallenge, error) {
+	for _, challenge := range m.store {
+		if challenge.TokenHash == tokenHash {
+			return challenge, nil
+		}
+	}
+	return nil, model.ErrInvalidTwoFactorChallenge
+}
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"user/pkg/domain/model"
+)
+
+func TestSearchUsers(t *testing.T) {
+	userService, repo, _, _ := setup(t)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, email := range []string{"carol@example.com", "alice@example.com", "Bob@Example.com", "alan@example.com"} {
+		user := registerActiveUser(t, userService, email)
+		stored, _ := repo.Find(user.ID)
+		stored.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+	}
+	pending, err := userService.RegisterNewUser("Alfred", "Pending", "zed@example.com", "password123")
+	require.NoError(t, err)
+
+	emails := func(page *model.UserSearchPage) []string {
+		var result []string
+		for _, user := range page.Users {
+			assert.Empty(t, user.HashedPassword)
+			result = append(result, user.NormalizedEmail)
+		}
+		return result
+	}
+
+	t.Run("Prefix matches name and email case-insensitively", func(t *testing.T) {
+		page, err := userService.SearchUsers(model.UserSearchQuery{Prefix: "AL", SortBy: model.SortByEmail})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alan@example.com", "alice@example.com", "zed@example.com"}, emails(page))
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("Status and created-at filters", func(t *testing.T) {
+		from, to := base.Add(time.Hour), base.Add(3*time.Hour)
+		page, err := userService.SearchUsers(model.UserSearchQuery{
+			Statuses:    []model.UserStatus{model.Active},
+			CreatedFrom: &from,
+			CreatedTo:   &to,
+			Descending:  true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bob@example.com", "alice@example.com"}, emails(page))
+
+		page, err = userService.SearchUsers(model.UserSearchQuery{Statuses: []model.UserStatus{pending.Status}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"zed@example.com"}, emails(page))
+	})
+
+	t.Run("Cursor pagination", func(t *testing.T) {
+		query := model.UserSearchQuery{SortBy: model.SortByEmail, PageSize: 2}
+		var all []string
+		for i := 0; i < 3; i++ {
+			page, err := userService.SearchUsers(query)
+			require.NoError(t, err)
+			all = append(all, emails(page)...)
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+		assert.Equal(t, []string{
+			"alan@example.com", "alice@example.com", "bob@example.com", "carol@example.com", "zed@example.com",
+		}, all)
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		_, err := userService.SearchUsers(model.UserSearchQuery{PageSize: 1000})
+		assert.ErrorIs(t, err, model.ErrInvalidSearchQuery)
+		_, err = userService.SearchUsers(model.UserSearchQuery{SortBy: model.UserSortField(42)})
+		assert.ErrorIs(t, err, model.ErrInvalidSearchQuery)
+		from := base
+		_, err = userService.SearchUsers(model.UserSearchQuery{CreatedFrom: &from, CreatedTo: &from})
+		assert.ErrorIs(t, err, model.ErrInvalidSearchQuery)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		_, err := userService.SearchUsers(model.UserSearchQuery{Cursor: "not a cursor"})
+		assert.ErrorIs(t, err, model.ErrInvalidSearchCursor)
+
+		page, err := userService.SearchUsers(model.UserSearchQuery{SortBy: model.SortByEmail, PageSize: 1})
+		require.NoError(t, err)
+		_, err = userService.SearchUsers(model.UserSearchQuery{SortBy: model.SortByLastName, Cursor: page.NextCursor})
+		assert.ErrorIs(t, err, model.ErrInvalidSearchCursor)
+	})
+}
+
+// Search повторяет выборку MySQL-репозитория, но намеренно оставляет хеш пароля,
+// чтобы тест проверял очистку на уровне сервиса
+func (m *mockUserRepository) Search(criteria model.UserSearchCriteria) ([]model.User, error) {
+	prefix := strings.ToLower(criteria.Prefix)
+	var users []model.User
+	for _, user := range m.store {
+		if prefix != "" &&
+			!strings.HasPrefix(strings.ToLower(user.FirstName), prefix) &&
+			!strings.HasPrefix(strings.ToLower(user.LastName), prefix) &&
+			!strings.HasPrefix(user.NormalizedEmail, prefix) {
+			continue
+		}
+		if len(criteria.Statuses) > 0 && !containsStatus(criteria.Statuses, user.Status) {
+			continue
+		}
+		if criteria.CreatedFrom != nil && user.CreatedAt.Before(*criteria.CreatedFrom) {
+			continue
+		}
+		if criteria.CreatedTo != nil && !user.CreatedAt.Before(*criteria.CreatedTo) {
+			continue
+		}
+		if criteria.After != nil && compareSearchPosition(criteria.SortBy, *user, *criteria.After)*direction(criteria) <= 0 {
+			continue
+		}
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return compareSearchPosition(criteria.SortBy, users[i], searchPosition(users[j]))*direction(criteria) < 0
+	})
+	if len(users) > criteria.Limit {
+		users = users[:criteria.Limit]
+	}
+	return users, nil
+}
+
+func direction(criteria model.UserSearchCriteria) int {
+	if criteria.Descending {
+		return -1
+	}
+	return 1
+}
+
+func searchPosition(user model.User) model.UserSearchPosition {
+	return model.UserSearchPosition{
+		CreatedAt: user.CreatedAt,
+		Email:     user.NormalizedEmail,
+		LastName:  user.LastName,
+		ID:        user.ID,
+	}
+}
+
+func compareSearchPosition(field model.UserSortField, user model.User, position model.UserSearchPosition) int {
+	var result int
+	switch field {
+	case model.SortByEmail:
+		result = strings.Compare(user.NormalizedEmail, position.Email)
+	case model.SortByLastName:
+		result = strings.Compare(user.LastName, position.LastName)
+	default:
+		result = user.CreatedAt.Compare(position.CreatedAt)
+	}
+	if result != 0 {
+		return result
+	}
+	return bytes.Compare(user.ID[:], position.ID[:])
+}
+
+func containsStatus(statuses []model.UserStatus, status model.UserStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -7,16 +7,50 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 	"user/pkg/domain/model"
 	"user/pkg/domain/service"
 )
 
 func setup(t *testing.T) (service.UserService, *mockUserRepository, *mockPasswordManager, *mockEventDispatcher) {
+	userService, repo, _, dispatcher := setupWithVerification(t)
+	return userService, repo, &mockPasswordManager{}, dispatcher
+}
+
+func setupWithVerification(t *testing.T) (service.UserService, *mockUserRepository, *mockEmailVerificationTokenRepository, *mockEventDispatcher) {
+	userService, repo, verificationRepo, _, dispatcher := setupWithLimits(t)
+	return userService, repo, verificationRepo, dispatcher
+}
+
+func setupWithLimits(t *testing.T) (
+	service.UserService,
+	*mockUserRepository,
+	*mockEmailVerificationTokenRepository,
+	*mockLoginFailureRepository,
+	*mockEventDispatcher,
+) {
 	repo := &mockUserRepository{store: make(map[uuid.UUID]*model.User)}
-	passManager := &mockPasswordManager{}
+	verificationRepo := &mockEmailVerificationTokenRepository{store: make(map[uuid.UUID]*model.EmailVerificationToken)}
 	dispatcher := &mockEventDispatcher{}
-	userService := service.NewUserService(repo, passManager, dispatcher)
-	return userService, repo, passManager, dispatcher
+	sessionService := service.NewSessionService(
+		newMockSessionRepository(),
+		&mockRefreshTokenRepository{store: make(map[uuid.UUID]*model.RefreshToken)},
+	)
+	loginFailureRepo := newMockLoginFailureRepository()
+	userService := service.NewUserService(repo, verificationRepo, loginFailureRepo, sessionService, &mockPasswordManager{}, newTestPasswordPolicy(), dispatcher, service.VerificationConfig{
+		TokenTTL:       time.Hour,
+		ResendInterval: time.Minute,
+	})
+	return userService, repo, verificationRepo, loginFailureRepo, dispatcher
+}
+
+// registerActiveUser регистрирует пользователя и сразу активирует его в обход подтверждения email
+func registerActiveUser(t *testing.T, userService service.UserService, email string) *model.User {
+	user, err := userService.RegisterNewUser("Active", "User", email, "password123")
+	require.NoError(t, err)
+	require.NoError(t, userService.ActivateUser(user.ID))
+	user.Status = model.Active
+	return user
 }
 
 func TestRegisterNewUser(t *testing.T) {
@@ -29,15 +63,18 @@ func TestRegisterNewUser(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, user)
 		assert.Equal(t, email, user.Email)
-		assert.Equal(t, model.Active, user.Status)
+		assert.Equal(t, model.PendingVerification, user.Status)
 		assert.Contains(t, user.HashedPassword, "-hashed")
 
-		savedUser, _ := repo.FindByEmail(email)
+		savedUser, _ := repo.FindByEmail(user.NormalizedEmail)
 		assert.Equal(t, user.ID, savedUser.ID)
 
-		require.Len(t, dispatcher.events, 1)
+		require.Len(t, dispatcher.events, 2)
 		_, ok := dispatcher.events[0].(model.UserRegistered)
 		assert.True(t, ok)
+		requested, ok := dispatcher.events[1].(model.UserVerificationRequested)
+		require.True(t, ok)
+		assert.NotEmpty(t, requested.Token)
 	})
 
 	t.Run("Fail on email taken", func(t *testing.T) {
@@ -47,17 +84,71 @@ func TestRegisterNewUser(t *testing.T) {
 		assert.Empty(t, dispatcher.events)
 	})
 
+	t.Run("Fail on email taken in another case", func(t *testing.T) {
+		_, err := userService.RegisterNewUser("Jane", "Doe", "  Test@EXAMPLE.com", "password123")
+		assert.ErrorIs(t, err, model.ErrEmailTaken)
+	})
+
+	t.Run("Fail on invalid email", func(t *testing.T) {
+		dispatcher.Reset()
+		_, err := userService.RegisterNewUser("Jane", "Doe", "not-an-email", "password123")
+		assert.ErrorIs(t, err, model.ErrInvalidEmail)
+		assert.Empty(t, dispatcher.events)
+	})
+
 	t.Run("Fail on short password", func(t *testing.T) {
 		dispatcher.Reset()
 		_, err := userService.RegisterNewUser("Jack", "Smith", "jack@example.com", "123")
-		assert.ErrorIs(t, err, service.ErrPasswordTooShort)
+		assert.ErrorIs(t, err, model.ErrPasswordPolicyViolation)
 		assert.Empty(t, dispatcher.events)
 	})
 }
 
+func TestRegisterNewUser_KeepsDisplayEmail(t *testing.T) {
+	userService, _, _, _ := setup(t)
+
+	user, err := userService.RegisterNewUser("Hans", "Müller", " Hans@Bücher.DE ", "password123")
+	require.NoError(t, err)
+	assert.Equal(t, "Hans@Bücher.DE", user.Email)
+	assert.Equal(t, "hans@xn--bcher-kva.de", user.NormalizedEmail)
+
+	found, err := userService.GetUserByEmail("hans@BÜCHER.de")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+}
+
+func TestRegisterNewUser_ConcurrentDuplicate(t *testing.T) {
+	userService, repo, _, _ := setup(t)
+	// Пользователь появился между проверкой FindByEmail и Create
+	repo.beforeCreate = func() {
+		repo.store[uuid.New()] = &model.User{Email: "race@example.com", NormalizedEmail: "race@example.com"}
+	}
+
+	_, err := userService.RegisterNewUser("Race", "Condition", "Race@example.com", "password123")
+	assert.ErrorIs(t, err, model.ErrEmailTaken)
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	for input, expected := range map[string]string{
+		" Foo@X.com ":    "foo@x.com",
+		"foo@Bücher.de":  "foo@xn--bcher-kva.de",
+		"a.b+tag@x.co":   "a.b+tag@x.co",
+		"user@sub.x.org": "user@sub.x.org",
+	} {
+		normalized, err := model.NormalizeEmail(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, normalized)
+	}
+
+	for _, input := range []string{"", "plain", "@x.com", "a@", "a@b", "a b@x.com", "a@x..com", "a@-x.com"} {
+		_, err := model.NormalizeEmail(input)
+		assert.ErrorIs(t, err, model.ErrInvalidEmail, input)
+	}
+}
+
 func TestSuspendUser(t *testing.T) {
 	userService, repo, _, dispatcher := setup(t)
-	user, _ := userService.RegisterNewUser("Initial", "User", "suspend@me.com", "longpassword")
+	user := registerActiveUser(t, userService, "suspend@me.com")
 	dispatcher.Reset()
 
 	err := userService.SuspendUser(user.ID)
@@ -73,16 +164,41 @@ func TestSuspendUser(t *testing.T) {
 	assert.Equal(t, model.Suspended, event.NewStatus)
 }
 
+func TestGetUser(t *testing.T) {
+	userService, _, _, _ := setup(t)
+	user, _ := userService.RegisterNewUser("Read", "Me", "read@me.com", "longpassword")
+
+	found, err := userService.GetUser(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Email, found.Email)
+
+	found, err = userService.GetUserByEmail("read@me.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	_, err = userService.GetUser(uuid.New())
+	assert.ErrorIs(t, err, model.ErrUserNotFound)
+}
+
 type mockUserRepository struct {
-	store map[uuid.UUID]*model.User
+	store        map[uuid.UUID]*model.User
+	beforeCreate func()
 }
 
 func (m *mockUserRepository) NextID() (uuid.UUID, error) { return uuid.New(), nil }
 func (m *mockUserRepository) Create(user *model.User) error {
-	m.store[user.ID] = user
-	return nil
+	if m.beforeCreate != nil {
+		m.beforeCreate()
+	}
+	return m.Update(user)
 }
 func (m *mockUserRepository) Update(user *model.User) error {
+	// Имитирует уникальный индекс по нормализованному email
+	for _, existing := range m.store {
+		if existing.ID != user.ID && existing.NormalizedEmail == user.NormalizedEmail {
+			return model.ErrEmailTaken
+		}
+	}
 	m.store[user.ID] = user
 	return nil
 }
@@ -92,15 +208,67 @@ func (m *mockUserRepository) Find(id uuid.UUID) (*model.User, error) {
 	}
 	return nil, model.ErrUserNotFound
 }
-func (m *mockUserRepository) FindByEmail(email string) (*model.User, error) {
+func (m *mockUserRepository) FindByEmail(normalizedEmail string) (*model.User, error) {
 	for _, user := range m.store {
-		if user.Email == email {
+		if user.NormalizedEmail == normalizedEmail {
 			return user, nil
 		}
 	}
 	return nil, model.ErrUserNotFound
 }
 
+type mockEmailVerificationTokenRepository struct {
+	store map[uuid.UUID]*model.EmailVerificationToken
+}
+
+func (m *mockEmailVerificationTokenRepository) NextID() (uuid.UUID, error) { return uuid.New(), nil }
+func (m *mockEmailVerificationTokenRepository) Create(token *model.EmailVerificationToken) error {
+	m.store[token.ID] = token
+	return nil
+}
+func (m *mockEmailVerificationTokenRepository) Update(token *model.EmailVerificationToken) error {
+	m.store[token.ID] = token
+	return nil
+}
+func (m *mockEmailVerificationTokenRepository) FindByHash(tokenHash string) (*model.EmailVerificationToken, error) {
+	for _, token := range m.store {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return nil, model.ErrInvalidVerificationToken
+}
+func (m *mockEmailVerificationTokenRepository) FindLatestForUser(userID uuid.UUID) (*model.EmailVerificationToken, error) {
+	var latest *model.EmailVerificationToken
+	for _, token := range m.store {
+		if token.UserID == userID && (latest == nil || token.CreatedAt.After(latest.CreatedAt)) {
+			latest = token
+		}
+	}
+	if latest == nil {
+		return nil, model.ErrVerificationTokenNotFound
+	}
+	return latest, nil
+}
+func (m *mockEmailVerificationTokenRepository) ListForUser(userID uuid.UUID) ([]model.EmailVerificationToken, error) {
+	var tokens []model.EmailVerificationToken
+	for _, token := range m.store {
+		if token.UserID == userID {
+			tokens = append(tokens, *token)
+		}
+	}
+	return tokens, nil
+}
+func (m *mockEmailVerificationTokenRepository) InvalidateForUser(userID uuid.UUID, at time.Time) error {
+	for _, token := range m.store {
+		if token.UserID == userID && token.UsedAt == nil {
+			usedAt := at
+			token.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
 type mockPasswordManager struct{}
 
 func (m *mockPasswordManager) Hash(pwd string) (string, error) {
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"user/pkg/domain/model"
+)
+
+func lastVerificationRequest(t *testing.T, dispatcher *mockEventDispatcher) model.UserVerificationRequested {
+	for i := len(dispatcher.events) - 1; i >= 0; i-- {
+		if event, ok := dispatcher.events[i].(model.UserVerificationRequested); ok {
+			return event
+		}
+	}
+	require.Fail(t, "UserVerificationRequested was not dispatched")
+	return model.UserVerificationRequested{}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	userService, repo, _, dispatcher := setup(t)
+	user, _ := userService.RegisterNewUser("Pending", "User", "pending@example.com", "password123")
+	request := lastVerificationRequest(t, dispatcher)
+	dispatcher.Reset()
+
+	require.NoError(t, userService.VerifyEmail(request.Token))
+
+	saved, _ := repo.Find(user.ID)
+	assert.Equal(t, model.Active, saved.Status)
+	require.Len(t, dispatcher.events, 2)
+	_, ok := dispatcher.events[0].(model.UserStatusChanged)
+	assert.True(t, ok)
+	verified, ok := dispatcher.events[1].(model.UserEmailVerified)
+	require.True(t, ok)
+	assert.Equal(t, user.ID, verified.UserID)
+
+	// Токен одноразовый
+	assert.ErrorIs(t, userService.VerifyEmail(request.Token), model.ErrInvalidVerificationToken)
+	assert.ErrorIs(t, userService.VerifyEmail("unknown"), model.ErrInvalidVerificationToken)
+}
+
+func TestVerifyEmail_Expired(t *testing.T) {
+	userService, _, verificationRepo, dispatcher := setupWithVerification(t)
+	userService.RegisterNewUser("Expired", "User", "expired@example.com", "password123")
+	request := lastVerificationRequest(t, dispatcher)
+
+	for _, token := range verificationRepo.store {
+		token.ExpiresAt = time.Now().UTC().Add(-time.Second)
+	}
+
+	assert.ErrorIs(t, userService.VerifyEmail(request.Token), model.ErrInvalidVerificationToken)
+}
+
+func TestResendVerification(t *testing.T) {
+	userService, _, verificationRepo, loginFailureRepo, dispatcher := setupWithLimits(t)
+	userService.RegisterNewUser("Resend", "User", "resend@example.com", "password123")
+	first := lastVerificationRequest(t, dispatcher)
+	elapse := func() {
+		for _, token := range verificationRepo.store {
+			token.CreatedAt = token.CreatedAt.Add(-2 * time.Minute)
+		}
+		loginFailureRepo.shift(-2 * time.Minute)
+	}
+
+	t.Run("Too early after registration is silently ignored", func(t *testing.T) {
+		dispatcher.Reset()
+		assert.NoError(t, userService.ResendVerification("resend@example.com"))
+		assert.Empty(t, dispatcher.events)
+	})
+
+	t.Run("New token replaces previous one", func(t *testing.T) {
+		elapse()
+		dispatcher.Reset()
+
+		require.NoError(t, userService.ResendVerification("resend@example.com"))
+		second := lastVerificationRequest(t, dispatcher)
+		assert.NotEqual(t, first.Token, second.Token)
+		assert.ErrorIs(t, userService.VerifyEmail(first.Token), model.ErrInvalidVerificationToken)
+	})
+
+	t.Run("Repeated request is limited by email", func(t *testing.T) {
+		for _, token := range verificationRepo.store {
+			token.CreatedAt = token.CreatedAt.Add(-2 * time.Minute)
+		}
+		dispatcher.Reset()
+
+		assert.NoError(t, userService.ResendVerification(" Resend@Example.com "))
+		assert.Empty(t, dispatcher.events)
+	})
+
+	t.Run("Unknown email is limited before lookup", func(t *testing.T) {
+		assert.NoError(t, userService.ResendVerification("nobody@example.com"))
+		assert.NoError(t, userService.ResendVerification("nobody@example.com"))
+
+		counter, err := loginFailureRepo.Get(model.LoginFailureScopeVerificationEmail, "nobody@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 2, counter.Failures)
+		assert.Empty(t, dispatcher.events)
+	})
+
+	t.Run("Verified email is silently ignored", func(t *testing.T) {
+		elapse()
+		dispatcher.Reset()
+		require.NoError(t, userService.ResendVerification("resend@example.com"))
+		request := lastVerificationRequest(t, dispatcher)
+		require.NoError(t, userService.VerifyEmail(request.Token))
+
+		elapse()
+		dispatcher.Reset()
+		assert.NoError(t, userService.ResendVerification("resend@example.com"))
+		assert.Empty(t, dispatcher.events)
+	})
+}
+
+func TestAuthenticate_PendingVerification(t *testing.T) {
+	authService, userService, _ := setupAuth(t)
+	userService.RegisterNewUser("Pending", "User", "pending-auth@example.com", "password123")
+
+	_, err := authService.Authenticate("pending-auth@example.com", "password123", model.ClientInfo{})
+	assert.ErrorIs(t, err, model.ErrUserNotActive)
+}
@@ -0,0 +1,34 @@
+package event
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"user/pkg/domain/service"
+)
+
+// NewLogEventDispatcher пишет доменные события в лог; секреты из событий в лог не попадают
+func NewLogEventDispatcher(logger *log.Logger) service.EventDispatcher {
+	return &logEventDispatcher{logger: logger}
+}
+
+type logEventDispatcher struct {
+	logger *log.Logger
+}
+
+// redactedEvent реализуют события с секретами, которые нельзя писать в лог
+type redactedEvent interface {
+	Redacted() interface{}
+}
+
+func (d *logEventDispatcher) Dispatch(event service.Event) error {
+	var payload interface{} = event
+	if e, ok := event.(redactedEvent); ok {
+		payload = e.Redacted()
+	}
+
+	d.logger.WithFields(log.Fields{
+		"eventType": event.Type(),
+		"event":     payload,
+	}).Infof("domain event dispatched")
+	return nil
+}
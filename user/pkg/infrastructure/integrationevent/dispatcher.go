@@ -0,0 +1,73 @@
+package integrationevent
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"user/pkg/domain/model"
+	"user/pkg/domain/service"
+)
+
+// NewEventDispatcher публикует в domain_event_exchange события, на которые подписаны другие сервисы,
+// и передаёт все события в next. Outbox у сервиса нет: при недоступном брокере событие теряется,
+// письмо подтверждения или сброса пароля можно запросить повторно
+func NewEventDispatcher(publisher Publisher, next service.EventDispatcher, logger *log.Logger) service.EventDispatcher {
+	return &eventDispatcher{
+		publisher: publisher,
+		next:      next,
+		logger:    logger,
+	}
+}
+
+type eventDispatcher struct {
+	publisher Publisher
+	next      service.EventDispatcher
+	logger    *log.Logger
+}
+
+func (d *eventDispatcher) Dispatch(event service.Event) error {
+	if err := d.next.Dispatch(event); err != nil {
+		return err
+	}
+
+	routingKey, payload, ok := toIntegrationEvent(event)
+	if !ok {
+		return nil
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = d.publisher.Publish(routingKey, body); err != nil {
+		d.logger.WithError(err).WithField("routingKey", routingKey).Error("failed to publish integration event")
+		return err
+	}
+	return nil
+}
+
+func toIntegrationEvent(event service.Event) (string, interface{}, bool) {
+	switch e := event.(type) {
+	case model.UserVerificationRequested:
+		return UserVerificationRequestedRoutingKey, UserVerificationRequested{
+			UserID:    e.UserID.String(),
+			Email:     e.Email,
+			FirstName: e.FirstName,
+			Token:     e.Token,
+			ExpiresAt: e.ExpiresAt.Unix(),
+		}, true
+	case model.PasswordResetRequested:
+		return PasswordResetRequestedRoutingKey, PasswordResetRequested{
+			UserID:    e.UserID.String(),
+			Email:     e.Email,
+			FirstName: e.FirstName,
+			Token:     e.Token,
+			ExpiresAt: e.ExpiresAt.Unix(),
+		}, true
+	case model.UserErased:
+		return UserErasedRoutingKey, UserErased{UserID: e.UserID.String()}, true
+	default:
+		return "", nil, false
+	}
+}
@@ -0,0 +1,34 @@
+package integrationevent
+
+const (
+	ExchangeName = "domain_event_exchange"
+	ExchangeKind = "topic"
+
+	// Ключи строятся как у сервиса user2: "user." + тип события в snake_case
+	UserVerificationRequestedRoutingKey = "user.user_verification_requested"
+	PasswordResetRequestedRoutingKey    = "user.password_reset_requested"
+	UserErasedRoutingKey                = "user.user_erased"
+)
+
+// UserVerificationRequested несёт исходный токен: письмо с ним отправляет сервис уведомлений
+type UserVerificationRequested struct {
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// PasswordResetRequested несёт исходный токен сброса пароля
+type PasswordResetRequested struct {
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// UserErased - получатели удаляют свои копии персональных данных пользователя
+type UserErased struct {
+	UserID string `json:"user_id"`
+}
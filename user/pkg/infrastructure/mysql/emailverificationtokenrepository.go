@@ -0,0 +1,127 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+func NewEmailVerificationTokenRepository(db *sqlx.DB) model.EmailVerificationTokenRepository {
+	return &emailVerificationTokenRepository{db: db}
+}
+
+type emailVerificationTokenRepository struct {
+	db dbExecutor
+}
+
+type sqlxEmailVerificationToken struct {
+	ID        uuid.UUID           `db:"id"`
+	UserID    uuid.UUID           `db:"user_id"`
+	TokenHash string              `db:"token_hash"`
+	ExpiresAt time.Time           `db:"expires_at"`
+	CreatedAt time.Time           `db:"created_at"`
+	UsedAt    sql.Null[time.Time] `db:"used_at"`
+}
+
+func (r *emailVerificationTokenRepository) NextID() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+func (r *emailVerificationTokenRepository) Create(token *model.EmailVerificationToken) error {
+	_, err := r.db.Exec(
+		`INSERT INTO email_verification_token (id, user_id, token_hash, expires_at, created_at, used_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+		toSQLNull(token.UsedAt),
+	)
+	return errors.WithStack(err)
+}
+
+func (r *emailVerificationTokenRepository) Update(token *model.EmailVerificationToken) error {
+	_, err := r.db.Exec(
+		`UPDATE email_verification_token SET used_at = ? WHERE id = ?`,
+		toSQLNull(token.UsedAt),
+		token.ID,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *emailVerificationTokenRepository) FindByHash(tokenHash string) (*model.EmailVerificationToken, error) {
+	token, err := r.findOne(
+		`SELECT id, user_id, token_hash, expires_at, created_at, used_at FROM email_verification_token WHERE token_hash = ?`,
+		tokenHash,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.WithStack(model.ErrInvalidVerificationToken)
+	}
+	return token, err
+}
+
+func (r *emailVerificationTokenRepository) FindLatestForUser(userID uuid.UUID) (*model.EmailVerificationToken, error) {
+	token, err := r.findOne(
+		`SELECT id, user_id, token_hash, expires_at, created_at, used_at FROM email_verification_token
+		WHERE user_id = ? ORDER BY created_at DESC LIMIT 1`,
+		userID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.WithStack(model.ErrVerificationTokenNotFound)
+	}
+	return token, err
+}
+
+func (r *emailVerificationTokenRepository) InvalidateForUser(userID uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE email_verification_token SET used_at = ? WHERE user_id = ? AND used_at IS NULL`,
+		at,
+		userID,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *emailVerificationTokenRepository) ListForUser(userID uuid.UUID) ([]model.EmailVerificationToken, error) {
+	var rows []sqlxEmailVerificationToken
+	err := r.db.Select(
+		&rows,
+		`SELECT id, user_id, token_hash, expires_at, created_at, used_at FROM email_verification_token
+		WHERE user_id = ? ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	tokens := make([]model.EmailVerificationToken, 0, len(rows))
+	for _, row := range rows {
+		tokens = append(tokens, *fromSQLXEmailVerificationToken(row))
+	}
+	return tokens, nil
+}
+
+func (r *emailVerificationTokenRepository) findOne(query string, args ...interface{}) (*model.EmailVerificationToken, error) {
+	var token sqlxEmailVerificationToken
+	if err := r.db.Get(&token, query, args...); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return fromSQLXEmailVerificationToken(token), nil
+}
+
+func fromSQLXEmailVerificationToken(token sqlxEmailVerificationToken) *model.EmailVerificationToken {
+	return &model.EmailVerificationToken{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+		UsedAt:    fromSQLNull(token.UsedAt),
+	}
+}
@@ -0,0 +1,13 @@
+package mysql
+
+import (
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+const duplicateEntryErrorNumber = 1062
+
+func isDuplicateKeyError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == duplicateEntryErrorNumber
+}
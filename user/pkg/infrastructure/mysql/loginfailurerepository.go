@@ -0,0 +1,122 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+func NewLoginFailureRepository(db *sqlx.DB) model.LoginFailureRepository {
+	return &loginFailureRepository{db: db}
+}
+
+type loginFailureRepository struct {
+	db dbExecutor
+}
+
+type sqlxLoginFailureCounter struct {
+	Scope         string              `db:"scope"`
+	Key           string              `db:"key"`
+	Failures      int                 `db:"failures"`
+	LastFailureAt time.Time           `db:"last_failure_at"`
+	LockedUntil   sql.Null[time.Time] `db:"locked_until"`
+}
+
+func (r *loginFailureRepository) Get(scope model.LoginFailureScope, key string) (*model.LoginFailureCounter, error) {
+	var counter sqlxLoginFailureCounter
+	err := r.db.Get(
+		&counter,
+		"SELECT `scope`, `key`, failures, last_failure_at, locked_until FROM login_failure WHERE `scope` = ? AND `key` = ?",
+		scope,
+		key,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.LoginFailureCounter{Scope: scope, Key: key}, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &model.LoginFailureCounter{
+		Scope:         model.LoginFailureScope(counter.Scope),
+		Key:           counter.Key,
+		Failures:      counter.Failures,
+		LastFailureAt: counter.LastFailureAt,
+		LockedUntil:   fromSQLNull(counter.LockedUntil),
+	}, nil
+}
+
+func (r *loginFailureRepository) Increment(
+	scope model.LoginFailureScope,
+	key string,
+	at, resetBefore time.Time,
+) (*model.LoginFailureCounter, error) {
+	var updated sqlxLoginFailureCounter
+	err := withinTransaction(r.db, func(tx dbExecutor) error {
+		// Присваивания выполняются слева направо: failures и locked_until читают ещё старое last_failure_at
+		_, err := tx.Exec(
+			"INSERT INTO login_failure (`scope`, `key`, failures, last_failure_at, locked_until) VALUES (?, ?, 1, ?, NULL) "+
+				"ON DUPLICATE KEY UPDATE "+
+				"failures = IF(last_failure_at < ?, 1, failures + 1), "+
+				"locked_until = IF(locked_until > VALUES(last_failure_at), locked_until, NULL), "+
+				"last_failure_at = VALUES(last_failure_at)",
+			scope,
+			key,
+			at,
+			resetBefore,
+		)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		return errors.WithStack(tx.Get(
+			&updated,
+			"SELECT `scope`, `key`, failures, last_failure_at, locked_until FROM login_failure WHERE `scope` = ? AND `key` = ?",
+			scope,
+			key,
+		))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginFailureCounter{
+		Scope:         model.LoginFailureScope(updated.Scope),
+		Key:           updated.Key,
+		Failures:      updated.Failures,
+		LastFailureAt: updated.LastFailureAt,
+		LockedUntil:   fromSQLNull(updated.LockedUntil),
+	}, nil
+}
+
+func (r *loginFailureRepository) Lock(
+	scope model.LoginFailureScope,
+	key string,
+	threshold int,
+	lockedUntil time.Time,
+) (bool, error) {
+	result, err := r.db.Exec(
+		"UPDATE login_failure SET locked_until = ?, failures = 0 WHERE `scope` = ? AND `key` = ? AND failures >= ?",
+		lockedUntil,
+		scope,
+		key,
+		threshold,
+	)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return affected == 1, nil
+}
+
+func (r *loginFailureRepository) Delete(scope model.LoginFailureScope, key string) error {
+	_, err := r.db.Exec("DELETE FROM login_failure WHERE `scope` = ? AND `key` = ?", scope, key)
+	return errors.WithStack(err)
+}
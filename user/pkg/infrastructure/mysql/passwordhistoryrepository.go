@@ -0,0 +1,64 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+func NewPasswordHistoryRepository(db *sqlx.DB) model.PasswordHistoryRepository {
+	return &passwordHistoryRepository{db: db}
+}
+
+type passwordHistoryRepository struct {
+	db dbExecutor
+}
+
+type sqlxPasswordHistoryEntry struct {
+	UserID         uuid.UUID `db:"user_id"`
+	HashedPassword string    `db:"hashed_password"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+func (r *passwordHistoryRepository) Add(entry model.PasswordHistoryEntry) error {
+	_, err := r.db.Exec(
+		`INSERT INTO password_history (user_id, hashed_password, created_at) VALUES (?, ?, ?)`,
+		entry.UserID,
+		entry.HashedPassword,
+		entry.CreatedAt,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *passwordHistoryRepository) ListRecent(userID uuid.UUID, limit int) ([]model.PasswordHistoryEntry, error) {
+	var rows []sqlxPasswordHistoryEntry
+	err := r.db.Select(
+		&rows,
+		`SELECT user_id, hashed_password, created_at FROM password_history
+		WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		userID,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	entries := make([]model.PasswordHistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, model.PasswordHistoryEntry{
+			UserID:         row.UserID,
+			HashedPassword: row.HashedPassword,
+			CreatedAt:      row.CreatedAt,
+		})
+	}
+	return entries, nil
+}
+
+func (r *passwordHistoryRepository) DeleteForUser(userID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM password_history WHERE user_id = ?`, userID)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,112 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+func NewPasswordResetTokenRepository(db *sqlx.DB) model.PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db: db}
+}
+
+type passwordResetTokenRepository struct {
+	db dbExecutor
+}
+
+type sqlxPasswordResetToken struct {
+	ID        uuid.UUID           `db:"id"`
+	UserID    uuid.UUID           `db:"user_id"`
+	TokenHash string              `db:"token_hash"`
+	ExpiresAt time.Time           `db:"expires_at"`
+	CreatedAt time.Time           `db:"created_at"`
+	UsedAt    sql.Null[time.Time] `db:"used_at"`
+}
+
+func (r *passwordResetTokenRepository) NextID() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+func (r *passwordResetTokenRepository) Create(token *model.PasswordResetToken) error {
+	_, err := r.db.Exec(
+		`INSERT INTO password_reset_token (id, user_id, token_hash, expires_at, created_at, used_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+		toSQLNull(token.UsedAt),
+	)
+	return errors.WithStack(err)
+}
+
+func (r *passwordResetTokenRepository) Update(token *model.PasswordResetToken) error {
+	_, err := r.db.Exec(
+		`UPDATE password_reset_token SET used_at = ? WHERE id = ?`,
+		toSQLNull(token.UsedAt),
+		token.ID,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *passwordResetTokenRepository) FindByHash(tokenHash string) (*model.PasswordResetToken, error) {
+	var token sqlxPasswordResetToken
+	err := r.db.Get(
+		&token,
+		`SELECT id, user_id, token_hash, expires_at, created_at, used_at FROM password_reset_token WHERE token_hash = ?`,
+		tokenHash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrInvalidPasswordResetToken)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	return fromSQLXPasswordResetToken(token), nil
+}
+
+func (r *passwordResetTokenRepository) ListForUser(userID uuid.UUID) ([]model.PasswordResetToken, error) {
+	var rows []sqlxPasswordResetToken
+	err := r.db.Select(
+		&rows,
+		`SELECT id, user_id, token_hash, expires_at, created_at, used_at FROM password_reset_token
+		WHERE user_id = ? ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	tokens := make([]model.PasswordResetToken, 0, len(rows))
+	for _, row := range rows {
+		tokens = append(tokens, *fromSQLXPasswordResetToken(row))
+	}
+	return tokens, nil
+}
+
+func (r *passwordResetTokenRepository) InvalidateForUser(userID uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE password_reset_token SET used_at = ? WHERE user_id = ? AND used_at IS NULL`,
+		at,
+		userID,
+	)
+	return errors.WithStack(err)
+}
+
+func fromSQLXPasswordResetToken(token sqlxPasswordResetToken) *model.PasswordResetToken {
+	return &model.PasswordResetToken{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+		UsedAt:    fromSQLNull(token.UsedAt),
+	}
+}
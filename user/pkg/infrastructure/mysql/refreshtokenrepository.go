@@ -0,0 +1,116 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+func NewRefreshTokenRepository(db *sqlx.DB) model.RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+type refreshTokenRepository struct {
+	db dbExecutor
+}
+
+type sqlxRefreshToken struct {
+	ID        uuid.UUID           `db:"id"`
+	UserID    uuid.UUID           `db:"user_id"`
+	FamilyID  uuid.UUID           `db:"family_id"`
+	TokenHash string              `db:"token_hash"`
+	ExpiresAt time.Time           `db:"expires_at"`
+	CreatedAt time.Time           `db:"created_at"`
+	RevokedAt sql.Null[time.Time] `db:"revoked_at"`
+}
+
+const refreshTokenColumns = `id, user_id, family_id, token_hash, expires_at, created_at, revoked_at`
+
+func (r *refreshTokenRepository) NextID() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+func (r *refreshTokenRepository) Create(token *model.RefreshToken) error {
+	_, err := r.db.Exec(
+		`INSERT INTO refresh_token (id, user_id, family_id, token_hash, expires_at, created_at, revoked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		token.ID,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+		toSQLNull(token.RevokedAt),
+	)
+	return errors.WithStack(err)
+}
+
+func (r *refreshTokenRepository) Update(token *model.RefreshToken) error {
+	_, err := r.db.Exec(
+		`UPDATE refresh_token SET revoked_at = ? WHERE id = ?`,
+		toSQLNull(token.RevokedAt),
+		token.ID,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *refreshTokenRepository) FindByHash(tokenHash string) (*model.RefreshToken, error) {
+	var token sqlxRefreshToken
+	err := r.db.Get(&token, `SELECT `+refreshTokenColumns+` FROM refresh_token WHERE token_hash = ?`, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrInvalidRefreshToken)
+		}
+		return nil, errors.WithStack(err)
+	}
+	return fromSQLXRefreshToken(token), nil
+}
+
+func (r *refreshTokenRepository) ListForUser(userID uuid.UUID) ([]model.RefreshToken, error) {
+	var rows []sqlxRefreshToken
+	err := r.db.Select(&rows, `SELECT `+refreshTokenColumns+` FROM refresh_token WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	tokens := make([]model.RefreshToken, 0, len(rows))
+	for _, row := range rows {
+		tokens = append(tokens, *fromSQLXRefreshToken(row))
+	}
+	return tokens, nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(familyID uuid.UUID, revokedAt time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE refresh_token SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`,
+		revokedAt,
+		familyID,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *refreshTokenRepository) RevokeAllForUser(userID uuid.UUID, revokedAt time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE refresh_token SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`,
+		revokedAt,
+		userID,
+	)
+	return errors.WithStack(err)
+}
+
+func fromSQLXRefreshToken(token sqlxRefreshToken) *model.RefreshToken {
+	return &model.RefreshToken{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		FamilyID:  token.FamilyID,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+		RevokedAt: fromSQLNull(token.RevokedAt),
+	}
+}
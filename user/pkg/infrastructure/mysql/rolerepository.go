@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+func NewRoleRepository(db *sqlx.DB) model.RoleRepository {
+	return &roleRepository{db: db}
+}
+
+type roleRepository struct {
+	db dbExecutor
+}
+
+type sqlxRolePermission struct {
+	Role       string           `db:"role"`
+	Permission sql.Null[string] `db:"permission"`
+}
+
+func (r *roleRepository) FindRole(name string) (*model.Role, error) {
+	roles, err := r.selectRoles(
+		`SELECT r.name AS role, rp.permission FROM role r
+		LEFT JOIN role_permission rp ON rp.role = r.name
+		WHERE r.name = ?`,
+		name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, errors.WithStack(model.ErrRoleNotFound)
+	}
+	return &roles[0], nil
+}
+
+func (r *roleRepository) ListUserRoles(userID uuid.UUID) ([]model.Role, error) {
+	return r.selectRoles(
+		`SELECT ur.role, rp.permission FROM user_role ur
+		LEFT JOIN role_permission rp ON rp.role = ur.role
+		WHERE ur.user_id = ?
+		ORDER BY ur.role`,
+		userID,
+	)
+}
+
+func (r *roleRepository) AssignRole(userID uuid.UUID, roleName string) error {
+	_, err := r.db.Exec(
+		`INSERT IGNORE INTO user_role (user_id, role) VALUES (?, ?)`,
+		userID,
+		roleName,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *roleRepository) RevokeRole(userID uuid.UUID, roleName string) error {
+	_, err := r.db.Exec(
+		`DELETE FROM user_role WHERE user_id = ? AND role = ?`,
+		userID,
+		roleName,
+	)
+	return errors.WithStack(err)
+}
+
+// selectRoles собирает плоские строки роль-разрешение в роли, сохраняя порядок выборки
+func (r *roleRepository) selectRoles(query string, args ...interface{}) ([]model.Role, error) {
+	var rows []sqlxRolePermission
+	if err := r.db.Select(&rows, query, args...); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var roles []model.Role
+	index := make(map[string]int)
+	for _, row := range rows {
+		i, ok := index[row.Role]
+		if !ok {
+			i = len(roles)
+			index[row.Role] = i
+			roles = append(roles, model.Role{Name: row.Role})
+		}
+		if row.Permission.Valid {
+			roles[i].Permissions = append(roles[i].Permissions, model.Permission(row.Permission.V))
+		}
+	}
+	return roles, nil
+}
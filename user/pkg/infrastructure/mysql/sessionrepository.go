@@ -0,0 +1,120 @@
+package mysql
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+// maxUserAgentLength соответствует размеру колонки user_session.user_agent
+const maxUserAgentLength = 512
+
+func NewSessionRepository(db *sqlx.DB) model.SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+type sessionRepository struct {
+	db dbExecutor
+}
+
+type sqlxSession struct {
+	ID         uuid.UUID           `db:"id"`
+	UserID     uuid.UUID           `db:"user_id"`
+	UserAgent  string              `db:"user_agent"`
+	IPAddress  string              `db:"ip_address"`
+	CreatedAt  time.Time           `db:"created_at"`
+	LastSeenAt time.Time           `db:"last_seen_at"`
+	ExpiresAt  time.Time           `db:"expires_at"`
+	RevokedAt  sql.Null[time.Time] `db:"revoked_at"`
+}
+
+const sessionColumns = `id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at`
+
+func (r *sessionRepository) Create(session *model.Session) error {
+	userAgent := session.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+	_, err := r.db.Exec(
+		`INSERT INTO user_session (`+sessionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		session.ID,
+		session.UserID,
+		userAgent,
+		session.IPAddress,
+		session.CreatedAt,
+		session.LastSeenAt,
+		session.ExpiresAt,
+		toSQLNull(session.RevokedAt),
+	)
+	return errors.WithStack(err)
+}
+
+func (r *sessionRepository) Update(session *model.Session) error {
+	_, err := r.db.Exec(
+		`UPDATE user_session SET last_seen_at = ?, expires_at = ?, revoked_at = ? WHERE id = ?`,
+		session.LastSeenAt,
+		session.ExpiresAt,
+		toSQLNull(session.RevokedAt),
+		session.ID,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *sessionRepository) Find(id uuid.UUID) (*model.Session, error) {
+	var session sqlxSession
+	err := r.db.Get(&session, `SELECT `+sessionColumns+` FROM user_session WHERE id = ?`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrSessionNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	return fromSQLXSession(session), nil
+}
+
+func (r *sessionRepository) ListForUser(userID uuid.UUID) ([]model.Session, error) {
+	var rows []sqlxSession
+	err := r.db.Select(&rows, `SELECT `+sessionColumns+` FROM user_session WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sessions := make([]model.Session, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, *fromSQLXSession(row))
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) RevokeAllForUser(userID uuid.UUID, revokedAt time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE user_session SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`,
+		revokedAt,
+		userID,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *sessionRepository) DeleteForUser(userID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM user_session WHERE user_id = ?`, userID)
+	return errors.WithStack(err)
+}
+
+func fromSQLXSession(session sqlxSession) *model.Session {
+	return &model.Session{
+		ID:         session.ID,
+		UserID:     session.UserID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+		RevokedAt:  fromSQLNull(session.RevokedAt),
+	}
+}
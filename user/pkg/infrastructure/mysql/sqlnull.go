@@ -0,0 +1,20 @@
+package mysql
+
+import "database/sql"
+
+func fromSQLNull[T any](v sql.Null[T]) *T {
+	if v.Valid {
+		return &v.V
+	}
+	return nil
+}
+
+func toSQLNull[T any](v *T) sql.Null[T] {
+	if v == nil {
+		return sql.Null[T]{}
+	}
+	return sql.Null[T]{
+		V:     *v,
+		Valid: true,
+	}
+}
@@ -0,0 +1,95 @@
+package mysql
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+func NewTwoFactorChallengeRepository(db *sqlx.DB) model.TwoFactorChallengeRepository {
+	return &twoFactorChallengeRepository{db: db}
+}
+
+type twoFactorChallengeRepository struct {
+	db *sqlx.DB
+}
+
+type sqlxTwoFactorChallenge struct {
+	ID        uuid.UUID           `db:"id"`
+	UserID    uuid.UUID           `db:"user_id"`
+	TokenHash string              `db:"token_hash"`
+	IPAddress string              `db:"ip_address"`
+	UserAgent string              `db:"user_agent"`
+	Attempts  int                 `db:"attempts"`
+	ExpiresAt time.Time           `db:"expires_at"`
+	CreatedAt time.Time           `db:"created_at"`
+	UsedAt    sql.Null[time.Time] `db:"used_at"`
+}
+
+func (r *twoFactorChallengeRepository) NextID() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+func (r *twoFactorChallengeRepository) Create(challenge *model.TwoFactorChallenge) error {
+	userAgent := challenge.Client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+	_, err := r.db.Exec(
+		`INSERT INTO two_factor_challenge (id, user_id, token_hash, ip_address, user_agent, attempts, expires_at, created_at, used_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		challenge.ID,
+		challenge.UserID,
+		challenge.TokenHash,
+		challenge.Client.IPAddress,
+		userAgent,
+		challenge.Attempts,
+		challenge.ExpiresAt,
+		challenge.CreatedAt,
+		toSQLNull(challenge.UsedAt),
+	)
+	return errors.WithStack(err)
+}
+
+func (r *twoFactorChallengeRepository) Update(challenge *model.TwoFactorChallenge) error {
+	_, err := r.db.Exec(
+		`UPDATE two_factor_challenge SET attempts = ?, used_at = ? WHERE id = ?`,
+		challenge.Attempts,
+		toSQLNull(challenge.UsedAt),
+		challenge.ID,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *twoFactorChallengeRepository) FindByHash(tokenHash string) (*model.TwoFactorChallenge, error) {
+	var row sqlxTwoFactorChallenge
+	err := r.db.Get(
+		&row,
+		`SELECT id, user_id, token_hash, ip_address, user_agent, attempts, expires_at, created_at, used_at
+		FROM two_factor_challenge WHERE token_hash = ?`,
+		tokenHash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrInvalidTwoFactorChallenge)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	return &model.TwoFactorChallenge{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		TokenHash: row.TokenHash,
+		Client:    model.ClientInfo{IPAddress: row.IPAddress, UserAgent: row.UserAgent},
+		Attempts:  row.Attempts,
+		ExpiresAt: row.ExpiresAt,
+		CreatedAt: row.CreatedAt,
+		UsedAt:    fromSQLNull(row.UsedAt),
+	}, nil
+}
@@ -0,0 +1,141 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+// SecretCipher шифрует TOTP-секрет перед записью; associatedData привязывает значение к пользователю
+type SecretCipher interface {
+	Encrypt(plaintext string, associatedData []byte) (string, error)
+	Decrypt(stored string, associatedData []byte) (string, error)
+}
+
+func NewTwoFactorRepository(db *sqlx.DB, cipher SecretCipher) model.TwoFactorRepository {
+	return &twoFactorRepository{db: db, cipher: cipher}
+}
+
+type twoFactorRepository struct {
+	db     dbExecutor
+	cipher SecretCipher
+}
+
+type sqlxTwoFactor struct {
+	UserID       uuid.UUID           `db:"user_id"`
+	Secret       string              `db:"secret"`
+	CreatedAt    time.Time           `db:"created_at"`
+	EnabledAt    sql.Null[time.Time] `db:"enabled_at"`
+	LastUsedStep int64               `db:"last_used_step"`
+}
+
+func (r *twoFactorRepository) Find(userID uuid.UUID) (*model.TwoFactor, error) {
+	var row sqlxTwoFactor
+	err := r.db.Get(
+		&row,
+		`SELECT user_id, secret, created_at, enabled_at, last_used_step FROM two_factor WHERE user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrTwoFactorNotEnrolled)
+		}
+		return nil, errors.WithStack(err)
+	}
+	secret, err := r.cipher.Decrypt(row.Secret, row.UserID[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TwoFactor{
+		UserID:       row.UserID,
+		Secret:       secret,
+		CreatedAt:    row.CreatedAt,
+		EnabledAt:    fromSQLNull(row.EnabledAt),
+		LastUsedStep: row.LastUsedStep,
+	}, nil
+}
+
+func (r *twoFactorRepository) Save(twoFactor *model.TwoFactor) error {
+	secret, err := r.cipher.Encrypt(twoFactor.Secret, twoFactor.UserID[:])
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(
+		`INSERT INTO two_factor (user_id, secret, created_at, enabled_at, last_used_step) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE secret = VALUES(secret), created_at = VALUES(created_at),
+			enabled_at = VALUES(enabled_at), last_used_step = VALUES(last_used_step)`,
+		twoFactor.UserID,
+		secret,
+		twoFactor.CreatedAt,
+		toSQLNull(twoFactor.EnabledAt),
+		twoFactor.LastUsedStep,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *twoFactorRepository) Delete(userID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM two_factor WHERE user_id = ?`, userID)
+	return errors.WithStack(err)
+}
+
+func NewRecoveryCodeRepository(db *sqlx.DB) model.RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+type recoveryCodeRepository struct {
+	db dbExecutor
+}
+
+func (r *recoveryCodeRepository) Replace(userID uuid.UUID, codeHashes []string, createdAt time.Time) error {
+	return withinTransaction(r.db, func(tx dbExecutor) error {
+		if _, err := tx.Exec(`DELETE FROM recovery_code WHERE user_id = ?`, userID); err != nil {
+			return errors.WithStack(err)
+		}
+		for _, codeHash := range codeHashes {
+			_, err := tx.Exec(
+				`INSERT INTO recovery_code (user_id, code_hash, created_at) VALUES (?, ?, ?)`,
+				userID,
+				codeHash,
+				createdAt,
+			)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *recoveryCodeRepository) Consume(userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error) {
+	result, err := r.db.Exec(
+		`UPDATE recovery_code SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`,
+		usedAt,
+		userID,
+		codeHash,
+	)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return affected == 1, nil
+}
+
+func (r *recoveryCodeRepository) CountUnused(userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.Get(&count, `SELECT COUNT(*) FROM recovery_code WHERE user_id = ? AND used_at IS NULL`, userID)
+	return count, errors.WithStack(err)
+}
+
+func (r *recoveryCodeRepository) DeleteForUser(userID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM recovery_code WHERE user_id = ?`, userID)
+	return errors.WithStack(err)
+}
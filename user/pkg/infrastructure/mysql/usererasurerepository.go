@@ -0,0 +1,118 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+// cipher нужен репозиторию TwoFactor, который выдаёт WithinTransaction
+func NewUserErasureRepository(db *sqlx.DB, cipher SecretCipher) model.UserErasureRepository {
+	return &userErasureRepository{db: db, cipher: cipher}
+}
+
+type userErasureRepository struct {
+	db     dbExecutor
+	cipher SecretCipher
+}
+
+type sqlxUserErasure struct {
+	ID          uuid.UUID `db:"id"`
+	UserID      uuid.UUID `db:"user_id"`
+	RequestedBy uuid.UUID `db:"requested_by"`
+	Reason      string    `db:"reason"`
+	ErasedAt    time.Time `db:"erased_at"`
+}
+
+func (r *userErasureRepository) NextID() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+func (r *userErasureRepository) Create(erasure *model.UserErasure) error {
+	_, err := r.db.Exec(
+		`INSERT INTO user_erasure (id, user_id, requested_by, reason, erased_at) VALUES (?, ?, ?, ?, ?)`,
+		erasure.ID,
+		erasure.UserID,
+		erasure.RequestedBy,
+		erasure.Reason,
+		erasure.ErasedAt,
+	)
+	if isDuplicateKeyError(err) {
+		return errors.WithStack(model.ErrUserAlreadyErased)
+	}
+	return errors.WithStack(err)
+}
+
+func (r *userErasureRepository) WithinTransaction(fn func(repos model.ErasureRepositories) error) error {
+	return withinTransaction(r.db, func(tx dbExecutor) error {
+		return fn(&erasureRepositories{tx: tx, cipher: r.cipher})
+	})
+}
+
+func (r *userErasureRepository) FindByUserID(userID uuid.UUID) (*model.UserErasure, error) {
+	var erasure sqlxUserErasure
+	err := r.db.Get(
+		&erasure,
+		`SELECT id, user_id, requested_by, reason, erased_at FROM user_erasure WHERE user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrUserNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	return &model.UserErasure{
+		ID:          erasure.ID,
+		UserID:      erasure.UserID,
+		RequestedBy: erasure.RequestedBy,
+		Reason:      erasure.Reason,
+		ErasedAt:    erasure.ErasedAt,
+	}, nil
+}
+
+// erasureRepositories выдаёт репозитории, привязанные к одной транзакции
+type erasureRepositories struct {
+	tx     dbExecutor
+	cipher SecretCipher
+}
+
+func (r *erasureRepositories) Users() model.UserRepository {
+	return &userRepository{db: r.tx}
+}
+func (r *erasureRepositories) Roles() model.RoleRepository {
+	return &roleRepository{db: r.tx}
+}
+func (r *erasureRepositories) RefreshTokens() model.RefreshTokenRepository {
+	return &refreshTokenRepository{db: r.tx}
+}
+func (r *erasureRepositories) Sessions() model.SessionRepository {
+	return &sessionRepository{db: r.tx}
+}
+func (r *erasureRepositories) Verifications() model.EmailVerificationTokenRepository {
+	return &emailVerificationTokenRepository{db: r.tx}
+}
+func (r *erasureRepositories) PasswordResets() model.PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db: r.tx}
+}
+func (r *erasureRepositories) PasswordHistory() model.PasswordHistoryRepository {
+	return &passwordHistoryRepository{db: r.tx}
+}
+func (r *erasureRepositories) LoginFailures() model.LoginFailureRepository {
+	return &loginFailureRepository{db: r.tx}
+}
+func (r *erasureRepositories) TwoFactor() model.TwoFactorRepository {
+	return &twoFactorRepository{db: r.tx, cipher: r.cipher}
+}
+func (r *erasureRepositories) RecoveryCodes() model.RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: r.tx}
+}
+func (r *erasureRepositories) Erasures() model.UserErasureRepository {
+	return &userErasureRepository{db: r.tx, cipher: r.cipher}
+}
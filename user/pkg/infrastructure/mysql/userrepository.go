@@ -0,0 +1,120 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+func NewUserRepository(db *sqlx.DB) model.UserRepository {
+	return &userRepository{db: db}
+}
+
+type userRepository struct {
+	db dbExecutor
+}
+
+type sqlxUser struct {
+	ID              uuid.UUID `db:"id"`
+	Email           string    `db:"email"`
+	NormalizedEmail string    `db:"email_normalized"`
+	HashedPassword  string    `db:"hashed_password"`
+	FirstName       string    `db:"first_name"`
+	LastName        string    `db:"last_name"`
+	Status          int       `db:"status"`
+	CreatedAt       time.Time `db:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at"`
+}
+
+const userColumns = `id, email, email_normalized, hashed_password, first_name, last_name, status, created_at, updated_at`
+
+func (r *userRepository) NextID() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+func (r *userRepository) Create(user *model.User) error {
+	_, err := r.db.Exec(
+		`INSERT INTO user (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		user.ID,
+		user.Email,
+		user.NormalizedEmail,
+		user.HashedPassword,
+		user.FirstName,
+		user.LastName,
+		user.Status,
+		user.CreatedAt,
+		user.UpdatedAt,
+	)
+	return wrapUserWriteError(err)
+}
+
+func (r *userRepository) Update(user *model.User) error {
+	_, err := r.db.Exec(
+		`UPDATE user SET
+			email = ?,
+			email_normalized = ?,
+			hashed_password = ?,
+			first_name = ?,
+			last_name = ?,
+			status = ?,
+			updated_at = ?
+		WHERE id = ?`,
+		user.Email,
+		user.NormalizedEmail,
+		user.HashedPassword,
+		user.FirstName,
+		user.LastName,
+		user.Status,
+		user.UpdatedAt,
+		user.ID,
+	)
+	return wrapUserWriteError(err)
+}
+
+func (r *userRepository) Find(id uuid.UUID) (*model.User, error) {
+	return r.findOne(`id = ?`, id)
+}
+
+func (r *userRepository) FindByEmail(normalizedEmail string) (*model.User, error) {
+	return r.findOne(`email_normalized = ?`, normalizedEmail)
+}
+
+func (r *userRepository) findOne(condition string, args ...interface{}) (*model.User, error) {
+	var user sqlxUser
+	err := r.db.Get(&user, `SELECT `+userColumns+` FROM user WHERE `+condition, args...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.WithStack(model.ErrUserNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	return toModelUser(user), nil
+}
+
+func toModelUser(user sqlxUser) *model.User {
+	return &model.User{
+		ID:              user.ID,
+		Email:           user.Email,
+		NormalizedEmail: user.NormalizedEmail,
+		HashedPassword:  user.HashedPassword,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Status:          model.UserStatus(user.Status),
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+}
+
+// wrapUserWriteError переводит нарушение уникального индекса email_normalized в ErrEmailTaken,
+// поэтому из одновременных регистраций одного адреса успешна только одна
+func wrapUserWriteError(err error) error {
+	if isDuplicateKeyError(err) {
+		return errors.WithStack(model.ErrEmailTaken)
+	}
+	return errors.WithStack(err)
+}
@@ -0,0 +1,93 @@
+package mysql
+
+import (
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+// searchColumns не содержит hashed_password: результаты поиска уходят наружу
+const searchColumns = `id, email, email_normalized, first_name, last_name, status, created_at, updated_at`
+
+func (r *userRepository) Search(criteria model.UserSearchCriteria) ([]model.User, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if criteria.Prefix != "" {
+		pattern := escapeLike(criteria.Prefix) + "%"
+		conditions = append(conditions, `(first_name LIKE ? OR last_name LIKE ? OR email_normalized LIKE ?)`)
+		args = append(args, pattern, pattern, strings.ToLower(pattern))
+	}
+	if len(criteria.Statuses) > 0 {
+		query, inArgs, err := sqlx.In(`status IN (?)`, criteria.Statuses)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		conditions = append(conditions, query)
+		args = append(args, inArgs...)
+	}
+	if criteria.CreatedFrom != nil {
+		conditions = append(conditions, `created_at >= ?`)
+		args = append(args, *criteria.CreatedFrom)
+	}
+	if criteria.CreatedTo != nil {
+		conditions = append(conditions, `created_at < ?`)
+		args = append(args, *criteria.CreatedTo)
+	}
+
+	column := sortColumn(criteria.SortBy)
+	direction, comparison := `ASC`, `>`
+	if criteria.Descending {
+		direction, comparison = `DESC`, `<`
+	}
+	if after := criteria.After; after != nil {
+		var value interface{}
+		switch criteria.SortBy {
+		case model.SortByEmail:
+			value = after.Email
+		case model.SortByLastName:
+			value = after.LastName
+		default:
+			value = after.CreatedAt
+		}
+		conditions = append(conditions, `(`+column+`, id) `+comparison+` (?, ?)`)
+		args = append(args, value, after.ID)
+	}
+
+	query := `SELECT ` + searchColumns + ` FROM user`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	query += ` ORDER BY ` + column + ` ` + direction + `, id ` + direction + ` LIMIT ?`
+	args = append(args, criteria.Limit)
+
+	var rows []sqlxUser
+	if err := r.db.Select(&rows, query, args...); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	users := make([]model.User, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, *toModelUser(row))
+	}
+	return users, nil
+}
+
+func sortColumn(field model.UserSortField) string {
+	switch field {
+	case model.SortByEmail:
+		return `email_normalized`
+	case model.SortByLastName:
+		return `last_name`
+	default:
+		return `created_at`
+	}
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы префикс искался буквально
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,35 @@
+package password
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+
+	"user/pkg/domain/model"
+)
+
+func NewBcryptPasswordManager(cost int) model.PasswordManager {
+	return &bcryptPasswordManager{cost: cost}
+}
+
+type bcryptPasswordManager struct {
+	cost int
+}
+
+func (m *bcryptPasswordManager) Hash(plainTextPassword string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plainTextPassword), m.cost)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return string(hash), nil
+}
+
+func (m *bcryptPasswordManager) Check(hashedPassword, plainTextPassword string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plainTextPassword))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, nil
+}
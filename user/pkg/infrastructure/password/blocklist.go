@@ -0,0 +1,44 @@
+package password
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"user/pkg/domain/model"
+)
+
+// LoadBlocklist читает файл с распространёнными паролями: по одному в строке, строки с # пропускаются
+func LoadBlocklist(path string) (model.PasswordBlocklist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open password blocklist %s", path)
+	}
+	defer file.Close()
+
+	passwords := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read password blocklist %s", path)
+	}
+
+	return &blocklist{passwords: passwords}, nil
+}
+
+type blocklist struct {
+	passwords map[string]struct{}
+}
+
+func (b *blocklist) Contains(plainTextPassword string) bool {
+	_, ok := b.passwords[strings.ToLower(plainTextPassword)]
+	return ok
+}
@@ -0,0 +1,65 @@
+package password
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// encryptedSecretPrefix - версия формата, чтобы можно было сменить алгоритм без миграции данных
+const encryptedSecretPrefix = "v1:"
+
+var ErrInvalidEncryptedSecret = errors.New("encrypted secret is malformed or was encrypted with another key")
+
+// NewSecretCipher шифрует секреты для хранения в БД алгоритмом AES-256-GCM. key должен быть 32 байта
+func NewSecretCipher(key []byte) (*SecretCipher, error) {
+	if len(key) != 32 {
+		return nil, errors.Errorf("secret encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &SecretCipher{aead: aead}, nil
+}
+
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+// Encrypt привязывает шифротекст к associatedData, чтобы значение нельзя было переставить в чужую строку
+func (c *SecretCipher) Encrypt(plaintext string, associatedData []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), associatedData)
+	return encryptedSecretPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt возвращает ErrInvalidEncryptedSecret и для значения без префикса: открытый секрет в БД
+// означает подмену или повреждение данных, а не старый формат
+func (c *SecretCipher) Decrypt(stored string, associatedData []byte) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, encryptedSecretPrefix)
+	if !ok {
+		return "", errors.WithStack(ErrInvalidEncryptedSecret)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", errors.WithStack(ErrInvalidEncryptedSecret)
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, associatedData)
+	if err != nil {
+		return "", errors.WithStack(ErrInvalidEncryptedSecret)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,68 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"user/pkg/authtoken"
+	"user/pkg/domain/model"
+)
+
+// NewEd25519Issuer подписывает access-токены ключом privateKey, keyID публикуется в JWKS
+func NewEd25519Issuer(privateKey ed25519.PrivateKey, keyID, issuer string) *Ed25519Issuer {
+	return &Ed25519Issuer{
+		privateKey: privateKey,
+		keyID:      keyID,
+		issuer:     issuer,
+	}
+}
+
+type Ed25519Issuer struct {
+	privateKey ed25519.PrivateKey
+	keyID      string
+	issuer     string
+}
+
+func (i *Ed25519Issuer) Issue(user *model.User, sessionID uuid.UUID, permissions []model.Permission, expiresAt time.Time) (string, error) {
+	tokenID, err := uuid.NewV7()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	claims := authtoken.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID.String(),
+			Issuer:    i.issuer,
+			Subject:   user.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		SessionID:   sessionID.String(),
+		Permissions: make([]string, 0, len(permissions)),
+	}
+	for _, permission := range permissions {
+		claims.Permissions = append(claims.Permissions, string(permission))
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = i.keyID
+	signed, err := token.SignedString(i.privateKey)
+	return signed, errors.WithStack(err)
+}
+
+func (i *Ed25519Issuer) KeySet() authtoken.JWKSet {
+	publicKey, _ := i.privateKey.Public().(ed25519.PublicKey)
+	return authtoken.JWKSet{
+		Keys: []authtoken.JWK{authtoken.NewJWK(i.keyID, publicKey)},
+	}
+}
+
+// KeySource позволяет самому сервису проверять выпущенные им токены без загрузки JWKS
+func (i *Ed25519Issuer) KeySource() authtoken.KeySource {
+	publicKey, _ := i.privateKey.Public().(ed25519.PublicKey)
+	return authtoken.StaticKeySet{i.keyID: publicKey}
+}
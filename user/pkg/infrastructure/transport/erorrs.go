@@ -4,7 +4,13 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/protoadapt"
+
+	"user/pkg/authtoken"
+	"user/pkg/domain/model"
+	"user/pkg/domain/service"
 )
 
 type errorSet map[error]struct{}
@@ -22,19 +28,61 @@ func (s errorSet) Has(err error) bool {
 	return ok
 }
 
-var badRequestErrorCodes = newErrorSet()
+var badRequestErrorCodes = newErrorSet(
+	model.ErrPasswordPolicyViolation,
+	model.ErrInvalidEmail,
+	model.ErrInvalidVerificationToken,
+	model.ErrInvalidPasswordResetToken,
+	model.ErrErasureReasonRequired,
+	model.ErrInvalidSearchQuery,
+	model.ErrInvalidSearchCursor,
+	ErrInvalidUserID,
+	ErrInvalidSessionID,
+)
+
+var notFoundErrorCodes = newErrorSet(
+	model.ErrUserNotFound,
+	model.ErrRoleNotFound,
+	model.ErrSessionNotFound,
+)
+
+var alreadyExistsErrorCodes = newErrorSet(
+	model.ErrEmailTaken,
+)
+
+var failedPreconditionErrorCodes = newErrorSet(
+	model.ErrUserAlreadyErased,
+	model.ErrTwoFactorNotEnrolled,
+	model.ErrTwoFactorAlreadyEnabled,
+	service.ErrUserCannotBeChanged,
+)
 
-var notFoundErrorCodes = newErrorSet()
+var unauthorizedErrorCodes = newErrorSet(
+	authtoken.ErrUnauthenticated,
+	model.ErrInvalidCredentials,
+	model.ErrInvalidRefreshToken,
+	model.ErrInvalidTwoFactorCode,
+	model.ErrInvalidTwoFactorChallenge,
+)
 
-var unauthorizedErrorCodes = newErrorSet()
+var permissionDeniedErrorCodes = newErrorSet(
+	authtoken.ErrPermissionDenied,
+	model.ErrUserNotActive,
+)
 
-var permissionDeniedErrorCodes = newErrorSet()
+var resourceExhaustedErrorCodes = newErrorSet(
+	model.ErrLoginThrottled,
+	model.ErrAccountLocked,
+)
 
 var internalErrorCodes = newErrorSet()
 
 // getGRPCCode recursively unwraps joined errors and returns GRPC code by the first meaningful error
 func getGRPCCode(err error) codes.Code {
 	cause := errors.Cause(err)
+	if errors.Is(cause, model.ErrPasswordPolicyViolation) {
+		cause = model.ErrPasswordPolicyViolation
+	}
 
 	switch {
 	case cause == nil:
@@ -43,10 +91,16 @@ func getGRPCCode(err error) codes.Code {
 		return codes.InvalidArgument
 	case isNotFoundError(cause):
 		return codes.NotFound
+	case isAlreadyExistsError(cause):
+		return codes.AlreadyExists
+	case isFailedPreconditionError(cause):
+		return codes.FailedPrecondition
 	case isUnauthorizedError(cause):
 		return codes.Unauthenticated
 	case isPermissionDeniedError(cause):
 		return codes.PermissionDenied
+	case isResourceExhaustedError(cause):
+		return codes.ResourceExhausted
 	case isInternalError(cause):
 		return codes.Internal
 	}
@@ -61,6 +115,23 @@ func getGRPCCode(err error) codes.Code {
 	}
 }
 
+// getErrorDetails возвращает структурированные детали ошибки для клиента, если они есть
+func getErrorDetails(err error) protoadapt.MessageV1 {
+	var policyErr *model.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return nil
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(policyErr.FailedRules))
+	for _, rule := range policyErr.FailedRules {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "password",
+			Description: string(rule),
+		})
+	}
+	return &errdetails.BadRequest{FieldViolations: violations}
+}
+
 func isWarnLevel(err error) bool {
 	switch getGRPCCode(err) {
 	case codes.Canceled,
@@ -68,8 +139,10 @@ func isWarnLevel(err error) bool {
 		codes.PermissionDenied,
 		codes.InvalidArgument,
 		codes.NotFound,
+		codes.AlreadyExists,
 		codes.FailedPrecondition,
-		codes.Unauthenticated:
+		codes.Unauthenticated,
+		codes.ResourceExhausted:
 		return true
 	default:
 		return false
@@ -84,6 +157,14 @@ func isNotFoundError(cause error) bool {
 	return notFoundErrorCodes.Has(cause)
 }
 
+func isAlreadyExistsError(cause error) bool {
+	return alreadyExistsErrorCodes.Has(cause)
+}
+
+func isFailedPreconditionError(cause error) bool {
+	return failedPreconditionErrorCodes.Has(cause)
+}
+
 func isUnauthorizedError(cause error) bool {
 	return unauthorizedErrorCodes.Has(cause)
 }
@@ -92,6 +173,10 @@ func isPermissionDeniedError(cause error) bool {
 	return permissionDeniedErrorCodes.Has(cause)
 }
 
+func isResourceExhaustedError(cause error) bool {
+	return resourceExhaustedErrorCodes.Has(cause)
+}
+
 func isInternalError(cause error) bool {
 	return internalErrorCodes.Has(cause)
 }
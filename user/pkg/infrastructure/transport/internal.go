@@ -2,19 +2,554 @@ package transport
 
 import (
 	"context"
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
 	api "user/api/server/userinternal"
+	"user/pkg/domain/model"
+	"user/pkg/domain/service"
+)
+
+var (
+	ErrInvalidUserID    = errors.New("invalid user id")
+	ErrInvalidSessionID = errors.New("invalid session id")
 )
 
-func NewInternalAPI() api.UserInternalServiceServer {
-	return &internalAPI{}
+func NewInternalAPI(
+	userService service.UserService,
+	authService service.AuthService,
+	passwordService service.PasswordService,
+	roleService service.RoleService,
+	privacyService service.PrivacyService,
+	sessionService service.SessionService,
+	twoFactorService service.TwoFactorService,
+	trustedProxies []*net.IPNet,
+) api.UserInternalServiceServer {
+	return &internalAPI{
+		userService:      userService,
+		authService:      authService,
+		passwordService:  passwordService,
+		roleService:      roleService,
+		privacyService:   privacyService,
+		sessionService:   sessionService,
+		twoFactorService: twoFactorService,
+		trustedProxies:   trustedProxies,
+	}
 }
 
 type internalAPI struct {
+	userService      service.UserService
+	authService      service.AuthService
+	passwordService  service.PasswordService
+	roleService      service.RoleService
+	privacyService   service.PrivacyService
+	sessionService   service.SessionService
+	twoFactorService service.TwoFactorService
+	// trustedProxies - подсети шлюзов, которым разрешено передавать IP пользователя в запросе
+	trustedProxies []*net.IPNet
+
+	api.UnimplementedUserInternalServiceServer
+}
+
+func (i *internalAPI) RegisterUser(_ context.Context, request *api.RegisterUserRequest) (*api.RegisterUserResponse, error) {
+	user, err := i.userService.RegisterNewUser(request.FirstName, request.LastName, request.Email, request.Password)
+	if err != nil {
+		return nil, err
+	}
+	return &api.RegisterUserResponse{User: toAPIUser(user)}, nil
+}
+
+func (i *internalAPI) UpdateUserProfile(ctx context.Context, request *api.UpdateUserProfileRequest) (*api.UpdateUserProfileResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = authorizeSelfOrAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err = i.userService.UpdateUserProfile(userID, request.FirstName, request.LastName); err != nil {
+		return nil, err
+	}
+	return &api.UpdateUserProfileResponse{}, nil
+}
+
+func (i *internalAPI) SuspendUser(_ context.Context, request *api.SuspendUserRequest) (*api.SuspendUserResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = i.userService.SuspendUser(userID); err != nil {
+		return nil, err
+	}
+	return &api.SuspendUserResponse{}, nil
+}
+
+func (i *internalAPI) ActivateUser(_ context.Context, request *api.ActivateUserRequest) (*api.ActivateUserResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = i.userService.ActivateUser(userID); err != nil {
+		return nil, err
+	}
+	return &api.ActivateUserResponse{}, nil
+}
+
+func (i *internalAPI) DeactivateUser(_ context.Context, request *api.DeactivateUserRequest) (*api.DeactivateUserResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = i.userService.DeactivateUser(userID); err != nil {
+		return nil, err
+	}
+	return &api.DeactivateUserResponse{}, nil
+}
+
+func (i *internalAPI) GetUser(_ context.Context, request *api.GetUserRequest) (*api.GetUserResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := i.userService.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.GetUserResponse{User: toAPIUser(user)}, nil
+}
+
+func (i *internalAPI) GetUserByEmail(_ context.Context, request *api.GetUserByEmailRequest) (*api.GetUserResponse, error) {
+	user, err := i.userService.GetUserByEmail(request.Email)
+	if err != nil {
+		return nil, err
+	}
+	return &api.GetUserResponse{User: toAPIUser(user)}, nil
+}
+
+func (i *internalAPI) SearchUsers(_ context.Context, request *api.SearchUsersRequest) (*api.SearchUsersResponse, error) {
+	query := model.UserSearchQuery{
+		Prefix:     request.Prefix,
+		SortBy:     model.UserSortField(request.SortBy),
+		Descending: request.Descending,
+		PageSize:   int(request.PageSize),
+		Cursor:     request.Cursor,
+	}
+	for _, status := range request.Statuses {
+		query.Statuses = append(query.Statuses, model.UserStatus(status))
+	}
+	if request.CreatedFrom != 0 {
+		from := time.Unix(request.CreatedFrom, 0)
+		query.CreatedFrom = &from
+	}
+	if request.CreatedTo != 0 {
+		to := time.Unix(request.CreatedTo, 0)
+		query.CreatedTo = &to
+	}
+
+	page, err := i.userService.SearchUsers(query)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*api.User, 0, len(page.Users))
+	for _, user := range page.Users {
+		users = append(users, toAPIUser(&user))
+	}
+	return &api.SearchUsersResponse{Users: users, NextCursor: page.NextCursor}, nil
+}
+
+func (i *internalAPI) VerifyEmail(_ context.Context, request *api.VerifyEmailRequest) (*api.VerifyEmailResponse, error) {
+	if err := i.userService.VerifyEmail(request.Token); err != nil {
+		return nil, err
+	}
+	return &api.VerifyEmailResponse{}, nil
+}
+
+func (i *internalAPI) ResendVerificationEmail(
+	_ context.Context,
+	request *api.ResendVerificationEmailRequest,
+) (*api.ResendVerificationEmailResponse, error) {
+	if err := i.userService.ResendVerification(request.Email); err != nil {
+		return nil, err
+	}
+	return &api.ResendVerificationEmailResponse{}, nil
+}
+
+func (i *internalAPI) ChangePassword(ctx context.Context, request *api.ChangePasswordRequest) (*api.ChangePasswordResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = authorizeSelfOrAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err = i.passwordService.ChangePassword(userID, request.CurrentPassword, request.NewPassword); err != nil {
+		return nil, err
+	}
+	return &api.ChangePasswordResponse{}, nil
+}
+
+func (i *internalAPI) RequestPasswordReset(
+	ctx context.Context,
+	request *api.RequestPasswordResetRequest,
+) (*api.RequestPasswordResetResponse, error) {
+	if err := i.passwordService.RequestPasswordReset(request.Email, i.clientIP(ctx, request.IpAddress)); err != nil {
+		return nil, err
+	}
+	return &api.RequestPasswordResetResponse{}, nil
+}
+
+func (i *internalAPI) ConfirmPasswordReset(
+	_ context.Context,
+	request *api.ConfirmPasswordResetRequest,
+) (*api.ConfirmPasswordResetResponse, error) {
+	if err := i.passwordService.ConfirmPasswordReset(request.Token, request.NewPassword); err != nil {
+		return nil, err
+	}
+	return &api.ConfirmPasswordResetResponse{}, nil
+}
+
+func (i *internalAPI) UnlockUser(_ context.Context, request *api.UnlockUserRequest) (*api.UnlockUserResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = i.authService.UnlockUser(userID); err != nil {
+		return nil, err
+	}
+	return &api.UnlockUserResponse{}, nil
+}
+
+func (i *internalAPI) ExportUserData(ctx context.Context, request *api.ExportUserDataRequest) (*api.ExportUserDataResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = authorizeSelfOrAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	export, err := i.privacyService.ExportUserData(userID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(export)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &api.ExportUserDataResponse{Data: data}, nil
+}
+
+func (i *internalAPI) EraseUser(ctx context.Context, request *api.EraseUserRequest) (*api.EraseUserResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	requestedBy, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = i.privacyService.EraseUser(userID, requestedBy, request.Reason); err != nil {
+		return nil, err
+	}
+	return &api.EraseUserResponse{}, nil
+}
+
+func (i *internalAPI) AssignRole(_ context.Context, request *api.AssignRoleRequest) (*api.AssignRoleResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = i.roleService.AssignRole(userID, request.Role); err != nil {
+		return nil, err
+	}
+	return &api.AssignRoleResponse{}, nil
 }
 
-func (i *internalAPI) Ping(_ context.Context, _ *api.PingRequest) (*api.PingResponse, error) {
-	return &api.PingResponse{
-		Message: "pong",
+func (i *internalAPI) RevokeRole(_ context.Context, request *api.RevokeRoleRequest) (*api.RevokeRoleResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = i.roleService.RevokeRole(userID, request.Role); err != nil {
+		return nil, err
+	}
+	return &api.RevokeRoleResponse{}, nil
+}
+
+func (i *internalAPI) GetUserRoles(_ context.Context, request *api.GetUserRolesRequest) (*api.GetUserRolesResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := i.roleService.GetUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	apiRoles := make([]*api.Role, 0, len(roles))
+	for _, role := range roles {
+		permissions := make([]string, 0, len(role.Permissions))
+		for _, permission := range role.Permissions {
+			permissions = append(permissions, string(permission))
+		}
+		apiRoles = append(apiRoles, &api.Role{Name: role.Name, Permissions: permissions})
+	}
+	return &api.GetUserRolesResponse{Roles: apiRoles}, nil
+}
+
+func (i *internalAPI) Authenticate(ctx context.Context, request *api.AuthenticateRequest) (*api.AuthenticateResponse, error) {
+	result, err := i.authService.Authenticate(request.Email, request.Password, model.ClientInfo{
+		IPAddress: i.clientIP(ctx, request.IpAddress),
+		UserAgent: clientUserAgent(ctx, request.UserAgent),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Challenge != nil {
+		return &api.AuthenticateResponse{
+			TwoFactorRequired:  true,
+			ChallengeToken:     result.Challenge.Token,
+			ChallengeExpiresAt: result.Challenge.ExpiresAt.Unix(),
+		}, nil
+	}
+	return &api.AuthenticateResponse{Tokens: toAPITokenPair(result.Tokens)}, nil
+}
+
+func (i *internalAPI) CompleteTwoFactorLogin(
+	_ context.Context,
+	request *api.CompleteTwoFactorLoginRequest,
+) (*api.CompleteTwoFactorLoginResponse, error) {
+	tokens, err := i.authService.CompleteTwoFactorLogin(request.ChallengeToken, request.Code)
+	if err != nil {
+		return nil, err
+	}
+	return &api.CompleteTwoFactorLoginResponse{Tokens: toAPITokenPair(tokens)}, nil
+}
+
+func (i *internalAPI) RefreshToken(_ context.Context, request *api.RefreshTokenRequest) (*api.RefreshTokenResponse, error) {
+	tokens, err := i.authService.Refresh(request.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return &api.RefreshTokenResponse{Tokens: toAPITokenPair(tokens)}, nil
+}
+
+func (i *internalAPI) RevokeToken(_ context.Context, request *api.RevokeTokenRequest) (*api.RevokeTokenResponse, error) {
+	if err := i.authService.Revoke(request.RefreshToken); err != nil {
+		return nil, err
+	}
+	return &api.RevokeTokenResponse{}, nil
+}
+
+func (i *internalAPI) ListSessions(ctx context.Context, request *api.ListSessionsRequest) (*api.ListSessionsResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = authorizeSelfOrAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	sessions, err := i.sessionService.ListSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	apiSessions := make([]*api.Session, 0, len(sessions))
+	for _, session := range sessions {
+		apiSessions = append(apiSessions, &api.Session{
+			SessionID:  session.ID.String(),
+			UserAgent:  session.UserAgent,
+			IpAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt.Unix(),
+			LastSeenAt: session.LastSeenAt.Unix(),
+			ExpiresAt:  session.ExpiresAt.Unix(),
+		})
+	}
+	return &api.ListSessionsResponse{Sessions: apiSessions}, nil
+}
+
+func (i *internalAPI) RevokeSession(ctx context.Context, request *api.RevokeSessionRequest) (*api.RevokeSessionResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	sessionID, err := uuid.Parse(request.SessionID)
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidSessionID)
+	}
+	if err = authorizeSelfOrAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err = i.sessionService.RevokeSession(userID, sessionID); err != nil {
+		return nil, err
+	}
+	return &api.RevokeSessionResponse{}, nil
+}
+
+func (i *internalAPI) RevokeAllSessions(
+	ctx context.Context,
+	request *api.RevokeAllSessionsRequest,
+) (*api.RevokeAllSessionsResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = authorizeSelfOrAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err = i.sessionService.RevokeAllSessions(userID); err != nil {
+		return nil, err
+	}
+	return &api.RevokeAllSessionsResponse{}, nil
+}
+
+func (i *internalAPI) EnrollTOTP(ctx context.Context, request *api.EnrollTOTPRequest) (*api.EnrollTOTPResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+	enrollment, err := i.twoFactorService.EnrollTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.EnrollTOTPResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
 	}, nil
 }
+
+func (i *internalAPI) ConfirmTOTP(ctx context.Context, request *api.ConfirmTOTPRequest) (*api.ConfirmTOTPResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+	codes, err := i.twoFactorService.ConfirmTOTP(userID, request.Code)
+	if err != nil {
+		return nil, err
+	}
+	return &api.ConfirmTOTPResponse{RecoveryCodes: codes}, nil
+}
+
+func (i *internalAPI) DisableTOTP(ctx context.Context, request *api.DisableTOTPRequest) (*api.DisableTOTPResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err = i.twoFactorService.DisableTOTP(userID, request.Code); err != nil {
+		return nil, err
+	}
+	return &api.DisableTOTPResponse{}, nil
+}
+
+func (i *internalAPI) RegenerateRecoveryCodes(
+	ctx context.Context,
+	request *api.RegenerateRecoveryCodesRequest,
+) (*api.RegenerateRecoveryCodesResponse, error) {
+	userID, err := parseUserID(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+	codes, err := i.twoFactorService.RegenerateRecoveryCodes(userID, request.Code)
+	if err != nil {
+		return nil, err
+	}
+	return &api.RegenerateRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// clientUserAgent возвращает переданный вызывающим user agent пользователя или user agent gRPC-клиента
+func clientUserAgent(ctx context.Context, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("user-agent"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// clientIP возвращает адрес gRPC-клиента. Адрес из запроса принимается только от доверенного шлюза,
+// иначе любой анонимный клиент обходил бы ограничения по IP, подставляя чужой адрес
+func (i *internalAPI) clientIP(ctx context.Context, requested string) string {
+	peerIP := peerAddress(ctx)
+	if requested == "" || !i.isTrustedProxy(peerIP) {
+		return peerIP
+	}
+	if ip := net.ParseIP(requested); ip != nil {
+		return ip.String()
+	}
+	return peerIP
+}
+
+func (i *internalAPI) isTrustedProxy(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range i.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+func parseUserID(userID string) (uuid.UUID, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, errors.WithStack(ErrInvalidUserID)
+	}
+	return id, nil
+}
+
+// toAPIUser намеренно не переносит HashedPassword
+func toAPIUser(user *model.User) *api.User {
+	return &api.User{
+		UserID:    user.ID.String(),
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Status:    api.UserStatus(user.Status), // nolint:gosec
+		CreatedAt: user.CreatedAt.Unix(),
+		UpdatedAt: user.UpdatedAt.Unix(),
+	}
+}
+
+func toAPITokenPair(tokens *model.TokenPair) *api.TokenPair {
+	return &api.TokenPair{
+		AccessToken:           tokens.AccessToken,
+		AccessTokenExpiresAt:  tokens.AccessTokenExpiresAt.Unix(),
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt.Unix(),
+	}
+}
@@ -0,0 +1,23 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"user/pkg/authtoken"
+)
+
+// JWKSPath - адрес, по которому другие сервисы забирают ключи для проверки access-токенов
+const JWKSPath = "/.well-known/jwks.json"
+
+func NewJWKSHandler(keySet authtoken.JWKSet) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_ = json.NewEncoder(w).Encode(keySet)
+	})
+}
@@ -22,7 +22,13 @@ func (i ErrorInterceptor) TranslateGRPCError(err error) error {
 		return err
 	}
 
-	return status.Error(getGRPCCode(err), err.Error())
+	st := status.New(getGRPCCode(err), err.Error())
+	if details := getErrorDetails(err); details != nil {
+		if withDetails, detailsErr := st.WithDetails(details); detailsErr == nil {
+			st = withDetails
+		}
+	}
+	return st.Err()
 }
 
 func MakeLoggerServerInterceptor(logger *log.Logger) grpc.UnaryServerInterceptor {
@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	api "user/api/server/userinternal"
+	"user/pkg/authtoken"
+	"user/pkg/domain/model"
+)
+
+// MethodPermissions - требования к вызывающему для каждого метода UserInternalService
+func MethodPermissions() authtoken.MethodPermissions {
+	return authtoken.MethodPermissions{
+		api.UserInternalService_RegisterUser_FullMethodName:            authtoken.Anonymous,
+		api.UserInternalService_VerifyEmail_FullMethodName:             authtoken.Anonymous,
+		api.UserInternalService_ResendVerificationEmail_FullMethodName: authtoken.Anonymous,
+		api.UserInternalService_RequestPasswordReset_FullMethodName:    authtoken.Anonymous,
+		api.UserInternalService_ConfirmPasswordReset_FullMethodName:    authtoken.Anonymous,
+		api.UserInternalService_Authenticate_FullMethodName:            authtoken.Anonymous,
+		api.UserInternalService_RefreshToken_FullMethodName:            authtoken.Anonymous,
+		api.UserInternalService_RevokeToken_FullMethodName:             authtoken.Anonymous,
+		api.UserInternalService_CompleteTwoFactorLogin_FullMethodName:  authtoken.Anonymous,
+
+		// Свой профиль можно менять без особых прав, чужой - только администратору, см. authorizeSelfOrAdmin
+		api.UserInternalService_UpdateUserProfile_FullMethodName: authtoken.Authenticated,
+		api.UserInternalService_ChangePassword_FullMethodName:    authtoken.Authenticated,
+		api.UserInternalService_ExportUserData_FullMethodName:    authtoken.Authenticated,
+		api.UserInternalService_ListSessions_FullMethodName:      authtoken.Authenticated,
+		api.UserInternalService_RevokeSession_FullMethodName:     authtoken.Authenticated,
+		api.UserInternalService_RevokeAllSessions_FullMethodName: authtoken.Authenticated,
+
+		// Второй фактор настраивает только сам пользователь, см. authorizeSelf
+		api.UserInternalService_EnrollTOTP_FullMethodName:              authtoken.Authenticated,
+		api.UserInternalService_ConfirmTOTP_FullMethodName:             authtoken.Authenticated,
+		api.UserInternalService_DisableTOTP_FullMethodName:             authtoken.Authenticated,
+		api.UserInternalService_RegenerateRecoveryCodes_FullMethodName: authtoken.Authenticated,
+
+		api.UserInternalService_GetUser_FullMethodName:        string(model.PermissionUsersRead),
+		api.UserInternalService_GetUserByEmail_FullMethodName: string(model.PermissionUsersRead),
+		api.UserInternalService_GetUserRoles_FullMethodName:   string(model.PermissionUsersRead),
+		api.UserInternalService_SearchUsers_FullMethodName:    string(model.PermissionUsersSearch),
+
+		api.UserInternalService_SuspendUser_FullMethodName:    string(model.PermissionUsersAdmin),
+		api.UserInternalService_ActivateUser_FullMethodName:   string(model.PermissionUsersAdmin),
+		api.UserInternalService_DeactivateUser_FullMethodName: string(model.PermissionUsersAdmin),
+		api.UserInternalService_UnlockUser_FullMethodName:     string(model.PermissionUsersAdmin),
+		api.UserInternalService_EraseUser_FullMethodName:      string(model.PermissionUsersAdmin),
+		api.UserInternalService_AssignRole_FullMethodName:     string(model.PermissionUsersAdmin),
+		api.UserInternalService_RevokeRole_FullMethodName:     string(model.PermissionUsersAdmin),
+	}
+}
+
+func authorizeSelfOrAdmin(ctx context.Context, userID uuid.UUID) error {
+	claims, ok := authtoken.ClaimsFromContext(ctx)
+	if !ok {
+		return errors.WithStack(authtoken.ErrUnauthenticated)
+	}
+	if caller, err := claims.UserID(); err == nil && caller == userID {
+		return nil
+	}
+	if claims.HasPermission(string(model.PermissionUsersAdmin)) {
+		return nil
+	}
+	return errors.WithStack(authtoken.ErrPermissionDenied)
+}
+
+func authorizeSelf(ctx context.Context, userID uuid.UUID) error {
+	caller, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	if caller != userID {
+		return errors.WithStack(authtoken.ErrPermissionDenied)
+	}
+	return nil
+}
+
+// callerID возвращает ID пользователя из проверенного access-токена
+func callerID(ctx context.Context) (uuid.UUID, error) {
+	claims, ok := authtoken.ClaimsFromContext(ctx)
+	if !ok {
+		return uuid.Nil, errors.WithStack(authtoken.ErrUnauthenticated)
+	}
+	id, err := claims.UserID()
+	if err != nil {
+		return uuid.Nil, errors.Wrap(authtoken.ErrUnauthenticated, err.Error())
+	}
+	return id, nil
+}